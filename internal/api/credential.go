@@ -0,0 +1,43 @@
+package api
+
+import (
+	"context"
+	"time"
+)
+
+// Credential represents a generated credential, such as a key or a key pair.
+type Credential struct {
+	Type    string `json:"type"`
+	Private string `json:"private,omitempty"`
+	Public  string `json:"public,omitempty"`
+	// Secret is the secret the private part of the credential is
+	// stored as, if stored.
+	Secret *Secret `json:"secret,omitempty"`
+}
+
+// StoreCredentialRequest represents a request to generate a credential
+// and store its private part as a secret.
+type StoreCredentialRequest struct {
+	Passphrase string `json:"passphrase,omitempty"`
+	TTL        string `json:"ttl,omitempty"`
+	ExpiresAt  *Time  `json:"expiresAt,omitempty"`
+	// NotBefore is the time from which the secret can be read.
+	NotBefore *Time `json:"notBefore,omitempty"`
+	// AllowedNetworks contains the networks (CIDRs or IP addresses)
+	// that are allowed to read the secret.
+	AllowedNetworks []string `json:"allowedNetworks,omitempty"`
+	// SecondFactor is the second factor required to read the secret.
+	SecondFactor *SecondFactor `json:"secondFactor,omitempty"`
+}
+
+// Valid validates the StoreCredentialRequest.
+func (r StoreCredentialRequest) Valid(ctx context.Context) map[string]string {
+	errs := make(map[string]string)
+	if len(r.TTL) > 0 {
+		_, err := time.ParseDuration(r.TTL)
+		if err != nil {
+			errs["ttl"] = "ttl is invalid, expected format is 1h30m"
+		}
+	}
+	return errs
+}
@@ -0,0 +1,13 @@
+package api
+
+// Health represents the health of the service.
+type Health struct {
+	Status       string       `json:"status"`
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+}
+
+// Dependency represents the health of a dependency of the service.
+type Dependency struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
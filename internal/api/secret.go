@@ -2,6 +2,8 @@ package api
 
 import (
 	"context"
+	"fmt"
+	"net/url"
 	"time"
 )
 
@@ -12,6 +14,45 @@ type Secret struct {
 	Passphrase string `json:"passphrase,omitempty"`
 	TTL        string `json:"ttl,omitempty"`
 	ExpiresAt  *Time  `json:"expiresAt,omitempty"`
+	NotBefore  *Time  `json:"notBefore,omitempty"`
+	// Type is the type of the content of the secret. It is only set
+	// for structured content (credentials and keyValue).
+	Type string `json:"type,omitempty"`
+	// Credentials is the content of a secret of the type credentials.
+	Credentials *Credentials `json:"credentials,omitempty"`
+	// KeyValue is the content of a secret of the type keyValue.
+	KeyValue map[string]string `json:"keyValue,omitempty"`
+	// Label is the unencrypted label of the secret.
+	Label string `json:"label,omitempty"`
+	// Note is the note delivered alongside the value of the secret.
+	Note string `json:"note,omitempty"`
+	// SecondFactor is the second factor required to read the secret.
+	SecondFactor *SecondFactor `json:"secondFactor,omitempty"`
+	// Link is the link to share to read the secret in the UI. It is
+	// only set for secrets created in a batch.
+	Link string `json:"link,omitempty"`
+}
+
+// Credentials represents the content of a secret of the type credentials.
+type Credentials struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Notes    string `json:"notes,omitempty"`
+}
+
+// SecondFactor represents the second factor required to read a secret.
+type SecondFactor struct {
+	// Type is the type of the second factor, totp or email.
+	Type string `json:"type"`
+	// TOTPSecret is the base32 encoded TOTP secret. One is generated
+	// if it is not provided.
+	TOTPSecret string `json:"totpSecret,omitempty"`
+	// TOTPURI is the otpauth URI of the TOTP secret, to be added to
+	// an authenticator app.
+	TOTPURI string `json:"totpUri,omitempty"`
+	// Email is the address codes are sent to.
+	Email string `json:"email,omitempty"`
 }
 
 // CreateSecretRequest represents a request to create a secret.
@@ -20,13 +61,79 @@ type CreateSecretRequest struct {
 	Passphrase string `json:"passphrase,omitempty"`
 	TTL        string `json:"ttl,omitempty"`
 	ExpiresAt  *Time  `json:"expiresAt,omitempty"`
+	// NotBefore is the time from which the secret can be read.
+	NotBefore *Time `json:"notBefore,omitempty"`
+	// AllowedNetworks contains the networks (CIDRs or IP addresses)
+	// that are allowed to read the secret.
+	AllowedNetworks []string `json:"allowedNetworks,omitempty"`
+	// SecondFactor is the second factor required to read the secret.
+	SecondFactor *SecondFactor `json:"secondFactor,omitempty"`
+	// Generate contains the options to generate the value of the
+	// secret, instead of providing it.
+	Generate *GenerateSecretRequest `json:"generate,omitempty"`
+	// Type is the type of the content of the secret: text (default),
+	// credentials or keyValue.
+	Type string `json:"type,omitempty"`
+	// Credentials is the content of a secret of the type credentials.
+	Credentials *Credentials `json:"credentials,omitempty"`
+	// KeyValue is the content of a secret of the type keyValue.
+	KeyValue map[string]string `json:"keyValue,omitempty"`
+	// Label is a short label that is stored unencrypted and shown
+	// before the secret is read.
+	Label string `json:"label,omitempty"`
+	// Note is a free-text note that is encrypted and delivered
+	// alongside the value of the secret.
+	Note string `json:"note,omitempty"`
+}
+
+// GenerateSecretRequest represents the options to generate the value
+// of a secret when it is created.
+type GenerateSecretRequest struct {
+	Length            int    `json:"length,omitempty"`
+	Lowercase         bool   `json:"lowercase,omitempty"`
+	Uppercase         bool   `json:"uppercase,omitempty"`
+	Digits            bool   `json:"digits,omitempty"`
+	SpecialCharacters bool   `json:"specialCharacters,omitempty"`
+	Custom            string `json:"custom,omitempty"`
+	ExcludeAmbiguous  bool   `json:"excludeAmbiguous,omitempty"`
+	Words             int    `json:"words,omitempty"`
+	Separator         string `json:"separator,omitempty"`
+	Capitalize        bool   `json:"capitalize,omitempty"`
+	Number            bool   `json:"number,omitempty"`
+	Symbol            bool   `json:"symbol,omitempty"`
+	// ReturnValue returns the generated value in the response.
+	ReturnValue bool `json:"returnValue,omitempty"`
 }
 
 // Valid validates the CreateSecretRequest.
 func (r CreateSecretRequest) Valid(ctx context.Context) map[string]string {
 	errs := make(map[string]string)
-	if len(r.Value) == 0 {
-		errs["value"] = "value is required"
+	switch r.Type {
+	case "", "text":
+		if len(r.Value) == 0 && r.Generate == nil {
+			errs["value"] = "value is required"
+		}
+		if len(r.Value) > 0 && r.Generate != nil {
+			errs["value"] = "value can not be provided together with generate"
+		}
+		if r.Credentials != nil || len(r.KeyValue) > 0 {
+			errs["type"] = "type must be credentials or keyValue for structured content"
+		}
+	case "credentials":
+		if r.Credentials == nil {
+			errs["credentials"] = "credentials are required"
+		}
+	case "keyValue":
+		if len(r.KeyValue) == 0 {
+			errs["keyValue"] = "keyValue is required"
+		}
+	default:
+		errs["type"] = "type must be text, credentials or keyValue"
+	}
+	if r.Type == "credentials" || r.Type == "keyValue" {
+		if len(r.Value) > 0 || r.Generate != nil {
+			errs["value"] = "value can not be provided together with structured content"
+		}
 	}
 	if len(r.TTL) > 0 {
 		_, err := time.ParseDuration(r.TTL)
@@ -36,3 +143,37 @@ func (r CreateSecretRequest) Valid(ctx context.Context) map[string]string {
 	}
 	return errs
 }
+
+// CreateSecretsRequest represents a request to create a batch of secrets.
+type CreateSecretsRequest struct {
+	Secrets []CreateSecretRequest `json:"secrets"`
+	// BaseURL is the base URL of the links of the created secrets.
+	// Defaults to the scheme and host of the request.
+	BaseURL string `json:"baseUrl,omitempty"`
+}
+
+// Valid validates the CreateSecretsRequest.
+func (r CreateSecretsRequest) Valid(ctx context.Context) map[string]string {
+	errs := make(map[string]string)
+	if len(r.Secrets) == 0 {
+		errs["secrets"] = "secrets are required"
+	}
+	for i, secret := range r.Secrets {
+		for key, err := range secret.Valid(ctx) {
+			errs[fmt.Sprintf("secrets[%d].%s", i, key)] = fmt.Sprintf("secret %d: %s", i, err)
+		}
+	}
+	if len(r.BaseURL) > 0 {
+		u, err := url.Parse(r.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			errs["baseUrl"] = "baseUrl is invalid, expected an absolute http or https URL"
+		}
+	}
+	return errs
+}
+
+// CreateSecretsResponse represents the response of a request to create
+// a batch of secrets.
+type CreateSecretsResponse struct {
+	Secrets []Secret `json:"secrets"`
+}
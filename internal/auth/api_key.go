@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/security"
+)
+
+var (
+	// ErrAPIKeyNotFound is returned when an API key is not found.
+	ErrAPIKeyNotFound = errors.New("api key not found")
+)
+
+// Scope is a scope of an API key. It determines which operations
+// the key is allowed to perform.
+type Scope string
+
+const (
+	// ScopeCreate allows creating secrets.
+	ScopeCreate Scope = "create"
+	// ScopeGenerate allows generating secrets.
+	ScopeGenerate Scope = "generate"
+	// ScopeDelete allows deleting secrets.
+	ScopeDelete Scope = "delete"
+	// ScopeAdmin allows all operations.
+	ScopeAdmin Scope = "admin"
+)
+
+// ParseScope parses a scope from a string.
+func ParseScope(s string) (Scope, error) {
+	switch scope := Scope(s); scope {
+	case ScopeCreate, ScopeGenerate, ScopeDelete, ScopeAdmin:
+		return scope, nil
+	}
+	return "", fmt.Errorf("unsupported scope: %s", s)
+}
+
+// APIKey represents an API key. Only the hash of the key is kept.
+type APIKey struct {
+	// Name identifies the key in logs and rate limits.
+	Name string
+	// Hash is the hex encoded SHA-256 hash of the key.
+	Hash string
+	// Scopes are the scopes of the key.
+	Scopes []Scope
+	// Quota is the quota of the key. No quota is applied if Requests
+	// is zero.
+	Quota Quota
+}
+
+// HasScope returns true if the key has the scope, or the admin scope.
+func (k APIKey) HasScope(scope Scope) bool {
+	return slices.Contains(k.Scopes, scope) || slices.Contains(k.Scopes, ScopeAdmin)
+}
+
+// Quota is the number of requests that are allowed during a period.
+// Requests are replenished gradually during the period.
+type Quota struct {
+	Requests int
+	Period   time.Duration
+}
+
+// HashAPIKey returns the hex encoded SHA-256 hash of the key.
+func HashAPIKey(key string) string {
+	return hex.EncodeToString(security.SHA256([]byte(key)))
+}
+
+// APIKeyStore is the interface for looking up API keys.
+type APIKeyStore interface {
+	// Get an API key by the hash of the key.
+	Get(ctx context.Context, hash string) (APIKey, error)
+}
+
+// apiKeyStore is an in-memory APIKeyStore.
+type apiKeyStore struct {
+	keys map[string]APIKey
+}
+
+// NewAPIKeyStore returns a new in-memory APIKeyStore with the provided keys.
+func NewAPIKeyStore(keys ...APIKey) (APIKeyStore, error) {
+	s := &apiKeyStore{
+		keys: make(map[string]APIKey, len(keys)),
+	}
+	names := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		if len(key.Name) == 0 {
+			return nil, errors.New("api key name is required")
+		}
+		if _, ok := names[key.Name]; ok {
+			return nil, fmt.Errorf("duplicate api key name: %s", key.Name)
+		}
+		names[key.Name] = struct{}{}
+
+		key.Hash = strings.ToLower(key.Hash)
+		if b, err := hex.DecodeString(key.Hash); err != nil || len(b) != 32 {
+			return nil, fmt.Errorf("api key %s: hash must be a hex encoded SHA-256 hash", key.Name)
+		}
+		if _, ok := s.keys[key.Hash]; ok {
+			return nil, fmt.Errorf("api key %s: duplicate hash", key.Name)
+		}
+		if len(key.Scopes) == 0 {
+			return nil, fmt.Errorf("api key %s: at least one scope is required", key.Name)
+		}
+		for _, scope := range key.Scopes {
+			if _, err := ParseScope(string(scope)); err != nil {
+				return nil, fmt.Errorf("api key %s: %w", key.Name, err)
+			}
+		}
+		if key.Quota.Requests < 0 || key.Quota.Requests > 0 && key.Quota.Period <= 0 {
+			return nil, fmt.Errorf("api key %s: quota must have a positive number of requests and period", key.Name)
+		}
+		s.keys[key.Hash] = key
+	}
+	return s, nil
+}
+
+// Get an API key by the hash of the key.
+func (s *apiKeyStore) Get(ctx context.Context, hash string) (APIKey, error) {
+	key, ok := s.keys[strings.ToLower(hash)]
+	if !ok {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+// contextKey is a custom type for context keys.
+type contextKey int
+
+const (
+	// contextKeyAPIKey is the context key for the API key.
+	contextKeyAPIKey contextKey = 0
+)
+
+// NewContext returns a new context with the API key.
+func NewContext(ctx context.Context, key APIKey) context.Context {
+	return context.WithValue(ctx, contextKeyAPIKey, key)
+}
+
+// FromContext returns the API key from the context, if any.
+func FromContext(ctx context.Context) (APIKey, bool) {
+	key, ok := ctx.Value(contextKeyAPIKey).(APIKey)
+	return key, ok
+}
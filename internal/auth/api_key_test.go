@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestNewAPIKeyStore(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   []APIKey
+		wantErr bool
+	}{
+		{
+			name: "new api key store",
+			input: []APIKey{
+				{Name: "ci", Hash: HashAPIKey("key1"), Scopes: []Scope{ScopeCreate}},
+				{Name: "admin", Hash: strings.ToUpper(HashAPIKey("key2")), Scopes: []Scope{ScopeAdmin}, Quota: Quota{Requests: 10, Period: time.Hour}},
+			},
+		},
+		{
+			name:    "new api key store - missing name",
+			input:   []APIKey{{Hash: HashAPIKey("key1"), Scopes: []Scope{ScopeCreate}}},
+			wantErr: true,
+		},
+		{
+			name: "new api key store - duplicate name",
+			input: []APIKey{
+				{Name: "ci", Hash: HashAPIKey("key1"), Scopes: []Scope{ScopeCreate}},
+				{Name: "ci", Hash: HashAPIKey("key2"), Scopes: []Scope{ScopeCreate}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "new api key store - duplicate hash",
+			input: []APIKey{
+				{Name: "ci", Hash: HashAPIKey("key1"), Scopes: []Scope{ScopeCreate}},
+				{Name: "ci2", Hash: HashAPIKey("key1"), Scopes: []Scope{ScopeCreate}},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "new api key store - invalid hash",
+			input:   []APIKey{{Name: "ci", Hash: "key1", Scopes: []Scope{ScopeCreate}}},
+			wantErr: true,
+		},
+		{
+			name:    "new api key store - missing scopes",
+			input:   []APIKey{{Name: "ci", Hash: HashAPIKey("key1")}},
+			wantErr: true,
+		},
+		{
+			name:    "new api key store - invalid scope",
+			input:   []APIKey{{Name: "ci", Hash: HashAPIKey("key1"), Scopes: []Scope{"read"}}},
+			wantErr: true,
+		},
+		{
+			name:    "new api key store - quota without period",
+			input:   []APIKey{{Name: "ci", Hash: HashAPIKey("key1"), Scopes: []Scope{ScopeCreate}, Quota: Quota{Requests: 10}}},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, gotErr := NewAPIKeyStore(test.input...)
+
+			if (gotErr != nil) != test.wantErr {
+				t.Errorf("NewAPIKeyStore() = unexpected error: %v\n", gotErr)
+			}
+		})
+	}
+}
+
+func TestAPIKeyStore_Get(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   string
+		want    APIKey
+		wantErr error
+	}{
+		{
+			name:  "get api key",
+			input: HashAPIKey("key1"),
+			want:  APIKey{Name: "ci", Hash: HashAPIKey("key1"), Scopes: []Scope{ScopeCreate}},
+		},
+		{
+			name:  "get api key - upper case hash",
+			input: strings.ToUpper(HashAPIKey("key1")),
+			want:  APIKey{Name: "ci", Hash: HashAPIKey("key1"), Scopes: []Scope{ScopeCreate}},
+		},
+		{
+			name:    "get api key - not found",
+			input:   HashAPIKey("key2"),
+			wantErr: ErrAPIKeyNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store, err := NewAPIKeyStore(APIKey{Name: "ci", Hash: HashAPIKey("key1"), Scopes: []Scope{ScopeCreate}})
+			if err != nil {
+				t.Fatalf("NewAPIKeyStore() = unexpected error: %v", err)
+			}
+
+			got, gotErr := store.Get(context.Background(), test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Get() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Get() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestAPIKey_HasScope(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			scopes []Scope
+			scope  Scope
+		}
+		want bool
+	}{
+		{
+			name: "has scope",
+			input: struct {
+				scopes []Scope
+				scope  Scope
+			}{
+				scopes: []Scope{ScopeCreate, ScopeGenerate},
+				scope:  ScopeGenerate,
+			},
+			want: true,
+		},
+		{
+			name: "has scope - admin",
+			input: struct {
+				scopes []Scope
+				scope  Scope
+			}{
+				scopes: []Scope{ScopeAdmin},
+				scope:  ScopeDelete,
+			},
+			want: true,
+		},
+		{
+			name: "does not have scope",
+			input: struct {
+				scopes []Scope
+				scope  Scope
+			}{
+				scopes: []Scope{ScopeCreate},
+				scope:  ScopeDelete,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := APIKey{Scopes: test.input.scopes}.HasScope(test.input.scope)
+
+			if test.want != got {
+				t.Errorf("HasScope() = unexpected result, want: %v, got: %v\n", test.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+var (
+	// ErrUserNotAllowed is returned when an authenticated user is not
+	// allowed to sign in.
+	ErrUserNotAllowed = errors.New("user not allowed")
+	// ErrInvalidIDToken is returned when the ID token of a sign in
+	// is missing or invalid.
+	ErrInvalidIDToken = errors.New("invalid id token")
+	// ErrCodeExchange is returned when the provider rejects the exchange
+	// of an authorization code.
+	ErrCodeExchange = errors.New("code exchange failed")
+)
+
+var (
+	// defaultOIDCScopes are the default scopes requested from the
+	// OpenID Connect provider.
+	defaultOIDCScopes = []string{oidc.ScopeOpenID, "email", "profile"}
+)
+
+// oidcAuthenticator authenticates users with OpenID Connect using
+// the authorization code flow with PKCE.
+type oidcAuthenticator struct {
+	config         oauth2.Config
+	verifier       *oidc.IDTokenVerifier
+	allowedDomains []string
+	httpClient     *http.Client
+}
+
+// OIDCOptions contains options for the OpenID Connect authenticator.
+type OIDCOptions struct {
+	// Issuer is the URL of the OpenID Connect provider. It is used
+	// for discovery of the provider endpoints.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the URL the provider redirects to after sign in.
+	RedirectURL string
+	Scopes      []string
+	// AllowedDomains restricts sign in to users with a verified email
+	// address in one of the domains. All users are allowed if empty.
+	AllowedDomains []string
+	HTTPClient     *http.Client
+}
+
+// OIDCOption is a function that sets options for the OpenID Connect
+// authenticator.
+type OIDCOption func(o *OIDCOptions)
+
+// NewOIDC returns a new OpenID Connect authenticator. The endpoints of
+// the provider are discovered from the issuer.
+func NewOIDC(ctx context.Context, options ...OIDCOption) (*oidcAuthenticator, error) {
+	opts := OIDCOptions{
+		Scopes:     defaultOIDCScopes,
+		HTTPClient: http.DefaultClient,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	if len(opts.Issuer) == 0 {
+		return nil, errors.New("oidc: issuer is required")
+	}
+	if len(opts.ClientID) == 0 {
+		return nil, errors.New("oidc: client id is required")
+	}
+	if len(opts.RedirectURL) == 0 {
+		return nil, errors.New("oidc: redirect url is required")
+	}
+	if !slices.Contains(opts.Scopes, oidc.ScopeOpenID) {
+		opts.Scopes = append([]string{oidc.ScopeOpenID}, opts.Scopes...)
+	}
+
+	provider, err := oidc.NewProvider(oidc.ClientContext(ctx, opts.HTTPClient), opts.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: %w", err)
+	}
+
+	allowedDomains := make([]string, len(opts.AllowedDomains))
+	for i, domain := range opts.AllowedDomains {
+		allowedDomains[i] = strings.ToLower(strings.TrimPrefix(domain, "@"))
+	}
+
+	return &oidcAuthenticator{
+		config: oauth2.Config{
+			ClientID:     opts.ClientID,
+			ClientSecret: opts.ClientSecret,
+			Endpoint:     provider.Endpoint(),
+			RedirectURL:  opts.RedirectURL,
+			Scopes:       opts.Scopes,
+		},
+		verifier:       provider.Verifier(&oidc.Config{ClientID: opts.ClientID}),
+		allowedDomains: allowedDomains,
+		httpClient:     opts.HTTPClient,
+	}, nil
+}
+
+// AuthCodeURL returns the URL of the provider to redirect to for sign in.
+// The nonce is included in the ID token and the verifier is used for PKCE.
+func (a oidcAuthenticator) AuthCodeURL(state, nonce, verifier string) string {
+	return a.config.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+}
+
+// Exchange exchanges the authorization code for an ID token and returns the
+// user of the token. The user is the email address of the user, or the
+// subject if the provider does not return an email address.
+func (a oidcAuthenticator) Exchange(ctx context.Context, code, nonce, verifier string) (string, error) {
+	ctx = oidc.ClientContext(ctx, a.httpClient)
+
+	token, err := a.config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrCodeExchange, err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return "", ErrInvalidIDToken
+	}
+
+	idToken, err := a.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(nonce)) != 1 {
+		return "", fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	// The domain of the email address can only be trusted if the provider
+	// has verified it. A missing claim is treated as not verified.
+	if len(a.allowedDomains) > 0 {
+		if !claims.EmailVerified {
+			return "", fmt.Errorf("%w: email not verified", ErrUserNotAllowed)
+		}
+		_, domain, ok := strings.Cut(strings.ToLower(claims.Email), "@")
+		if !ok || !slices.Contains(a.allowedDomains, domain) {
+			return "", fmt.Errorf("%w: domain not allowed", ErrUserNotAllowed)
+		}
+	}
+
+	if len(claims.Email) > 0 {
+		return claims.Email, nil
+	}
+	return idToken.Subject, nil
+}
@@ -0,0 +1,310 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"golang.org/x/oauth2"
+)
+
+func TestOIDC_AuthCodeURL(t *testing.T) {
+	idp := newTestIdP(t)
+
+	authenticator, err := NewOIDC(context.Background(), func(o *OIDCOptions) {
+		o.Issuer = idp.URL
+		o.ClientID = "burnit"
+		o.RedirectURL = "http://localhost:3000/ui/auth/callback"
+	})
+	if err != nil {
+		t.Fatalf("NewOIDC() = unexpected error: %v", err)
+	}
+
+	got, err := url.Parse(authenticator.AuthCodeURL("state", "nonce", "verifier"))
+	if err != nil {
+		t.Fatalf("AuthCodeURL() = unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"client_id":             "burnit",
+		"redirect_uri":          "http://localhost:3000/ui/auth/callback",
+		"response_type":         "code",
+		"scope":                 "openid email profile",
+		"state":                 "state",
+		"nonce":                 "nonce",
+		"code_challenge":        oauth2.S256ChallengeFromVerifier("verifier"),
+		"code_challenge_method": "S256",
+	}
+	for key, value := range want {
+		if got.Query().Get(key) != value {
+			t.Errorf("AuthCodeURL() = unexpected %s, want: %s, got: %s\n", key, value, got.Query().Get(key))
+		}
+	}
+}
+
+func TestOIDC_Exchange(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			claims         map[string]any
+			nonce          string
+			verifier       string
+			allowedDomains []string
+		}
+		want    string
+		wantErr error
+	}{
+		{
+			name: "exchange",
+			input: struct {
+				claims         map[string]any
+				nonce          string
+				verifier       string
+				allowedDomains []string
+			}{
+				claims:   map[string]any{"email": "user@example.com"},
+				nonce:    "nonce",
+				verifier: "verifier",
+			},
+			want: "user@example.com",
+		},
+		{
+			name: "exchange - without email",
+			input: struct {
+				claims         map[string]any
+				nonce          string
+				verifier       string
+				allowedDomains []string
+			}{
+				claims:   map[string]any{},
+				nonce:    "nonce",
+				verifier: "verifier",
+			},
+			want: "subject",
+		},
+		{
+			name: "exchange - allowed domain",
+			input: struct {
+				claims         map[string]any
+				nonce          string
+				verifier       string
+				allowedDomains []string
+			}{
+				claims:         map[string]any{"email": "user@Example.com", "email_verified": true},
+				nonce:          "nonce",
+				verifier:       "verifier",
+				allowedDomains: []string{"example.com"},
+			},
+			want: "user@Example.com",
+		},
+		{
+			name: "exchange - domain not allowed",
+			input: struct {
+				claims         map[string]any
+				nonce          string
+				verifier       string
+				allowedDomains []string
+			}{
+				claims:         map[string]any{"email": "user@example.org", "email_verified": true},
+				nonce:          "nonce",
+				verifier:       "verifier",
+				allowedDomains: []string{"example.com"},
+			},
+			wantErr: ErrUserNotAllowed,
+		},
+		{
+			name: "exchange - email not verified",
+			input: struct {
+				claims         map[string]any
+				nonce          string
+				verifier       string
+				allowedDomains []string
+			}{
+				claims:         map[string]any{"email": "user@example.com", "email_verified": false},
+				nonce:          "nonce",
+				verifier:       "verifier",
+				allowedDomains: []string{"example.com"},
+			},
+			wantErr: ErrUserNotAllowed,
+		},
+		{
+			name: "exchange - email verified claim missing",
+			input: struct {
+				claims         map[string]any
+				nonce          string
+				verifier       string
+				allowedDomains []string
+			}{
+				claims:         map[string]any{"email": "user@example.com"},
+				nonce:          "nonce",
+				verifier:       "verifier",
+				allowedDomains: []string{"example.com"},
+			},
+			wantErr: ErrUserNotAllowed,
+		},
+		{
+			name: "exchange - nonce mismatch",
+			input: struct {
+				claims         map[string]any
+				nonce          string
+				verifier       string
+				allowedDomains []string
+			}{
+				claims:   map[string]any{"email": "user@example.com"},
+				nonce:    "other",
+				verifier: "verifier",
+			},
+			wantErr: ErrInvalidIDToken,
+		},
+		{
+			name: "exchange - invalid verifier",
+			input: struct {
+				claims         map[string]any
+				nonce          string
+				verifier       string
+				allowedDomains []string
+			}{
+				claims:   map[string]any{"email": "user@example.com"},
+				nonce:    "nonce",
+				verifier: "other",
+			},
+			wantErr: ErrCodeExchange,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			idp := newTestIdP(t)
+			idp.claims = test.input.claims
+
+			authenticator, err := NewOIDC(context.Background(), func(o *OIDCOptions) {
+				o.Issuer = idp.URL
+				o.ClientID = "burnit"
+				o.ClientSecret = "secret"
+				o.RedirectURL = "http://localhost:3000/ui/auth/callback"
+				o.AllowedDomains = test.input.allowedDomains
+			})
+			if err != nil {
+				t.Fatalf("NewOIDC() = unexpected error: %v", err)
+			}
+
+			// The code challenge is sent to the provider with the authorization request.
+			idp.challenge = oauth2.S256ChallengeFromVerifier("verifier")
+
+			got, gotErr := authenticator.Exchange(context.Background(), "code", test.input.nonce, test.input.verifier)
+
+			if test.want != got {
+				t.Errorf("Exchange() = unexpected result, want: %s, got: %s\n", test.want, got)
+			}
+
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Errorf("Exchange() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+// testIdP is a minimal OpenID Connect provider for tests.
+type testIdP struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	claims    map[string]any
+	challenge string
+}
+
+// newTestIdP returns a new test provider that issues ID tokens with the
+// nonce "nonce" for the client "burnit".
+func newTestIdP(t *testing.T) *testIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() = unexpected error: %v", err)
+	}
+
+	idp := &testIdP{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"issuer":                                idp.URL,
+			"authorization_endpoint":                idp.URL + "/authorize",
+			"token_endpoint":                        idp.URL + "/token",
+			"jwks_uri":                              idp.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "test", Algorithm: "RS256", Use: "sig"}}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if r.FormValue("code") != "code" || base64.RawURLEncoding.EncodeToString(sum[:]) != idp.challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+
+		claims := map[string]any{
+			"iss":   idp.URL,
+			"sub":   "subject",
+			"aud":   "burnit",
+			"nonce": "nonce",
+			"iat":   time.Now().Unix(),
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+		for k, v := range idp.claims {
+			claims[k] = v
+		}
+
+		writeJSON(w, map[string]any{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idp.sign(t, claims),
+		})
+	})
+
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+// sign signs the claims and returns a compact JWT.
+func (idp *testIdP) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: idp.key}, (&jose.SignerOptions{}).WithHeader("kid", "test"))
+	if err != nil {
+		t.Fatalf("NewSigner() = unexpected error: %v", err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("Marshal() = unexpected error: %v", err)
+	}
+	jws, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatalf("Sign() = unexpected error: %v", err)
+	}
+	token, err := jws.CompactSerialize()
+	if err != nil {
+		t.Fatalf("CompactSerialize() = unexpected error: %v", err)
+	}
+	return token
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,236 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/api"
+)
+
+var (
+	// ErrNoSecrets is returned when there are no secrets to create.
+	ErrNoSecrets = errors.New("no secrets to create")
+	// ErrInvalidFormat is returned when the format is not csv or json.
+	ErrInvalidFormat = errors.New("invalid format")
+)
+
+// csvColumns are the supported columns of a CSV file with secrets.
+var csvColumns = []string{"value", "passphrase", "ttl", "label", "note", "length", "words"}
+
+// Read reads the secrets to create from r in the given format (csv or json).
+//
+// A CSV file must have a header row with one or more of the columns value,
+// passphrase, ttl, label, note, length and words. The value of a secret
+// without a value is generated, as a passphrase if words is set, otherwise
+// with the set length.
+//
+// A JSON file contains an array of secrets, or an object with the secrets in
+// the field secrets, in the same format as the request to create a secret.
+func Read(r io.Reader, format string) ([]api.CreateSecretRequest, error) {
+	var secrets []api.CreateSecretRequest
+	var err error
+	switch format {
+	case "csv":
+		secrets, err = readCSV(r)
+	case "json":
+		secrets, err = readJSON(r)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFormat, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(secrets) == 0 {
+		return nil, ErrNoSecrets
+	}
+	return secrets, nil
+}
+
+// readCSV reads secrets from a CSV file with a header row.
+func readCSV(r io.Reader) ([]api.CreateSecretRequest, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrNoSecrets
+		}
+		return nil, fmt.Errorf("could not read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !slices.Contains(csvColumns, column) {
+			return nil, fmt.Errorf("unsupported column %q, supported columns are: %s", column, strings.Join(csvColumns, ", "))
+		}
+		columns[column] = i
+	}
+
+	var secrets []api.CreateSecretRequest
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+
+		field := func(column string) string {
+			if i, ok := columns[column]; ok {
+				return record[i]
+			}
+			return ""
+		}
+
+		secret := api.CreateSecretRequest{
+			Value:      field("value"),
+			Passphrase: field("passphrase"),
+			TTL:        field("ttl"),
+			Label:      field("label"),
+			Note:       field("note"),
+		}
+		if len(secret.Value) == 0 {
+			generate, err := csvGenerate(field("length"), field("words"))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			secret.Generate = generate
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets, nil
+}
+
+// csvGenerate returns the options to generate the value of a secret from
+// the length and words columns of a CSV file.
+func csvGenerate(length, words string) (*api.GenerateSecretRequest, error) {
+	generate := &api.GenerateSecretRequest{ReturnValue: true}
+	if len(words) > 0 {
+		w, err := strconv.Atoi(words)
+		if err != nil {
+			return nil, fmt.Errorf("invalid words: %s", words)
+		}
+		generate.Words = w
+		return generate, nil
+	}
+	if len(length) > 0 {
+		l, err := strconv.Atoi(length)
+		if err != nil {
+			return nil, fmt.Errorf("invalid length: %s", length)
+		}
+		generate.Length = l
+	}
+	return generate, nil
+}
+
+// readJSON reads secrets from a JSON array, or an object with the field
+// secrets.
+func readJSON(r io.Reader) ([]api.CreateSecretRequest, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets []api.CreateSecretRequest
+	if b = bytes.TrimSpace(b); len(b) > 0 && b[0] == '[' {
+		err = json.Unmarshal(b, &secrets)
+	} else {
+		var request api.CreateSecretsRequest
+		err = json.Unmarshal(b, &request)
+		secrets = request.Secrets
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not decode secrets: %w", err)
+	}
+	return secrets, nil
+}
+
+// Create creates the secrets with a request to the server at url. The
+// API key is optional.
+func Create(ctx context.Context, client *http.Client, url, apiKey string, request api.CreateSecretsRequest) ([]api.Secret, error) {
+	b, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url+"/secrets:batch", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(apiKey) > 0 {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		var respErr api.Error
+		if err := json.NewDecoder(resp.Body).Decode(&respErr); err != nil || len(respErr.Err) == 0 {
+			return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+		if len(respErr.Code) > 0 {
+			return nil, fmt.Errorf("%s: %s", respErr.Code, respErr.Err)
+		}
+		return nil, respErr
+	}
+
+	var response api.CreateSecretsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("could not decode response: %w", err)
+	}
+	return response.Secrets, nil
+}
+
+// Write writes the created secrets to w in the given format (csv or json).
+// Each secret is written with its label, ID, passphrase, generated value
+// (if any), expiration time and link.
+func Write(w io.Writer, format string, secrets []api.Secret) error {
+	switch format {
+	case "csv":
+		return writeCSV(w, secrets)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(api.CreateSecretsResponse{Secrets: secrets})
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidFormat, format)
+	}
+}
+
+// writeCSV writes the created secrets as CSV with a header row.
+func writeCSV(w io.Writer, secrets []api.Secret) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"label", "id", "passphrase", "value", "expiresAt", "link"}); err != nil {
+		return err
+	}
+	for _, secret := range secrets {
+		var expiresAt string
+		if secret.ExpiresAt != nil {
+			expiresAt = secret.ExpiresAt.Format(time.RFC3339)
+		}
+		if err := writer.Write([]string{secret.Label, secret.ID, secret.Passphrase, secret.Value, expiresAt, secret.Link}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,333 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/api"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestRead(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			r      io.Reader
+			format string
+		}
+		want    []api.CreateSecretRequest
+		wantErr error
+	}{
+		{
+			name: "read csv",
+			input: struct {
+				r      io.Reader
+				format string
+			}{
+				r:      strings.NewReader("label,value,passphrase,ttl,words\nAnna,secret,key,72h,\nBertil,,,,4\n"),
+				format: "csv",
+			},
+			want: []api.CreateSecretRequest{
+				{
+					Value:      "secret",
+					Passphrase: "key",
+					TTL:        "72h",
+					Label:      "Anna",
+				},
+				{
+					Label: "Bertil",
+					Generate: &api.GenerateSecretRequest{
+						Words:       4,
+						ReturnValue: true,
+					},
+				},
+			},
+		},
+		{
+			name: "read csv - generate with length",
+			input: struct {
+				r      io.Reader
+				format string
+			}{
+				r:      strings.NewReader("label, length\nAnna, 32\n"),
+				format: "csv",
+			},
+			want: []api.CreateSecretRequest{
+				{
+					Label: "Anna",
+					Generate: &api.GenerateSecretRequest{
+						Length:      32,
+						ReturnValue: true,
+					},
+				},
+			},
+		},
+		{
+			name: "read csv - unsupported column",
+			input: struct {
+				r      io.Reader
+				format string
+			}{
+				r:      strings.NewReader("label,email\nAnna,anna@example.com\n"),
+				format: "csv",
+			},
+			wantErr: cmpopts.AnyError,
+		},
+		{
+			name: "read csv - only header",
+			input: struct {
+				r      io.Reader
+				format string
+			}{
+				r:      strings.NewReader("label,value\n"),
+				format: "csv",
+			},
+			wantErr: ErrNoSecrets,
+		},
+		{
+			name: "read json - array",
+			input: struct {
+				r      io.Reader
+				format string
+			}{
+				r:      strings.NewReader(`[{"value":"secret","label":"Anna"},{"generate":{"words":4},"label":"Bertil"}]`),
+				format: "json",
+			},
+			want: []api.CreateSecretRequest{
+				{
+					Value: "secret",
+					Label: "Anna",
+				},
+				{
+					Label: "Bertil",
+					Generate: &api.GenerateSecretRequest{
+						Words: 4,
+					},
+				},
+			},
+		},
+		{
+			name: "read json - object",
+			input: struct {
+				r      io.Reader
+				format string
+			}{
+				r:      strings.NewReader(` {"secrets":[{"value":"secret","label":"Anna"}]}`),
+				format: "json",
+			},
+			want: []api.CreateSecretRequest{
+				{
+					Value: "secret",
+					Label: "Anna",
+				},
+			},
+		},
+		{
+			name: "read json - empty",
+			input: struct {
+				r      io.Reader
+				format string
+			}{
+				r:      strings.NewReader(`[]`),
+				format: "json",
+			},
+			wantErr: ErrNoSecrets,
+		},
+		{
+			name: "read - invalid format",
+			input: struct {
+				r      io.Reader
+				format string
+			}{
+				r:      strings.NewReader(""),
+				format: "xml",
+			},
+			wantErr: ErrInvalidFormat,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := Read(test.input.r, test.input.format)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Read() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Read() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			handler http.HandlerFunc
+			apiKey  string
+			request api.CreateSecretsRequest
+		}
+		want    []api.Secret
+		wantErr error
+	}{
+		{
+			name: "create secrets",
+			input: struct {
+				handler http.HandlerFunc
+				apiKey  string
+				request api.CreateSecretsRequest
+			}{
+				handler: func(w http.ResponseWriter, r *http.Request) {
+					if r.Method != http.MethodPost || r.URL.Path != "/secrets:batch" || r.Header.Get("X-API-Key") != "key" {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					w.WriteHeader(http.StatusCreated)
+					w.Write([]byte(`{"secrets":[{"id":"1","passphrase":"passphrase","label":"Anna","link":"http://localhost/ui/secrets/1/hash"}]}`))
+				},
+				apiKey: "key",
+				request: api.CreateSecretsRequest{
+					Secrets: []api.CreateSecretRequest{{Value: "secret", Label: "Anna"}},
+				},
+			},
+			want: []api.Secret{
+				{
+					ID:         "1",
+					Passphrase: "passphrase",
+					Label:      "Anna",
+					Link:       "http://localhost/ui/secrets/1/hash",
+				},
+			},
+		},
+		{
+			name: "create secrets - error response",
+			input: struct {
+				handler http.HandlerFunc
+				apiKey  string
+				request api.CreateSecretsRequest
+			}{
+				handler: func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"statusCode":400,"code":"InvalidBatch","error":"invalid batch"}`))
+				},
+				request: api.CreateSecretsRequest{
+					Secrets: []api.CreateSecretRequest{{Value: "secret"}},
+				},
+			},
+			wantErr: errors.New("InvalidBatch: invalid batch"),
+		},
+		{
+			name: "create secrets - unexpected response",
+			input: struct {
+				handler http.HandlerFunc
+				apiKey  string
+				request api.CreateSecretsRequest
+			}{
+				handler: func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusBadGateway)
+				},
+				request: api.CreateSecretsRequest{
+					Secrets: []api.CreateSecretRequest{{Value: "secret"}},
+				},
+			},
+			wantErr: errors.New("unexpected status code: 502"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := httptest.NewServer(test.input.handler)
+			defer srv.Close()
+
+			got, gotErr := Create(context.Background(), srv.Client(), srv.URL, test.input.apiKey, test.input.request)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Create() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if test.wantErr == nil && gotErr != nil {
+				t.Errorf("Create() = unexpected error: %v\n", gotErr)
+			}
+			if test.wantErr != nil && (gotErr == nil || gotErr.Error() != test.wantErr.Error()) {
+				t.Errorf("Create() = expected error: %v, got: %v\n", test.wantErr, gotErr)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	expiresAt := &api.Time{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	var tests = []struct {
+		name  string
+		input struct {
+			format  string
+			secrets []api.Secret
+		}
+		want    string
+		wantErr error
+	}{
+		{
+			name: "write csv",
+			input: struct {
+				format  string
+				secrets []api.Secret
+			}{
+				format: "csv",
+				secrets: []api.Secret{
+					{ID: "1", Passphrase: "passphrase", ExpiresAt: expiresAt, Label: "Anna", Link: "http://localhost/ui/secrets/1/hash"},
+					{ID: "2", Passphrase: "passphrase", Value: "generated", Label: "Bertil, B"},
+				},
+			},
+			want: "label,id,passphrase,value,expiresAt,link\nAnna,1,passphrase,,2024-01-01T00:00:00Z,http://localhost/ui/secrets/1/hash\n\"Bertil, B\",2,passphrase,generated,,\n",
+		},
+		{
+			name: "write json",
+			input: struct {
+				format  string
+				secrets []api.Secret
+			}{
+				format: "json",
+				secrets: []api.Secret{
+					{ID: "1", Passphrase: "passphrase", Label: "Anna", Link: "http://localhost/ui/secrets/1/hash"},
+				},
+			},
+			want: "{\n  \"secrets\": [\n    {\n      \"id\": \"1\",\n      \"passphrase\": \"passphrase\",\n      \"label\": \"Anna\",\n      \"link\": \"http://localhost/ui/secrets/1/hash\"\n    }\n  ]\n}\n",
+		},
+		{
+			name: "write - invalid format",
+			input: struct {
+				format  string
+				secrets []api.Secret
+			}{
+				format: "xml",
+			},
+			wantErr: ErrInvalidFormat,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			gotErr := Write(&buf, test.input.format, test.input.secrets)
+
+			if diff := cmp.Diff(test.want, buf.String()); diff != "" {
+				t.Errorf("Write() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Write() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
@@ -5,15 +5,26 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"reflect"
 	"regexp"
 	"time"
 
+	"github.com/RedeployAB/burnit/internal/security"
 	"github.com/caarlos0/env/v11"
 	"gopkg.in/yaml.v3"
 )
 
+var (
+	// ErrAPIKeysRequireSignIn is returned when API keys are configured
+	// together with the UI without single sign-on.
+	ErrAPIKeysRequireSignIn = errors.New("api keys require single sign-on (oidc) or a backend only server when the UI is enabled")
+	// ErrInvalidBaseURL is returned when the base URL of the server is
+	// not an absolute http or https URL.
+	ErrInvalidBaseURL = errors.New("invalid base url, expected an absolute http or https url")
+)
+
 const (
 	// defaultListenHost is the default host to listen on.
 	defaultListenHost = "0.0.0.0"
@@ -49,6 +60,24 @@ const (
 	defaultRateLimiterTTL = 5 * time.Minute
 	// defaultRateLimiterCleanupInterval is the default rate limiter cleanup interval.
 	defaultRateLimiterCleanupInterval = 10 * time.Second
+	// defaultAPIKeyQuotaPeriod is the default period for API key quotas.
+	defaultAPIKeyQuotaPeriod = 24 * time.Hour
+)
+
+const (
+	// rateLimiterStoreMemory is the in-memory rate limit store.
+	rateLimiterStoreMemory = "memory"
+	// rateLimiterStoreRedis is the Redis rate limit store.
+	rateLimiterStoreRedis = "redis"
+)
+
+const (
+	// rateLimiterKeyIP keys rate limits by source IP.
+	rateLimiterKeyIP = "ip"
+	// rateLimiterKeyAPIKey keys rate limits by API key.
+	rateLimiterKeyAPIKey = "apiKey"
+	// rateLimiterKeySecret keys rate limits by secret ID.
+	rateLimiterKeySecret = "secret"
 )
 
 const (
@@ -61,6 +90,9 @@ const (
 const (
 	// defaultSessionServiceTimeout is the default timeout for the session service.
 	defaultSessionServiceTimeout = 5 * time.Second
+	// defaultOIDCDiscoveryTimeout is the default timeout for discovery of
+	// the OpenID Connect provider.
+	defaultOIDCDiscoveryTimeout = 10 * time.Second
 )
 
 const (
@@ -81,12 +113,20 @@ type Configuration struct {
 
 // Server contains the configuration for the server.
 type Server struct {
-	Host        string      `env:"LISTEN_HOST" yaml:"host"`
-	Port        int         `env:"LISTEN_PORT" yaml:"port"`
-	TLS         TLS         `yaml:"tls"`
-	CORS        CORS        `yaml:"cors"`
-	RateLimiter RateLimiter `yaml:"rateLimiter"`
-	BackendOnly *bool       `env:"BACKEND_ONLY" yaml:"backendOnly"`
+	Host            string        `env:"LISTEN_HOST" yaml:"host"`
+	Port            int           `env:"LISTEN_PORT" yaml:"port"`
+	TLS             TLS           `yaml:"tls"`
+	CORS            CORS          `yaml:"cors"`
+	RateLimiter     RateLimiter   `yaml:"rateLimiter"`
+	Metrics         Metrics       `yaml:"metrics"`
+	Tracing         Tracing       `yaml:"tracing"`
+	APIKeys         APIKeys       `yaml:"apiKeys"`
+	TrustedProxies  []string      `env:"TRUSTED_PROXIES" yaml:"trustedProxies"`
+	AllowedNetworks []string      `env:"ALLOWED_NETWORKS" yaml:"allowedNetworks"`
+	RequestIDHeader string        `env:"REQUEST_ID_HEADER" yaml:"requestIdHeader"`
+	ShutdownDelay   time.Duration `env:"SHUTDOWN_DELAY" yaml:"shutdownDelay"`
+	BaseURL         string        `env:"BASE_URL" yaml:"baseUrl"`
+	BackendOnly     *bool         `env:"BACKEND_ONLY" yaml:"backendOnly"`
 }
 
 // MarshalJSON returns the JSON encoding of Server. A custom marshalling method
@@ -105,24 +145,55 @@ func (s Server) MarshalJSON() ([]byte, error) {
 	}
 
 	var rateLimiter *RateLimiter
-	if s.RateLimiter.Burst > 0 || s.RateLimiter.Rate > 0 || s.RateLimiter.TTL > 0 || s.RateLimiter.CleanupInterval > 0 {
+	if s.RateLimiter.isSet() || len(s.RateLimiter.Store) > 0 {
 		rateLimiter = &s.RateLimiter
 	}
 
+	var metrics *Metrics
+	if s.Metrics.Enabled != nil || len(s.Metrics.Address) > 0 {
+		metrics = &s.Metrics
+	}
+
+	var tracing *Tracing
+	if s.Tracing.Enabled != nil || len(s.Tracing.Endpoint) > 0 || s.Tracing.SampleRatio > 0 {
+		tracing = &s.Tracing
+	}
+
+	var apiKeys *APIKeys
+	if s.APIKeys.isSet() {
+		apiKeys = &s.APIKeys
+	}
+
 	return json.Marshal(struct {
-		Host        string       `json:",omitempty"`
-		Port        int          `json:",omitempty"`
-		TLS         *TLS         `json:",omitempty"`
-		CORS        *CORS        `json:",omitempty"`
-		RateLimiter *RateLimiter `json:",omitempty"`
-		BackendOnly *bool        `json:",omitempty"`
+		Host            string        `json:",omitempty"`
+		Port            int           `json:",omitempty"`
+		TLS             *TLS          `json:",omitempty"`
+		CORS            *CORS         `json:",omitempty"`
+		RateLimiter     *RateLimiter  `json:",omitempty"`
+		Metrics         *Metrics      `json:",omitempty"`
+		Tracing         *Tracing      `json:",omitempty"`
+		APIKeys         *APIKeys      `json:",omitempty"`
+		TrustedProxies  []string      `json:",omitempty"`
+		AllowedNetworks []string      `json:",omitempty"`
+		RequestIDHeader string        `json:",omitempty"`
+		ShutdownDelay   time.Duration `json:",omitempty"`
+		BaseURL         string        `json:",omitempty"`
+		BackendOnly     *bool         `json:",omitempty"`
 	}{
-		Host:        s.Host,
-		Port:        s.Port,
-		TLS:         tls,
-		CORS:        cors,
-		RateLimiter: rateLimiter,
-		BackendOnly: s.BackendOnly,
+		Host:            s.Host,
+		Port:            s.Port,
+		TLS:             tls,
+		CORS:            cors,
+		RateLimiter:     rateLimiter,
+		Metrics:         metrics,
+		Tracing:         tracing,
+		APIKeys:         apiKeys,
+		TrustedProxies:  s.TrustedProxies,
+		AllowedNetworks: s.AllowedNetworks,
+		RequestIDHeader: s.RequestIDHeader,
+		ShutdownDelay:   s.ShutdownDelay,
+		BaseURL:         s.BaseURL,
+		BackendOnly:     s.BackendOnly,
 	})
 }
 
@@ -139,11 +210,154 @@ type CORS struct {
 
 // RateLimiter contains the configuration for the rate limiter.
 type RateLimiter struct {
-	Enabled         *bool         `env:"RATE_LIMITER" yaml:"enabled"`
-	Rate            float64       `env:"RATE_LIMITER_RATE" yaml:"rate"`
-	Burst           int           `env:"RATE_LIMITER_BURST" yaml:"burst"`
-	TTL             time.Duration `env:"RATE_LIMITER_TTL" yaml:"ttl"`
-	CleanupInterval time.Duration `env:"RATE_LIMITER_CLEANUP_INTERVAL" yaml:"cleanupInterval"`
+	Enabled         *bool               `env:"RATE_LIMITER" yaml:"enabled"`
+	Rate            float64             `env:"RATE_LIMITER_RATE" yaml:"rate"`
+	Burst           int                 `env:"RATE_LIMITER_BURST" yaml:"burst"`
+	TTL             time.Duration       `env:"RATE_LIMITER_TTL" yaml:"ttl"`
+	CleanupInterval time.Duration       `env:"RATE_LIMITER_CLEANUP_INTERVAL" yaml:"cleanupInterval"`
+	Store           string              `env:"RATE_LIMITER_STORE" yaml:"store"`
+	Redis           RateLimiterRedis    `yaml:"redis"`
+	Policies        RateLimiterPolicies `yaml:"policies"`
+}
+
+// isSet returns true if any of the rate limit options are set.
+func (r RateLimiter) isSet() bool {
+	return r.Rate > 0 || r.Burst > 0 || r.TTL > 0 || r.CleanupInterval > 0 || r.Policies.isSet()
+}
+
+// RateLimiterPolicies contains the configuration for the rate limit
+// policies, per operation.
+type RateLimiterPolicies struct {
+	Generate         RateLimiterPolicy `envPrefix:"RATE_LIMITER_GENERATE_" yaml:"generate"`
+	Create           RateLimiterPolicy `envPrefix:"RATE_LIMITER_CREATE_" yaml:"create"`
+	Retrieve         RateLimiterPolicy `envPrefix:"RATE_LIMITER_RETRIEVE_" yaml:"retrieve"`
+	FailedPassphrase RateLimiterPolicy `envPrefix:"RATE_LIMITER_FAILED_PASSPHRASE_" yaml:"failedPassphrase"`
+	UI               RateLimiterPolicy `envPrefix:"RATE_LIMITER_UI_" yaml:"ui"`
+}
+
+// isSet returns true if any of the policies are set.
+func (p RateLimiterPolicies) isSet() bool {
+	return p.Generate.isSet() || p.Create.isSet() || p.Retrieve.isSet() || p.FailedPassphrase.isSet() || p.UI.isSet()
+}
+
+// validate the keys of the policies.
+func (p RateLimiterPolicies) validate() error {
+	policies := []struct {
+		name   string
+		policy RateLimiterPolicy
+	}{
+		{name: "generate", policy: p.Generate},
+		{name: "create", policy: p.Create},
+		{name: "retrieve", policy: p.Retrieve},
+		{name: "failedPassphrase", policy: p.FailedPassphrase},
+		{name: "ui", policy: p.UI},
+	}
+	for _, policy := range policies {
+		switch policy.policy.Key {
+		case "", rateLimiterKeyIP, rateLimiterKeyAPIKey, rateLimiterKeySecret:
+		default:
+			return fmt.Errorf("unsupported key for rate limit policy %s: %s", policy.name, policy.policy.Key)
+		}
+	}
+	return nil
+}
+
+// RateLimiterPolicy contains the configuration for a rate limit policy.
+type RateLimiterPolicy struct {
+	Rate  float64 `env:"RATE" yaml:"rate"`
+	Burst int     `env:"BURST" yaml:"burst"`
+	Key   string  `env:"KEY" yaml:"key"`
+}
+
+// isSet returns true if any of the policy options are set.
+func (p RateLimiterPolicy) isSet() bool {
+	return p.Rate > 0 || p.Burst > 0 || len(p.Key) > 0
+}
+
+// RateLimiterRedis contains the configuration for the Redis rate limit store.
+type RateLimiterRedis struct {
+	URI       string `env:"RATE_LIMITER_REDIS_URI" yaml:"uri"`
+	Address   string `env:"RATE_LIMITER_REDIS_ADDRESS" yaml:"address"`
+	Username  string `env:"RATE_LIMITER_REDIS_USERNAME" yaml:"username"`
+	Password  string `env:"RATE_LIMITER_REDIS_PASSWORD" yaml:"password"`
+	EnableTLS *bool  `env:"RATE_LIMITER_REDIS_ENABLE_TLS" yaml:"enableTLS"`
+	KeyPrefix string `env:"RATE_LIMITER_REDIS_KEY_PREFIX" yaml:"keyPrefix"`
+}
+
+// MarshalJSON returns the JSON encoding of RateLimiterRedis. A custom marshalling method
+// is defined to hide sensitive values. The reason for not just using the struct tag
+// `json:"-"` is that this way we must explicitly set the properties to be marshalled
+// and thus output to the logs.
+func (r RateLimiterRedis) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		URI       string `json:",omitempty"`
+		Address   string `json:",omitempty"`
+		EnableTLS *bool  `json:",omitempty"`
+		KeyPrefix string `json:",omitempty"`
+	}{
+		URI:       maskURI(r.URI),
+		Address:   r.Address,
+		EnableTLS: r.EnableTLS,
+		KeyPrefix: r.KeyPrefix,
+	})
+}
+
+// Metrics contains the configuration for metrics.
+type Metrics struct {
+	Enabled *bool  `env:"METRICS" yaml:"enabled"`
+	Address string `env:"METRICS_ADDRESS" yaml:"address"`
+}
+
+// Tracing contains the configuration for tracing.
+type Tracing struct {
+	Enabled     *bool   `env:"TRACING" yaml:"enabled"`
+	Endpoint    string  `env:"TRACING_ENDPOINT" yaml:"endpoint"`
+	SampleRatio float64 `env:"TRACING_SAMPLE_RATIO" yaml:"sampleRatio"`
+}
+
+// APIKeys contains the configuration for API keys. Keys can be
+// set in the configuration file and in a separate keys file.
+type APIKeys struct {
+	File string   `env:"API_KEYS_FILE" yaml:"file"`
+	Keys []APIKey `yaml:"keys"`
+}
+
+// isSet returns true if any API keys are configured.
+func (k APIKeys) isSet() bool {
+	return len(k.File) > 0 || len(k.Keys) > 0
+}
+
+// APIKey contains the configuration for an API key.
+type APIKey struct {
+	Name   string      `yaml:"name"`
+	Hash   string      `yaml:"hash"`
+	Scopes []string    `yaml:"scopes"`
+	Quota  APIKeyQuota `yaml:"quota"`
+}
+
+// MarshalJSON returns the JSON encoding of APIKey. A custom marshalling method
+// is defined to hide the hash of the key.
+func (k APIKey) MarshalJSON() ([]byte, error) {
+	var quota *APIKeyQuota
+	if k.Quota.Requests > 0 {
+		quota = &k.Quota
+	}
+
+	return json.Marshal(struct {
+		Name   string       `json:",omitempty"`
+		Scopes []string     `json:",omitempty"`
+		Quota  *APIKeyQuota `json:",omitempty"`
+	}{
+		Name:   k.Name,
+		Scopes: k.Scopes,
+		Quota:  quota,
+	})
+}
+
+// APIKeyQuota contains the configuration for the quota of an API key.
+type APIKeyQuota struct {
+	Requests int           `yaml:"requests"`
+	Period   time.Duration `yaml:"period"`
 }
 
 // Services contains the configuration for the services.
@@ -153,8 +367,12 @@ type Services struct {
 
 // Secret contains the configuration for the secret service.
 type Secret struct {
-	Timeout  time.Duration `env:"SECRET_SERVICE_TIMEOUT" yaml:"timeout"`
-	Database Database      `yaml:"database"`
+	Timeout time.Duration `env:"SECRET_SERVICE_TIMEOUT" yaml:"timeout"`
+	// PassphraseMinScore is the minimum estimated strength (0-4) of
+	// custom passphrases. 0 disables the check.
+	PassphraseMinScore int      `env:"SECRET_PASSPHRASE_MIN_SCORE" yaml:"passphraseMinScore"`
+	Database           Database `yaml:"database"`
+	Mail               Mail     `yaml:"mail"`
 }
 
 // MarshalJSON returns the JSON encoding of Secret. A custom marshalling method
@@ -167,12 +385,56 @@ func (s Secret) MarshalJSON() ([]byte, error) {
 		secretDatabase = &s.Database
 	}
 
+	var mail *Mail
+	if s.Mail.isSet() {
+		mail = &s.Mail
+	}
+
 	return json.Marshal(struct {
-		Timeout  time.Duration `json:",omitempty"`
-		Database *Database     `json:",omitempty"`
+		Timeout            time.Duration `json:",omitempty"`
+		PassphraseMinScore int           `json:",omitempty"`
+		Database           *Database     `json:",omitempty"`
+		Mail               *Mail         `json:",omitempty"`
 	}{
-		Timeout:  s.Timeout,
-		Database: secretDatabase,
+		Timeout:            s.Timeout,
+		PassphraseMinScore: s.PassphraseMinScore,
+		Database:           secretDatabase,
+		Mail:               mail,
+	})
+}
+
+// Mail contains the configuration for sending codes for the email
+// second factor of secrets. Secrets with an email second factor
+// can only be created if an SMTP host is configured.
+type Mail struct {
+	From string `env:"MAIL_FROM" yaml:"from"`
+	SMTP SMTP   `yaml:"smtp"`
+}
+
+// isSet returns true if mail is configured.
+func (m Mail) isSet() bool {
+	return len(m.SMTP.Host) > 0
+}
+
+// SMTP contains the configuration for the SMTP server.
+type SMTP struct {
+	Host     string `env:"SMTP_HOST" yaml:"host"`
+	Port     int    `env:"SMTP_PORT" yaml:"port"`
+	Username string `env:"SMTP_USERNAME" yaml:"username"`
+	Password string `env:"SMTP_PASSWORD" yaml:"password"`
+}
+
+// MarshalJSON returns the JSON encoding of SMTP. A custom marshalling method
+// is defined to hide the password.
+func (s SMTP) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Host     string `json:",omitempty"`
+		Port     int    `json:",omitempty"`
+		Username string `json:",omitempty"`
+	}{
+		Host:     s.Host,
+		Port:     s.Port,
+		Username: s.Username,
 	})
 }
 
@@ -189,6 +451,9 @@ type Database struct {
 	MaxOpenConnections    int           `env:"DATABASE_MAX_OPEN_CONNECTIONS" yaml:"maxOpenConnections"`
 	MaxIdleConnections    int           `env:"DATABASE_MAX_IDLE_CONNECTIONS" yaml:"maxIdleConnections"`
 	MaxConnectionLifetime time.Duration `env:"DATABASE_MAX_CONNECTION_LIFETIME" yaml:"maxConnectionLifetime"`
+	Schema                string        `env:"DATABASE_SCHEMA" yaml:"schema"`
+	Table                 string        `env:"DATABASE_TABLE" yaml:"table"`
+	TLS                   DatabaseTLS   `yaml:"tls"`
 	Mongo                 Mongo         `yaml:"mongo"`
 	Postgres              Postgres      `yaml:"postgres"`
 	MSSQL                 MSSQL         `yaml:"mssql"`
@@ -202,16 +467,12 @@ type Database struct {
 // `json:"-"` is that this way we must explicitly set the properties to be marshalled
 // and thus output to the logs.
 func (d Database) MarshalJSON() ([]byte, error) {
-	var uri string
-	reg := regexp.MustCompile(`://.*:.*@`)
-	if len(d.URI) > 0 && reg.MatchString(d.URI) {
-		uri = reg.ReplaceAllString(d.URI, "://***:***@")
-	} else {
-		uri = d.URI
+	var tls *DatabaseTLS
+	if d.TLS.isSet() {
+		tls = &d.TLS
 	}
-
 	var mongo *Mongo
-	if d.Mongo.EnableTLS != nil {
+	if len(d.Mongo.Collection) > 0 || d.Mongo.EnableTLS != nil {
 		mongo = &d.Mongo
 	}
 	var postgres *Postgres
@@ -227,7 +488,7 @@ func (d Database) MarshalJSON() ([]byte, error) {
 		sqlite = &d.SQLite
 	}
 	var redis *Redis
-	if d.Redis.DialTimeout > 0 || d.Redis.MaxRetries > 0 || d.Redis.MinRetryBackoff > 0 || d.Redis.MaxRetryBackoff > 0 || d.Redis.EnableTLS != nil {
+	if d.Redis.DialTimeout > 0 || d.Redis.MaxRetries > 0 || d.Redis.MinRetryBackoff > 0 || d.Redis.MaxRetryBackoff > 0 || d.Redis.EnableTLS != nil || len(d.Redis.KeyPrefix) > 0 {
 		redis = &d.Redis
 	}
 
@@ -236,8 +497,11 @@ func (d Database) MarshalJSON() ([]byte, error) {
 		URI            string        `json:",omitempty"`
 		Address        string        `json:",omitempty"`
 		Database       string        `json:",omitempty"`
+		Schema         string        `json:",omitempty"`
+		Table          string        `json:",omitempty"`
 		Timeout        time.Duration `json:",omitempty"`
 		ConnectTimeout time.Duration `json:",omitempty"`
+		TLS            *DatabaseTLS  `json:",omitempty"`
 		Mongo          *Mongo        `json:",omitempty"`
 		Postgres       *Postgres     `json:",omitempty"`
 		MSSQL          *MSSQL        `json:",omitempty"`
@@ -245,11 +509,14 @@ func (d Database) MarshalJSON() ([]byte, error) {
 		Redis          *Redis        `json:",omitempty"`
 	}{
 		Driver:         d.Driver,
-		URI:            uri,
+		URI:            maskURI(d.URI),
 		Address:        d.Address,
 		Database:       d.Database,
+		Schema:         d.Schema,
+		Table:          d.Table,
 		Timeout:        d.Timeout,
 		ConnectTimeout: d.ConnectTimeout,
+		TLS:            tls,
 		Mongo:          mongo,
 		Postgres:       postgres,
 		MSSQL:          mssql,
@@ -258,9 +525,34 @@ func (d Database) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// credentialsRegexp matches credentials in a URI.
+var credentialsRegexp = regexp.MustCompile(`://.*:.*@`)
+
+// maskURI masks credentials in the URI.
+func maskURI(uri string) string {
+	if len(uri) > 0 && credentialsRegexp.MatchString(uri) {
+		return credentialsRegexp.ReplaceAllString(uri, "://***:***@")
+	}
+	return uri
+}
+
+// DatabaseTLS contains the TLS configuration for the database connection.
+type DatabaseTLS struct {
+	CAFile     string `env:"DATABASE_TLS_CA_FILE" yaml:"caFile"`
+	CertFile   string `env:"DATABASE_TLS_CERT_FILE" yaml:"certFile"`
+	KeyFile    string `env:"DATABASE_TLS_KEY_FILE" yaml:"keyFile"`
+	ServerName string `env:"DATABASE_TLS_SERVER_NAME" yaml:"serverName"`
+}
+
+// isSet returns true if any of the TLS options are set.
+func (t DatabaseTLS) isSet() bool {
+	return len(t.CAFile) > 0 || len(t.CertFile) > 0 || len(t.KeyFile) > 0 || len(t.ServerName) > 0
+}
+
 // Mongo contains the configuration for the Mongo database.
 type Mongo struct {
-	EnableTLS *bool `env:"DATABASE_MONGO_ENABLE_TLS" yaml:"enableTLS"`
+	Collection string `env:"DATABASE_MONGO_COLLECTION" yaml:"collection"`
+	EnableTLS  *bool  `env:"DATABASE_MONGO_ENABLE_TLS" yaml:"enableTLS"`
 }
 
 // Postgres contains the configuration for the Postgres database.
@@ -286,14 +578,54 @@ type Redis struct {
 	MinRetryBackoff time.Duration `env:"DATABASE_REDIS_MIN_RETRY_BACKOFF" yaml:"minRetryBackoff"`
 	MaxRetryBackoff time.Duration `env:"DATABASE_REDIS_MAX_RETRY_BACKOFF" yaml:"maxRetryBackoff"`
 	EnableTLS       *bool         `env:"DATABASE_REDIS_ENABLE_TLS" yaml:"enableTLS"`
+	KeyPrefix       string        `env:"DATABASE_REDIS_KEY_PREFIX" yaml:"keyPrefix"`
 }
 
 // UI contains the configuration for the UI.
 type UI struct {
 	RuntimeParse *bool      `env:"RUNTIME_PARSE" yaml:"runtimeParse"`
+	OIDC         OIDC       `yaml:"oidc"`
 	Services     UIServices `yaml:"services"`
 }
 
+// OIDC contains the configuration for signing in to the UI with
+// OpenID Connect. Creating secrets in the UI requires sign in
+// if an issuer is configured.
+type OIDC struct {
+	Issuer          string        `env:"OIDC_ISSUER" yaml:"issuer"`
+	ClientID        string        `env:"OIDC_CLIENT_ID" yaml:"clientId"`
+	ClientSecret    string        `env:"OIDC_CLIENT_SECRET" yaml:"clientSecret"`
+	RedirectURL     string        `env:"OIDC_REDIRECT_URL" yaml:"redirectUrl"`
+	Scopes          []string      `env:"OIDC_SCOPES" yaml:"scopes"`
+	AllowedDomains  []string      `env:"OIDC_ALLOWED_DOMAINS" yaml:"allowedDomains"`
+	SessionDuration time.Duration `env:"OIDC_SESSION_DURATION" yaml:"sessionDuration"`
+}
+
+// isSet returns true if OpenID Connect is configured.
+func (o OIDC) isSet() bool {
+	return len(o.Issuer) > 0
+}
+
+// MarshalJSON returns the JSON encoding of OIDC. A custom marshalling method
+// is defined to hide the client secret.
+func (o OIDC) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Issuer          string        `json:",omitempty"`
+		ClientID        string        `json:",omitempty"`
+		RedirectURL     string        `json:",omitempty"`
+		Scopes          []string      `json:",omitempty"`
+		AllowedDomains  []string      `json:",omitempty"`
+		SessionDuration time.Duration `json:",omitempty"`
+	}{
+		Issuer:          o.Issuer,
+		ClientID:        o.ClientID,
+		RedirectURL:     o.RedirectURL,
+		Scopes:          o.Scopes,
+		AllowedDomains:  o.AllowedDomains,
+		SessionDuration: o.SessionDuration,
+	})
+}
+
 // UIServices contains the configuration for the UI services.
 type UIServices struct {
 	Session Session `yaml:"session"`
@@ -326,22 +658,25 @@ func (s Session) MarshalJSON() ([]byte, error) {
 
 // SessionDatabase contains the configuration for the session database.
 type SessionDatabase struct {
-	Driver                string          `env:"SESSION_DATABASE_DRIVER" yaml:"driver"`
-	URI                   string          `env:"SESSION_DATABASE_URI" yaml:"uri"`
-	Address               string          `env:"SESSION_DATABASE_ADDRESS" yaml:"address"`
-	Database              string          `env:"SESSION_DATABASE" yaml:"database"`
-	Username              string          `env:"SESSION_DATABASE_USERNAME" yaml:"username"`
-	Password              string          `env:"SESSION_DATABASE_PASSWORD" yaml:"password"`
-	Timeout               time.Duration   `env:"SESSION_DATABASE_TIMEOUT" yaml:"timeout"`
-	ConnectTimeout        time.Duration   `env:"SESSION_DATABASE_CONNECT_TIMEOUT" yaml:"connectTimeout"`
-	MaxOpenConnections    int             `env:"SESSION_DATABASE_MAX_OPEN_CONNECTIONS" yaml:"maxOpenConnections"`
-	MaxIdleConnections    int             `env:"SESSION_DATABASE_MAX_IDLE_CONNECTIONS" yaml:"maxIdleConnections"`
-	MaxConnectionLifetime time.Duration   `env:"SESSION_DATABASE_MAX_CONNECTION_LIFETIME" yaml:"maxConnectionLifetime"`
-	Mongo                 SessionMongo    `yaml:"mongo"`
-	Postgres              SessionPostgres `yaml:"postgres"`
-	MSSQL                 SessionMSSQL    `yaml:"mssql"`
-	SQLite                SessionSQLite   `yaml:"sqlite"`
-	Redis                 SessionRedis    `yaml:"redis"`
+	Driver                string             `env:"SESSION_DATABASE_DRIVER" yaml:"driver"`
+	URI                   string             `env:"SESSION_DATABASE_URI" yaml:"uri"`
+	Address               string             `env:"SESSION_DATABASE_ADDRESS" yaml:"address"`
+	Database              string             `env:"SESSION_DATABASE" yaml:"database"`
+	Username              string             `env:"SESSION_DATABASE_USERNAME" yaml:"username"`
+	Password              string             `env:"SESSION_DATABASE_PASSWORD" yaml:"password"`
+	Timeout               time.Duration      `env:"SESSION_DATABASE_TIMEOUT" yaml:"timeout"`
+	ConnectTimeout        time.Duration      `env:"SESSION_DATABASE_CONNECT_TIMEOUT" yaml:"connectTimeout"`
+	MaxOpenConnections    int                `env:"SESSION_DATABASE_MAX_OPEN_CONNECTIONS" yaml:"maxOpenConnections"`
+	MaxIdleConnections    int                `env:"SESSION_DATABASE_MAX_IDLE_CONNECTIONS" yaml:"maxIdleConnections"`
+	MaxConnectionLifetime time.Duration      `env:"SESSION_DATABASE_MAX_CONNECTION_LIFETIME" yaml:"maxConnectionLifetime"`
+	Schema                string             `env:"SESSION_DATABASE_SCHEMA" yaml:"schema"`
+	Table                 string             `env:"SESSION_DATABASE_TABLE" yaml:"table"`
+	TLS                   SessionDatabaseTLS `yaml:"tls"`
+	Mongo                 SessionMongo       `yaml:"mongo"`
+	Postgres              SessionPostgres    `yaml:"postgres"`
+	MSSQL                 SessionMSSQL       `yaml:"mssql"`
+	SQLite                SessionSQLite      `yaml:"sqlite"`
+	Redis                 SessionRedis       `yaml:"redis"`
 }
 
 // MarshalJSON returns the JSON encoding of SessionDatabase. A custom marshalling method
@@ -349,16 +684,12 @@ type SessionDatabase struct {
 // `json:"-"` is that this way we must explicitly set the properties to be marshalled
 // and thus output to the logs.
 func (d SessionDatabase) MarshalJSON() ([]byte, error) {
-	var uri string
-	reg := regexp.MustCompile(`://.*:.*@`)
-	if len(d.URI) > 0 && reg.MatchString(d.URI) {
-		uri = reg.ReplaceAllString(d.URI, "://***:***@")
-	} else {
-		uri = d.URI
+	var tls *SessionDatabaseTLS
+	if DatabaseTLS(d.TLS).isSet() {
+		tls = &d.TLS
 	}
-
 	var mongo *SessionMongo
-	if d.Mongo.EnableTLS != nil {
+	if len(d.Mongo.Collection) > 0 || d.Mongo.EnableTLS != nil {
 		mongo = &d.Mongo
 	}
 	var postgres *SessionPostgres
@@ -374,29 +705,35 @@ func (d SessionDatabase) MarshalJSON() ([]byte, error) {
 		sqlite = &d.SQLite
 	}
 	var redis *SessionRedis
-	if d.Redis.DialTimeout > 0 || d.Redis.MaxRetries > 0 || d.Redis.MinRetryBackoff > 0 || d.Redis.MaxRetryBackoff > 0 || d.Redis.EnableTLS != nil {
+	if d.Redis.DialTimeout > 0 || d.Redis.MaxRetries > 0 || d.Redis.MinRetryBackoff > 0 || d.Redis.MaxRetryBackoff > 0 || d.Redis.EnableTLS != nil || len(d.Redis.KeyPrefix) > 0 {
 		redis = &d.Redis
 	}
 
 	return json.Marshal(struct {
-		Driver         string           `json:",omitempty"`
-		URI            string           `json:",omitempty"`
-		Address        string           `json:",omitempty"`
-		Database       string           `json:",omitempty"`
-		Timeout        time.Duration    `json:",omitempty"`
-		ConnectTimeout time.Duration    `json:",omitempty"`
-		Mongo          *SessionMongo    `json:",omitempty"`
-		Postgres       *SessionPostgres `json:",omitempty"`
-		MSSQL          *SessionMSSQL    `json:",omitempty"`
-		SQLite         *SessionSQLite   `json:",omitempty"`
-		Redis          *SessionRedis    `json:",omitempty"`
+		Driver         string              `json:",omitempty"`
+		URI            string              `json:",omitempty"`
+		Address        string              `json:",omitempty"`
+		Database       string              `json:",omitempty"`
+		Schema         string              `json:",omitempty"`
+		Table          string              `json:",omitempty"`
+		Timeout        time.Duration       `json:",omitempty"`
+		ConnectTimeout time.Duration       `json:",omitempty"`
+		TLS            *SessionDatabaseTLS `json:",omitempty"`
+		Mongo          *SessionMongo       `json:",omitempty"`
+		Postgres       *SessionPostgres    `json:",omitempty"`
+		MSSQL          *SessionMSSQL       `json:",omitempty"`
+		SQLite         *SessionSQLite      `json:",omitempty"`
+		Redis          *SessionRedis       `json:",omitempty"`
 	}{
 		Driver:         d.Driver,
-		URI:            uri,
+		URI:            maskURI(d.URI),
 		Address:        d.Address,
 		Database:       d.Database,
+		Schema:         d.Schema,
+		Table:          d.Table,
 		Timeout:        d.Timeout,
 		ConnectTimeout: d.ConnectTimeout,
+		TLS:            tls,
 		Mongo:          mongo,
 		Postgres:       postgres,
 		MSSQL:          mssql,
@@ -405,9 +742,18 @@ func (d SessionDatabase) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// SessionDatabaseTLS contains the TLS configuration for the session database connection.
+type SessionDatabaseTLS struct {
+	CAFile     string `env:"SESSION_DATABASE_TLS_CA_FILE" yaml:"caFile"`
+	CertFile   string `env:"SESSION_DATABASE_TLS_CERT_FILE" yaml:"certFile"`
+	KeyFile    string `env:"SESSION_DATABASE_TLS_KEY_FILE" yaml:"keyFile"`
+	ServerName string `env:"SESSION_DATABASE_TLS_SERVER_NAME" yaml:"serverName"`
+}
+
 // SessionMongo contains the configuration for the Mongo database.
 type SessionMongo struct {
-	EnableTLS *bool `env:"SESSION_DATABASE_MONGO_ENABLE_TLS" yaml:"enableTLS"`
+	Collection string `env:"SESSION_DATABASE_MONGO_COLLECTION" yaml:"collection"`
+	EnableTLS  *bool  `env:"SESSION_DATABASE_MONGO_ENABLE_TLS" yaml:"enableTLS"`
 }
 
 // SessionPostgres contains the configuration for the Postgres database.
@@ -433,6 +779,7 @@ type SessionRedis struct {
 	MinRetryBackoff time.Duration `env:"SESSION_DATABASE_REDIS_MIN_RETRY_BACKOFF" yaml:"minRetryBackoff"`
 	MaxRetryBackoff time.Duration `env:"SESSION_DATABASE_REDIS_MAX_RETRY_BACKOFF" yaml:"maxRetryBackoff"`
 	EnableTLS       *bool         `env:"SESSION_DATABASE_REDIS_ENABLE_TLS" yaml:"enableTLS"`
+	KeyPrefix       string        `env:"SESSION_DATABASE_REDIS_KEY_PREFIX" yaml:"keyPrefix"`
 }
 
 // Options contains the configuration options.
@@ -539,6 +886,23 @@ func New(options ...Option) (*Configuration, error) {
 			cfg.Server.RateLimiter.CleanupInterval = defaultRateLimiterCleanupInterval
 		}
 	}
+	// API keys only apply to the API. Creating secrets in the UI
+	// must require sign in, otherwise the keys can be bypassed.
+	if cfg.Server.APIKeys.isSet() && (cfg.Server.BackendOnly == nil || !*cfg.Server.BackendOnly) && !cfg.UI.OIDC.isSet() {
+		return nil, ErrAPIKeysRequireSignIn
+	}
+	if len(cfg.Server.BaseURL) > 0 {
+		u, err := url.Parse(cfg.Server.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return nil, ErrInvalidBaseURL
+		}
+	}
+	if err := cfg.Server.RateLimiter.Policies.validate(); err != nil {
+		return nil, err
+	}
+	if score := cfg.Services.Secret.PassphraseMinScore; score < security.MinPassphraseScore || score > security.MaxPassphraseScore {
+		return nil, fmt.Errorf("passphrase min score must be between %d and %d", security.MinPassphraseScore, security.MaxPassphraseScore)
+	}
 
 	return cfg, nil
 }
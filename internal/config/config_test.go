@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 func TestNew(t *testing.T) {
@@ -127,7 +128,31 @@ func TestNew(t *testing.T) {
 					"BURNIT_RATE_LIMITER_BURST":            "6",
 					"BURNIT_RATE_LIMITER_CLEANUP_INTERVAL": "10m",
 					"BURNIT_RATE_LIMITER_TTL":              "15m",
+					"BURNIT_RATE_LIMITER_STORE":            "redis",
+					"BURNIT_RATE_LIMITER_REDIS_ADDRESS":    "localhost:6379",
+					"BURNIT_RATE_LIMITER_REDIS_KEY_PREFIX": "prefix:",
+					"BURNIT_RATE_LIMITER_RETRIEVE_RATE":    "0.5",
+					"BURNIT_RATE_LIMITER_RETRIEVE_BURST":   "2",
+					"BURNIT_RATE_LIMITER_RETRIEVE_KEY":     "secret",
+					"BURNIT_METRICS":                       "true",
+					"BURNIT_METRICS_ADDRESS":               "localhost:9091",
+					"BURNIT_TRACING":                       "true",
+					"BURNIT_TRACING_ENDPOINT":              "http://collector:4318",
+					"BURNIT_TRACING_SAMPLE_RATIO":          "0.25",
+					"BURNIT_TRUSTED_PROXIES":               "10.0.0.0/8,192.168.1.1",
+					"BURNIT_ALLOWED_NETWORKS":              "10.8.0.0/16,fd00::/8",
+					"BURNIT_REQUEST_ID_HEADER":             "X-Correlation-ID",
+					"BURNIT_SHUTDOWN_DELAY":                "10s",
+					"BURNIT_BASE_URL":                      "https://burnit.example.com",
+					"BURNIT_API_KEYS_FILE":                 "keys.yaml",
+					"BURNIT_OIDC_ISSUER":                   "https://idp.example.com",
+					"BURNIT_OIDC_CLIENT_ID":                "burnit",
+					"BURNIT_OIDC_CLIENT_SECRET":            "secret",
+					"BURNIT_OIDC_REDIRECT_URL":             "https://burnit.example.com/ui/auth/callback",
+					"BURNIT_OIDC_ALLOWED_DOMAINS":          "example.com,example.org",
+					"BURNIT_OIDC_SESSION_DURATION":         "4h",
 					"BURNIT_SECRET_SERVICE_TIMEOUT":        "20s",
+					"BURNIT_SECRET_PASSPHRASE_MIN_SCORE":   "2",
 					"BURNIT_DATABASE_URI":                  "mongodb://localhost2:27018",
 					"BURNIT_DATABASE_ADDRESS":              "localhost2:27018",
 					"BURNIT_DATABASE":                      "test2",
@@ -135,6 +160,14 @@ func TestNew(t *testing.T) {
 					"BURNIT_DATABASE_PASSWORD":             "test2",
 					"BURNIT_DATABASE_TIMEOUT":              "20s",
 					"BURNIT_DATABASE_CONNECT_TIMEOUT":      "20s",
+					"BURNIT_DATABASE_MONGO_COLLECTION":     "secrets2",
+					"BURNIT_DATABASE_TLS_CA_FILE":          "ca2.pem",
+					"BURNIT_DATABASE_TLS_SERVER_NAME":      "db2.internal",
+					"BURNIT_MAIL_FROM":                     "burnit@example.com",
+					"BURNIT_SMTP_HOST":                     "smtp.example.com",
+					"BURNIT_SMTP_PORT":                     "2525",
+					"BURNIT_SMTP_USERNAME":                 "user",
+					"BURNIT_SMTP_PASSWORD":                 "password",
 				},
 			},
 			want: &Configuration{
@@ -153,11 +186,41 @@ func TestNew(t *testing.T) {
 						Burst:           6,
 						CleanupInterval: 10 * time.Minute,
 						TTL:             15 * time.Minute,
+						Store:           "redis",
+						Redis: RateLimiterRedis{
+							Address:   "localhost:6379",
+							KeyPrefix: "prefix:",
+						},
+						Policies: RateLimiterPolicies{
+							Retrieve: RateLimiterPolicy{
+								Rate:  0.5,
+								Burst: 2,
+								Key:   "secret",
+							},
+						},
+					},
+					Metrics: Metrics{
+						Enabled: toPtr(true),
+						Address: "localhost:9091",
+					},
+					Tracing: Tracing{
+						Enabled:     toPtr(true),
+						Endpoint:    "http://collector:4318",
+						SampleRatio: 0.25,
+					},
+					TrustedProxies:  []string{"10.0.0.0/8", "192.168.1.1"},
+					AllowedNetworks: []string{"10.8.0.0/16", "fd00::/8"},
+					RequestIDHeader: "X-Correlation-ID",
+					ShutdownDelay:   10 * time.Second,
+					BaseURL:         "https://burnit.example.com",
+					APIKeys: APIKeys{
+						File: "keys.yaml",
 					},
 				},
 				Services: Services{
 					Secret: Secret{
-						Timeout: 20 * time.Second,
+						Timeout:            20 * time.Second,
+						PassphraseMinScore: 2,
 						Database: Database{
 							Driver:         "mongodb",
 							URI:            "mongodb://localhost2:27018",
@@ -167,10 +230,34 @@ func TestNew(t *testing.T) {
 							Password:       "test2",
 							Timeout:        20 * time.Second,
 							ConnectTimeout: 20 * time.Second,
+							TLS: DatabaseTLS{
+								CAFile:     "ca2.pem",
+								ServerName: "db2.internal",
+							},
+							Mongo: Mongo{
+								Collection: "secrets2",
+							},
+						},
+						Mail: Mail{
+							From: "burnit@example.com",
+							SMTP: SMTP{
+								Host:     "smtp.example.com",
+								Port:     2525,
+								Username: "user",
+								Password: "password",
+							},
 						},
 					},
 				},
 				UI: UI{
+					OIDC: OIDC{
+						Issuer:          "https://idp.example.com",
+						ClientID:        "burnit",
+						ClientSecret:    "secret",
+						RedirectURL:     "https://burnit.example.com/ui/auth/callback",
+						AllowedDomains:  []string{"example.com", "example.org"},
+						SessionDuration: 4 * time.Hour,
+					},
 					Services: UIServices{
 						Session: Session{
 							Timeout: defaultSessionServiceTimeout,
@@ -202,6 +289,7 @@ func TestNew(t *testing.T) {
 					"-rate-limiter-cleanup-interval", "15m",
 					"-rate-limiter-ttl", "20m",
 					"-secret-service-timeout", "25s",
+					"-secret-passphrase-min-score", "3",
 					"-database-uri", "mongodb://localhost3:27019",
 					"-database-address", "localhost3:27019",
 					"-database", "test3",
@@ -250,7 +338,8 @@ func TestNew(t *testing.T) {
 				},
 				Services: Services{
 					Secret: Secret{
-						Timeout: 25 * time.Second,
+						Timeout:            25 * time.Second,
+						PassphraseMinScore: 3,
 						Database: Database{
 							Driver:         "mongodb",
 							URI:            "mongodb://localhost3:27019",
@@ -277,6 +366,30 @@ func TestNew(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "new configuration - api keys without sign in",
+			input: struct {
+				envs map[string]string
+				args []string
+			}{
+				envs: map[string]string{
+					"BURNIT_API_KEYS_FILE": "keys.yaml",
+				},
+			},
+			wantErr: ErrAPIKeysRequireSignIn,
+		},
+		{
+			name: "new configuration - invalid base url",
+			input: struct {
+				envs map[string]string
+				args []string
+			}{
+				envs: map[string]string{
+					"BURNIT_BASE_URL": "burnit.example.com",
+				},
+			},
+			wantErr: ErrInvalidBaseURL,
+		},
 	}
 
 	for _, test := range tests {
@@ -297,7 +410,7 @@ func TestNew(t *testing.T) {
 				t.Errorf("New() = unexpected result (-want +got)\n%s\n", diff)
 			}
 
-			if diff := cmp.Diff(test.wantErr, gotErr); diff != "" {
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
 				t.Errorf("New() = unexpected error (-want +got)\n%s\n", diff)
 			}
 		})
@@ -605,3 +718,41 @@ func TestMergeConfigurations(t *testing.T) {
 		})
 	}
 }
+
+func TestRateLimiterPolicies_validate(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   RateLimiterPolicies
+		wantErr bool
+	}{
+		{
+			name:  "validate rate limiter policies - empty",
+			input: RateLimiterPolicies{},
+		},
+		{
+			name: "validate rate limiter policies - valid keys",
+			input: RateLimiterPolicies{
+				Generate:         RateLimiterPolicy{Key: "ip"},
+				Create:           RateLimiterPolicy{Key: "apiKey"},
+				FailedPassphrase: RateLimiterPolicy{Key: "secret"},
+			},
+		},
+		{
+			name: "validate rate limiter policies - invalid key",
+			input: RateLimiterPolicies{
+				Retrieve: RateLimiterPolicy{Key: "user"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotErr := test.input.validate()
+
+			if (gotErr != nil) != test.wantErr {
+				t.Errorf("validate() = unexpected error: %v\n", gotErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultCreateSecretsTimeout is the default timeout for creating
+	// a batch of secrets.
+	defaultCreateSecretsTimeout = 30 * time.Second
+	// defaultCreateSecretsOutput is the default output format for
+	// created secrets.
+	defaultCreateSecretsOutput = "csv"
+)
+
+// CreateSecrets contains the configuration for creating a batch of
+// secrets.
+type CreateSecrets struct {
+	URL     string
+	APIKey  string
+	File    string
+	Format  string
+	Output  string
+	BaseURL string
+	TTL     time.Duration
+	Timeout time.Duration
+}
+
+// createSecretsFlags contains the flags for the create-secrets command.
+type createSecretsFlags struct {
+	url     string
+	apiKey  string
+	file    string
+	format  string
+	output  string
+	baseURL string
+	ttl     time.Duration
+	timeout time.Duration
+}
+
+// ParseCreateSecretsFlags parses the flags for the create-secrets
+// command and returns the resulting configuration.
+func ParseCreateSecretsFlags(args []string) (*CreateSecrets, error) {
+	var f createSecretsFlags
+
+	fs := flag.NewFlagSet("create-secrets", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: burnit create-secrets -url <url> -file <file>\n\nCreate a batch of secrets and write a link to share for each of them to stdout.\n\n")
+		fs.PrintDefaults()
+	}
+
+	fs.StringVar(&f.url, "url", "", "Required. URL of the server to create the secrets on.")
+	fs.StringVar(&f.apiKey, "api-key", "", "Optional. API key to authenticate with. Can also be set with the environment variable BURNIT_API_KEY.")
+	fs.StringVar(&f.file, "file", "", "Required. Path to a CSV or JSON file with the secrets to create. Use - to read from stdin.")
+	fs.StringVar(&f.format, "format", "", "Optional. Format of the file, csv or json. Default: determined from the file extension, otherwise csv.")
+	fs.StringVar(&f.output, "output", "", "Optional. Format of the output, csv or json. Default: "+defaultCreateSecretsOutput+".")
+	fs.StringVar(&f.baseURL, "base-url", "", "Optional. Base URL of the links to the secrets. Default: the URL of the server.")
+	fs.DurationVar(&f.ttl, "ttl", 0, "Optional. Time to live for secrets that do not have one set in the file.")
+	fs.DurationVar(&f.timeout, "timeout", 0, "Optional. Timeout for the request. Default: "+defaultCreateSecretsTimeout.String()+".")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if len(f.url) == 0 || len(f.file) == 0 {
+		return nil, errors.New("both -url and -file must be set")
+	}
+
+	if len(f.apiKey) == 0 {
+		f.apiKey = os.Getenv("BURNIT_API_KEY")
+	}
+
+	if len(f.format) == 0 {
+		f.format = "csv"
+		if strings.EqualFold(filepath.Ext(f.file), ".json") {
+			f.format = "json"
+		}
+	}
+	if f.format != "csv" && f.format != "json" {
+		return nil, fmt.Errorf("invalid format %q, must be csv or json", f.format)
+	}
+
+	if len(f.output) == 0 {
+		f.output = defaultCreateSecretsOutput
+	}
+	if f.output != "csv" && f.output != "json" {
+		return nil, fmt.Errorf("invalid output %q, must be csv or json", f.output)
+	}
+
+	if f.timeout == 0 {
+		f.timeout = defaultCreateSecretsTimeout
+	}
+
+	return &CreateSecrets{
+		URL:     strings.TrimSuffix(f.url, "/"),
+		APIKey:  f.apiKey,
+		File:    f.file,
+		Format:  f.format,
+		Output:  f.output,
+		BaseURL: f.baseURL,
+		TTL:     f.ttl,
+		Timeout: f.timeout,
+	}, nil
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseCreateSecretsFlags(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			args   []string
+			envKey string
+		}
+		want    *CreateSecrets
+		wantErr error
+	}{
+		{
+			name: "parse create secrets flags",
+			input: struct {
+				args   []string
+				envKey string
+			}{
+				args: []string{
+					"-url", "https://burnit.example.com/",
+					"-api-key", "key",
+					"-file", "secrets.csv",
+					"-output", "json",
+					"-ttl", "72h",
+					"-timeout", "1m",
+				},
+			},
+			want: &CreateSecrets{
+				URL:     "https://burnit.example.com",
+				APIKey:  "key",
+				File:    "secrets.csv",
+				Format:  "csv",
+				Output:  "json",
+				TTL:     72 * time.Hour,
+				Timeout: time.Minute,
+			},
+		},
+		{
+			name: "parse create secrets flags - defaults",
+			input: struct {
+				args   []string
+				envKey string
+			}{
+				args: []string{
+					"-url", "https://burnit.example.com",
+					"-file", "secrets.json",
+					"-base-url", "https://secrets.example.com",
+				},
+				envKey: "env-key",
+			},
+			want: &CreateSecrets{
+				URL:     "https://burnit.example.com",
+				APIKey:  "env-key",
+				File:    "secrets.json",
+				Format:  "json",
+				Output:  defaultCreateSecretsOutput,
+				BaseURL: "https://secrets.example.com",
+				Timeout: defaultCreateSecretsTimeout,
+			},
+		},
+		{
+			name: "parse create secrets flags - stdin",
+			input: struct {
+				args   []string
+				envKey string
+			}{
+				args: []string{
+					"-url", "https://burnit.example.com",
+					"-file", "-",
+					"-format", "json",
+				},
+			},
+			want: &CreateSecrets{
+				URL:     "https://burnit.example.com",
+				File:    "-",
+				Format:  "json",
+				Output:  defaultCreateSecretsOutput,
+				Timeout: defaultCreateSecretsTimeout,
+			},
+		},
+		{
+			name: "parse create secrets flags - missing file",
+			input: struct {
+				args   []string
+				envKey string
+			}{
+				args: []string{
+					"-url", "https://burnit.example.com",
+				},
+			},
+			wantErr: errors.New("both -url and -file must be set"),
+		},
+		{
+			name: "parse create secrets flags - invalid format",
+			input: struct {
+				args   []string
+				envKey string
+			}{
+				args: []string{
+					"-url", "https://burnit.example.com",
+					"-file", "secrets.xml",
+					"-format", "xml",
+				},
+			},
+			wantErr: errors.New(`invalid format "xml", must be csv or json`),
+		},
+		{
+			name: "parse create secrets flags - invalid output",
+			input: struct {
+				args   []string
+				envKey string
+			}{
+				args: []string{
+					"-url", "https://burnit.example.com",
+					"-file", "secrets.csv",
+					"-output", "xml",
+				},
+			},
+			wantErr: errors.New(`invalid output "xml", must be csv or json`),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("BURNIT_API_KEY", test.input.envKey)
+
+			got, gotErr := ParseCreateSecretsFlags(test.input.args)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("ParseCreateSecretsFlags() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if test.wantErr == nil && gotErr != nil {
+				t.Errorf("ParseCreateSecretsFlags() = unexpected error: %v\n", gotErr)
+			}
+			if test.wantErr != nil && gotErr == nil {
+				t.Errorf("ParseCreateSecretsFlags() = expected error: %v\n", test.wantErr)
+			}
+		})
+	}
+}
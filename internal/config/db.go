@@ -1,9 +1,13 @@
 package config
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"net/url"
+	"os"
 	"strings"
 
 	"github.com/RedeployAB/burnit/internal/db/mongo"
@@ -39,6 +43,21 @@ type dbClient struct {
 	redis redis.Client
 }
 
+// close the configured client.
+func (c *dbClient) close() error {
+	switch {
+	case c.mongo != nil:
+		ctx, cancel := context.WithTimeout(context.Background(), defaultDatabaseTimeout)
+		defer cancel()
+		return c.mongo.Disconnect(ctx)
+	case c.sql != nil:
+		return c.sql.Close()
+	case c.redis != nil:
+		return c.redis.Close()
+	}
+	return nil
+}
+
 // databaseDriver returns the database driver. Returns empty string if the driver could not be determined.
 func databaseDriver(db *Database) (string, error) {
 	var driver string
@@ -118,8 +137,13 @@ func setupDBClient(config *Database) (*dbClient, error) {
 // setupMongoClient sets up the mongo client.
 func setupMongoClient(config *Database) (mongo.Client, error) {
 	var enableTLS bool
-	if config.Redis.EnableTLS != nil {
-		enableTLS = *config.Redis.EnableTLS
+	if config.Mongo.EnableTLS != nil {
+		enableTLS = *config.Mongo.EnableTLS
+	}
+
+	tlsConfig, err := databaseTLSConfig(&config.TLS, config.Mongo.EnableTLS)
+	if err != nil {
+		return nil, err
 	}
 
 	return mongo.NewClient(func(o *mongo.ClientOptions) {
@@ -130,6 +154,7 @@ func setupMongoClient(config *Database) (mongo.Client, error) {
 		o.ConnectTimeout = config.ConnectTimeout
 		o.MaxOpenConnections = config.MaxOpenConnections
 		o.EnableTLS = enableTLS
+		o.TLSConfig = tlsConfig
 	})
 }
 
@@ -145,6 +170,11 @@ func setupSQLClient(config *Database) (sql.Client, error) {
 		inMemory = *config.SQLite.InMemory
 	}
 
+	tlsConfig, err := databaseTLSConfig(&config.TLS, nil)
+	if err != nil {
+		return nil, err
+	}
+
 	return sql.NewClient(func(o *sql.ClientOptions) {
 		o.Driver = drv
 		o.DSN = config.URI
@@ -158,6 +188,7 @@ func setupSQLClient(config *Database) (sql.Client, error) {
 		o.MaxConnectionLifetime = config.MaxConnectionLifetime
 		o.Postgres.SSLMode = sql.PostgresSSLMode(config.Postgres.SSLMode)
 		o.MSSQL.Encrypt = sql.MSSQLEncrypt(config.MSSQL.Encrypt)
+		o.TLSConfig = tlsConfig
 		o.SQLite.File = config.SQLite.File
 		o.SQLite.InMemory = inMemory
 	})
@@ -170,6 +201,11 @@ func setupRedisClient(config *Database) (redis.Client, error) {
 		enableTLS = *config.Redis.EnableTLS
 	}
 
+	tlsConfig, err := databaseTLSConfig(&config.TLS, config.Redis.EnableTLS)
+	if err != nil {
+		return nil, err
+	}
+
 	return redis.NewClient(func(o *redis.ClientOptions) {
 		o.URI = config.URI
 		o.Address = config.Address
@@ -184,5 +220,48 @@ func setupRedisClient(config *Database) (redis.Client, error) {
 		o.MaxIdleConnections = config.MaxIdleConnections
 		o.MaxConnectionLifetime = config.MaxConnectionLifetime
 		o.EnableTLS = enableTLS
+		o.TLSConfig = tlsConfig
 	})
 }
+
+// databaseTLSConfig creates a TLS configuration for the database client from
+// the provided TLS options. Returns nil if no options are set or if TLS has
+// been explicitly disabled.
+func databaseTLSConfig(config *DatabaseTLS, enableTLS *bool) (*tls.Config, error) {
+	if config == nil || !config.isSet() {
+		return nil, nil
+	}
+	if enableTLS != nil && !*enableTLS {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: config.ServerName,
+	}
+
+	if len(config.CAFile) > 0 {
+		b, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read database TLS CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("failed to parse database TLS CA file: no certificates found in %s", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(config.CertFile) > 0 || len(config.KeyFile) > 0 {
+		if len(config.CertFile) == 0 || len(config.KeyFile) == 0 {
+			return nil, errors.New("both database TLS cert file and key file must be set")
+		}
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load database TLS client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,403 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDatabaseTLSConfig(t *testing.T) {
+	certs := newTestCertificates(t)
+
+	var tests = []struct {
+		name  string
+		input struct {
+			config    *DatabaseTLS
+			enableTLS *bool
+		}
+		want struct {
+			isNil        bool
+			rootCAs      bool
+			certificates int
+			serverName   string
+		}
+		wantErr bool
+	}{
+		{
+			name: "no options",
+			input: struct {
+				config    *DatabaseTLS
+				enableTLS *bool
+			}{
+				config: &DatabaseTLS{},
+			},
+			want: struct {
+				isNil        bool
+				rootCAs      bool
+				certificates int
+				serverName   string
+			}{
+				isNil: true,
+			},
+		},
+		{
+			name: "CA file",
+			input: struct {
+				config    *DatabaseTLS
+				enableTLS *bool
+			}{
+				config: &DatabaseTLS{
+					CAFile: certs.caFile,
+				},
+			},
+			want: struct {
+				isNil        bool
+				rootCAs      bool
+				certificates int
+				serverName   string
+			}{
+				rootCAs: true,
+			},
+		},
+		{
+			name: "CA file, client certificate and server name",
+			input: struct {
+				config    *DatabaseTLS
+				enableTLS *bool
+			}{
+				config: &DatabaseTLS{
+					CAFile:     certs.caFile,
+					CertFile:   certs.clientCertFile,
+					KeyFile:    certs.clientKeyFile,
+					ServerName: "db.internal",
+				},
+			},
+			want: struct {
+				isNil        bool
+				rootCAs      bool
+				certificates int
+				serverName   string
+			}{
+				rootCAs:      true,
+				certificates: 1,
+				serverName:   "db.internal",
+			},
+		},
+		{
+			name: "TLS explicitly disabled",
+			input: struct {
+				config    *DatabaseTLS
+				enableTLS *bool
+			}{
+				config: &DatabaseTLS{
+					CAFile: certs.caFile,
+				},
+				enableTLS: toPtr(false),
+			},
+			want: struct {
+				isNil        bool
+				rootCAs      bool
+				certificates int
+				serverName   string
+			}{
+				isNil: true,
+			},
+		},
+		{
+			name: "CA file does not exist",
+			input: struct {
+				config    *DatabaseTLS
+				enableTLS *bool
+			}{
+				config: &DatabaseTLS{
+					CAFile: filepath.Join(t.TempDir(), "missing.pem"),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "CA file without certificates",
+			input: struct {
+				config    *DatabaseTLS
+				enableTLS *bool
+			}{
+				config: &DatabaseTLS{
+					CAFile: certs.clientKeyFile,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "cert file without key file",
+			input: struct {
+				config    *DatabaseTLS
+				enableTLS *bool
+			}{
+				config: &DatabaseTLS{
+					CertFile: certs.clientCertFile,
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := databaseTLSConfig(test.input.config, test.input.enableTLS)
+			if (gotErr != nil) != test.wantErr {
+				t.Fatalf("databaseTLSConfig() = unexpected error: %v", gotErr)
+			}
+			if test.wantErr {
+				return
+			}
+
+			if test.want.isNil {
+				if got != nil {
+					t.Errorf("databaseTLSConfig() = expected nil, got %v", got)
+				}
+				return
+			}
+
+			if diff := cmp.Diff(test.want.rootCAs, got.RootCAs != nil); diff != "" {
+				t.Errorf("databaseTLSConfig() = unexpected result for RootCAs (-want +got)\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.want.certificates, len(got.Certificates)); diff != "" {
+				t.Errorf("databaseTLSConfig() = unexpected result for Certificates (-want +got)\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.want.serverName, got.ServerName); diff != "" {
+				t.Errorf("databaseTLSConfig() = unexpected result for ServerName (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestDatabaseTLSConfig_Handshake(t *testing.T) {
+	certs := newTestCertificates(t)
+
+	var tests = []struct {
+		name    string
+		input   *DatabaseTLS
+		wantErr bool
+	}{
+		{
+			name: "verified server with client certificate",
+			input: &DatabaseTLS{
+				CAFile:     certs.caFile,
+				CertFile:   certs.clientCertFile,
+				KeyFile:    certs.clientKeyFile,
+				ServerName: "db.internal",
+			},
+		},
+		{
+			name: "missing client certificate",
+			input: &DatabaseTLS{
+				CAFile:     certs.caFile,
+				ServerName: "db.internal",
+			},
+			wantErr: true,
+		},
+		{
+			name: "server name mismatch",
+			input: &DatabaseTLS{
+				CAFile:     certs.caFile,
+				CertFile:   certs.clientCertFile,
+				KeyFile:    certs.clientKeyFile,
+				ServerName: "other.internal",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown CA",
+			input: &DatabaseTLS{
+				CertFile:   certs.clientCertFile,
+				KeyFile:    certs.clientKeyFile,
+				ServerName: "db.internal",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			addr := newTestTLSServer(t, certs)
+
+			tlsConfig, err := databaseTLSConfig(test.input, nil)
+			if err != nil {
+				t.Fatalf("databaseTLSConfig() = unexpected error: %v", err)
+			}
+
+			gotErr := dialTestTLSServer(addr, tlsConfig)
+			if (gotErr != nil) != test.wantErr {
+				t.Errorf("handshake = unexpected error: %v", gotErr)
+			}
+		})
+	}
+}
+
+// testCertificates contains paths to generated certificates and keys.
+type testCertificates struct {
+	caFile         string
+	caPool         *x509.CertPool
+	server         tls.Certificate
+	clientCertFile string
+	clientKeyFile  string
+}
+
+// newTestCertificates creates a CA, a server certificate for db.internal
+// and a client certificate.
+func newTestCertificates(t *testing.T) testCertificates {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate CA key: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "burnit test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create CA certificate: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("failed to parse CA certificate: %v", err)
+	}
+
+	issue := func(serial int64, template *x509.Certificate) ([]byte, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		template.SerialNumber = big.NewInt(serial)
+		template.NotBefore = time.Now().Add(-time.Hour)
+		template.NotAfter = time.Now().Add(time.Hour)
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatalf("failed to create certificate: %v", err)
+		}
+		return der, key
+	}
+
+	serverDER, serverKey := issue(2, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "db.internal"},
+		DNSNames:    []string{"db.internal"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	clientDER, clientKey := issue(3, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "burnit"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	clientKeyDER, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatalf("failed to marshal client key: %v", err)
+	}
+
+	certs := testCertificates{
+		caFile:         filepath.Join(dir, "ca.pem"),
+		caPool:         x509.NewCertPool(),
+		clientCertFile: filepath.Join(dir, "client.pem"),
+		clientKeyFile:  filepath.Join(dir, "client-key.pem"),
+		server: tls.Certificate{
+			Certificate: [][]byte{serverDER},
+			PrivateKey:  serverKey,
+		},
+	}
+	certs.caPool.AddCert(caCert)
+
+	writePEM(t, certs.caFile, "CERTIFICATE", caDER)
+	writePEM(t, certs.clientCertFile, "CERTIFICATE", clientDER)
+	writePEM(t, certs.clientKeyFile, "EC PRIVATE KEY", clientKeyDER)
+
+	return certs
+}
+
+// writePEM writes a PEM encoded block to the file.
+func writePEM(t *testing.T, path, blockType string, b []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: b}), 0600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+// newTestTLSServer starts a TLS listener that requires client certificates
+// issued by the test CA and returns its address.
+func newTestTLSServer(t *testing.T, certs testCertificates) string {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{certs.server},
+		ClientCAs:    certs.caPool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	})
+	if err != nil {
+		t.Fatalf("failed to start TLS listener: %v", err)
+	}
+	t.Cleanup(func() {
+		ln.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				if err := conn.(*tls.Conn).Handshake(); err != nil {
+					return
+				}
+				conn.Write([]byte("ok"))
+			}(conn)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+// dialTestTLSServer connects to the server and reads the response. Failures
+// to verify the client certificate are only reported by the server after
+// the handshake, so a read is needed to detect them.
+func dialTestTLSServer(addr string, tlsConfig *tls.Config) error {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 5 * time.Second}, "tcp", addr, tlsConfig)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		return err
+	}
+	b := make([]byte, 2)
+	if _, err := io.ReadFull(conn, b); err != nil {
+		return err
+	}
+	if string(b) != "ok" {
+		return errors.New("unexpected response")
+	}
+	return nil
+}
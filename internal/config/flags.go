@@ -5,45 +5,101 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // flags contains the flags.
 type flags struct {
-	configPath                   string
-	host                         string
-	port                         int
-	tlsCertFile                  string
-	tlsKeyFile                   string
-	corsOrigin                   string
-	rateLimiter                  *bool
-	rateLimiterRate              float64
-	rateLimiterBurst             int
-	rateLimiterCleanupInterval   time.Duration
-	rateLimiterTTL               time.Duration
-	secretServiceTimeout         time.Duration
-	backendOnly                  *bool
-	databaseDriver               string
-	databaseURI                  string
-	databaseAddr                 string
-	database                     string
-	databaseUser                 string
-	databasePass                 string
-	databaseTimeout              time.Duration
-	databaseConnectTimeout       time.Duration
-	databaseMongoEnableTLS       *bool
-	databasePostgresSSLMode      string
-	databaseMSSQLEncrypt         string
-	databaseSQLiteFile           string
-	databaseSQLiteInMemory       *bool
-	databaseRedisDialTimeout     time.Duration
-	databaseRedisMaxRetries      int
-	databaseRedisMinRetryBackoff time.Duration
-	databaseRedisMaxRetryBackoff time.Duration
-	databaseRedisEnableTLS       *bool
+	configPath                       string
+	host                             string
+	port                             int
+	tlsCertFile                      string
+	tlsKeyFile                       string
+	corsOrigin                       string
+	rateLimiter                      *bool
+	rateLimiterRate                  float64
+	rateLimiterBurst                 int
+	rateLimiterCleanupInterval       time.Duration
+	rateLimiterTTL                   time.Duration
+	rateLimiterStore                 string
+	rateLimiterRedisURI              string
+	rateLimiterRedisAddress          string
+	rateLimiterRedisUsername         string
+	rateLimiterRedisPassword         string
+	rateLimiterRedisEnableTLS        *bool
+	rateLimiterRedisKeyPrefix        string
+	rateLimiterGenerateRate          float64
+	rateLimiterGenerateBurst         int
+	rateLimiterGenerateKey           string
+	rateLimiterCreateRate            float64
+	rateLimiterCreateBurst           int
+	rateLimiterCreateKey             string
+	rateLimiterRetrieveRate          float64
+	rateLimiterRetrieveBurst         int
+	rateLimiterRetrieveKey           string
+	rateLimiterFailedPassphraseRate  float64
+	rateLimiterFailedPassphraseBurst int
+	rateLimiterFailedPassphraseKey   string
+	rateLimiterUIRate                float64
+	rateLimiterUIBurst               int
+	rateLimiterUIKey                 string
+	metrics                          *bool
+	metricsAddress                   string
+	tracing                          *bool
+	tracingEndpoint                  string
+	tracingSampleRatio               float64
+	trustedProxies                   []string
+	allowedNetworks                  []string
+	requestIDHeader                  string
+	shutdownDelay                    time.Duration
+	baseURL                          string
+	apiKeysFile                      string
+	secretServiceTimeout             time.Duration
+	secretPassphraseMinScore         int
+	backendOnly                      *bool
+	databaseDriver                   string
+	databaseURI                      string
+	databaseAddr                     string
+	database                         string
+	databaseUser                     string
+	databasePass                     string
+	databaseTimeout                  time.Duration
+	databaseSchema                   string
+	databaseTable                    string
+	databaseConnectTimeout           time.Duration
+	databaseTLSCAFile                string
+	databaseTLSCertFile              string
+	databaseTLSKeyFile               string
+	databaseTLSServerName            string
+	databaseMongoCollection          string
+	databaseMongoEnableTLS           *bool
+	databasePostgresSSLMode          string
+	databaseMSSQLEncrypt             string
+	databaseSQLiteFile               string
+	databaseSQLiteInMemory           *bool
+	databaseRedisDialTimeout         time.Duration
+	databaseRedisMaxRetries          int
+	databaseRedisMinRetryBackoff     time.Duration
+	databaseRedisMaxRetryBackoff     time.Duration
+	databaseRedisEnableTLS           *bool
+	databaseRedisKeyPrefix           string
+	// Mail flags.
+	mailFrom     string
+	smtpHost     string
+	smtpPort     int
+	smtpUsername string
+	smtpPassword string
 	// UI flags.
 	sessionServiceTimeout time.Duration
 	runtimeParse          *bool
+	oidcIssuer            string
+	oidcClientID          string
+	oidcClientSecret      string
+	oidcRedirectURL       string
+	oidcScopes            []string
+	oidcAllowedDomains    []string
+	oidcSessionDuration   time.Duration
 	// Session database flags.
 	sessionDatabaseDriver               string
 	sessionDatabaseURI                  string
@@ -52,7 +108,14 @@ type flags struct {
 	sessionDatabaseUser                 string
 	sessionDatabasePass                 string
 	sessionDatabaseTimeout              time.Duration
+	sessionDatabaseSchema               string
+	sessionDatabaseTable                string
 	sessionDatabaseConnectTimeout       time.Duration
+	sessionDatabaseTLSCAFile            string
+	sessionDatabaseTLSCertFile          string
+	sessionDatabaseTLSKeyFile           string
+	sessionDatabaseTLSServerName        string
+	sessionDatabaseMongoCollection      string
 	sessionDatabaseMongoEnableTLS       *bool
 	sessionDatabasePostgresSSLMode      string
 	sessionDatabaseMSSQLEncrypt         string
@@ -63,6 +126,7 @@ type flags struct {
 	sessionDatabaseRedisMinRetryBackoff time.Duration
 	sessionDatabaseRedisMaxRetryBackoff time.Duration
 	sessionDatabaseRedisEnableTLS       *bool
+	sessionDatabaseRedisKeyPrefix       string
 }
 
 // ParseFlags parses the flags.
@@ -71,6 +135,9 @@ func ParseFlags(args []string) (*flags, error) {
 		f                             flags
 		backendOnly                   boolFlag
 		rateLimiter                   boolFlag
+		rateLimiterRedisEnableTLS     boolFlag
+		metrics                       boolFlag
+		tracing                       boolFlag
 		databaseMongoEnableTLS        boolFlag
 		databaseSQLiteInMemory        boolFlag
 		databaseRedisEnableTLS        boolFlag
@@ -97,7 +164,47 @@ func ParseFlags(args []string) (*flags, error) {
 	fs.IntVar(&f.rateLimiterBurst, "rate-limiter-burst", 0, "Optional. The maximum burst of requests.")
 	fs.DurationVar(&f.rateLimiterCleanupInterval, "rate-limiter-cleanup-interval", 0, "Optional. The interval at which to clean up stale rate limiter entires.")
 	fs.DurationVar(&f.rateLimiterTTL, "rate-limiter-ttl", 0, "Optional. The time-to-live for rate limiter entries.")
+	fs.StringVar(&f.rateLimiterStore, "rate-limiter-store", "", "Optional. Store for rate limits (memory or redis). Default: memory.")
+	fs.StringVar(&f.rateLimiterRedisURI, "rate-limiter-redis-uri", "", "Optional. URI for the Redis rate limit store.")
+	fs.StringVar(&f.rateLimiterRedisAddress, "rate-limiter-redis-address", "", "Optional. Address (host and port) for the Redis rate limit store.")
+	fs.StringVar(&f.rateLimiterRedisUsername, "rate-limiter-redis-user", "", "Optional. User for the Redis rate limit store.")
+	fs.StringVar(&f.rateLimiterRedisPassword, "rate-limiter-redis-password", "", "Optional. Password for the Redis rate limit store.")
+	fs.Var(&rateLimiterRedisEnableTLS, "rate-limiter-redis-enable-tls", "Optional. Enable TLS for the Redis rate limit store. Default: false.")
+	fs.StringVar(&f.rateLimiterRedisKeyPrefix, "rate-limiter-redis-key-prefix", "", "Optional. Prefix for all rate limit keys written to Redis.")
+	fs.Float64Var(&f.rateLimiterGenerateRate, "rate-limiter-generate-rate", 0, "Optional. The average number of requests per second for generating secrets.")
+	fs.IntVar(&f.rateLimiterGenerateBurst, "rate-limiter-generate-burst", 0, "Optional. The maximum burst of requests for generating secrets.")
+	fs.StringVar(&f.rateLimiterGenerateKey, "rate-limiter-generate-key", "", "Optional. The key to limit generating secrets by (ip, apiKey or secret). Default: ip.")
+	fs.Float64Var(&f.rateLimiterCreateRate, "rate-limiter-create-rate", 0, "Optional. The average number of requests per second for creating secrets.")
+	fs.IntVar(&f.rateLimiterCreateBurst, "rate-limiter-create-burst", 0, "Optional. The maximum burst of requests for creating secrets.")
+	fs.StringVar(&f.rateLimiterCreateKey, "rate-limiter-create-key", "", "Optional. The key to limit creating secrets by (ip, apiKey or secret). Default: ip.")
+	fs.Float64Var(&f.rateLimiterRetrieveRate, "rate-limiter-retrieve-rate", 0, "Optional. The average number of requests per second for retrieving and deleting secrets.")
+	fs.IntVar(&f.rateLimiterRetrieveBurst, "rate-limiter-retrieve-burst", 0, "Optional. The maximum burst of requests for retrieving and deleting secrets.")
+	fs.StringVar(&f.rateLimiterRetrieveKey, "rate-limiter-retrieve-key", "", "Optional. The key to limit retrieving and deleting secrets by (ip, apiKey or secret). Default: ip.")
+	fs.Float64Var(&f.rateLimiterFailedPassphraseRate, "rate-limiter-failed-passphrase-rate", 0, "Optional. The average number of requests per second for failed passphrase attempts.")
+	fs.IntVar(&f.rateLimiterFailedPassphraseBurst, "rate-limiter-failed-passphrase-burst", 0, "Optional. The maximum burst of requests for failed passphrase attempts.")
+	fs.StringVar(&f.rateLimiterFailedPassphraseKey, "rate-limiter-failed-passphrase-key", "", "Optional. The key to limit failed passphrase attempts by (ip, apiKey or secret). Default: ip.")
+	fs.Float64Var(&f.rateLimiterUIRate, "rate-limiter-ui-rate", 0, "Optional. The average number of requests per second for UI form requests.")
+	fs.IntVar(&f.rateLimiterUIBurst, "rate-limiter-ui-burst", 0, "Optional. The maximum burst of requests for UI form requests.")
+	fs.StringVar(&f.rateLimiterUIKey, "rate-limiter-ui-key", "", "Optional. The key to limit UI form requests by (ip, apiKey or secret). Default: ip.")
+	fs.Var(&metrics, "metrics", "Optional. Enable metrics endpoint (/metrics). Default: false.")
+	fs.StringVar(&f.metricsAddress, "metrics-address", "", "Optional. Address (host and port) for a separate metrics listener. Defaults to serving metrics on the main listener.")
+	fs.Var(&tracing, "tracing", "Optional. Enable tracing (OTLP over HTTP). Default: false.")
+	fs.StringVar(&f.tracingEndpoint, "tracing-endpoint", "", "Optional. URL of the OTLP (HTTP) endpoint to export spans to. Default: http://localhost:4318.")
+	fs.Float64Var(&f.tracingSampleRatio, "tracing-sample-ratio", 0, "Optional. Ratio of traces to sample (0 to 1). Default: 1.")
+	fs.Func("trusted-proxies", "Optional. Comma-separated list of trusted proxies (CIDRs or IP addresses). Headers with request IDs and source IPs are only trusted from these.", func(value string) error {
+		f.trustedProxies = append(f.trustedProxies, strings.Split(value, ",")...)
+		return nil
+	})
+	fs.Func("allowed-networks", "Optional. Comma-separated list of networks (CIDRs or IP addresses) that are allowed to create and generate secrets. Default: all networks.", func(value string) error {
+		f.allowedNetworks = append(f.allowedNetworks, strings.Split(value, ",")...)
+		return nil
+	})
+	fs.StringVar(&f.requestIDHeader, "request-id-header", "", "Optional. Header for request IDs. Default: X-Request-ID.")
+	fs.DurationVar(&f.shutdownDelay, "shutdown-delay", 0, "Optional. Delay between reporting the server as not ready and shutting it down. Default: 0 (no delay).")
+	fs.StringVar(&f.baseURL, "base-url", "", "Optional. Base URL of links to created secrets, such as https://burnit.example.com. Default: the URL of the request.")
+	fs.StringVar(&f.apiKeysFile, "api-keys-file", "", "Optional. Path to a file with API keys. Creating, generating and deleting secrets requires an API key if keys are configured.")
 	fs.DurationVar(&f.secretServiceTimeout, "secret-service-timeout", 0, "Optional. Timeout for the internal secret service. Default: "+defaultSecretServiceTimeout.String()+".")
+	fs.IntVar(&f.secretPassphraseMinScore, "secret-passphrase-min-score", 0, "Optional. Minimum estimated strength (0-4) of custom passphrases. Default: 0 (disabled).")
 	fs.Var(&backendOnly, "backend-only", "Optional. Disable UI (frontend). Default: false.")
 	// Database flags.
 	fs.StringVar(&f.databaseDriver, "database-driver", "", "Optional. Database driver. This is normally evaluated by the other database configuration options but needs to be set if using a non-standard port (when using address) or sqlite without options.")
@@ -107,7 +214,14 @@ func ParseFlags(args []string) (*flags, error) {
 	fs.StringVar(&f.databaseUser, "database-user", "", "Optional. Database username.")
 	fs.StringVar(&f.databasePass, "database-password", "", "Optional. Database password.")
 	fs.DurationVar(&f.databaseTimeout, "database-timeout", 0, "Optional. Timeout for database operations. Default: "+defaultDatabaseTimeout.String()+".")
+	fs.StringVar(&f.databaseSchema, "database-schema", "", "Optional. Schema for the database tables (PostgreSQL and MSSQL). The schema must exist.")
+	fs.StringVar(&f.databaseTable, "database-table", "", "Optional. Table for secrets (SQL databases). Default: secrets.")
 	fs.DurationVar(&f.databaseConnectTimeout, "database-connect-timeout", 0, "Optional. Connect timeout for the database. Default: "+defaultDatabaseConnectTimeout.String()+".")
+	fs.StringVar(&f.databaseTLSCAFile, "database-tls-ca-file", "", "Optional. Path to a CA certificate bundle (PEM) used to verify the database server certificate.")
+	fs.StringVar(&f.databaseTLSCertFile, "database-tls-cert-file", "", "Optional. Path to a client certificate (PEM) for the database connection.")
+	fs.StringVar(&f.databaseTLSKeyFile, "database-tls-key-file", "", "Optional. Path to a client key (PEM) for the database connection.")
+	fs.StringVar(&f.databaseTLSServerName, "database-tls-server-name", "", "Optional. Server name used to verify the database server certificate. Defaults to the host.")
+	fs.StringVar(&f.databaseMongoCollection, "database-mongo-collection", "", "Optional. Collection for secrets for MongoDB. Default: secrets.")
 	fs.Var(&databaseMongoEnableTLS, "database-mongo-enable-tls", "Optional. Enable TLS for MongoDB. Default: true.")
 	fs.StringVar(&f.databasePostgresSSLMode, "database-postgres-ssl-mode", "", "Optional. SSL mode for PostgreSQL. Default: require.")
 	fs.StringVar(&f.databaseMSSQLEncrypt, "database-mssql-encrypt", "", "Optional. Encrypt for MSSQL. Default: true.")
@@ -118,9 +232,29 @@ func ParseFlags(args []string) (*flags, error) {
 	fs.DurationVar(&f.databaseRedisMinRetryBackoff, "database-redis-min-retry-backoff", 0, "Optional. Minimum retry backoff for the Redis client.")
 	fs.DurationVar(&f.databaseRedisMaxRetryBackoff, "database-redis-max-retry-backoff", 0, "Optional. Maximum retry backoff for the Redis client.")
 	fs.Var(&databaseRedisEnableTLS, "database-redis-enable-tls", "Optional. Enable TLS for the Redis client. Default: true.")
+	fs.StringVar(&f.databaseRedisKeyPrefix, "database-redis-key-prefix", "", "Optional. Prefix for all keys written to Redis.")
+	// Mail flags.
+	fs.StringVar(&f.mailFrom, "mail-from", "", "Optional. Address codes for email second factors are sent from.")
+	fs.StringVar(&f.smtpHost, "smtp-host", "", "Optional. Host of the SMTP server. Secrets with an email second factor can be created if set.")
+	fs.IntVar(&f.smtpPort, "smtp-port", 0, "Optional. Port of the SMTP server. Default: 587.")
+	fs.StringVar(&f.smtpUsername, "smtp-username", "", "Optional. Username for the SMTP server.")
+	fs.StringVar(&f.smtpPassword, "smtp-password", "", "Optional. Password for the SMTP server.")
 	// UI flags.
 	fs.DurationVar(&f.sessionServiceTimeout, "session-service-timeout", 0, "Optional. Timeout for the internal session service. Default: "+defaultSessionServiceTimeout.String()+".")
 	fs.Var(&runtimeParse, "runtime-parse", "Optional. Enable runtime parsing of the UI templates.")
+	fs.StringVar(&f.oidcIssuer, "oidc-issuer", "", "Optional. URL of the OpenID Connect provider. Creating secrets in the UI requires sign in if set.")
+	fs.StringVar(&f.oidcClientID, "oidc-client-id", "", "Optional. Client ID for the OpenID Connect provider.")
+	fs.StringVar(&f.oidcClientSecret, "oidc-client-secret", "", "Optional. Client secret for the OpenID Connect provider.")
+	fs.StringVar(&f.oidcRedirectURL, "oidc-redirect-url", "", "Optional. Redirect URL for the OpenID Connect provider (https://<host>/ui/auth/callback).")
+	fs.Func("oidc-scopes", "Optional. Comma-separated list of scopes to request. Default: openid,email,profile.", func(value string) error {
+		f.oidcScopes = append(f.oidcScopes, strings.Split(value, ",")...)
+		return nil
+	})
+	fs.Func("oidc-allowed-domains", "Optional. Comma-separated list of email domains allowed to sign in. Default: all.", func(value string) error {
+		f.oidcAllowedDomains = append(f.oidcAllowedDomains, strings.Split(value, ",")...)
+		return nil
+	})
+	fs.DurationVar(&f.oidcSessionDuration, "oidc-session-duration", 0, "Optional. Duration of the session of a signed in user. Default: 8h.")
 	// Session database flags.
 	fs.StringVar(&f.sessionDatabaseDriver, "session-database-driver", "", "Optional. Database driver. This is normally evaluated by the other database configuration options but needs to be set if using a non-standard port (when using address) or sqlite without options.")
 	fs.StringVar(&f.sessionDatabaseURI, "session-database-uri", "", "Optional. URI for the session database.")
@@ -129,7 +263,14 @@ func ParseFlags(args []string) (*flags, error) {
 	fs.StringVar(&f.sessionDatabaseUser, "session-database-user", "", "Optional. Session database username.")
 	fs.StringVar(&f.sessionDatabasePass, "session-database-password", "", "Optional. Session database password.")
 	fs.DurationVar(&f.sessionDatabaseTimeout, "session-database-timeout", 0, "Optional. Timeout for session database operations. Default: "+defaultDatabaseTimeout.String()+".")
+	fs.StringVar(&f.sessionDatabaseSchema, "session-database-schema", "", "Optional. Schema for the session database tables (PostgreSQL and MSSQL). The schema must exist.")
+	fs.StringVar(&f.sessionDatabaseTable, "session-database-table", "", "Optional. Table for sessions (SQL databases). Default: sessions.")
 	fs.DurationVar(&f.sessionDatabaseConnectTimeout, "session-database-connect-timeout", 0, "Optional. Connect timeout for the session database. Default: "+defaultDatabaseConnectTimeout.String()+".")
+	fs.StringVar(&f.sessionDatabaseTLSCAFile, "session-database-tls-ca-file", "", "Optional. Path to a CA certificate bundle (PEM) used to verify the session database server certificate.")
+	fs.StringVar(&f.sessionDatabaseTLSCertFile, "session-database-tls-cert-file", "", "Optional. Path to a client certificate (PEM) for the session database connection.")
+	fs.StringVar(&f.sessionDatabaseTLSKeyFile, "session-database-tls-key-file", "", "Optional. Path to a client key (PEM) for the session database connection.")
+	fs.StringVar(&f.sessionDatabaseTLSServerName, "session-database-tls-server-name", "", "Optional. Server name used to verify the session database server certificate. Defaults to the host.")
+	fs.StringVar(&f.sessionDatabaseMongoCollection, "session-database-mongo-collection", "", "Optional. Collection for sessions for MongoDB. Default: sessions.")
 	fs.Var(&sessionDatabaseMongoEnableTLS, "session-database-mongo-enable-tls", "Optional. Enable TLS for MongoDB. Default: true.")
 	fs.StringVar(&f.sessionDatabasePostgresSSLMode, "session-database-postgres-ssl-mode", "", "Optional. SSL mode for PostgreSQL. Default: require.")
 	fs.StringVar(&f.sessionDatabaseMSSQLEncrypt, "session-database-mssql-encrypt", "", "Optional. Encrypt for MSSQL. Default: true.")
@@ -140,6 +281,7 @@ func ParseFlags(args []string) (*flags, error) {
 	fs.DurationVar(&f.sessionDatabaseRedisMinRetryBackoff, "session-database-redis-min-retry-backoff", 0, "Optional. Minimum retry backoff for the Redis client.")
 	fs.DurationVar(&f.sessionDatabaseRedisMaxRetryBackoff, "session-database-redis-max-retry-backoff", 0, "Optional. Maximum retry backoff for the Redis client.")
 	fs.Var(&sessionDatabaseRedisEnableTLS, "session-database-redis-enable-tls", "Optional. Enable TLS for the Redis client. Default: true.")
+	fs.StringVar(&f.sessionDatabaseRedisKeyPrefix, "session-database-redis-key-prefix", "", "Optional. Prefix for all keys written to Redis.")
 
 	if err := fs.Parse(args); err != nil {
 		return &f, err
@@ -151,6 +293,15 @@ func ParseFlags(args []string) (*flags, error) {
 	if rateLimiter.isSet {
 		f.rateLimiter = &rateLimiter.value
 	}
+	if rateLimiterRedisEnableTLS.isSet {
+		f.rateLimiterRedisEnableTLS = &rateLimiterRedisEnableTLS.value
+	}
+	if metrics.isSet {
+		f.metrics = &metrics.value
+	}
+	if tracing.isSet {
+		f.tracing = &tracing.value
+	}
 	if databaseMongoEnableTLS.isSet {
 		f.databaseMongoEnableTLS = &databaseMongoEnableTLS.value
 	}
@@ -196,11 +347,65 @@ func configurationFromFlags(flags *flags) (Configuration, error) {
 				Burst:           flags.rateLimiterBurst,
 				CleanupInterval: flags.rateLimiterCleanupInterval,
 				TTL:             flags.rateLimiterTTL,
+				Store:           flags.rateLimiterStore,
+				Redis: RateLimiterRedis{
+					URI:       flags.rateLimiterRedisURI,
+					Address:   flags.rateLimiterRedisAddress,
+					Username:  flags.rateLimiterRedisUsername,
+					Password:  flags.rateLimiterRedisPassword,
+					EnableTLS: flags.rateLimiterRedisEnableTLS,
+					KeyPrefix: flags.rateLimiterRedisKeyPrefix,
+				},
+				Policies: RateLimiterPolicies{
+					Generate: RateLimiterPolicy{
+						Rate:  flags.rateLimiterGenerateRate,
+						Burst: flags.rateLimiterGenerateBurst,
+						Key:   flags.rateLimiterGenerateKey,
+					},
+					Create: RateLimiterPolicy{
+						Rate:  flags.rateLimiterCreateRate,
+						Burst: flags.rateLimiterCreateBurst,
+						Key:   flags.rateLimiterCreateKey,
+					},
+					Retrieve: RateLimiterPolicy{
+						Rate:  flags.rateLimiterRetrieveRate,
+						Burst: flags.rateLimiterRetrieveBurst,
+						Key:   flags.rateLimiterRetrieveKey,
+					},
+					FailedPassphrase: RateLimiterPolicy{
+						Rate:  flags.rateLimiterFailedPassphraseRate,
+						Burst: flags.rateLimiterFailedPassphraseBurst,
+						Key:   flags.rateLimiterFailedPassphraseKey,
+					},
+					UI: RateLimiterPolicy{
+						Rate:  flags.rateLimiterUIRate,
+						Burst: flags.rateLimiterUIBurst,
+						Key:   flags.rateLimiterUIKey,
+					},
+				},
+			},
+			Metrics: Metrics{
+				Enabled: flags.metrics,
+				Address: flags.metricsAddress,
+			},
+			TrustedProxies:  flags.trustedProxies,
+			AllowedNetworks: flags.allowedNetworks,
+			RequestIDHeader: flags.requestIDHeader,
+			ShutdownDelay:   flags.shutdownDelay,
+			BaseURL:         flags.baseURL,
+			APIKeys: APIKeys{
+				File: flags.apiKeysFile,
+			},
+			Tracing: Tracing{
+				Enabled:     flags.tracing,
+				Endpoint:    flags.tracingEndpoint,
+				SampleRatio: flags.tracingSampleRatio,
 			},
 		},
 		Services: Services{
 			Secret: Secret{
-				Timeout: flags.secretServiceTimeout,
+				Timeout:            flags.secretServiceTimeout,
+				PassphraseMinScore: flags.secretPassphraseMinScore,
 				Database: Database{
 					Driver:         flags.databaseDriver,
 					URI:            flags.databaseURI,
@@ -210,8 +415,17 @@ func configurationFromFlags(flags *flags) (Configuration, error) {
 					Password:       flags.databasePass,
 					Timeout:        flags.databaseTimeout,
 					ConnectTimeout: flags.databaseConnectTimeout,
+					Schema:         flags.databaseSchema,
+					Table:          flags.databaseTable,
+					TLS: DatabaseTLS{
+						CAFile:     flags.databaseTLSCAFile,
+						CertFile:   flags.databaseTLSCertFile,
+						KeyFile:    flags.databaseTLSKeyFile,
+						ServerName: flags.databaseTLSServerName,
+					},
 					Mongo: Mongo{
-						EnableTLS: flags.databaseMongoEnableTLS,
+						Collection: flags.databaseMongoCollection,
+						EnableTLS:  flags.databaseMongoEnableTLS,
 					},
 					Postgres: Postgres{
 						SSLMode: flags.databasePostgresSSLMode,
@@ -229,12 +443,31 @@ func configurationFromFlags(flags *flags) (Configuration, error) {
 						MinRetryBackoff: flags.databaseRedisMinRetryBackoff,
 						MaxRetryBackoff: flags.databaseRedisMaxRetryBackoff,
 						EnableTLS:       flags.databaseRedisEnableTLS,
+						KeyPrefix:       flags.databaseRedisKeyPrefix,
+					},
+				},
+				Mail: Mail{
+					From: flags.mailFrom,
+					SMTP: SMTP{
+						Host:     flags.smtpHost,
+						Port:     flags.smtpPort,
+						Username: flags.smtpUsername,
+						Password: flags.smtpPassword,
 					},
 				},
 			},
 		},
 		UI: UI{
 			RuntimeParse: flags.runtimeParse,
+			OIDC: OIDC{
+				Issuer:          flags.oidcIssuer,
+				ClientID:        flags.oidcClientID,
+				ClientSecret:    flags.oidcClientSecret,
+				RedirectURL:     flags.oidcRedirectURL,
+				Scopes:          flags.oidcScopes,
+				AllowedDomains:  flags.oidcAllowedDomains,
+				SessionDuration: flags.oidcSessionDuration,
+			},
 			Services: UIServices{
 				Session: Session{
 					Database: SessionDatabase{
@@ -246,8 +479,17 @@ func configurationFromFlags(flags *flags) (Configuration, error) {
 						Password:       flags.sessionDatabasePass,
 						Timeout:        flags.sessionDatabaseTimeout,
 						ConnectTimeout: flags.sessionDatabaseConnectTimeout,
+						Schema:         flags.sessionDatabaseSchema,
+						Table:          flags.sessionDatabaseTable,
+						TLS: SessionDatabaseTLS{
+							CAFile:     flags.sessionDatabaseTLSCAFile,
+							CertFile:   flags.sessionDatabaseTLSCertFile,
+							KeyFile:    flags.sessionDatabaseTLSKeyFile,
+							ServerName: flags.sessionDatabaseTLSServerName,
+						},
 						Mongo: SessionMongo{
-							EnableTLS: flags.sessionDatabaseMongoEnableTLS,
+							Collection: flags.sessionDatabaseMongoCollection,
+							EnableTLS:  flags.sessionDatabaseMongoEnableTLS,
 						},
 						Postgres: SessionPostgres{
 							SSLMode: flags.sessionDatabasePostgresSSLMode,
@@ -265,6 +507,7 @@ func configurationFromFlags(flags *flags) (Configuration, error) {
 							MinRetryBackoff: flags.sessionDatabaseRedisMinRetryBackoff,
 							MaxRetryBackoff: flags.sessionDatabaseRedisMaxRetryBackoff,
 							EnableTLS:       flags.sessionDatabaseRedisEnableTLS,
+							KeyPrefix:       flags.sessionDatabaseRedisKeyPrefix,
 						},
 					},
 				},
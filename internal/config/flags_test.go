@@ -30,8 +30,31 @@ func TestParseFlags(t *testing.T) {
 				"-rate-limiter-rate", "10",
 				"-rate-limiter-burst", "10",
 				"-rate-limiter-cleanup-interval", "15s",
+				"-rate-limiter-store", "redis",
+				"-rate-limiter-redis-uri", "redis://localhost:6379",
+				"-rate-limiter-redis-address", "localhost:6379",
+				"-rate-limiter-redis-user", "user",
+				"-rate-limiter-redis-password", "password",
+				"-rate-limiter-redis-enable-tls", "true",
+				"-rate-limiter-redis-key-prefix", "prefix:",
+				"-rate-limiter-generate-rate", "2",
+				"-rate-limiter-create-burst", "5",
+				"-rate-limiter-failed-passphrase-rate", "0.1",
+				"-rate-limiter-failed-passphrase-key", "secret",
+				"-metrics", "true",
+				"-metrics-address", "localhost:9090",
+				"-tracing", "true",
+				"-tracing-endpoint", "http://localhost:4318",
+				"-tracing-sample-ratio", "0.5",
+				"-trusted-proxies", "10.0.0.0/8,192.168.1.1",
+				"-allowed-networks", "10.8.0.0/16,fd00::/8",
+				"-request-id-header", "X-Correlation-ID",
+				"-shutdown-delay", "10s",
+				"-base-url", "https://burnit.example.com",
+				"-api-keys-file", "keys.yaml",
 				"-cors-origin", "origin",
 				"-secret-service-timeout", "15s",
+				"-secret-passphrase-min-score", "2",
 				"-database-driver", "postgres",
 				"-database-uri", "uri",
 				"-database-address", "address",
@@ -40,6 +63,13 @@ func TestParseFlags(t *testing.T) {
 				"-database-password", "password",
 				"-database-timeout", "15s",
 				"-database-connect-timeout", "15s",
+				"-database-schema", "schema",
+				"-database-tls-ca-file", "ca.pem",
+				"-database-tls-cert-file", "cert.pem",
+				"-database-tls-key-file", "key.pem",
+				"-database-tls-server-name", "db.internal",
+				"-database-table", "table",
+				"-database-mongo-collection", "collection",
 				"-database-mongo-enable-tls", "true",
 				"-database-postgres-ssl-mode", "enable",
 				"-database-mssql-encrypt", "true",
@@ -50,8 +80,21 @@ func TestParseFlags(t *testing.T) {
 				"-database-redis-min-retry-backoff", "15s",
 				"-database-redis-max-retry-backoff", "15s",
 				"-database-redis-enable-tls", "true",
+				"-database-redis-key-prefix", "prefix:",
+				"-mail-from", "burnit@example.com",
+				"-smtp-host", "smtp.example.com",
+				"-smtp-port", "2525",
+				"-smtp-username", "user",
+				"-smtp-password", "password",
 				"-session-service-timeout", "15s",
 				"-runtime-parse", "true",
+				"-oidc-issuer", "https://idp.example.com",
+				"-oidc-client-id", "burnit",
+				"-oidc-client-secret", "secret",
+				"-oidc-redirect-url", "https://burnit.example.com/ui/auth/callback",
+				"-oidc-scopes", "openid,email",
+				"-oidc-allowed-domains", "example.com",
+				"-oidc-session-duration", "4h",
 				"-session-database-driver", "postgres",
 				"-session-database-uri", "uri",
 				"-session-database-address", "address",
@@ -60,6 +103,13 @@ func TestParseFlags(t *testing.T) {
 				"-session-database-password", "password",
 				"-session-database-timeout", "15s",
 				"-session-database-connect-timeout", "15s",
+				"-session-database-schema", "schema",
+				"-session-database-tls-ca-file", "ca.pem",
+				"-session-database-tls-cert-file", "cert.pem",
+				"-session-database-tls-key-file", "key.pem",
+				"-session-database-tls-server-name", "db.internal",
+				"-session-database-table", "table",
+				"-session-database-mongo-collection", "collection",
 				"-session-database-mongo-enable-tls", "true",
 				"-session-database-postgres-ssl-mode", "enable",
 				"-session-database-mssql-encrypt", "true",
@@ -70,6 +120,7 @@ func TestParseFlags(t *testing.T) {
 				"-session-database-redis-min-retry-backoff", "15s",
 				"-session-database-redis-max-retry-backoff", "15s",
 				"-session-database-redis-enable-tls", "true",
+				"-session-database-redis-key-prefix", "prefix:",
 			},
 			want: &flags{
 				configPath:                          "path",
@@ -81,7 +132,30 @@ func TestParseFlags(t *testing.T) {
 				rateLimiterRate:                     10,
 				rateLimiterBurst:                    10,
 				rateLimiterCleanupInterval:          time.Second * 15,
+				rateLimiterStore:                    "redis",
+				rateLimiterRedisURI:                 "redis://localhost:6379",
+				rateLimiterRedisAddress:             "localhost:6379",
+				rateLimiterRedisUsername:            "user",
+				rateLimiterRedisPassword:            "password",
+				rateLimiterRedisEnableTLS:           toPtr(true),
+				rateLimiterRedisKeyPrefix:           "prefix:",
+				rateLimiterGenerateRate:             2,
+				rateLimiterCreateBurst:              5,
+				rateLimiterFailedPassphraseRate:     0.1,
+				rateLimiterFailedPassphraseKey:      "secret",
+				metrics:                             toPtr(true),
+				metricsAddress:                      "localhost:9090",
+				tracing:                             toPtr(true),
+				tracingEndpoint:                     "http://localhost:4318",
+				tracingSampleRatio:                  0.5,
+				trustedProxies:                      []string{"10.0.0.0/8", "192.168.1.1"},
+				allowedNetworks:                     []string{"10.8.0.0/16", "fd00::/8"},
+				requestIDHeader:                     "X-Correlation-ID",
+				shutdownDelay:                       10 * time.Second,
+				baseURL:                             "https://burnit.example.com",
+				apiKeysFile:                         "keys.yaml",
 				secretServiceTimeout:                time.Second * 15,
+				secretPassphraseMinScore:            2,
 				databaseDriver:                      "postgres",
 				databaseURI:                         "uri",
 				databaseAddr:                        "address",
@@ -100,8 +174,28 @@ func TestParseFlags(t *testing.T) {
 				databaseRedisMinRetryBackoff:        time.Second * 15,
 				databaseRedisMaxRetryBackoff:        time.Second * 15,
 				databaseRedisEnableTLS:              toPtr(true),
+				databaseSchema:                      "schema",
+				databaseTable:                       "table",
+				databaseMongoCollection:             "collection",
+				databaseTLSCAFile:                   "ca.pem",
+				databaseTLSCertFile:                 "cert.pem",
+				databaseTLSKeyFile:                  "key.pem",
+				databaseTLSServerName:               "db.internal",
+				databaseRedisKeyPrefix:              "prefix:",
+				mailFrom:                            "burnit@example.com",
+				smtpHost:                            "smtp.example.com",
+				smtpPort:                            2525,
+				smtpUsername:                        "user",
+				smtpPassword:                        "password",
 				sessionServiceTimeout:               time.Second * 15,
 				runtimeParse:                        toPtr(true),
+				oidcIssuer:                          "https://idp.example.com",
+				oidcClientID:                        "burnit",
+				oidcClientSecret:                    "secret",
+				oidcRedirectURL:                     "https://burnit.example.com/ui/auth/callback",
+				oidcScopes:                          []string{"openid", "email"},
+				oidcAllowedDomains:                  []string{"example.com"},
+				oidcSessionDuration:                 4 * time.Hour,
 				sessionDatabaseDriver:               "postgres",
 				sessionDatabaseURI:                  "uri",
 				sessionDatabaseAddr:                 "address",
@@ -120,6 +214,14 @@ func TestParseFlags(t *testing.T) {
 				sessionDatabaseRedisMinRetryBackoff: time.Second * 15,
 				sessionDatabaseRedisMaxRetryBackoff: time.Second * 15,
 				sessionDatabaseRedisEnableTLS:       toPtr(true),
+				sessionDatabaseSchema:               "schema",
+				sessionDatabaseTable:                "table",
+				sessionDatabaseMongoCollection:      "collection",
+				sessionDatabaseTLSCAFile:            "ca.pem",
+				sessionDatabaseTLSCertFile:          "cert.pem",
+				sessionDatabaseTLSKeyFile:           "key.pem",
+				sessionDatabaseTLSServerName:        "db.internal",
+				sessionDatabaseRedisKeyPrefix:       "prefix:",
 			},
 		},
 	}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/db"
+)
+
+const (
+	// defaultMigrateTimeout is the default timeout for a store migration.
+	defaultMigrateTimeout = 10 * time.Minute
+)
+
+// Migrate contains the configuration for migrating data between stores.
+type Migrate struct {
+	From    MigrateDatabase
+	To      MigrateDatabase
+	Timeout time.Duration
+}
+
+// MigrateDatabase contains the configuration for the secret and session
+// stores of a database to migrate from or to. They share the connection
+// settings but can have different names of their tables, collections
+// and key prefixes.
+type MigrateDatabase struct {
+	Secrets  Database
+	Sessions Database
+}
+
+// migrateFlags contains the flags for the migrate-store command.
+type migrateFlags struct {
+	from    migrateDatabaseFlags
+	to      migrateDatabaseFlags
+	timeout time.Duration
+}
+
+// migrateDatabaseFlags contains the flags for a database to migrate
+// from or to.
+type migrateDatabaseFlags struct {
+	uri                    string
+	driver                 string
+	database               string
+	schema                 string
+	table                  string
+	mongoCollection        string
+	redisKeyPrefix         string
+	sessionTable           string
+	sessionMongoCollection string
+	sessionRedisKeyPrefix  string
+}
+
+// ParseMigrateFlags parses the flags for the migrate-store command
+// and returns the resulting configuration.
+func ParseMigrateFlags(args []string) (*Migrate, error) {
+	var f migrateFlags
+
+	fs := flag.NewFlagSet("migrate-store", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: burnit migrate-store -from <uri> -to <uri>\n\nMigrate unexpired secrets and sessions from one database to another.\n\n")
+		fs.PrintDefaults()
+	}
+
+	fs.StringVar(&f.from.uri, "from", "", "Required. URI (DSN) for the database to migrate from.")
+	fs.StringVar(&f.from.driver, "from-driver", "", "Optional. Database driver for the database to migrate from. Needs to be set if it cannot be determined from the URI.")
+	migrateDatabaseFlagSet(fs, "from", &f.from)
+	fs.StringVar(&f.to.uri, "to", "", "Required. URI (DSN) for the database to migrate to.")
+	fs.StringVar(&f.to.driver, "to-driver", "", "Optional. Database driver for the database to migrate to. Needs to be set if it cannot be determined from the URI.")
+	migrateDatabaseFlagSet(fs, "to", &f.to)
+	fs.DurationVar(&f.timeout, "timeout", 0, "Optional. Timeout for the entire migration. Default: "+defaultMigrateTimeout.String()+".")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if f.timeout == 0 {
+		f.timeout = defaultMigrateTimeout
+	}
+
+	if len(f.from.uri) == 0 || len(f.to.uri) == 0 {
+		return nil, errors.New("both -from and -to must be set")
+	}
+
+	from, err := migrateDatabase(&f.from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source database: %w", err)
+	}
+	to, err := migrateDatabase(&f.to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid destination database: %w", err)
+	}
+
+	return &Migrate{
+		From:    from,
+		To:      to,
+		Timeout: f.timeout,
+	}, nil
+}
+
+// migrateDatabaseFlagSet defines the flags for the names of the tables,
+// collections and key prefixes of a database to migrate from or to. They
+// correspond to the options of the server and need to be set if the
+// server does not use the defaults.
+func migrateDatabaseFlagSet(fs *flag.FlagSet, prefix string, f *migrateDatabaseFlags) {
+	fs.StringVar(&f.database, prefix+"-database", "", "Optional. Database name. Default: "+defaultDatabaseName+".")
+	fs.StringVar(&f.schema, prefix+"-schema", "", "Optional. Schema for the database tables (PostgreSQL and MSSQL).")
+	fs.StringVar(&f.table, prefix+"-table", "", "Optional. Table for secrets (SQL databases). Default: secrets.")
+	fs.StringVar(&f.mongoCollection, prefix+"-mongo-collection", "", "Optional. Collection for secrets for MongoDB. Default: secrets.")
+	fs.StringVar(&f.redisKeyPrefix, prefix+"-redis-key-prefix", "", "Optional. Prefix for the keys of secrets in Redis.")
+	fs.StringVar(&f.sessionTable, prefix+"-session-table", "", "Optional. Table for sessions (SQL databases). Default: sessions.")
+	fs.StringVar(&f.sessionMongoCollection, prefix+"-session-mongo-collection", "", "Optional. Collection for sessions for MongoDB. Default: sessions.")
+	fs.StringVar(&f.sessionRedisKeyPrefix, prefix+"-session-redis-key-prefix", "", "Optional. Prefix for the keys of sessions in Redis.")
+}
+
+// migrateDatabase creates a database configuration from the provided
+// flags.
+func migrateDatabase(f *migrateDatabaseFlags) (MigrateDatabase, error) {
+	database := Database{
+		Driver:         f.driver,
+		URI:            f.uri,
+		Database:       defaultDatabaseName,
+		Timeout:        defaultDatabaseTimeout,
+		ConnectTimeout: defaultDatabaseConnectTimeout,
+		Schema:         f.schema,
+	}
+	if len(f.database) > 0 {
+		database.Database = f.database
+	}
+
+	var err error
+	database.Driver, err = databaseDriver(&database)
+	if err != nil {
+		return MigrateDatabase{}, err
+	}
+	if database.Driver == databaseDriverInMem {
+		return MigrateDatabase{}, errors.New("in-memory database cannot be migrated")
+	}
+
+	secrets, sessions := database, database
+	secrets.Table = f.table
+	secrets.Mongo.Collection = f.mongoCollection
+	secrets.Redis.KeyPrefix = f.redisKeyPrefix
+	sessions.Table = f.sessionTable
+	sessions.Mongo.Collection = f.sessionMongoCollection
+	sessions.Redis.KeyPrefix = f.sessionRedisKeyPrefix
+
+	return MigrateDatabase{
+		Secrets:  secrets,
+		Sessions: sessions,
+	}, nil
+}
+
+// Stores contains the secret and session stores for a database.
+type Stores struct {
+	Secrets  db.SecretStore
+	Sessions db.SessionStore
+}
+
+// Close the stores and their underlying connections.
+func (s *Stores) Close() error {
+	return errors.Join(s.Secrets.Close(), s.Sessions.Close())
+}
+
+// SetupStores sets up the secret and session stores for the provided database.
+func SetupStores(config *MigrateDatabase) (*Stores, error) {
+	secretClient, err := setupDBClient(&config.Secrets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup database client: %w", err)
+	}
+
+	secrets, err := setupSecretStore(secretClient, &config.Secrets)
+	if err != nil {
+		_ = secretClient.close()
+		return nil, fmt.Errorf("failed to setup secret store: %w", err)
+	}
+
+	sessionClient, err := setupDBClient(&config.Sessions)
+	if err != nil {
+		_ = secrets.Close()
+		return nil, fmt.Errorf("failed to setup database client: %w", err)
+	}
+
+	sessions, err := setupSessionStoreWithClient(sessionClient, &config.Sessions)
+	if err != nil {
+		_ = sessionClient.close()
+		_ = secrets.Close()
+		return nil, fmt.Errorf("failed to setup session store: %w", err)
+	}
+
+	return &Stores{
+		Secrets:  secrets,
+		Sessions: sessions,
+	}, nil
+}
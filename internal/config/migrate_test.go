@@ -0,0 +1,194 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseMigrateFlags(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   []string
+		want    *Migrate
+		wantErr error
+	}{
+		{
+			name: "parse migrate flags",
+			input: []string{
+				"-from", "mongodb://localhost:27017",
+				"-to", "postgres://localhost:5432/burnit",
+				"-timeout", "5m",
+			},
+			want: &Migrate{
+				From: MigrateDatabase{
+					Secrets: Database{
+						Driver:         databaseDriverMongo,
+						URI:            "mongodb://localhost:27017",
+						Database:       defaultDatabaseName,
+						Timeout:        defaultDatabaseTimeout,
+						ConnectTimeout: defaultDatabaseConnectTimeout,
+					},
+					Sessions: Database{
+						Driver:         databaseDriverMongo,
+						URI:            "mongodb://localhost:27017",
+						Database:       defaultDatabaseName,
+						Timeout:        defaultDatabaseTimeout,
+						ConnectTimeout: defaultDatabaseConnectTimeout,
+					},
+				},
+				To: MigrateDatabase{
+					Secrets: Database{
+						Driver:         databaseDriverPostgres,
+						URI:            "postgres://localhost:5432/burnit",
+						Database:       defaultDatabaseName,
+						Timeout:        defaultDatabaseTimeout,
+						ConnectTimeout: defaultDatabaseConnectTimeout,
+					},
+					Sessions: Database{
+						Driver:         databaseDriverPostgres,
+						URI:            "postgres://localhost:5432/burnit",
+						Database:       defaultDatabaseName,
+						Timeout:        defaultDatabaseTimeout,
+						ConnectTimeout: defaultDatabaseConnectTimeout,
+					},
+				},
+				Timeout: 5 * time.Minute,
+			},
+		},
+		{
+			name: "parse migrate flags - with driver",
+			input: []string{
+				"-from", "redis://localhost:6379",
+				"-to", "file:burnit.db",
+				"-to-driver", "sqlite",
+			},
+			want: &Migrate{
+				From: MigrateDatabase{
+					Secrets: Database{
+						Driver:         databaseDriverRedis,
+						URI:            "redis://localhost:6379",
+						Database:       defaultDatabaseName,
+						Timeout:        defaultDatabaseTimeout,
+						ConnectTimeout: defaultDatabaseConnectTimeout,
+					},
+					Sessions: Database{
+						Driver:         databaseDriverRedis,
+						URI:            "redis://localhost:6379",
+						Database:       defaultDatabaseName,
+						Timeout:        defaultDatabaseTimeout,
+						ConnectTimeout: defaultDatabaseConnectTimeout,
+					},
+				},
+				To: MigrateDatabase{
+					Secrets: Database{
+						Driver:         databaseDriverSQLite,
+						URI:            "file:burnit.db",
+						Database:       defaultDatabaseName,
+						Timeout:        defaultDatabaseTimeout,
+						ConnectTimeout: defaultDatabaseConnectTimeout,
+					},
+					Sessions: Database{
+						Driver:         databaseDriverSQLite,
+						URI:            "file:burnit.db",
+						Database:       defaultDatabaseName,
+						Timeout:        defaultDatabaseTimeout,
+						ConnectTimeout: defaultDatabaseConnectTimeout,
+					},
+				},
+				Timeout: defaultMigrateTimeout,
+			},
+		},
+		{
+			name: "parse migrate flags - with names",
+			input: []string{
+				"-from", "redis://localhost:6379",
+				"-from-redis-key-prefix", "burnit:",
+				"-from-session-redis-key-prefix", "burnit:",
+				"-to", "postgres://localhost:5432/burnit",
+				"-to-schema", "burnit",
+				"-to-table", "burnit_secrets",
+				"-to-session-table", "burnit_sessions",
+			},
+			want: &Migrate{
+				From: MigrateDatabase{
+					Secrets: Database{
+						Driver:         databaseDriverRedis,
+						URI:            "redis://localhost:6379",
+						Database:       defaultDatabaseName,
+						Timeout:        defaultDatabaseTimeout,
+						ConnectTimeout: defaultDatabaseConnectTimeout,
+						Redis: Redis{
+							KeyPrefix: "burnit:",
+						},
+					},
+					Sessions: Database{
+						Driver:         databaseDriverRedis,
+						URI:            "redis://localhost:6379",
+						Database:       defaultDatabaseName,
+						Timeout:        defaultDatabaseTimeout,
+						ConnectTimeout: defaultDatabaseConnectTimeout,
+						Redis: Redis{
+							KeyPrefix: "burnit:",
+						},
+					},
+				},
+				To: MigrateDatabase{
+					Secrets: Database{
+						Driver:         databaseDriverPostgres,
+						URI:            "postgres://localhost:5432/burnit",
+						Database:       defaultDatabaseName,
+						Timeout:        defaultDatabaseTimeout,
+						ConnectTimeout: defaultDatabaseConnectTimeout,
+						Schema:         "burnit",
+						Table:          "burnit_secrets",
+					},
+					Sessions: Database{
+						Driver:         databaseDriverPostgres,
+						URI:            "postgres://localhost:5432/burnit",
+						Database:       defaultDatabaseName,
+						Timeout:        defaultDatabaseTimeout,
+						ConnectTimeout: defaultDatabaseConnectTimeout,
+						Schema:         "burnit",
+						Table:          "burnit_sessions",
+					},
+				},
+				Timeout: defaultMigrateTimeout,
+			},
+		},
+		{
+			name: "parse migrate flags - missing destination",
+			input: []string{
+				"-from", "mongodb://localhost:27017",
+			},
+			wantErr: errors.New("both -from and -to must be set"),
+		},
+		{
+			name: "parse migrate flags - unknown driver",
+			input: []string{
+				"-from", "mongodb://localhost:27017",
+				"-to", "file:burnit.db",
+			},
+			wantErr: ErrCouldNotDetermineDatabaseDriver,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := ParseMigrateFlags(test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("ParseMigrateFlags() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if test.wantErr == nil && gotErr != nil {
+				t.Errorf("ParseMigrateFlags() = unexpected error: %v\n", gotErr)
+			}
+			if test.wantErr != nil && gotErr == nil {
+				t.Errorf("ParseMigrateFlags() = expected error: %v\n", test.wantErr)
+			}
+		})
+	}
+}
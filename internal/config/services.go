@@ -1,16 +1,25 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"slices"
+	"strings"
 
+	"github.com/RedeployAB/burnit/internal/auth"
 	"github.com/RedeployAB/burnit/internal/db"
 	"github.com/RedeployAB/burnit/internal/db/inmem"
 	"github.com/RedeployAB/burnit/internal/db/mongo"
 	"github.com/RedeployAB/burnit/internal/db/redis"
 	"github.com/RedeployAB/burnit/internal/db/sql"
+	"github.com/RedeployAB/burnit/internal/mail"
+	"github.com/RedeployAB/burnit/internal/metrics"
 	"github.com/RedeployAB/burnit/internal/secret"
 	"github.com/RedeployAB/burnit/internal/session"
 	"github.com/RedeployAB/burnit/internal/ui"
+	"gopkg.in/yaml.v3"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 	_ "github.com/microsoft/go-mssqldb"
@@ -19,33 +28,60 @@ import (
 
 // services contains the configured services and UI.
 type services struct {
-	Secrets secret.Service
-	UI      ui.UI
+	Secrets        secret.Service
+	UI             ui.UI
+	Metrics        *metrics.Metrics
+	RateLimitStore db.RateLimitStore
+	APIKeys        auth.APIKeyStore
 }
 
 // Setup configures the services and UI and returns the configured components.
 func Setup(config *Configuration) (*services, error) {
-	secretSvc, err := setupSecretService(config.Services.Secret)
+	var m *metrics.Metrics
+	if config.Server.Metrics.Enabled != nil && *config.Server.Metrics.Enabled {
+		m = metrics.New()
+	}
+
+	secretSvc, err := setupSecretService(config.Services.Secret, m)
 	if err != nil {
 		return nil, fmt.Errorf("failed to setup secret service: %w", err)
 	}
 
 	var ui ui.UI
 	if config.Server.BackendOnly == nil || !*config.Server.BackendOnly {
-		ui, err = setupUI(config.UI)
+		ui, err = setupUI(config.UI, m)
 		if err != nil {
 			return nil, fmt.Errorf("failed to setup frontend services: %w", err)
 		}
 	}
 
+	var apiKeys auth.APIKeyStore
+	if config.Server.APIKeys.isSet() {
+		apiKeys, err = setupAPIKeys(config.Server.APIKeys)
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup api keys: %w", err)
+		}
+	}
+
+	var rateLimitStore db.RateLimitStore
+	if config.Server.RateLimiter.isSet() || config.Server.APIKeys.isSet() {
+		rateLimitStore, err = setupRateLimitStore(&config.Server.RateLimiter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup rate limit store: %w", err)
+		}
+	}
+
 	return &services{
-		Secrets: secretSvc,
-		UI:      ui,
+		Secrets:        secretSvc,
+		UI:             ui,
+		Metrics:        m,
+		RateLimitStore: rateLimitStore,
+		APIKeys:        apiKeys,
 	}, nil
 }
 
 // setupSecretService sets up the secret service.
-func setupSecretService(config Secret) (secret.Service, error) {
+func setupSecretService(config Secret, m *metrics.Metrics) (secret.Service, error) {
 	dbClient, err := setupDBClient(&config.Database)
 	if err != nil {
 		return nil, fmt.Errorf("failed to setup database client: %w", err)
@@ -56,9 +92,30 @@ func setupSecretService(config Secret) (secret.Service, error) {
 		return nil, fmt.Errorf("failed to setup secret store: %w", err)
 	}
 
-	return secret.NewService(
-		store,
+	options := []secret.ServiceOption{
 		secret.WithTimeout(config.Timeout),
+		secret.WithPassphraseMinScore(config.PassphraseMinScore),
+		secret.WithMetrics(m),
+	}
+	if config.Mail.isSet() {
+		mailer, err := mail.NewSMTP(func(o *mail.SMTPOptions) {
+			o.Host = config.Mail.SMTP.Host
+			if config.Mail.SMTP.Port > 0 {
+				o.Port = config.Mail.SMTP.Port
+			}
+			o.Username = config.Mail.SMTP.Username
+			o.Password = config.Mail.SMTP.Password
+			o.From = config.Mail.From
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup mailer: %w", err)
+		}
+		options = append(options, secret.WithMailer(mailer))
+	}
+
+	return secret.NewService(
+		db.NewTracingSecretStore(store, databaseSystem(&config.Database)),
+		options...,
 	)
 }
 
@@ -69,14 +126,26 @@ func setupSecretStore(clients *dbClient, config *Database) (db.SecretStore, erro
 	switch {
 	case clients.mongo != nil:
 		store, err = mongo.NewSecretStore(clients.mongo, func(o *mongo.SecretStoreOptions) {
+			if len(config.Database) > 0 {
+				o.Database = config.Database
+			}
+			if len(config.Mongo.Collection) > 0 {
+				o.Collection = config.Mongo.Collection
+			}
 			o.Timeout = config.Timeout
 		})
 	case clients.sql != nil:
 		store, err = sql.NewSecretStore(clients.sql, func(o *sql.SecretStoreOptions) {
+			o.Schema = config.Schema
+			if len(config.Table) > 0 {
+				o.Table = config.Table
+			}
 			o.Timeout = config.Timeout
 		})
 	case clients.redis != nil:
-		store, err = redis.NewSecretStore(clients.redis)
+		store, err = redis.NewSecretStore(clients.redis, func(o *redis.SecretStoreOptions) {
+			o.KeyPrefix = config.Redis.KeyPrefix
+		})
 	default:
 		store = inmem.NewSecretStore()
 		err = nil
@@ -89,8 +158,78 @@ func setupSecretStore(clients *dbClient, config *Database) (db.SecretStore, erro
 	return store, nil
 }
 
+// setupRateLimitStore sets up the rate limit store. Returns nil for the
+// in-memory store, which is created by the rate limiter itself.
+func setupRateLimitStore(config *RateLimiter) (db.RateLimitStore, error) {
+	switch config.Store {
+	case "", rateLimiterStoreMemory:
+		return nil, nil
+	case rateLimiterStoreRedis:
+		if len(config.Redis.URI) == 0 && len(config.Redis.Address) == 0 {
+			return nil, errors.New("redis rate limit store requires an URI or address")
+		}
+		client, err := setupRedisClient(&Database{
+			URI:            config.Redis.URI,
+			Address:        config.Redis.Address,
+			Username:       config.Redis.Username,
+			Password:       config.Redis.Password,
+			ConnectTimeout: defaultDatabaseConnectTimeout,
+			Redis: Redis{
+				EnableTLS: config.Redis.EnableTLS,
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup redis client: %w", err)
+		}
+		return redis.NewRateLimitStore(client, func(o *redis.RateLimitStoreOptions) {
+			o.KeyPrefix = config.Redis.KeyPrefix
+		})
+	default:
+		return nil, fmt.Errorf("unsupported rate limit store: %s", config.Store)
+	}
+}
+
+// setupAPIKeys sets up the API key store with the keys from the configuration
+// and the keys file.
+func setupAPIKeys(config APIKeys) (auth.APIKeyStore, error) {
+	keys := config.Keys
+	if len(config.File) > 0 {
+		b, err := os.ReadFile(config.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read api keys file: %w", err)
+		}
+		var file APIKeys
+		if err := yaml.Unmarshal(b, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse api keys file: %w", err)
+		}
+		keys = append(slices.Clone(keys), file.Keys...)
+	}
+
+	apiKeys := make([]auth.APIKey, 0, len(keys))
+	for _, key := range keys {
+		scopes := make([]auth.Scope, 0, len(key.Scopes))
+		for _, scope := range key.Scopes {
+			scopes = append(scopes, auth.Scope(scope))
+		}
+		quota := auth.Quota{
+			Requests: key.Quota.Requests,
+			Period:   key.Quota.Period,
+		}
+		if quota.Requests > 0 && quota.Period == 0 {
+			quota.Period = defaultAPIKeyQuotaPeriod
+		}
+		apiKeys = append(apiKeys, auth.APIKey{
+			Name:   key.Name,
+			Hash:   key.Hash,
+			Scopes: scopes,
+			Quota:  quota,
+		})
+	}
+	return auth.NewAPIKeyStore(apiKeys...)
+}
+
 // setupUI sets up the UI.
-func setupUI(config UI) (ui.UI, error) {
+func setupUI(config UI, m *metrics.Metrics) (ui.UI, error) {
 	var templatesDir, staticDir string
 	var runtimeParse bool
 
@@ -106,17 +245,27 @@ func setupUI(config UI) (ui.UI, error) {
 	}
 
 	sessionSvc, err := session.NewService(
-		sessionStore,
+		db.NewTracingSessionStore(sessionStore, databaseSystem(sessionDatabaseToDatabase(&config.Services.Session.Database))),
 		session.WithTimeout(config.Services.Session.Timeout),
+		session.WithMetrics(m),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to setup session service: %w", err)
 	}
 
+	var uiAuth *ui.Auth
+	if config.OIDC.isSet() {
+		uiAuth, err = setupUIAuth(config.OIDC)
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup UI sign in: %w", err)
+		}
+	}
+
 	u, err := ui.New(sessionSvc, func(o *ui.Options) {
 		o.RuntimeParse = runtimeParse
 		o.TemplateDir = templatesDir
 		o.StaticDir = staticDir
+		o.Auth = uiAuth
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to setup UI: %w", err)
@@ -125,31 +274,83 @@ func setupUI(config UI) (ui.UI, error) {
 	return u, nil
 }
 
+// setupUIAuth sets up sign in to the UI with OpenID Connect. The endpoints
+// of the provider are discovered on setup.
+func setupUIAuth(config OIDC) (*ui.Auth, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultOIDCDiscoveryTimeout)
+	defer cancel()
+
+	authenticator, err := auth.NewOIDC(ctx, func(o *auth.OIDCOptions) {
+		o.Issuer = config.Issuer
+		o.ClientID = config.ClientID
+		o.ClientSecret = config.ClientSecret
+		o.RedirectURL = config.RedirectURL
+		if len(config.Scopes) > 0 {
+			o.Scopes = config.Scopes
+		}
+		o.AllowedDomains = config.AllowedDomains
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ui.Auth{
+		Authenticator:   authenticator,
+		SessionDuration: config.SessionDuration,
+		SecureCookies:   strings.HasPrefix(config.RedirectURL, "https://"),
+	}, nil
+}
+
 // setupSessionStore sets up the session store.
 func setupSessionStore(config *SessionDatabase) (db.SessionStore, error) {
-	client, err := setupDBClient(sessionDatabaseToDatabase(config))
+	database := sessionDatabaseToDatabase(config)
+	client, err := setupDBClient(database)
 	if err != nil {
 		return nil, fmt.Errorf("failed to setup database client: %w", err)
 	}
 
+	store, err := setupSessionStoreWithClient(client, database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup session store: %w", err)
+	}
+
+	return store, nil
+}
+
+// setupSessionStoreWithClient sets up the session store with the provided
+// database client.
+func setupSessionStoreWithClient(client *dbClient, config *Database) (db.SessionStore, error) {
 	var store db.SessionStore
+	var err error
 	switch {
 	case client != nil && client.mongo != nil:
 		store, err = mongo.NewSessionStore(client.mongo, func(o *mongo.SessionStoreOptions) {
+			if len(config.Database) > 0 {
+				o.Database = config.Database
+			}
+			if len(config.Mongo.Collection) > 0 {
+				o.Collection = config.Mongo.Collection
+			}
 			o.Timeout = config.Timeout
 		})
 	case client != nil && client.sql != nil:
 		store, err = sql.NewSessionStore(client.sql, func(o *sql.SessionStoreOptions) {
+			o.Schema = config.Schema
+			if len(config.Table) > 0 {
+				o.Table = config.Table
+			}
 			o.Timeout = config.Timeout
 		})
 	case client != nil && client.redis != nil:
-		store, err = redis.NewSessionStore(client.redis)
+		store, err = redis.NewSessionStore(client.redis, func(o *redis.SessionStoreOptions) {
+			o.KeyPrefix = config.Redis.KeyPrefix
+		})
 	default:
 		store = inmem.NewSessionStore()
 		err = nil
 	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to setup session store: %w", err)
+		return nil, err
 	}
 
 	return store, nil
@@ -169,6 +370,9 @@ func sessionDatabaseToDatabase(db *SessionDatabase) *Database {
 		MaxOpenConnections:    db.MaxOpenConnections,
 		MaxIdleConnections:    db.MaxIdleConnections,
 		MaxConnectionLifetime: db.MaxConnectionLifetime,
+		Schema:                db.Schema,
+		Table:                 db.Table,
+		TLS:                   DatabaseTLS(db.TLS),
 		Mongo:                 Mongo(db.Mongo),
 		Postgres:              Postgres(db.Postgres),
 		MSSQL:                 MSSQL(db.MSSQL),
@@ -176,3 +380,12 @@ func sessionDatabaseToDatabase(db *SessionDatabase) *Database {
 		Redis:                 Redis(db.Redis),
 	}
 }
+
+// databaseSystem returns the database system (driver) of the database
+// for use in traces.
+func databaseSystem(config *Database) string {
+	if len(config.Driver) == 0 {
+		return databaseDriverInMem
+	}
+	return config.Driver
+}
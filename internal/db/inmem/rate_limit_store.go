@@ -0,0 +1,157 @@
+package inmem
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/db"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// defaultRateLimitTTL is the default time-to-live for rate limit entries.
+	defaultRateLimitTTL = 5 * time.Minute
+	// defaultRateLimitCleanupInterval is the default interval for cleaning
+	// up expired rate limit entries.
+	defaultRateLimitCleanupInterval = 10 * time.Second
+)
+
+// rateLimitEntry represents a rate limiter for a key.
+type rateLimitEntry struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+// rateLimitStore is an in-memory store for rate limits. The limits
+// are local to the process.
+type rateLimitStore struct {
+	entries         map[string]*rateLimitEntry
+	ttl             time.Duration
+	cleanupInterval time.Duration
+	stop            chan struct{}
+	mu              sync.Mutex
+}
+
+// RateLimitStoreOptions is the options for the RateLimitStore.
+type RateLimitStoreOptions struct {
+	// TTL is the time-to-live for rate limit entries.
+	TTL time.Duration
+	// CleanupInterval is the interval for cleaning up expired
+	// rate limit entries.
+	CleanupInterval time.Duration
+}
+
+// RateLimitStoreOption is a function that sets options for the RateLimitStore.
+type RateLimitStoreOption func(o *RateLimitStoreOptions)
+
+// NewRateLimitStore creates a new in-memory rate limit store. Expired
+// entries are cleaned up periodically until the store is closed.
+func NewRateLimitStore(options ...RateLimitStoreOption) *rateLimitStore {
+	opts := RateLimitStoreOptions{
+		TTL:             defaultRateLimitTTL,
+		CleanupInterval: defaultRateLimitCleanupInterval,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	s := &rateLimitStore{
+		entries:         make(map[string]*rateLimitEntry),
+		ttl:             opts.TTL,
+		cleanupInterval: opts.CleanupInterval,
+		stop:            make(chan struct{}),
+		mu:              sync.Mutex{},
+	}
+	go s.cleanup()
+	return s
+}
+
+// Allow reports whether a request for the key is allowed within the limit.
+func (s *rateLimitStore) Allow(ctx context.Context, key string, limit db.RateLimit) (db.RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := now()
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &rateLimitEntry{
+			limiter: rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst),
+		}
+		s.entries[key] = entry
+	}
+	entry.lastUsed = t
+
+	allowed := entry.limiter.AllowN(t, 1)
+	tokens := entry.limiter.TokensAt(t)
+
+	result := db.RateLimitResult{
+		Allowed:    allowed,
+		Remaining:  max(int(math.Floor(tokens)), 0),
+		ResetAfter: tokensDuration(float64(limit.Burst)-tokens, limit.Rate),
+	}
+	if !allowed {
+		result.RetryAfter = tokensDuration(1-tokens, limit.Rate)
+	}
+	return result, nil
+}
+
+// Refund a request for the key that has been counted against the limit.
+func (s *rateLimitStore) Refund(ctx context.Context, key string, limit db.RateLimit) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	// A negative number of events returns tokens to the limiter. The
+	// tokens are capped at the burst when the limiter is advanced.
+	entry.limiter.AllowN(now(), -1)
+	return nil
+}
+
+// Close the store and stop the cleanup of expired entries.
+func (s *rateLimitStore) Close() error {
+	s.stop <- struct{}{}
+	return nil
+}
+
+// cleanup removes rate limit entries that have expired.
+func (s *rateLimitStore) cleanup() {
+	for {
+		select {
+		case <-time.After(s.cleanupInterval):
+			s.deleteExpired()
+		case <-s.stop:
+			close(s.stop)
+			return
+		}
+	}
+}
+
+// deleteExpired removes rate limit entries that have not been used
+// within the TTL and whose limiters have been refilled. Removing an
+// entry before it is refilled would reset the limit, such as the quota
+// of an API key with a period longer than the TTL.
+func (s *rateLimitStore) deleteExpired() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := now()
+	for key, entry := range s.entries {
+		if t.Sub(entry.lastUsed) > s.ttl && entry.limiter.TokensAt(t) >= float64(entry.limiter.Burst()) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// tokensDuration returns the duration it takes to get the
+// number of tokens at the rate.
+func tokensDuration(tokens, r float64) time.Duration {
+	if tokens <= 0 || r <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / r * float64(time.Second))
+}
@@ -0,0 +1,197 @@
+package inmem
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/db"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRateLimitStore_Allow(t *testing.T) {
+	n := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var tests = []struct {
+		name  string
+		input struct {
+			limit    db.RateLimit
+			requests int
+			elapsed  time.Duration
+		}
+		want []db.RateLimitResult
+	}{
+		{
+			name: "Allow within burst",
+			input: struct {
+				limit    db.RateLimit
+				requests int
+				elapsed  time.Duration
+			}{
+				limit:    db.RateLimit{Rate: 1, Burst: 3},
+				requests: 3,
+			},
+			want: []db.RateLimitResult{
+				{Allowed: true, Remaining: 2, ResetAfter: time.Second},
+				{Allowed: true, Remaining: 1, ResetAfter: 2 * time.Second},
+				{Allowed: true, Remaining: 0, ResetAfter: 3 * time.Second},
+			},
+		},
+		{
+			name: "Reject when burst is exceeded",
+			input: struct {
+				limit    db.RateLimit
+				requests int
+				elapsed  time.Duration
+			}{
+				limit:    db.RateLimit{Rate: 1, Burst: 2},
+				requests: 3,
+			},
+			want: []db.RateLimitResult{
+				{Allowed: true, Remaining: 1, ResetAfter: time.Second},
+				{Allowed: true, Remaining: 0, ResetAfter: 2 * time.Second},
+				{Allowed: false, Remaining: 0, RetryAfter: time.Second, ResetAfter: 2 * time.Second},
+			},
+		},
+		{
+			name: "Allow after tokens are refilled",
+			input: struct {
+				limit    db.RateLimit
+				requests int
+				elapsed  time.Duration
+			}{
+				limit:    db.RateLimit{Rate: 2, Burst: 1},
+				requests: 2,
+				elapsed:  500 * time.Millisecond,
+			},
+			want: []db.RateLimitResult{
+				{Allowed: true, Remaining: 0, ResetAfter: 500 * time.Millisecond},
+				{Allowed: true, Remaining: 0, ResetAfter: 500 * time.Millisecond},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			current := n
+			now = func() time.Time {
+				return current
+			}
+			t.Cleanup(func() {
+				now = func() time.Time {
+					return time.Now().UTC()
+				}
+			})
+
+			s := &rateLimitStore{
+				entries: make(map[string]*rateLimitEntry),
+				mu:      sync.Mutex{},
+			}
+
+			var got []db.RateLimitResult
+			for range test.input.requests {
+				result, err := s.Allow(context.Background(), "key", test.input.limit)
+				if err != nil {
+					t.Fatalf("Allow() = unexpected error: %v", err)
+				}
+				got = append(got, result)
+				current = current.Add(test.input.elapsed)
+			}
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Allow() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestRateLimitStore_Refund(t *testing.T) {
+	n := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time {
+		return n
+	}
+	t.Cleanup(func() {
+		now = func() time.Time {
+			return time.Now().UTC()
+		}
+	})
+
+	s := &rateLimitStore{
+		entries: make(map[string]*rateLimitEntry),
+		mu:      sync.Mutex{},
+	}
+	limit := db.RateLimit{Rate: 1, Burst: 2}
+
+	if err := s.Refund(context.Background(), "key", limit); err != nil {
+		t.Fatalf("Refund() = unexpected error: %v", err)
+	}
+
+	for range 2 {
+		s.Allow(context.Background(), "key", limit)
+	}
+	s.Refund(context.Background(), "key", limit)
+
+	got, _ := s.Allow(context.Background(), "key", limit)
+	if diff := cmp.Diff(db.RateLimitResult{Allowed: true, Remaining: 0, ResetAfter: 2 * time.Second}, got); diff != "" {
+		t.Errorf("Allow() = unexpected result after refund (-want +got)\n%s\n", diff)
+	}
+
+	got, _ = s.Allow(context.Background(), "key", limit)
+	if diff := cmp.Diff(db.RateLimitResult{Allowed: false, RetryAfter: time.Second, ResetAfter: 2 * time.Second}, got); diff != "" {
+		t.Errorf("Allow() = unexpected result (-want +got)\n%s\n", diff)
+	}
+
+	for range 3 {
+		s.Refund(context.Background(), "key", limit)
+	}
+
+	got, _ = s.Allow(context.Background(), "key", limit)
+	if diff := cmp.Diff(db.RateLimitResult{Allowed: true, Remaining: 1, ResetAfter: time.Second}, got); diff != "" {
+		t.Errorf("Allow() = unexpected result after refunds above burst (-want +got)\n%s\n", diff)
+	}
+}
+
+func TestRateLimitStore_deleteExpired(t *testing.T) {
+	current := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time {
+		return current
+	}
+	t.Cleanup(func() {
+		now = func() time.Time {
+			return time.Now().UTC()
+		}
+	})
+
+	s := &rateLimitStore{
+		entries: make(map[string]*rateLimitEntry),
+		ttl:     defaultRateLimitTTL,
+		mu:      sync.Mutex{},
+	}
+	// A quota of 2 requests per 24 hours.
+	limit := db.RateLimit{Rate: 2 / (24 * time.Hour).Seconds(), Burst: 2}
+
+	for range 2 {
+		s.Allow(context.Background(), "quota:apikey:key", limit)
+	}
+
+	// The exhausted quota is kept after the TTL.
+	current = current.Add(defaultRateLimitTTL + time.Minute)
+	s.deleteExpired()
+
+	got, _ := s.Allow(context.Background(), "quota:apikey:key", limit)
+	if got.Allowed {
+		t.Errorf("Allow() = expected exhausted quota to be rejected after cleanup\n")
+	}
+	if _, ok := s.entries["quota:apikey:key"]; !ok {
+		t.Errorf("deleteExpired() = expected entry to be kept\n")
+	}
+
+	// The entry is removed when the quota has been refilled.
+	current = current.Add(24*time.Hour + defaultRateLimitTTL)
+	s.deleteExpired()
+
+	if _, ok := s.entries["quota:apikey:key"]; ok {
+		t.Errorf("deleteExpired() = expected entry to be removed\n")
+	}
+}
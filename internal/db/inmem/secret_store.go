@@ -2,7 +2,9 @@ package inmem
 
 import (
 	"context"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/RedeployAB/burnit/internal/db"
 	dberrors "github.com/RedeployAB/burnit/internal/db/errors"
@@ -41,15 +43,26 @@ func (s *secretStore) Create(ctx context.Context, secret db.Secret) (db.Secret,
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.secrets[secret.ID] = db.Secret{
-		ID:        secret.ID,
-		Value:     secret.Value,
-		ExpiresAt: secret.ExpiresAt,
-	}
+	s.secrets[secret.ID] = copySecret(secret)
 
 	return s.secrets[secret.ID], nil
 }
 
+// CreateMany creates secrets. All secrets are created at once
+// under the lock of the store.
+func (s *secretStore) CreateMany(ctx context.Context, secrets []db.Secret) ([]db.Secret, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created := make([]db.Secret, 0, len(secrets))
+	for _, secret := range secrets {
+		s.secrets[secret.ID] = copySecret(secret)
+		created = append(created, s.secrets[secret.ID])
+	}
+
+	return created, nil
+}
+
 // Delete a secret by its ID.
 func (s *secretStore) Delete(ctx context.Context, id string) error {
 	s.mu.Lock()
@@ -64,6 +77,38 @@ func (s *secretStore) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// IncrementFailedAttempts increments the number of failed attempts
+// to verify the second factor of a secret and returns the new number.
+func (s *secretStore) IncrementFailedAttempts(ctx context.Context, id string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secret, ok := s.secrets[id]
+	if !ok {
+		return 0, dberrors.ErrSecretNotFound
+	}
+	secret.FailedAttempts++
+	s.secrets[id] = secret
+
+	return secret.FailedAttempts, nil
+}
+
+// SetCode sets the hash and expiration time of the code sent for the
+// second factor of a secret. An empty hash removes the code.
+func (s *secretStore) SetCode(ctx context.Context, id, hash string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secret, ok := s.secrets[id]
+	if !ok {
+		return dberrors.ErrSecretNotFound
+	}
+	secret.CodeHash, secret.CodeExpiresAt = hash, expiresAt
+	s.secrets[id] = secret
+
+	return nil
+}
+
 // DeleteExpired deletes all expired secrets.
 // Note: The current implementation is very inefficient.
 func (s *secretStore) DeleteExpired(ctx context.Context) error {
@@ -79,7 +124,52 @@ func (s *secretStore) DeleteExpired(ctx context.Context) error {
 	return nil
 }
 
+// Iterate calls fn for every unexpired secret.
+func (s *secretStore) Iterate(ctx context.Context, fn func(secret db.Secret) error) error {
+	s.mu.RLock()
+	secrets := make([]db.Secret, 0, len(s.secrets))
+	for _, secret := range s.secrets {
+		if !secret.ExpiresAt.Before(now()) {
+			secrets = append(secrets, secret)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, secret := range secrets {
+		if err := fn(secret); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Ping checks the connection to the store.
+func (s *secretStore) Ping(ctx context.Context) error {
+	return nil
+}
+
 // Close the store and its underlying connections.
 func (s *secretStore) Close() error {
 	return nil
 }
+
+// copySecret returns a copy of the secret that does not share
+// memory with the provided secret.
+func copySecret(secret db.Secret) db.Secret {
+	return db.Secret{
+		ID:               secret.ID,
+		Value:            secret.Value,
+		ExpiresAt:        secret.ExpiresAt,
+		NotBefore:        secret.NotBefore,
+		CreatedBy:        secret.CreatedBy,
+		AllowedNetworks:  slices.Clone(secret.AllowedNetworks),
+		SecondFactor:     secret.SecondFactor,
+		SecondFactorData: secret.SecondFactorData,
+		Label:            secret.Label,
+		Note:             secret.Note,
+		FailedAttempts:   secret.FailedAttempts,
+		CodeHash:         secret.CodeHash,
+		CodeExpiresAt:    secret.CodeExpiresAt,
+	}
+}
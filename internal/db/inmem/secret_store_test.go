@@ -2,6 +2,7 @@ package inmem
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"testing"
 	"time"
@@ -136,6 +137,60 @@ func TestSecretStore_Create(t *testing.T) {
 	}
 }
 
+func TestSecretStore_CreateMany(t *testing.T) {
+	n := now()
+	var tests = []struct {
+		name  string
+		input struct {
+			secrets map[string]db.Secret
+			create  []db.Secret
+		}
+		want    []db.Secret
+		wantErr error
+	}{
+		{
+			name: "Create secrets",
+			input: struct {
+				secrets map[string]db.Secret
+				create  []db.Secret
+			}{
+				secrets: map[string]db.Secret{},
+				create: []db.Secret{
+					{ID: "1", Value: "secret", ExpiresAt: n.Add(1), Label: "first"},
+					{ID: "2", Value: "secret", ExpiresAt: n.Add(1), Label: "second"},
+				},
+			},
+			want: []db.Secret{
+				{ID: "1", Value: "secret", ExpiresAt: n.Add(1), Label: "first"},
+				{ID: "2", Value: "secret", ExpiresAt: n.Add(1), Label: "second"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &secretStore{
+				secrets: test.input.secrets,
+				mu:      sync.RWMutex{},
+			}
+
+			got, gotErr := s.CreateMany(context.Background(), test.input.create)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("CreateMany() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("CreateMany() = unexpected error (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(len(test.want), len(s.secrets)); diff != "" {
+				t.Errorf("CreateMany() = unexpected number of secrets (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
 func TestSecretStore_Delete(t *testing.T) {
 	var tests = []struct {
 		name  string
@@ -162,6 +217,170 @@ func TestSecretStore_Delete(t *testing.T) {
 	}
 }
 
+func TestSecretStore_IncrementFailedAttempts(t *testing.T) {
+	n := now()
+	var tests = []struct {
+		name  string
+		input struct {
+			secrets map[string]db.Secret
+			id      string
+		}
+		want    int
+		wantErr error
+	}{
+		{
+			name: "increment failed attempts",
+			input: struct {
+				secrets map[string]db.Secret
+				id      string
+			}{
+				secrets: map[string]db.Secret{
+					"test": {
+						ID:             "test",
+						Value:          "secret",
+						ExpiresAt:      n.Add(1),
+						FailedAttempts: 2,
+					},
+				},
+				id: "test",
+			},
+			want: 3,
+		},
+		{
+			name: "secret not found",
+			input: struct {
+				secrets map[string]db.Secret
+				id      string
+			}{
+				secrets: map[string]db.Secret{},
+				id:      "test",
+			},
+			wantErr: dberrors.ErrSecretNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &secretStore{
+				secrets: test.input.secrets,
+				mu:      sync.RWMutex{},
+			}
+
+			got, gotErr := s.IncrementFailedAttempts(context.Background(), test.input.id)
+
+			if test.want != got {
+				t.Errorf("IncrementFailedAttempts() = unexpected result, want: %d, got: %d\n", test.want, got)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("IncrementFailedAttempts() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestSecretStore_SetCode(t *testing.T) {
+	n := now()
+	var tests = []struct {
+		name  string
+		input struct {
+			secrets   map[string]db.Secret
+			id        string
+			hash      string
+			expiresAt time.Time
+		}
+		want    db.Secret
+		wantErr error
+	}{
+		{
+			name: "set code",
+			input: struct {
+				secrets   map[string]db.Secret
+				id        string
+				hash      string
+				expiresAt time.Time
+			}{
+				secrets: map[string]db.Secret{
+					"test": {
+						ID:        "test",
+						Value:     "secret",
+						ExpiresAt: n.Add(1 * time.Hour),
+					},
+				},
+				id:        "test",
+				hash:      "hash",
+				expiresAt: n.Add(10 * time.Minute),
+			},
+			want: db.Secret{
+				ID:            "test",
+				Value:         "secret",
+				ExpiresAt:     n.Add(1 * time.Hour),
+				CodeHash:      "hash",
+				CodeExpiresAt: n.Add(10 * time.Minute),
+			},
+		},
+		{
+			name: "remove code",
+			input: struct {
+				secrets   map[string]db.Secret
+				id        string
+				hash      string
+				expiresAt time.Time
+			}{
+				secrets: map[string]db.Secret{
+					"test": {
+						ID:            "test",
+						Value:         "secret",
+						ExpiresAt:     n.Add(1 * time.Hour),
+						CodeHash:      "hash",
+						CodeExpiresAt: n.Add(10 * time.Minute),
+					},
+				},
+				id: "test",
+			},
+			want: db.Secret{
+				ID:        "test",
+				Value:     "secret",
+				ExpiresAt: n.Add(1 * time.Hour),
+			},
+		},
+		{
+			name: "secret not found",
+			input: struct {
+				secrets   map[string]db.Secret
+				id        string
+				hash      string
+				expiresAt time.Time
+			}{
+				secrets: map[string]db.Secret{},
+				id:      "test",
+				hash:    "hash",
+			},
+			wantErr: dberrors.ErrSecretNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &secretStore{
+				secrets: test.input.secrets,
+				mu:      sync.RWMutex{},
+			}
+
+			gotErr := s.SetCode(context.Background(), test.input.id, test.input.hash, test.input.expiresAt)
+			got := s.secrets[test.input.id]
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("SetCode() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("SetCode() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
 func TestSecretStore_DeleteExpired(t *testing.T) {
 	n := now()
 	var tests = []struct {
@@ -224,3 +443,89 @@ func TestSecretStore_DeleteExpired(t *testing.T) {
 		})
 	}
 }
+
+func TestSecretStore_Iterate(t *testing.T) {
+	n := now()
+	var tests = []struct {
+		name  string
+		input struct {
+			secrets map[string]db.Secret
+			err     error
+		}
+		want    []db.Secret
+		wantErr error
+	}{
+		{
+			name: "Iterate secrets",
+			input: struct {
+				secrets map[string]db.Secret
+				err     error
+			}{
+				secrets: map[string]db.Secret{
+					"test1": {
+						ID:        "test1",
+						Value:     "secret1",
+						ExpiresAt: n.Add(-1),
+					},
+					"test2": {
+						ID:        "test2",
+						Value:     "secret2",
+						ExpiresAt: n.Add(time.Hour),
+					},
+				},
+			},
+			want: []db.Secret{
+				{
+					ID:        "test2",
+					Value:     "secret2",
+					ExpiresAt: n.Add(time.Hour),
+				},
+			},
+		},
+		{
+			name: "Iterate secrets - error",
+			input: struct {
+				secrets map[string]db.Secret
+				err     error
+			}{
+				secrets: map[string]db.Secret{
+					"test1": {
+						ID:        "test1",
+						Value:     "secret1",
+						ExpiresAt: n.Add(time.Hour),
+					},
+				},
+				err: errIterate,
+			},
+			wantErr: errIterate,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &secretStore{
+				secrets: test.input.secrets,
+				mu:      sync.RWMutex{},
+			}
+
+			var got []db.Secret
+			gotErr := s.Iterate(context.Background(), func(secret db.Secret) error {
+				if test.input.err != nil {
+					return test.input.err
+				}
+				got = append(got, secret)
+				return nil
+			})
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Iterate() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Iterate() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+var errIterate = errors.New("iterate error")
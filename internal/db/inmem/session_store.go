@@ -68,6 +68,7 @@ func (s *sessionStore) Upsert(ctx context.Context, session db.Session) (db.Sessi
 			Token:     session.CSRF.Token,
 			ExpiresAt: session.CSRF.ExpiresAt,
 		},
+		User: session.User,
 	}
 	if len(session.CSRF.Token) > 0 {
 		s.sessionCSRF[session.CSRF.Token] = session.ID
@@ -128,6 +129,31 @@ func (s *sessionStore) DeleteExpired(ctx context.Context) error {
 	return nil
 }
 
+// Iterate calls fn for every unexpired session.
+func (s *sessionStore) Iterate(ctx context.Context, fn func(session db.Session) error) error {
+	s.mu.RLock()
+	sessions := make([]db.Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		if !session.ExpiresAt.Before(now()) {
+			sessions = append(sessions, session)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, session := range sessions {
+		if err := fn(session); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Ping checks the connection to the store.
+func (s *sessionStore) Ping(ctx context.Context) error {
+	return nil
+}
+
 // Close the store and its underlying connections.
 func (s *sessionStore) Close() error {
 	return nil
@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/RedeployAB/burnit/internal/db"
 	dberrors "github.com/RedeployAB/burnit/internal/db/errors"
@@ -349,3 +350,71 @@ func TestSessionStore_DeleteExpired(t *testing.T) {
 		})
 	}
 }
+
+func TestSessionStore_Iterate(t *testing.T) {
+	n := now()
+
+	var tests = []struct {
+		name  string
+		input struct {
+			sessions map[string]db.Session
+		}
+		want []db.Session
+	}{
+		{
+			name: "Iterate sessions",
+			input: struct {
+				sessions map[string]db.Session
+			}{
+				sessions: map[string]db.Session{
+					"test1": {
+						ID:        "test1",
+						ExpiresAt: n.Add(-1),
+					},
+					"test2": {
+						ID:        "test2",
+						ExpiresAt: n.Add(time.Hour),
+						CSRF: db.CSRF{
+							Token:     "token",
+							ExpiresAt: n.Add(time.Hour),
+						},
+					},
+				},
+			},
+			want: []db.Session{
+				{
+					ID:        "test2",
+					ExpiresAt: n.Add(time.Hour),
+					CSRF: db.CSRF{
+						Token:     "token",
+						ExpiresAt: n.Add(time.Hour),
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &sessionStore{
+				sessions:    test.input.sessions,
+				sessionCSRF: map[string]string{},
+				mu:          sync.RWMutex{},
+			}
+
+			var got []db.Session
+			gotErr := s.Iterate(context.Background(), func(session db.Session) error {
+				got = append(got, session)
+				return nil
+			})
+
+			if gotErr != nil {
+				t.Errorf("Iterate() = unexpected error: %v\n", gotErr)
+			}
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Iterate() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
@@ -25,6 +25,14 @@ type Result interface {
 	Decode(v any) error
 }
 
+// Cursor is the interface for MongoDB cursors.
+type Cursor interface {
+	Next(ctx context.Context) bool
+	Decode(v any) error
+	Err() error
+	Close(ctx context.Context) error
+}
+
 // ClientOptions contains options for the client.
 type ClientOptions struct {
 	URI                string
@@ -34,6 +42,7 @@ type ClientOptions struct {
 	ConnectTimeout     time.Duration
 	MaxOpenConnections int
 	EnableTLS          bool
+	TLSConfig          *tls.Config
 	ReplicaSet         string
 }
 
@@ -48,7 +57,9 @@ type TxFunc func(ctx context.Context, client Client) (any, error)
 type Client interface {
 	Database(database string) Client
 	Collection(collection string) Client
+	Find(ctx context.Context, filter any) (Cursor, error)
 	FindOne(ctx context.Context, filter any) (Result, error)
+	FindOneAndUpdate(ctx context.Context, filter, update any) (Result, error)
 	InsertOne(ctx context.Context, document any) (string, error)
 	UpsertOne(ctx context.Context, filter, update any) (string, error)
 	DeleteOne(ctx context.Context, filter any) error
@@ -56,6 +67,7 @@ type Client interface {
 	WithTransaction(ctx context.Context, fn TxFunc) (any, error)
 	WithTransactions(ctx context.Context, fns ...TxFunc) ([]any, error)
 	ReplicaSetEnabled() bool
+	Ping(ctx context.Context) error
 	Disconnect(ctx context.Context) error
 }
 
@@ -115,7 +127,11 @@ func createClientOptions(options *ClientOptions) *mgoopts.ClientOptions {
 	}
 
 	if len(options.URI) > 0 {
-		return opts.ApplyURI(options.URI)
+		opts.ApplyURI(options.URI)
+		if options.TLSConfig != nil {
+			opts.SetTLSConfig(options.TLSConfig)
+		}
+		return opts
 	}
 	if len(options.Hosts) > 0 {
 		opts.Hosts = options.Hosts
@@ -129,7 +145,9 @@ func createClientOptions(options *ClientOptions) *mgoopts.ClientOptions {
 	if options.MaxOpenConnections > 0 {
 		opts.SetMaxPoolSize(uint64(options.MaxOpenConnections))
 	}
-	if options.EnableTLS {
+	if options.TLSConfig != nil {
+		opts.SetTLSConfig(options.TLSConfig)
+	} else if options.EnableTLS {
 		opts.TLSConfig = &tls.Config{}
 	}
 	if len(options.ReplicaSet) > 0 {
@@ -157,12 +175,25 @@ func (c *client) Collection(collection string) Client {
 	return c
 }
 
+// Find finds documents in the collection.
+func (c *client) Find(ctx context.Context, filter any) (Cursor, error) {
+	return c.coll.Find(ctx, filter)
+}
+
 // FindOne finds a document in the collection.
 func (c *client) FindOne(ctx context.Context, filter any) (Result, error) {
 	res := c.coll.FindOne(ctx, filter)
 	return res, res.Err()
 }
 
+// FindOneAndUpdate updates a document in the collection and returns
+// the document after the update. The document is not created if it
+// does not exist.
+func (c *client) FindOneAndUpdate(ctx context.Context, filter, update any) (Result, error) {
+	res := c.coll.FindOneAndUpdate(ctx, filter, update, mgoopts.FindOneAndUpdate().SetReturnDocument(mgoopts.After))
+	return res, res.Err()
+}
+
 // InsertOne inserts a document into the collection.
 func (c *client) InsertOne(ctx context.Context, document any) (string, error) {
 	res, err := c.coll.InsertOne(ctx, document)
@@ -272,6 +303,11 @@ func (c *client) ReplicaSetEnabled() bool {
 	return len(c.replicaSet) > 0
 }
 
+// Ping checks the connection to the database.
+func (c *client) Ping(ctx context.Context) error {
+	return c.cl.Ping(ctx, nil)
+}
+
 // Disconnect disconnects the client.
 func (c *client) Disconnect(ctx context.Context) error {
 	err := c.cl.Disconnect(ctx)
@@ -24,6 +24,25 @@ func (c *stubMongoClient) Collection(collection string) Client {
 	return c
 }
 
+func (c stubMongoClient) Find(ctx context.Context, filter any) (Cursor, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	var docs []any
+	for _, secret := range c.secrets {
+		if !secret.ExpiresAt.Before(now()) {
+			docs = append(docs, secret)
+		}
+	}
+	for _, session := range c.sessions {
+		if !session.ExpiresAt.Before(now()) {
+			docs = append(docs, session)
+		}
+	}
+	return &stubCursor{docs: docs, index: -1}, nil
+}
+
 func (c stubMongoClient) FindOne(ctx context.Context, filter any) (Result, error) {
 	if c.err != nil {
 		return nil, c.err
@@ -71,6 +90,52 @@ func (c stubMongoClient) FindOne(ctx context.Context, filter any) (Result, error
 	return nil, ErrNoDocuments
 }
 
+func (c *stubMongoClient) FindOneAndUpdate(ctx context.Context, filter, update any) (Result, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	f, ok := filter.(bson.D)
+	if !ok {
+		return nil, errors.New("invalid filter")
+	}
+	u, ok := update.(bson.D)
+	if !ok {
+		return nil, errors.New("invalid update")
+	}
+	for i, secret := range c.secrets {
+		if f[0].Value != secret.ID {
+			continue
+		}
+		for _, op := range u {
+			fields, ok := op.Value.(bson.D)
+			if !ok {
+				return nil, errors.New("invalid update")
+			}
+			for _, field := range fields {
+				switch {
+				case op.Key == "$inc" && field.Key == "failedAttempts":
+					secret.FailedAttempts += field.Value.(int)
+				case op.Key == "$set" && field.Key == "codeHash":
+					secret.CodeHash = field.Value.(string)
+				case op.Key == "$set" && field.Key == "codeExpiresAt":
+					secret.CodeExpiresAt = field.Value.(time.Time)
+				default:
+					return nil, errors.New("unsupported update")
+				}
+			}
+		}
+		c.secrets[i] = secret
+		data, err := json.Marshal(secret)
+		if err != nil {
+			return nil, err
+		}
+		return stubResult{data: data}, nil
+	}
+
+	return nil, ErrNoDocuments
+}
+
 func (c *stubMongoClient) InsertOne(ctx context.Context, document any) (string, error) {
 	if c.err != nil {
 		return "", c.err
@@ -143,6 +208,10 @@ func (c stubMongoClient) ReplicaSetEnabled() bool {
 	return false
 }
 
+func (c stubMongoClient) Ping(ctx context.Context) error {
+	return c.err
+}
+
 func (c stubMongoClient) Disconnect(ctx context.Context) error {
 	return nil
 }
@@ -162,7 +231,34 @@ func (r stubResult) Decode(v any) error {
 	return nil
 }
 
+type stubCursor struct {
+	docs  []any
+	index int
+}
+
+func (c *stubCursor) Next(ctx context.Context) bool {
+	c.index++
+	return c.index < len(c.docs)
+}
+
+func (c *stubCursor) Decode(v any) error {
+	data, err := json.Marshal(c.docs[c.index])
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (c *stubCursor) Err() error {
+	return nil
+}
+
+func (c *stubCursor) Close(ctx context.Context) error {
+	return nil
+}
+
 var (
+	errFind       = errors.New("find error")
 	errFindOne    = errors.New("find one error")
 	errInsertOne  = errors.New("insert one error")
 	errDeleteOne  = errors.New("delete one error")
@@ -21,10 +21,11 @@ const (
 
 // secretStore is a MongoDB implementation of a SecretStore.
 type secretStore struct {
-	client       Client
-	collection   string
-	createSecret createSecretFunc
-	timeout      time.Duration
+	client        Client
+	collection    string
+	createSecret  createSecretFunc
+	createSecrets createSecretsFunc
+	timeout       time.Duration
 }
 
 // SecretStoreOptions is the options for the SecretStore.
@@ -64,8 +65,10 @@ func NewSecretStore(client Client, options ...SecretStoreOption) (*secretStore,
 
 	if client.ReplicaSetEnabled() {
 		setCreateSecretWithTransaction(store)
+		setCreateSecretsWithTransaction(store)
 	} else {
 		setCreateSecret(store)
+		setCreateSecrets(store)
 	}
 
 	return store, nil
@@ -93,6 +96,12 @@ func (s secretStore) Create(ctx context.Context, secret db.Secret) (db.Secret, e
 	return s.createSecret(ctx, secret)
 }
 
+// CreateMany creates secrets. The secrets are created in a transaction
+// if the client is connected to a replica set.
+func (s secretStore) CreateMany(ctx context.Context, secrets []db.Secret) ([]db.Secret, error) {
+	return s.createSecrets(ctx, secrets)
+}
+
 // Delete a secret by its ID.
 func (s secretStore) Delete(ctx context.Context, id string) error {
 	if err := s.client.Collection(s.collection).DeleteOne(ctx, bson.D{{Key: "_id", Value: id}}); err != nil {
@@ -104,6 +113,38 @@ func (s secretStore) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// IncrementFailedAttempts increments the number of failed attempts
+// to verify the second factor of a secret and returns the new number.
+func (s secretStore) IncrementFailedAttempts(ctx context.Context, id string) (int, error) {
+	update := bson.D{{Key: "$inc", Value: bson.D{{Key: "failedAttempts", Value: 1}}}}
+	res, err := s.client.Collection(s.collection).FindOneAndUpdate(ctx, bson.D{{Key: "_id", Value: id}}, update)
+	if err != nil {
+		if errors.Is(err, ErrNoDocuments) {
+			return 0, dberrors.ErrSecretNotFound
+		}
+		return 0, err
+	}
+
+	var secret db.Secret
+	if err := res.Decode(&secret); err != nil {
+		return 0, err
+	}
+	return secret.FailedAttempts, nil
+}
+
+// SetCode sets the hash and expiration time of the code sent for the
+// second factor of a secret. An empty hash removes the code.
+func (s secretStore) SetCode(ctx context.Context, id, hash string, expiresAt time.Time) error {
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "codeHash", Value: hash}, {Key: "codeExpiresAt", Value: expiresAt}}}}
+	if _, err := s.client.Collection(s.collection).FindOneAndUpdate(ctx, bson.D{{Key: "_id", Value: id}}, update); err != nil {
+		if errors.Is(err, ErrNoDocuments) {
+			return dberrors.ErrSecretNotFound
+		}
+		return err
+	}
+	return nil
+}
+
 // DeleteExpired deletes all expired secrets.
 func (s secretStore) DeleteExpired(ctx context.Context) error {
 	filter := bson.D{{Key: "expiresAt", Value: bson.D{{Key: "$lt", Value: now()}}}}
@@ -117,6 +158,33 @@ func (s secretStore) DeleteExpired(ctx context.Context) error {
 	return nil
 }
 
+// Iterate calls fn for every unexpired secret.
+func (s secretStore) Iterate(ctx context.Context, fn func(secret db.Secret) error) error {
+	filter := bson.D{{Key: "expiresAt", Value: bson.D{{Key: "$gte", Value: now()}}}}
+	cursor, err := s.client.Collection(s.collection).Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var secret db.Secret
+		if err := cursor.Decode(&secret); err != nil {
+			return err
+		}
+		if err := fn(secret); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// Ping checks the connection to the store.
+func (s secretStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx)
+}
+
 // Close the store and its underlying connections.
 func (s secretStore) Close() error {
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
@@ -174,3 +242,59 @@ func setCreateSecretWithTransaction(store *secretStore) {
 		return secret, nil
 	}
 }
+
+// createSecretsFunc is a function that creates secrets.
+type createSecretsFunc func(ctx context.Context, secrets []db.Secret) ([]db.Secret, error)
+
+// setCreateSecrets sets a createSecretsFunc to the store. The secrets
+// are created one by one.
+func setCreateSecrets(store *secretStore) {
+	store.createSecrets = func(ctx context.Context, secrets []db.Secret) ([]db.Secret, error) {
+		created := make([]db.Secret, 0, len(secrets))
+		for _, secret := range secrets {
+			secret, err := store.createSecret(ctx, secret)
+			if err != nil {
+				return nil, err
+			}
+			created = append(created, secret)
+		}
+		return created, nil
+	}
+}
+
+// setCreateSecretsWithTransaction sets a createSecretsFunc for use with
+// transactions to the store.
+func setCreateSecretsWithTransaction(store *secretStore) {
+	store.createSecrets = func(ctx context.Context, secrets []db.Secret) ([]db.Secret, error) {
+		result, err := store.client.WithTransaction(ctx, func(ctx context.Context, client Client) (any, error) {
+			created := make([]db.Secret, 0, len(secrets))
+			for _, secret := range secrets {
+				id, err := store.client.Collection(store.collection).InsertOne(ctx, secret)
+				if err != nil {
+					return nil, err
+				}
+
+				res, err := store.client.Collection(store.collection).FindOne(ctx, bson.D{{Key: "_id", Value: id}})
+				if err != nil {
+					return nil, err
+				}
+
+				var secret db.Secret
+				if err := res.Decode(&secret); err != nil {
+					return nil, err
+				}
+				created = append(created, secret)
+			}
+			return created, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		created, ok := result.([]db.Secret)
+		if !ok {
+			return nil, errors.New("invalid documents for secrets")
+		}
+		return created, nil
+	}
+}
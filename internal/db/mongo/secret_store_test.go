@@ -72,7 +72,7 @@ func TestNewSecretStore(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			got, gotErr := NewSecretStore(test.input.client, test.input.options...)
 
-			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(secretStore{}, stubMongoClient{}), cmpopts.IgnoreFields(secretStore{}, "createSecret")); diff != "" {
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(secretStore{}, stubMongoClient{}), cmpopts.IgnoreFields(secretStore{}, "createSecret", "createSecrets")); diff != "" {
 				t.Errorf("NewSecretStore() = unexpected result (-want +got)\n%s\n", diff)
 			}
 
@@ -239,6 +239,71 @@ func TestSecretStore_Create(t *testing.T) {
 	}
 }
 
+func TestSecretStore_CreateMany(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			secrets []db.Secret
+			err     error
+		}
+		want    []db.Secret
+		wantErr error
+	}{
+		{
+			name: "create secrets",
+			input: struct {
+				secrets []db.Secret
+				err     error
+			}{
+				secrets: []db.Secret{
+					{ID: "1", Value: "secret"},
+					{ID: "2", Value: "secret"},
+				},
+			},
+			want: []db.Secret{
+				{ID: "1", Value: "secret"},
+				{ID: "2", Value: "secret"},
+			},
+		},
+		{
+			name: "create secrets - error",
+			input: struct {
+				secrets []db.Secret
+				err     error
+			}{
+				secrets: []db.Secret{
+					{ID: "1", Value: "secret"},
+				},
+				err: errInsertOne,
+			},
+			wantErr: errInsertOne,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := &secretStore{
+				client: &stubMongoClient{
+					secrets: []db.Secret{},
+					err:     test.input.err,
+				},
+			}
+			setCreateSecret(store)
+			setCreateSecrets(store)
+
+			got, gotErr := store.CreateMany(context.Background(), test.input.secrets)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("CreateMany() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("CreateMany() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
 func TestSecretStore_Delete(t *testing.T) {
 	var tests = []struct {
 		name  string
@@ -310,6 +375,138 @@ func TestSecretStore_Delete(t *testing.T) {
 	}
 }
 
+func TestSecretStore_IncrementFailedAttempts(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			secrets []db.Secret
+			id      string
+		}
+		want    int
+		wantErr error
+	}{
+		{
+			name: "increment failed attempts",
+			input: struct {
+				secrets []db.Secret
+				id      string
+			}{
+				secrets: []db.Secret{
+					{
+						ID:             "1",
+						Value:          "secret",
+						FailedAttempts: 2,
+					},
+				},
+				id: "1",
+			},
+			want: 3,
+		},
+		{
+			name: "increment failed attempts - not found",
+			input: struct {
+				secrets []db.Secret
+				id      string
+			}{
+				secrets: []db.Secret{},
+				id:      "1",
+			},
+			wantErr: dberrors.ErrSecretNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := &secretStore{
+				client: &stubMongoClient{
+					secrets: test.input.secrets,
+				},
+			}
+
+			got, gotErr := store.IncrementFailedAttempts(context.Background(), test.input.id)
+
+			if test.want != got {
+				t.Errorf("IncrementFailedAttempts() = unexpected result, want: %d, got: %d\n", test.want, got)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("IncrementFailedAttempts() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestSecretStore_SetCode(t *testing.T) {
+	expiresAt := time.Date(2024, time.January, 1, 0, 10, 0, 0, time.UTC)
+
+	var tests = []struct {
+		name  string
+		input struct {
+			secrets []db.Secret
+			id      string
+		}
+		want    []db.Secret
+		wantErr error
+	}{
+		{
+			name: "set code",
+			input: struct {
+				secrets []db.Secret
+				id      string
+			}{
+				secrets: []db.Secret{
+					{
+						ID:    "1",
+						Value: "secret",
+					},
+				},
+				id: "1",
+			},
+			want: []db.Secret{
+				{
+					ID:            "1",
+					Value:         "secret",
+					CodeHash:      "hash",
+					CodeExpiresAt: expiresAt,
+				},
+			},
+		},
+		{
+			name: "set code - not found",
+			input: struct {
+				secrets []db.Secret
+				id      string
+			}{
+				secrets: []db.Secret{},
+				id:      "1",
+			},
+			want:    []db.Secret{},
+			wantErr: dberrors.ErrSecretNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &stubMongoClient{
+				secrets: test.input.secrets,
+			}
+			store := &secretStore{
+				client: client,
+			}
+
+			gotErr := store.SetCode(context.Background(), test.input.id, "hash", expiresAt)
+
+			if diff := cmp.Diff(test.want, client.secrets); diff != "" {
+				t.Errorf("SetCode() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("SetCode() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
 func TestSecretStore_DeleteExpired(t *testing.T) {
 	date := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
 	now = func() time.Time {
@@ -373,3 +570,83 @@ func TestSecretStore_DeleteExpired(t *testing.T) {
 		})
 	}
 }
+
+func TestSecretStore_Iterate(t *testing.T) {
+	date := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time {
+		return date
+	}
+
+	var tests = []struct {
+		name  string
+		input struct {
+			secrets []db.Secret
+			err     error
+		}
+		want    []db.Secret
+		wantErr error
+	}{
+		{
+			name: "iterate secrets",
+			input: struct {
+				secrets []db.Secret
+				err     error
+			}{
+				secrets: []db.Secret{
+					{
+						ID:        "1",
+						Value:     "secret",
+						ExpiresAt: date.Add(time.Hour),
+					},
+					{
+						ID:        "2",
+						Value:     "secret",
+						ExpiresAt: date.Add(-time.Hour),
+					},
+				},
+			},
+			want: []db.Secret{
+				{
+					ID:        "1",
+					Value:     "secret",
+					ExpiresAt: date.Add(time.Hour),
+				},
+			},
+		},
+		{
+			name: "iterate secrets - error",
+			input: struct {
+				secrets []db.Secret
+				err     error
+			}{
+				err: errFind,
+			},
+			wantErr: errFind,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := &secretStore{
+				client: &stubMongoClient{
+					secrets: test.input.secrets,
+					err:     test.input.err,
+				},
+			}
+
+			var got []db.Secret
+			gotErr := store.Iterate(context.Background(), func(secret db.Secret) error {
+				got = append(got, secret)
+				return nil
+			})
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Iterate() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Iterate() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
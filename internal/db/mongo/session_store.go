@@ -145,6 +145,33 @@ func (s sessionStore) DeleteExpired(ctx context.Context) error {
 	return nil
 }
 
+// Iterate calls fn for every unexpired session.
+func (s sessionStore) Iterate(ctx context.Context, fn func(session db.Session) error) error {
+	filter := bson.D{{Key: "expiresAt", Value: bson.D{{Key: "$gte", Value: now()}}}}
+	cursor, err := s.client.Collection(s.collection).Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var session db.Session
+		if err := cursor.Decode(&session); err != nil {
+			return err
+		}
+		if err := fn(session); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// Ping checks the connection to the store.
+func (s sessionStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx)
+}
+
 // Close the store and its underlying connections.
 func (s sessionStore) Close() error {
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
@@ -164,6 +191,7 @@ func setUpsertSession(store *sessionStore) {
 				{Key: "expiresAt", Value: session.ExpiresAt},
 				{Key: "csrf.token", Value: session.CSRF.Token},
 				{Key: "csrf.expiresAt", Value: session.CSRF.ExpiresAt},
+				{Key: "user", Value: session.User},
 			}},
 		}
 
@@ -185,6 +213,7 @@ func setUpsertSessionWithTransaction(store *sessionStore) {
 					{Key: "expiresAt", Value: session.ExpiresAt},
 					{Key: "csrf.token", Value: session.CSRF.Token},
 					{Key: "csrf.expiresAt", Value: session.CSRF.ExpiresAt},
+					{Key: "user", Value: session.User},
 				}},
 			}
 
@@ -208,7 +237,7 @@ func setUpsertSessionWithTransaction(store *sessionStore) {
 			return session, nil
 		})
 		if err != nil {
-			return db.Session{}, nil
+			return db.Session{}, err
 		}
 
 		session, ok := result.(db.Session)
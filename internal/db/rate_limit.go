@@ -0,0 +1,25 @@
+package db
+
+import "time"
+
+// RateLimit represents the limit for a rate limited key.
+type RateLimit struct {
+	// Rate is the average number of requests per second.
+	Rate float64
+	// Burst is the maximum number of requests in a burst.
+	Burst int
+}
+
+// RateLimitResult represents the result of a rate limited request.
+type RateLimitResult struct {
+	// Allowed is true if the request is allowed.
+	Allowed bool
+	// Remaining is the number of requests that can be made
+	// immediately after the request.
+	Remaining int
+	// RetryAfter is the duration until a request is allowed,
+	// if the request is not allowed.
+	RetryAfter time.Duration
+	// ResetAfter is the duration until the full burst is available.
+	ResetAfter time.Duration
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -25,8 +26,11 @@ type Client interface {
 	HSet(ctx context.Context, key string, value map[string]any) error
 	Delete(ctx context.Context, key string) error
 	Expire(ctx context.Context, key string, exp time.Duration) error
+	Scan(ctx context.Context, pattern string) ([]string, error)
 	WithTransaction(ctx context.Context, fn TxFunc) (TxResult, error)
 	WithTransactions(ctx context.Context, fns ...TxFunc) (TxResult, error)
+	RunScript(ctx context.Context, script *redis.Script, keys []string, args ...any) ([]int64, error)
+	Ping(ctx context.Context) error
 	Close() error
 }
 
@@ -51,6 +55,7 @@ type ClientOptions struct {
 	MaxIdleConnections    int
 	MaxConnectionLifetime time.Duration
 	EnableTLS             bool
+	TLSConfig             *tls.Config
 }
 
 // ClientOption is a function that sets options for the client.
@@ -97,6 +102,12 @@ func createClientOptions(options *ClientOptions) (*redis.Options, error) {
 		if err != nil {
 			return nil, err
 		}
+		if options.TLSConfig != nil {
+			if opts.TLSConfig == nil {
+				return nil, ErrTLSRequiresRediss
+			}
+			opts.TLSConfig = withTLSConfig(opts.TLSConfig, options.TLSConfig)
+		}
 		return opts, nil
 	}
 	if options.Database > 0 {
@@ -130,13 +141,26 @@ func createClientOptions(options *ClientOptions) (*redis.Options, error) {
 	if options.MaxConnectionLifetime > 0 {
 		opts.ConnMaxLifetime = options.MaxConnectionLifetime
 	}
-	if options.EnableTLS {
+	if options.TLSConfig != nil {
+		opts.TLSConfig = options.TLSConfig.Clone()
+	} else if options.EnableTLS {
 		opts.TLSConfig = &tls.Config{}
 	}
 
 	return opts, nil
 }
 
+// withTLSConfig returns a copy of tlsConfig to replace the current TLS configuration
+// parsed from a URI. The server name of the current configuration is kept if
+// tlsConfig has none.
+func withTLSConfig(current, tlsConfig *tls.Config) *tls.Config {
+	cfg := tlsConfig.Clone()
+	if len(cfg.ServerName) == 0 {
+		cfg.ServerName = current.ServerName
+	}
+	return cfg
+}
+
 // Get returns the value for the key.
 func (c client) Get(ctx context.Context, key string) ([]byte, error) {
 	b, err := c.rdb.Get(ctx, key).Bytes()
@@ -192,6 +216,28 @@ func (c client) Expire(ctx context.Context, key string, exp time.Duration) error
 	return c.rdb.Expire(ctx, key, exp).Err()
 }
 
+// Scan returns all keys matching the pattern.
+func (c client) Scan(ctx context.Context, pattern string) ([]string, error) {
+	var keys []string
+	iter := c.rdb.Scan(ctx, 0, pattern, 0).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// escapePattern escapes the glob-style special characters in s
+// so that it can be used as a literal in a pattern.
+func escapePattern(s string) string {
+	return globReplacer.Replace(s)
+}
+
+// globReplacer escapes glob-style special characters.
+var globReplacer = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
 // WithTransaction runs the function as a transaction.
 func (c *client) WithTransaction(ctx context.Context, fn TxFunc) (TxResult, error) {
 	pipe := c.rdb.TxPipeline()
@@ -214,6 +260,18 @@ func (c *client) WithTransactions(ctx context.Context, fns ...TxFunc) (TxResult,
 	return execCommands(ctx, tx)
 }
 
+// RunScript runs the Lua script with the keys and arguments and returns
+// the result as a slice of integers. The script is loaded on the server
+// on first use and run by its SHA1 digest after that.
+func (c client) RunScript(ctx context.Context, script *redis.Script, keys []string, args ...any) ([]int64, error) {
+	return script.Run(ctx, c.rdb, keys, args...).Int64Slice()
+}
+
+// Ping checks the connection to the database.
+func (c client) Ping(ctx context.Context) error {
+	return c.rdb.Ping(ctx).Err()
+}
+
 // Close the client and its underlying connections.
 func (c client) Close() error {
 	err := c.rdb.Close()
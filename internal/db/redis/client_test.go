@@ -1 +1,96 @@
 package redis
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestEscapePattern(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "no special characters",
+			input: "staging:secret:",
+			want:  "staging:secret:",
+		},
+		{
+			name:  "special characters",
+			input: `a*b?c[d]e\`,
+			want:  `a\*b\?c\[d\]e\\`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := escapePattern(test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("escapePattern() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestCreateClientOptions_TLS(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   *ClientOptions
+		want    *tls.Config
+		wantErr error
+	}{
+		{
+			name: "uri with tls",
+			input: &ClientOptions{
+				URI:       "rediss://localhost:6379",
+				TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+			},
+			want: &tls.Config{MinVersion: tls.VersionTLS12, ServerName: "localhost"},
+		},
+		{
+			name: "uri without tls",
+			input: &ClientOptions{
+				URI: "redis://localhost:6379",
+			},
+		},
+		{
+			name: "uri without tls - with tls config",
+			input: &ClientOptions{
+				URI:       "redis://localhost:6379",
+				TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+			},
+			wantErr: ErrTLSRequiresRediss,
+		},
+		{
+			name: "address with tls config",
+			input: &ClientOptions{
+				Address:   "localhost:6379",
+				TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+			},
+			want: &tls.Config{MinVersion: tls.VersionTLS12},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got *tls.Config
+			opts, gotErr := createClientOptions(test.input)
+			if opts != nil {
+				got = opts.TLSConfig
+			}
+
+			if diff := cmp.Diff(test.want, got, cmpopts.IgnoreUnexported(tls.Config{})); diff != "" {
+				t.Errorf("createClientOptions() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("createClientOptions() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
@@ -5,4 +5,7 @@ import "errors"
 var (
 	// ErrKeyNotFound is returned when the key is not found.
 	ErrKeyNotFound = errors.New("key not found")
+	// ErrTLSRequiresRediss is returned when a TLS configuration is provided
+	// together with a URI that does not enable TLS (rediss://).
+	ErrTLSRequiresRediss = errors.New("tls configuration requires a rediss:// URI")
 )
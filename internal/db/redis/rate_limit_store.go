@@ -0,0 +1,155 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/db"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// rateLimitPrefix is the key prefix for rate limits.
+	rateLimitPrefix = "ratelimit:"
+)
+
+// gcraScript implements the generic cell rate algorithm (GCRA). The
+// theoretical arrival time (TAT) of the next request is stored per key,
+// in microseconds. The time of the Redis server is used, so that the
+// result is the same regardless of which instance runs the script.
+//
+// KEYS[1]: The key.
+// ARGV[1]: The emission interval (1/rate) in microseconds.
+// ARGV[2]: The burst tolerance (emission interval * burst) in microseconds.
+//
+// Returns: {allowed (0 or 1), remaining, retry after (µs), reset after (µs)}.
+var gcraScript = redis.NewScript(`
+local emission = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+
+local newTat = tat + emission
+local allowAt = newTat - tolerance
+if now < allowAt then
+	return {0, 0, allowAt - now, tat - now}
+end
+
+local reset = newTat - now
+redis.call("SET", KEYS[1], newTat, "PX", math.ceil(reset / 1000))
+return {1, math.floor((tolerance - reset) / emission), 0, reset}
+`)
+
+// refundScript moves the theoretical arrival time (TAT) of the key back
+// by one emission interval, which returns one request to the limit. The
+// key is removed if the TAT is moved back to or before the current time.
+//
+// KEYS[1]: The key.
+// ARGV[1]: The emission interval (1/rate) in microseconds.
+//
+// Returns: {refunded (0 or 1)}.
+var refundScript = redis.NewScript(`
+local emission = tonumber(ARGV[1])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if not tat or tat <= now then
+	return {0}
+end
+
+tat = tat - emission
+if tat <= now then
+	redis.call("DEL", KEYS[1])
+	return {1}
+end
+redis.call("SET", KEYS[1], tat, "PX", math.ceil((tat - now) / 1000))
+return {1}
+`)
+
+// rateLimitStore is a Redis implementation of a RateLimitStore. The
+// limits are shared by all instances using the same Redis database.
+type rateLimitStore struct {
+	client Client
+	prefix string
+}
+
+// RateLimitStoreOptions is the options for the RateLimitStore.
+type RateLimitStoreOptions struct {
+	// KeyPrefix is prepended to all keys written by the store. Used
+	// to separate multiple instances sharing the same Redis database.
+	KeyPrefix string
+}
+
+// RateLimitStoreOption is a function that sets options for the RateLimitStore.
+type RateLimitStoreOption func(o *RateLimitStoreOptions)
+
+// NewRateLimitStore creates and configures a new RateLimitStore.
+func NewRateLimitStore(client Client, options ...RateLimitStoreOption) (*rateLimitStore, error) {
+	if client == nil {
+		return nil, errors.New("nil client")
+	}
+
+	opts := RateLimitStoreOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return &rateLimitStore{
+		client: client,
+		prefix: opts.KeyPrefix + rateLimitPrefix,
+	}, nil
+}
+
+// Allow reports whether a request for the key is allowed within the limit.
+func (s rateLimitStore) Allow(ctx context.Context, key string, limit db.RateLimit) (db.RateLimitResult, error) {
+	if limit.Rate <= 0 || limit.Burst <= 0 {
+		return db.RateLimitResult{}, errors.New("rate and burst must be greater than 0")
+	}
+
+	emission := emissionInterval(limit.Rate)
+	tolerance := emission * int64(limit.Burst)
+
+	res, err := s.client.RunScript(ctx, gcraScript, []string{s.prefix + key}, emission, tolerance)
+	if err != nil {
+		return db.RateLimitResult{}, err
+	}
+	if len(res) != 4 {
+		return db.RateLimitResult{}, fmt.Errorf("unexpected rate limit result: %v", res)
+	}
+
+	return db.RateLimitResult{
+		Allowed:    res[0] == 1,
+		Remaining:  int(res[1]),
+		RetryAfter: time.Duration(res[2]) * time.Microsecond,
+		ResetAfter: time.Duration(res[3]) * time.Microsecond,
+	}, nil
+}
+
+// Refund a request for the key that has been counted against the limit.
+func (s rateLimitStore) Refund(ctx context.Context, key string, limit db.RateLimit) error {
+	if limit.Rate <= 0 {
+		return errors.New("rate must be greater than 0")
+	}
+
+	_, err := s.client.RunScript(ctx, refundScript, []string{s.prefix + key}, emissionInterval(limit.Rate))
+	return err
+}
+
+// emissionInterval returns the emission interval (1/rate) in microseconds.
+func emissionInterval(rate float64) int64 {
+	return int64(math.Ceil(float64(time.Second/time.Microsecond) / rate))
+}
+
+// Close the store and its underlying client.
+func (s rateLimitStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,230 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/db"
+	"github.com/google/go-cmp/cmp"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestNewRateLimitStore(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			client  Client
+			options []RateLimitStoreOption
+		}
+		want    *rateLimitStore
+		wantErr error
+	}{
+		{
+			name: "new rate limit store",
+			input: struct {
+				client  Client
+				options []RateLimitStoreOption
+			}{
+				client: &client{},
+			},
+			want: &rateLimitStore{
+				client: &client{},
+				prefix: "ratelimit:",
+			},
+		},
+		{
+			name: "new rate limit store - with key prefix",
+			input: struct {
+				client  Client
+				options []RateLimitStoreOption
+			}{
+				client: &client{},
+				options: []RateLimitStoreOption{
+					func(o *RateLimitStoreOptions) {
+						o.KeyPrefix = "staging:"
+					},
+				},
+			},
+			want: &rateLimitStore{
+				client: &client{},
+				prefix: "staging:ratelimit:",
+			},
+		},
+		{
+			name: "new rate limit store - nil client",
+			input: struct {
+				client  Client
+				options []RateLimitStoreOption
+			}{
+				client: nil,
+			},
+			wantErr: errors.New("nil client"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := NewRateLimitStore(test.input.client, test.input.options...)
+
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(rateLimitStore{}, client{})); diff != "" {
+				t.Errorf("NewRateLimitStore() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmp.Comparer(func(x, y error) bool {
+				return x.Error() == y.Error()
+			})); diff != "" {
+				t.Errorf("NewRateLimitStore() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestRateLimitStore_Allow(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			client *stubScriptClient
+			limit  db.RateLimit
+		}
+		want     db.RateLimitResult
+		wantKeys []string
+		wantArgs []any
+		wantErr  bool
+	}{
+		{
+			name: "allowed",
+			input: struct {
+				client *stubScriptClient
+				limit  db.RateLimit
+			}{
+				client: &stubScriptClient{result: []int64{1, 2, 0, 1000000}},
+				limit:  db.RateLimit{Rate: 1, Burst: 3},
+			},
+			want: db.RateLimitResult{
+				Allowed:    true,
+				Remaining:  2,
+				ResetAfter: time.Second,
+			},
+			wantKeys: []string{"ratelimit:key"},
+			wantArgs: []any{int64(1000000), int64(3000000)},
+		},
+		{
+			name: "not allowed",
+			input: struct {
+				client *stubScriptClient
+				limit  db.RateLimit
+			}{
+				client: &stubScriptClient{result: []int64{0, 0, 500000, 1500000}},
+				limit:  db.RateLimit{Rate: 2, Burst: 3},
+			},
+			want: db.RateLimitResult{
+				RetryAfter: 500 * time.Millisecond,
+				ResetAfter: 1500 * time.Millisecond,
+			},
+			wantKeys: []string{"ratelimit:key"},
+			wantArgs: []any{int64(500000), int64(1500000)},
+		},
+		{
+			name: "invalid limit",
+			input: struct {
+				client *stubScriptClient
+				limit  db.RateLimit
+			}{
+				client: &stubScriptClient{},
+				limit:  db.RateLimit{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error",
+			input: struct {
+				client *stubScriptClient
+				limit  db.RateLimit
+			}{
+				client: &stubScriptClient{err: errors.New("error")},
+				limit:  db.RateLimit{Rate: 1, Burst: 3},
+			},
+			wantKeys: []string{"ratelimit:key"},
+			wantArgs: []any{int64(1000000), int64(3000000)},
+			wantErr:  true,
+		},
+		{
+			name: "unexpected result",
+			input: struct {
+				client *stubScriptClient
+				limit  db.RateLimit
+			}{
+				client: &stubScriptClient{result: []int64{1}},
+				limit:  db.RateLimit{Rate: 1, Burst: 3},
+			},
+			wantKeys: []string{"ratelimit:key"},
+			wantArgs: []any{int64(1000000), int64(3000000)},
+			wantErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &rateLimitStore{
+				client: test.input.client,
+				prefix: rateLimitPrefix,
+			}
+
+			got, gotErr := s.Allow(context.Background(), "key", test.input.limit)
+			if (gotErr != nil) != test.wantErr {
+				t.Fatalf("Allow() = unexpected error: %v", gotErr)
+			}
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Allow() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantKeys, test.input.client.keys); diff != "" {
+				t.Errorf("Allow() = unexpected keys (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantArgs, test.input.client.args); diff != "" {
+				t.Errorf("Allow() = unexpected args (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+type stubScriptClient struct {
+	Client
+	keys   []string
+	args   []any
+	result []int64
+	err    error
+}
+
+func (c *stubScriptClient) RunScript(ctx context.Context, script *redis.Script, keys []string, args ...any) ([]int64, error) {
+	c.keys = keys
+	c.args = args
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.result, nil
+}
+
+func TestRateLimitStore_Refund(t *testing.T) {
+	client := &stubScriptClient{result: []int64{1}}
+	s := &rateLimitStore{
+		client: client,
+		prefix: rateLimitPrefix,
+	}
+
+	if err := s.Refund(context.Background(), "key", db.RateLimit{Rate: 2, Burst: 3}); err != nil {
+		t.Fatalf("Refund() = unexpected error: %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"ratelimit:key"}, client.keys); diff != "" {
+		t.Errorf("Refund() = unexpected keys (-want +got)\n%s\n", diff)
+	}
+
+	if diff := cmp.Diff([]any{int64(500000)}, client.args); diff != "" {
+		t.Errorf("Refund() = unexpected args (-want +got)\n%s\n", diff)
+	}
+}
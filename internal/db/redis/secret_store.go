@@ -3,10 +3,13 @@ package redis
 import (
 	"context"
 	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/RedeployAB/burnit/internal/db"
 	dberrors "github.com/RedeployAB/burnit/internal/db/errors"
+	"github.com/redis/go-redis/v9"
 )
 
 const (
@@ -14,13 +17,48 @@ const (
 	secretPrefix = "secret:"
 )
 
+// incrementFailedAttemptsScript increments the number of failed attempts
+// of a secret. The key is not created if it does not exist, since it
+// might have expired or been deleted.
+//
+// KEYS[1]: The key of the secret.
+//
+// Returns: {failed attempts}, or {-1} if the secret does not exist.
+var incrementFailedAttemptsScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return {-1}
+end
+return {redis.call("HINCRBY", KEYS[1], "failed_attempts", 1)}
+`)
+
+// setCodeScript sets the hash and expiration time of the code of a
+// secret. The key is not created if it does not exist.
+//
+// KEYS[1]: The key of the secret.
+// ARGV[1]: The hash of the code.
+// ARGV[2]: The expiration time of the code (RFC 3339).
+//
+// Returns: {1}, or {-1} if the secret does not exist.
+var setCodeScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return {-1}
+end
+redis.call("HSET", KEYS[1], "code_hash", ARGV[1], "code_expires_at", ARGV[2])
+return {1}
+`)
+
 // secretStore is a Redis implementation of a SecretStore.
 type secretStore struct {
 	client Client
+	prefix string
 }
 
 // SecretStoreOptions is the options for the SecretStore.
-type SecretStoreOptions struct{}
+type SecretStoreOptions struct {
+	// KeyPrefix is prepended to all keys written by the store. Used
+	// to separate multiple instances sharing the same Redis database.
+	KeyPrefix string
+}
 
 // SecretStoreOption is a function that sets options for the SecretStore.
 type SecretStoreOption func(o *SecretStoreOptions)
@@ -38,12 +76,13 @@ func NewSecretStore(client Client, options ...SecretStoreOption) (*secretStore,
 
 	return &secretStore{
 		client: client,
+		prefix: opts.KeyPrefix + secretPrefix,
 	}, nil
 }
 
 // Get a secret by its ID.
 func (s secretStore) Get(ctx context.Context, id string) (db.Secret, error) {
-	data, err := s.client.HGet(ctx, secretPrefix+id)
+	data, err := s.client.HGet(ctx, s.prefix+id)
 	if err != nil {
 		if errors.Is(err, ErrKeyNotFound) {
 			return db.Secret{}, dberrors.ErrSecretNotFound
@@ -56,24 +95,52 @@ func (s secretStore) Get(ctx context.Context, id string) (db.Secret, error) {
 // Create a secret.
 func (s secretStore) Create(ctx context.Context, secret db.Secret) (db.Secret, error) {
 	result, err := s.client.WithTransaction(ctx, func(tx Tx) {
-		tx.HSet(ctx, secretPrefix+secret.ID, secretToMap(&secret))
-		tx.Expire(ctx, secretPrefix+secret.ID, time.Until(secret.ExpiresAt))
-		tx.HGet(ctx, secretPrefix+secret.ID)
+		tx.HSet(ctx, s.prefix+secret.ID, secretToMap(&secret))
+		tx.Expire(ctx, s.prefix+secret.ID, time.Until(secret.ExpiresAt))
+		tx.HGet(ctx, s.prefix+secret.ID)
 	})
 	if err != nil {
-		return db.Secret{}, nil
+		return db.Secret{}, err
 	}
 
 	data := result.LastMap()
 	if data == nil {
-		return db.Secret{}, dberrors.ErrSessionNotFound
+		return db.Secret{}, dberrors.ErrSecretNotFound
 	}
 	return secretFromMap(data)
 }
 
+// CreateMany creates secrets in a transaction.
+func (s secretStore) CreateMany(ctx context.Context, secrets []db.Secret) ([]db.Secret, error) {
+	result, err := s.client.WithTransaction(ctx, func(tx Tx) {
+		for _, secret := range secrets {
+			tx.HSet(ctx, s.prefix+secret.ID, secretToMap(&secret))
+			tx.Expire(ctx, s.prefix+secret.ID, time.Until(secret.ExpiresAt))
+			tx.HGet(ctx, s.prefix+secret.ID)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data := result.AllMaps()
+	if len(data) != len(secrets) {
+		return nil, dberrors.ErrSecretNotFound
+	}
+	created := make([]db.Secret, 0, len(data))
+	for _, d := range data {
+		secret, err := secretFromMap(d)
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, secret)
+	}
+	return created, nil
+}
+
 // Delete a secret by its ID.
 func (s secretStore) Delete(ctx context.Context, id string) error {
-	if err := s.client.Delete(ctx, secretPrefix+id); err != nil {
+	if err := s.client.Delete(ctx, s.prefix+id); err != nil {
 		if errors.Is(err, ErrKeyNotFound) {
 			return dberrors.ErrSecretNotFound
 		}
@@ -82,12 +149,77 @@ func (s secretStore) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// IncrementFailedAttempts increments the number of failed attempts
+// to verify the second factor of a secret and returns the new number.
+func (s secretStore) IncrementFailedAttempts(ctx context.Context, id string) (int, error) {
+	res, err := s.client.RunScript(ctx, incrementFailedAttemptsScript, []string{s.prefix + id})
+	if err != nil {
+		return 0, err
+	}
+	if len(res) != 1 {
+		return 0, errors.New("unexpected result from failed attempts script")
+	}
+	if res[0] < 0 {
+		return 0, dberrors.ErrSecretNotFound
+	}
+	return int(res[0]), nil
+}
+
+// SetCode sets the hash and expiration time of the code sent for the
+// second factor of a secret. An empty hash removes the code.
+func (s secretStore) SetCode(ctx context.Context, id, hash string, expiresAt time.Time) error {
+	res, err := s.client.RunScript(ctx, setCodeScript, []string{s.prefix + id}, hash, formatTime(expiresAt))
+	if err != nil {
+		return err
+	}
+	if len(res) != 1 {
+		return errors.New("unexpected result from code script")
+	}
+	if res[0] < 0 {
+		return dberrors.ErrSecretNotFound
+	}
+	return nil
+}
+
 // DeleteExpired deletes all expired secrets. This is a no-op for Redis
 // since Redis handles expiration automatically.
 func (s secretStore) DeleteExpired(ctx context.Context) error {
 	return nil
 }
 
+// Iterate calls fn for every unexpired secret. Keys that expire
+// during iteration are skipped.
+func (s secretStore) Iterate(ctx context.Context, fn func(secret db.Secret) error) error {
+	keys, err := s.client.Scan(ctx, escapePattern(s.prefix)+"*")
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		data, err := s.client.HGet(ctx, key)
+		if err != nil {
+			if errors.Is(err, ErrKeyNotFound) {
+				continue
+			}
+			return err
+		}
+		secret, err := secretFromMap(data)
+		if err != nil {
+			return err
+		}
+		if err := fn(secret); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Ping checks the connection to the store.
+func (s secretStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx)
+}
+
 // Close the store and its underlying connections.
 func (s secretStore) Close() error {
 	return s.client.Close()
@@ -96,21 +228,77 @@ func (s secretStore) Close() error {
 // secretToMap creates a map from the provided secret.
 func secretToMap(secret *db.Secret) map[string]any {
 	return map[string]any{
-		"id":         secret.ID,
-		"value":      secret.Value,
-		"expires_at": secret.ExpiresAt,
+		"id":                 secret.ID,
+		"value":              secret.Value,
+		"expires_at":         secret.ExpiresAt,
+		"created_by":         secret.CreatedBy,
+		"allowed_networks":   strings.Join(secret.AllowedNetworks, ","),
+		"not_before":         formatTime(secret.NotBefore),
+		"second_factor":      secret.SecondFactor,
+		"second_factor_data": secret.SecondFactorData,
+		"label":              secret.Label,
+		"note":               secret.Note,
+		"failed_attempts":    secret.FailedAttempts,
+		"code_hash":          secret.CodeHash,
+		"code_expires_at":    formatTime(secret.CodeExpiresAt),
 	}
 }
 
+// formatTime formats the time for storage. The zero time is stored
+// as an empty string.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// parseTime parses a time formatted with formatTime.
+func parseTime(s string) (time.Time, error) {
+	if len(s) == 0 {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
 // secretFromMap creates a db.Secret from the provided map.
 func secretFromMap(secret map[string]string) (db.Secret, error) {
 	expiresAt, err := time.Parse(time.RFC3339, secret["expires_at"])
 	if err != nil {
 		return db.Secret{}, err
 	}
+	var allowedNetworks []string
+	if len(secret["allowed_networks"]) > 0 {
+		allowedNetworks = strings.Split(secret["allowed_networks"], ",")
+	}
+	notBefore, err := parseTime(secret["not_before"])
+	if err != nil {
+		return db.Secret{}, err
+	}
+	var failedAttempts int
+	if len(secret["failed_attempts"]) > 0 {
+		failedAttempts, err = strconv.Atoi(secret["failed_attempts"])
+		if err != nil {
+			return db.Secret{}, err
+		}
+	}
+	codeExpiresAt, err := parseTime(secret["code_expires_at"])
+	if err != nil {
+		return db.Secret{}, err
+	}
 	return db.Secret{
-		ID:        secret["id"],
-		Value:     secret["value"],
-		ExpiresAt: expiresAt,
+		ID:               secret["id"],
+		Value:            secret["value"],
+		ExpiresAt:        expiresAt,
+		NotBefore:        notBefore,
+		CreatedBy:        secret["created_by"],
+		AllowedNetworks:  allowedNetworks,
+		SecondFactor:     secret["second_factor"],
+		SecondFactorData: secret["second_factor_data"],
+		Label:            secret["label"],
+		Note:             secret["note"],
+		FailedAttempts:   failedAttempts,
+		CodeHash:         secret["code_hash"],
+		CodeExpiresAt:    codeExpiresAt,
 	}, nil
 }
@@ -1 +1,309 @@
 package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/db"
+	dberrors "github.com/RedeployAB/burnit/internal/db/errors"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestNewSecretStore(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			client  Client
+			options []SecretStoreOption
+		}
+		want    *secretStore
+		wantErr error
+	}{
+		{
+			name: "new secret store",
+			input: struct {
+				client  Client
+				options []SecretStoreOption
+			}{
+				client: &client{},
+			},
+			want: &secretStore{
+				client: &client{},
+				prefix: "secret:",
+			},
+		},
+		{
+			name: "new secret store - with key prefix",
+			input: struct {
+				client  Client
+				options []SecretStoreOption
+			}{
+				client: &client{},
+				options: []SecretStoreOption{
+					func(o *SecretStoreOptions) {
+						o.KeyPrefix = "staging:"
+					},
+				},
+			},
+			want: &secretStore{
+				client: &client{},
+				prefix: "staging:secret:",
+			},
+		},
+		{
+			name: "new secret store - nil client",
+			input: struct {
+				client  Client
+				options []SecretStoreOption
+			}{
+				client: nil,
+			},
+			wantErr: errors.New("nil client"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := NewSecretStore(test.input.client, test.input.options...)
+
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(secretStore{}, client{})); diff != "" {
+				t.Errorf("NewSecretStore() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if test.wantErr != nil && gotErr == nil {
+				t.Errorf("NewSecretStore() = expected error: %v\n", test.wantErr)
+			}
+		})
+	}
+}
+
+func TestSecretStore_Create(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			client *stubTxClient
+			secret db.Secret
+		}
+		want    db.Secret
+		wantErr error
+	}{
+		{
+			name: "create secret",
+			input: struct {
+				client *stubTxClient
+				secret db.Secret
+			}{
+				client: &stubTxClient{
+					result: TxResult{
+						m: []map[string]string{
+							{"id": "1", "value": "secret", "expires_at": "2024-01-01T00:00:00Z"},
+						},
+					},
+				},
+				secret: db.Secret{ID: "1", Value: "secret", ExpiresAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			},
+			want: db.Secret{ID: "1", Value: "secret", ExpiresAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "create secret - transaction error",
+			input: struct {
+				client *stubTxClient
+				secret db.Secret
+			}{
+				client: &stubTxClient{err: errTx},
+				secret: db.Secret{ID: "1", Value: "secret"},
+			},
+			wantErr: errTx,
+		},
+		{
+			name: "create secret - not found after create",
+			input: struct {
+				client *stubTxClient
+				secret db.Secret
+			}{
+				client: &stubTxClient{},
+				secret: db.Secret{ID: "1", Value: "secret"},
+			},
+			wantErr: dberrors.ErrSecretNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &secretStore{client: test.input.client, prefix: secretPrefix}
+			got, gotErr := s.Create(context.Background(), test.input.secret)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Create() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Create() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestSecretStore_IncrementFailedAttempts(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			client *stubScriptClient
+		}
+		want    int
+		wantErr error
+	}{
+		{
+			name: "increment failed attempts",
+			input: struct {
+				client *stubScriptClient
+			}{
+				client: &stubScriptClient{result: []int64{3}},
+			},
+			want: 3,
+		},
+		{
+			name: "secret not found",
+			input: struct {
+				client *stubScriptClient
+			}{
+				client: &stubScriptClient{result: []int64{-1}},
+			},
+			wantErr: dberrors.ErrSecretNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &secretStore{client: test.input.client, prefix: secretPrefix}
+			got, gotErr := s.IncrementFailedAttempts(context.Background(), "1")
+
+			if test.want != got {
+				t.Errorf("IncrementFailedAttempts() = unexpected result, want: %d, got: %d\n", test.want, got)
+			}
+
+			if diff := cmp.Diff([]string{secretPrefix + "1"}, test.input.client.keys); diff != "" {
+				t.Errorf("IncrementFailedAttempts() = unexpected keys (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("IncrementFailedAttempts() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestSecretStore_SetCode(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			client    *stubScriptClient
+			hash      string
+			expiresAt time.Time
+		}
+		wantArgs []any
+		wantErr  error
+	}{
+		{
+			name: "set code",
+			input: struct {
+				client    *stubScriptClient
+				hash      string
+				expiresAt time.Time
+			}{
+				client:    &stubScriptClient{result: []int64{1}},
+				hash:      "hash",
+				expiresAt: time.Date(2024, 1, 1, 0, 10, 0, 0, time.UTC),
+			},
+			wantArgs: []any{"hash", "2024-01-01T00:10:00Z"},
+		},
+		{
+			name: "remove code",
+			input: struct {
+				client    *stubScriptClient
+				hash      string
+				expiresAt time.Time
+			}{
+				client: &stubScriptClient{result: []int64{1}},
+			},
+			wantArgs: []any{"", ""},
+		},
+		{
+			name: "secret not found",
+			input: struct {
+				client    *stubScriptClient
+				hash      string
+				expiresAt time.Time
+			}{
+				client: &stubScriptClient{result: []int64{-1}},
+				hash:   "hash",
+			},
+			wantArgs: []any{"hash", ""},
+			wantErr:  dberrors.ErrSecretNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &secretStore{client: test.input.client, prefix: secretPrefix}
+			gotErr := s.SetCode(context.Background(), "1", test.input.hash, test.input.expiresAt)
+
+			if diff := cmp.Diff(test.wantArgs, test.input.client.args); diff != "" {
+				t.Errorf("SetCode() = unexpected args (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("SetCode() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestSecretFromMap(t *testing.T) {
+	secret := db.Secret{
+		ID:             "1",
+		Value:          "secret",
+		ExpiresAt:      time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC),
+		FailedAttempts: 2,
+		CodeHash:       "hash",
+		CodeExpiresAt:  time.Date(2024, 1, 1, 0, 10, 0, 0, time.UTC),
+	}
+	data := map[string]string{}
+	for k, v := range secretToMap(&secret) {
+		switch v := v.(type) {
+		case time.Time:
+			data[k] = v.Format(time.RFC3339Nano)
+		default:
+			data[k] = fmt.Sprint(v)
+		}
+	}
+
+	got, err := secretFromMap(data)
+	if err != nil {
+		t.Fatalf("secretFromMap() = unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(secret, got); diff != "" {
+		t.Errorf("secretFromMap() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
+
+type stubTxClient struct {
+	Client
+	result TxResult
+	err    error
+}
+
+func (c *stubTxClient) WithTransaction(ctx context.Context, fn TxFunc) (TxResult, error) {
+	if c.err != nil {
+		return TxResult{}, c.err
+	}
+	return c.result, nil
+}
+
+var (
+	errTx = errors.New("transaction error")
+)
@@ -18,11 +18,17 @@ const (
 
 // sessionStore is a Redis implementation of a SessionStore.
 type sessionStore struct {
-	client Client
+	client     Client
+	prefix     string
+	csrfPrefix string
 }
 
 // SessionStoreOptions is the options for the SessionStore.
-type SessionStoreOptions struct{}
+type SessionStoreOptions struct {
+	// KeyPrefix is prepended to all keys written by the store. Used
+	// to separate multiple instances sharing the same Redis database.
+	KeyPrefix string
+}
 
 // SessionStoreOption is a function that sets options for the SessionStore.
 type SessionStoreOption func(o *SessionStoreOptions)
@@ -39,13 +45,15 @@ func NewSessionStore(client Client, options ...SessionStoreOption) (*sessionStor
 	}
 
 	return &sessionStore{
-		client: client,
+		client:     client,
+		prefix:     opts.KeyPrefix + sessionPrefix,
+		csrfPrefix: opts.KeyPrefix + sessionCSRFPrefix,
 	}, nil
 }
 
 // Get a session by its ID.
 func (s sessionStore) Get(ctx context.Context, id string) (db.Session, error) {
-	data, err := s.client.HGet(ctx, sessionPrefix+id)
+	data, err := s.client.HGet(ctx, s.prefix+id)
 	if err != nil {
 		if errors.Is(err, ErrKeyNotFound) {
 			return db.Session{}, dberrors.ErrSessionNotFound
@@ -57,7 +65,7 @@ func (s sessionStore) Get(ctx context.Context, id string) (db.Session, error) {
 
 // GetByCSRFToken gets a session by its CSRF token.
 func (s sessionStore) GetByCSRFToken(ctx context.Context, token string) (db.Session, error) {
-	data, err := s.client.Get(ctx, sessionCSRFPrefix+token)
+	data, err := s.client.Get(ctx, s.csrfPrefix+token)
 	if err != nil {
 		if errors.Is(err, ErrKeyNotFound) {
 			return db.Session{}, dberrors.ErrSessionNotFound
@@ -78,14 +86,14 @@ func (s sessionStore) Upsert(ctx context.Context, session db.Session) (db.Sessio
 
 	result, err := s.client.WithTransaction(ctx, func(tx Tx) {
 		if len(token) > 0 {
-			tx.Delete(ctx, sessionCSRFPrefix+token)
+			tx.Delete(ctx, s.csrfPrefix+token)
 		}
-		tx.HSet(ctx, sessionPrefix+session.ID, sessionToMap(&session))
-		tx.Expire(ctx, sessionPrefix+session.ID, time.Until(session.ExpiresAt))
+		tx.HSet(ctx, s.prefix+session.ID, sessionToMap(&session))
+		tx.Expire(ctx, s.prefix+session.ID, time.Until(session.ExpiresAt))
 		if len(session.CSRF.Token) > 0 {
-			tx.Set(ctx, sessionCSRFPrefix+session.CSRF.Token, []byte(session.ID), time.Until(session.CSRF.ExpiresAt))
+			tx.Set(ctx, s.csrfPrefix+session.CSRF.Token, []byte(session.ID), time.Until(session.CSRF.ExpiresAt))
 		}
-		tx.HGet(ctx, sessionPrefix+session.ID)
+		tx.HGet(ctx, s.prefix+session.ID)
 	})
 	if err != nil {
 		return db.Session{}, err
@@ -107,9 +115,9 @@ func (s sessionStore) Delete(ctx context.Context, id string) error {
 
 	if _, err = s.client.WithTransaction(ctx, func(tx Tx) {
 		if len(session.CSRF.Token) > 0 {
-			tx.Delete(ctx, sessionCSRFPrefix+session.CSRF.Token)
+			tx.Delete(ctx, s.csrfPrefix+session.CSRF.Token)
 		}
-		tx.Delete(ctx, sessionPrefix+session.ID)
+		tx.Delete(ctx, s.prefix+session.ID)
 	}); err != nil {
 		if errors.Is(err, ErrKeyNotFound) {
 			return dberrors.ErrSessionNotFound
@@ -127,8 +135,8 @@ func (s sessionStore) DeleteByCSRFToken(ctx context.Context, token string) error
 	}
 
 	if _, err := s.client.WithTransaction(ctx, func(tx Tx) {
-		tx.Delete(ctx, sessionCSRFPrefix+token)
-		tx.Delete(ctx, sessionPrefix+session.ID)
+		tx.Delete(ctx, s.csrfPrefix+token)
+		tx.Delete(ctx, s.prefix+session.ID)
 	}); err != nil {
 		if errors.Is(err, ErrKeyNotFound) {
 			return dberrors.ErrSessionNotFound
@@ -144,6 +152,39 @@ func (s sessionStore) DeleteExpired(ctx context.Context) error {
 	return nil
 }
 
+// Iterate calls fn for every unexpired session. Keys that expire
+// during iteration are skipped.
+func (s sessionStore) Iterate(ctx context.Context, fn func(session db.Session) error) error {
+	keys, err := s.client.Scan(ctx, escapePattern(s.prefix)+"*")
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		data, err := s.client.HGet(ctx, key)
+		if err != nil {
+			if errors.Is(err, ErrKeyNotFound) {
+				continue
+			}
+			return err
+		}
+		session, err := sessionFromMap(data)
+		if err != nil {
+			return err
+		}
+		if err := fn(session); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Ping checks the connection to the store.
+func (s sessionStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx)
+}
+
 // Close the store and its underlying connections.
 func (s sessionStore) Close() error {
 	return s.client.Close()
@@ -156,6 +197,7 @@ func sessionToMap(session *db.Session) map[string]any {
 		"expires_at":      session.ExpiresAt,
 		"csrf_token":      session.CSRF.Token,
 		"csrf_expires_at": session.CSRF.ExpiresAt,
+		"user":            session.User,
 	}
 }
 
@@ -176,5 +218,6 @@ func sessionFromMap(session map[string]string) (db.Session, error) {
 			Token:     session["csrf_token"],
 			ExpiresAt: csrfExpiresAt,
 		},
+		User: session["user"],
 	}, nil
 }
@@ -1 +1,78 @@
 package redis
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewSessionStore(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			client  Client
+			options []SessionStoreOption
+		}
+		want    *sessionStore
+		wantErr error
+	}{
+		{
+			name: "new session store",
+			input: struct {
+				client  Client
+				options []SessionStoreOption
+			}{
+				client: &client{},
+			},
+			want: &sessionStore{
+				client:     &client{},
+				prefix:     "session:",
+				csrfPrefix: "session-csrf:",
+			},
+		},
+		{
+			name: "new session store - with key prefix",
+			input: struct {
+				client  Client
+				options []SessionStoreOption
+			}{
+				client: &client{},
+				options: []SessionStoreOption{
+					func(o *SessionStoreOptions) {
+						o.KeyPrefix = "staging:"
+					},
+				},
+			},
+			want: &sessionStore{
+				client:     &client{},
+				prefix:     "staging:session:",
+				csrfPrefix: "staging:session-csrf:",
+			},
+		},
+		{
+			name: "new session store - nil client",
+			input: struct {
+				client  Client
+				options []SessionStoreOption
+			}{
+				client: nil,
+			},
+			wantErr: errors.New("nil client"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := NewSessionStore(test.input.client, test.input.options...)
+
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(sessionStore{}, client{})); diff != "" {
+				t.Errorf("NewSessionStore() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if test.wantErr != nil && gotErr == nil {
+				t.Errorf("NewSessionStore() = expected error: %v\n", test.wantErr)
+			}
+		})
+	}
+}
@@ -7,4 +7,29 @@ type Secret struct {
 	ID        string    `json:"id,omitempty" bson:"_id,omitempty"`
 	Value     string    `json:"value" bson:"value"`
 	ExpiresAt time.Time `json:"expiresAt" bson:"expiresAt"`
+	// NotBefore is the time from which the secret can be read.
+	NotBefore time.Time `json:"notBefore,omitempty" bson:"notBefore,omitempty"`
+	// CreatedBy is the subject of the user who created the secret.
+	CreatedBy string `json:"createdBy,omitempty" bson:"createdBy,omitempty"`
+	// AllowedNetworks contains the networks (CIDRs) that are allowed
+	// to read the secret. All networks are allowed if empty.
+	AllowedNetworks []string `json:"allowedNetworks,omitempty" bson:"allowedNetworks,omitempty"`
+	// SecondFactor is the type of the second factor required to read
+	// the secret, if any.
+	SecondFactor string `json:"secondFactor,omitempty" bson:"secondFactor,omitempty"`
+	// SecondFactorData contains the encrypted data used to verify
+	// the second factor.
+	SecondFactorData string `json:"secondFactorData,omitempty" bson:"secondFactorData,omitempty"`
+	// Label is a short plaintext label shown before the secret is read.
+	Label string `json:"label,omitempty" bson:"label,omitempty"`
+	// Note is an encrypted free-text note delivered with the value.
+	Note string `json:"note,omitempty" bson:"note,omitempty"`
+	// FailedAttempts is the number of failed attempts to verify the
+	// second factor of the secret.
+	FailedAttempts int `json:"failedAttempts,omitempty" bson:"failedAttempts,omitempty"`
+	// CodeHash is the hash of the code sent for an email second factor.
+	CodeHash string `json:"codeHash,omitempty" bson:"codeHash,omitempty"`
+	// CodeExpiresAt is the time the code sent for an email second
+	// factor expires.
+	CodeExpiresAt time.Time `json:"codeExpiresAt,omitempty" bson:"codeExpiresAt,omitempty"`
 }
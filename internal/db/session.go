@@ -7,6 +7,7 @@ type Session struct {
 	ID        string    `json:"id" bson:"_id"`
 	CSRF      CSRF      `json:"csrf" bson:"csrf"`
 	ExpiresAt time.Time `json:"expiresAt" bson:"expiresAt"`
+	User      string    `json:"user,omitempty" bson:"user,omitempty"`
 }
 
 // CSRF holds the CSRF token and its expiration time.
@@ -2,12 +2,19 @@ package sql
 
 import (
 	"context"
+	"crypto/tls"
 	"database/sql"
 	"errors"
 	"fmt"
 	"net/url"
+	"regexp"
 	"strings"
 	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/microsoft/go-mssqldb/msdsn"
 )
 
 const (
@@ -78,6 +85,14 @@ type Row interface {
 	Scan(dest ...any) error
 }
 
+// Rows is the result rows of a query.
+type Rows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+	Close() error
+}
+
 // Result is the result of a query.
 type Result interface {
 	RowsAffected() (int64, error)
@@ -85,15 +100,18 @@ type Result interface {
 
 // Client is the interface for the database client.
 type Client interface {
+	Query(ctx context.Context, query string, args ...any) (Rows, error)
 	QueryRow(ctx context.Context, query string, args ...any) Row
 	Exec(ctx context.Context, query string, args ...any) (Result, error)
 	Transaction(ctx context.Context) (Tx, error)
 	Driver() Driver
+	Ping(ctx context.Context) error
 	Close() error
 }
 
 // Tx is the interface for the database transaction.
 type Tx interface {
+	Query(ctx context.Context, query string, args ...any) (Rows, error)
 	QueryRow(ctx context.Context, query string, args ...any) Row
 	Exec(ctx context.Context, query string, args ...any) (Result, error)
 	Commit() error
@@ -118,6 +136,7 @@ type ClientOptions struct {
 	MaxOpenConnections    int
 	MaxIdleConnections    int
 	MaxConnectionLifetime time.Duration
+	TLSConfig             *tls.Config
 	Postgres              PostgresOptions
 	MSSQL                 MSSQLOptions
 	SQLite                SQLiteOptions
@@ -153,7 +172,7 @@ func NewClient(options ...ClientOption) (*client, error) {
 
 	dsn := buildDSN(driver, &opts)
 
-	db, err := sql.Open(string(driver), dsn)
+	db, err := openDB(driver, dsn, opts.TLSConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -178,6 +197,11 @@ func NewClient(options ...ClientOption) (*client, error) {
 	return &client{db: db, driver: driver}, nil
 }
 
+// Query executes a query that returns rows.
+func (c client) Query(ctx context.Context, query string, args ...any) (Rows, error) {
+	return c.db.QueryContext(ctx, query, args...)
+}
+
 // QueryRow executes a query that is expected to return at most one row.
 func (c client) QueryRow(ctx context.Context, query string, args ...any) Row {
 	return c.db.QueryRowContext(ctx, query, args...)
@@ -193,6 +217,11 @@ func (c client) Driver() Driver {
 	return c.driver
 }
 
+// Ping checks the connection to the database.
+func (c client) Ping(ctx context.Context) error {
+	return c.db.PingContext(ctx)
+}
+
 // Close the database and release any open resources.
 func (c client) Close() error {
 	err := c.db.Close()
@@ -207,6 +236,11 @@ type tx struct {
 	*sql.Tx
 }
 
+// Query executes a query that returns rows.
+func (t tx) Query(ctx context.Context, query string, args ...any) (Rows, error) {
+	return t.Tx.QueryContext(ctx, query, args...)
+}
+
 // QueryRow executes a query that is expected to return at most one row.
 func (t tx) QueryRow(ctx context.Context, query string, args ...any) Row {
 	return t.Tx.QueryRowContext(ctx, query, args...)
@@ -310,6 +344,55 @@ func buildDSN(driver Driver, options *ClientOptions) string {
 	return u.String()
 }
 
+// openDB opens the database. If a TLS configuration is provided it replaces
+// the TLS configuration of the driver (PostgreSQL and MSSQL) as long as the
+// data source name does not disable encryption.
+func openDB(driver Driver, dsn string, tlsConfig *tls.Config) (*sql.DB, error) {
+	if tlsConfig == nil {
+		return sql.Open(string(driver), dsn)
+	}
+
+	switch driver {
+	case DriverPostgres:
+		cfg, err := pgx.ParseConfig(dsn)
+		if err != nil {
+			return nil, err
+		}
+		cfg.TLSConfig = withTLSConfig(cfg.TLSConfig, tlsConfig)
+		for _, fallback := range cfg.Fallbacks {
+			fallback.TLSConfig = withTLSConfig(fallback.TLSConfig, tlsConfig)
+		}
+		return stdlib.OpenDB(*cfg), nil
+	case DriverMSSQL:
+		cfg, err := msdsn.Parse(dsn)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.TLSConfig != nil {
+			cfg.TLSConfig = withTLSConfig(cfg.TLSConfig, tlsConfig)
+			if len(tlsConfig.ServerName) > 0 {
+				cfg.HostInCertificateProvided = true
+			}
+		}
+		return sql.OpenDB(mssql.NewConnectorConfig(cfg)), nil
+	}
+	return sql.Open(string(driver), dsn)
+}
+
+// withTLSConfig returns a copy of tlsConfig to replace the current TLS configuration
+// of the driver. The server name of the current configuration is kept if tlsConfig
+// has none. Returns nil if the driver has TLS disabled.
+func withTLSConfig(current, tlsConfig *tls.Config) *tls.Config {
+	if current == nil {
+		return nil
+	}
+	cfg := tlsConfig.Clone()
+	if len(cfg.ServerName) == 0 {
+		cfg.ServerName = current.ServerName
+	}
+	return cfg
+}
+
 // databaseFileDSN returns the database file for SQLite.
 func databaseFileDSN(file string, inMemory bool) string {
 	if inMemory {
@@ -321,6 +404,28 @@ func databaseFileDSN(file string, inMemory bool) string {
 	return "file:" + defaultDatabaseFile
 }
 
+// identifierRegexp matches valid table and schema names.
+var identifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}$`)
+
+// qualifiedTable validates the schema and table names and returns
+// the (schema qualified) table name for the driver.
+func qualifiedTable(driver Driver, schema, table string) (string, error) {
+	if !identifierRegexp.MatchString(table) {
+		return "", fmt.Errorf("%w: table %q", ErrInvalidIdentifier, table)
+	}
+	if len(schema) > 0 && !identifierRegexp.MatchString(schema) {
+		return "", fmt.Errorf("%w: schema %q", ErrInvalidIdentifier, schema)
+	}
+
+	if driver == DriverMSSQL {
+		table = firstToUpper(table)
+	}
+	if len(schema) > 0 {
+		return schema + "." + table, nil
+	}
+	return table, nil
+}
+
 // firstToUpper returns the string with the first letter in uppercase.
 func firstToUpper(s string) string {
 	return strings.ToUpper(s[:1]) + s[1:]
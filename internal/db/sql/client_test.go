@@ -1,9 +1,11 @@
 package sql
 
 import (
+	"crypto/tls"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 func TestBuildDSN(t *testing.T) {
@@ -160,3 +162,148 @@ func TestBuildDSN(t *testing.T) {
 		})
 	}
 }
+
+func TestQualifiedTable(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			driver Driver
+			schema string
+			table  string
+		}
+		want    string
+		wantErr error
+	}{
+		{
+			name: "postgres",
+			input: struct {
+				driver Driver
+				schema string
+				table  string
+			}{
+				driver: DriverPostgres,
+				table:  "secrets",
+			},
+			want: "secrets",
+		},
+		{
+			name: "postgres - with schema",
+			input: struct {
+				driver Driver
+				schema string
+				table  string
+			}{
+				driver: DriverPostgres,
+				schema: "staging",
+				table:  "secrets",
+			},
+			want: "staging.secrets",
+		},
+		{
+			name: "mssql - with schema",
+			input: struct {
+				driver Driver
+				schema string
+				table  string
+			}{
+				driver: DriverMSSQL,
+				schema: "staging",
+				table:  "secrets",
+			},
+			want: "staging.Secrets",
+		},
+		{
+			name: "invalid table",
+			input: struct {
+				driver Driver
+				schema string
+				table  string
+			}{
+				driver: DriverPostgres,
+				table:  "secrets; DROP TABLE secrets",
+			},
+			wantErr: ErrInvalidIdentifier,
+		},
+		{
+			name: "invalid schema",
+			input: struct {
+				driver Driver
+				schema string
+				table  string
+			}{
+				driver: DriverPostgres,
+				schema: "staging.prod",
+				table:  "secrets",
+			},
+			wantErr: ErrInvalidIdentifier,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := qualifiedTable(test.input.driver, test.input.schema, test.input.table)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("qualifiedTable() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("qualifiedTable() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestWithTLSConfig(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			current   *tls.Config
+			tlsConfig *tls.Config
+		}
+		want *tls.Config
+	}{
+		{
+			name: "driver TLS disabled",
+			input: struct {
+				current   *tls.Config
+				tlsConfig *tls.Config
+			}{
+				tlsConfig: &tls.Config{ServerName: "db.internal"},
+			},
+			want: nil,
+		},
+		{
+			name: "keep server name from driver",
+			input: struct {
+				current   *tls.Config
+				tlsConfig *tls.Config
+			}{
+				current:   &tls.Config{ServerName: "localhost", InsecureSkipVerify: true},
+				tlsConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+			},
+			want: &tls.Config{ServerName: "localhost", MinVersion: tls.VersionTLS12},
+		},
+		{
+			name: "override server name",
+			input: struct {
+				current   *tls.Config
+				tlsConfig *tls.Config
+			}{
+				current:   &tls.Config{ServerName: "localhost"},
+				tlsConfig: &tls.Config{ServerName: "db.internal"},
+			},
+			want: &tls.Config{ServerName: "db.internal"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := withTLSConfig(test.input.current, test.input.tlsConfig)
+
+			if diff := cmp.Diff(test.want, got, cmpopts.IgnoreUnexported(tls.Config{})); diff != "" {
+				t.Errorf("withTLSConfig() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
@@ -5,4 +5,6 @@ import "errors"
 var (
 	// ErrDriverNotSupported is returned when the driver is not supported.
 	ErrDriverNotSupported = errors.New("driver not supported")
+	// ErrInvalidIdentifier is returned when a table or schema name is invalid.
+	ErrInvalidIdentifier = errors.New("invalid identifier")
 )
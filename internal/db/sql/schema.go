@@ -0,0 +1,83 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// column is a column that is added to tables created by earlier
+// versions of a store.
+type column struct {
+	// name is the name of the column.
+	name string
+	// definition is the type and constraints of the column.
+	definition string
+}
+
+// addColumnsIfNotExist adds the columns that do not exist to the table.
+// Added columns must be nullable or have a default value, since the
+// table may already contain rows.
+func addColumnsIfNotExist(ctx context.Context, client Client, driver Driver, table string, columns ...column) error {
+	for _, col := range columns {
+		var query string
+		switch driver {
+		case DriverPostgres:
+			query = fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", table, col.name, col.definition)
+		case DriverMSSQL:
+			query = fmt.Sprintf("IF COL_LENGTH(N'%s', N'%s') IS NULL ALTER TABLE %s ADD %s %s", table, col.name, table, col.name, col.definition)
+		case DriverSQLite:
+			// SQLite does not support IF NOT EXISTS when adding columns.
+			exists, err := sqliteColumnExists(ctx, client, table, col.name)
+			if err != nil {
+				return err
+			}
+			if exists {
+				continue
+			}
+			query = fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, col.name, col.definition)
+		default:
+			return fmt.Errorf("%w: %s", ErrDriverNotSupported, driver)
+		}
+
+		if _, err := client.Exec(ctx, query); err != nil {
+			return fmt.Errorf("add column %s to %s: %w", col.name, table, err)
+		}
+	}
+	return nil
+}
+
+// sqliteColumnExists returns true if the column exists in the table.
+// The table may be qualified with a schema.
+func sqliteColumnExists(ctx context.Context, client Client, table, name string) (bool, error) {
+	schema := "main"
+	if s, t, ok := strings.Cut(table, "."); ok {
+		schema, table = s, t
+	}
+
+	var count int
+	if err := client.QueryRow(ctx, "SELECT COUNT(*) FROM pragma_table_info(?1, ?2) WHERE name = ?3", table, schema, name).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// createPlaceholders returns n query placeholders for the driver.
+func createPlaceholders(driver Driver, n int) []string {
+	var prefix string
+	switch driver {
+	case DriverPostgres:
+		prefix = "$"
+	case DriverMSSQL:
+		prefix = "@p"
+	case DriverSQLite:
+		prefix = "?"
+	}
+
+	placeholders := make([]string, n)
+	for i := range placeholders {
+		placeholders[i] = prefix + strconv.Itoa(i+1)
+	}
+	return placeholders
+}
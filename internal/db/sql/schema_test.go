@@ -0,0 +1,66 @@
+package sql
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/db"
+	"github.com/google/go-cmp/cmp"
+	_ "modernc.org/sqlite"
+)
+
+func TestAddColumnsIfNotExist(t *testing.T) {
+	client, err := NewClient(func(o *ClientOptions) {
+		o.Driver = DriverSQLite
+		o.SQLite.File = filepath.Join(t.TempDir(), "burnit.db")
+	})
+	if err != nil {
+		t.Fatalf("NewClient() = unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	// Create the table as created by an earlier version of the store.
+	if _, err := client.Exec(ctx, "CREATE TABLE secrets (id TEXT NOT NULL PRIMARY KEY, value TEXT NOT NULL, expires_at DATETIME NOT NULL)"); err != nil {
+		t.Fatalf("Exec() = unexpected error: %v", err)
+	}
+	if _, err := client.Exec(ctx, "INSERT INTO secrets (id, value, expires_at) VALUES (?1, ?2, ?3)", "1", "secret", expiresAt); err != nil {
+		t.Fatalf("Exec() = unexpected error: %v", err)
+	}
+
+	// Creating the store twice verifies that existing columns are skipped.
+	for range 2 {
+		if _, err := NewSecretStore(client); err != nil {
+			t.Fatalf("NewSecretStore() = unexpected error: %v", err)
+		}
+	}
+
+	store, err := NewSecretStore(client)
+	if err != nil {
+		t.Fatalf("NewSecretStore() = unexpected error: %v", err)
+	}
+
+	got, err := store.Get(ctx, "1")
+	if err != nil {
+		t.Fatalf("Get() = unexpected error: %v", err)
+	}
+
+	want := db.Secret{ID: "1", Value: "secret", ExpiresAt: expiresAt}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Get() = unexpected result (-want +got)\n%s\n", diff)
+	}
+
+	want = db.Secret{ID: "2", Value: "secret", ExpiresAt: expiresAt, CreatedBy: "user@example.com", AllowedNetworks: []string{"10.8.0.0/16", "fd00::/8"}, NotBefore: expiresAt.Add(-30 * time.Minute), SecondFactor: "totp", SecondFactorData: "data", Label: "Staging DB", Note: "note"}
+	created, err := store.Create(ctx, want)
+	if err != nil {
+		t.Fatalf("Create() = unexpected error: %v", err)
+	}
+
+	if diff := cmp.Diff(want, created); diff != "" {
+		t.Errorf("Create() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/RedeployAB/burnit/internal/db"
@@ -29,6 +30,7 @@ type secretStore struct {
 
 // SecretStoreOptions is the options for the SecretStore.
 type SecretStoreOptions struct {
+	Schema  string
 	Table   string
 	Timeout time.Duration
 }
@@ -51,7 +53,12 @@ func NewSecretStore(client Client, options ...SecretStoreOption) (*secretStore,
 	}
 
 	driver := client.Driver()
-	queries, err := createSecretQueries(driver, opts.Table)
+	table, err := qualifiedTable(driver, opts.Schema, opts.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	queries, err := createSecretQueries(driver, table)
 	if err != nil {
 		return nil, err
 	}
@@ -59,7 +66,7 @@ func NewSecretStore(client Client, options ...SecretStoreOption) (*secretStore,
 	s := &secretStore{
 		client:  client,
 		driver:  driver,
-		table:   opts.Table,
+		table:   table,
 		queries: queries,
 		timeout: opts.Timeout,
 	}
@@ -85,25 +92,54 @@ func (s secretStore) createTableIfNotExists(ctx context.Context) error {
 		CREATE TABLE IF NOT EXISTS %s (
 			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
 			value TEXT NOT NULL,
-			expires_at TIMESTAMPTZ NOT NULL
+			expires_at TIMESTAMPTZ NOT NULL,
+			created_by TEXT NOT NULL DEFAULT '',
+			allowed_networks TEXT NOT NULL DEFAULT '',
+			not_before TIMESTAMPTZ NULL,
+			second_factor TEXT NOT NULL DEFAULT '',
+			second_factor_data TEXT NOT NULL DEFAULT '',
+			label TEXT NOT NULL DEFAULT '',
+			note TEXT NOT NULL DEFAULT '',
+			failed_attempts INTEGER NOT NULL DEFAULT 0,
+			code_hash TEXT NOT NULL DEFAULT '',
+			code_expires_at TIMESTAMPTZ NULL
 		)`
 		args = append(args, s.table)
 	case DriverMSSQL:
-		table := firstToUpper(s.table)
 		query = `
-		IF NOT EXISTS (SELECT * FROM sysobjects WHERE name='%s' and xtype='U')
+		IF OBJECT_ID(N'%s', N'U') IS NULL
 		CREATE TABLE %s (
 			ID VARCHAR(36) NOT NULL PRIMARY KEY,
 			Value NVARCHAR(MAX) NOT NULL,
-			ExpiresAt DATETIMEOFFSET NOT NULL
+			ExpiresAt DATETIMEOFFSET NOT NULL,
+			CreatedBy NVARCHAR(255) NOT NULL DEFAULT '',
+			AllowedNetworks NVARCHAR(MAX) NOT NULL DEFAULT '',
+			NotBefore DATETIMEOFFSET NULL,
+			SecondFactor NVARCHAR(16) NOT NULL DEFAULT '',
+			SecondFactorData NVARCHAR(MAX) NOT NULL DEFAULT '',
+			Label NVARCHAR(255) NOT NULL DEFAULT '',
+			Note NVARCHAR(MAX) NOT NULL DEFAULT '',
+			FailedAttempts INT NOT NULL DEFAULT 0,
+			CodeHash NVARCHAR(64) NOT NULL DEFAULT '',
+			CodeExpiresAt DATETIMEOFFSET NULL
 		)`
-		args = append(args, table, table)
+		args = append(args, s.table, s.table)
 	case DriverSQLite:
 		query = `
 		CREATE TABLE IF NOT EXISTS %s (
 			id TEXT NOT NULL PRIMARY KEY,
 			value TEXT NOT NULL,
-			expires_at DATETIME NOT NULL
+			expires_at DATETIME NOT NULL,
+			created_by TEXT NOT NULL DEFAULT '',
+			allowed_networks TEXT NOT NULL DEFAULT '',
+			not_before DATETIME NULL,
+			second_factor TEXT NOT NULL DEFAULT '',
+			second_factor_data TEXT NOT NULL DEFAULT '',
+			label TEXT NOT NULL DEFAULT '',
+			note TEXT NOT NULL DEFAULT '',
+			failed_attempts INTEGER NOT NULL DEFAULT 0,
+			code_hash TEXT NOT NULL DEFAULT '',
+			code_expires_at DATETIME NULL
 		)`
 		args = append(args, s.table)
 	default:
@@ -113,13 +149,13 @@ func (s secretStore) createTableIfNotExists(ctx context.Context) error {
 	if _, err := s.client.Exec(ctx, fmt.Sprintf(query, args...)); err != nil {
 		return err
 	}
-	return nil
+	return addColumnsIfNotExist(ctx, s.client, s.driver, s.table, s.queries.added...)
 }
 
 // Get a secret by its ID.
 func (s secretStore) Get(ctx context.Context, id string) (db.Secret, error) {
-	var secret db.Secret
-	if err := s.client.QueryRow(ctx, s.queries.selectByID, id).Scan(&secret.ID, &secret.Value, &secret.ExpiresAt); err != nil {
+	secret, err := scanSecret(s.client.QueryRow(ctx, s.queries.selectByID, id))
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return db.Secret{}, dberrors.ErrSecretNotFound
 		}
@@ -135,14 +171,15 @@ func (s secretStore) Create(ctx context.Context, secret db.Secret) (db.Secret, e
 		return db.Secret{}, err
 	}
 
-	if _, err := tx.Exec(ctx, s.queries.insert, secret.ID, secret.Value, secret.ExpiresAt); err != nil {
+	if _, err := tx.Exec(ctx, s.queries.insert, secret.ID, secret.Value, secret.ExpiresAt, secret.CreatedBy, strings.Join(secret.AllowedNetworks, ","), nullTime(secret.NotBefore), secret.SecondFactor, secret.SecondFactorData, secret.Label, secret.Note, secret.FailedAttempts, secret.CodeHash, nullTime(secret.CodeExpiresAt)); err != nil {
 		if err := tx.Rollback(); err != nil {
 			return db.Secret{}, err
 		}
 		return db.Secret{}, err
 	}
 
-	if err := tx.QueryRow(ctx, s.queries.selectByID, secret.ID).Scan(&secret.ID, &secret.Value, &secret.ExpiresAt); err != nil {
+	secret, err = scanSecret(tx.QueryRow(ctx, s.queries.selectByID, secret.ID))
+	if err != nil {
 		if err := tx.Rollback(); err != nil {
 			return db.Secret{}, err
 		}
@@ -156,6 +193,39 @@ func (s secretStore) Create(ctx context.Context, secret db.Secret) (db.Secret, e
 	return secret, nil
 }
 
+// CreateMany creates secrets in a transaction.
+func (s secretStore) CreateMany(ctx context.Context, secrets []db.Secret) ([]db.Secret, error) {
+	tx, err := s.client.Transaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	created := make([]db.Secret, 0, len(secrets))
+	for _, secret := range secrets {
+		if _, err := tx.Exec(ctx, s.queries.insert, secret.ID, secret.Value, secret.ExpiresAt, secret.CreatedBy, strings.Join(secret.AllowedNetworks, ","), nullTime(secret.NotBefore), secret.SecondFactor, secret.SecondFactorData, secret.Label, secret.Note, secret.FailedAttempts, secret.CodeHash, nullTime(secret.CodeExpiresAt)); err != nil {
+			if err := tx.Rollback(); err != nil {
+				return nil, err
+			}
+			return nil, err
+		}
+
+		secret, err = scanSecret(tx.QueryRow(ctx, s.queries.selectByID, secret.ID))
+		if err != nil {
+			if err := tx.Rollback(); err != nil {
+				return nil, err
+			}
+			return nil, err
+		}
+		created = append(created, secret)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
 // Delete a secret by its ID.
 func (s secretStore) Delete(ctx context.Context, id string) error {
 	result, err := s.client.Exec(ctx, s.queries.delete, id)
@@ -175,6 +245,71 @@ func (s secretStore) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// IncrementFailedAttempts increments the number of failed attempts
+// to verify the second factor of a secret and returns the new number.
+func (s secretStore) IncrementFailedAttempts(ctx context.Context, id string) (int, error) {
+	tx, err := s.client.Transaction(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(ctx, s.queries.incrementFailedAttempts, id)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, err
+		}
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, err
+		}
+		return 0, err
+	}
+	if rows == 0 {
+		if err := tx.Rollback(); err != nil {
+			return 0, err
+		}
+		return 0, dberrors.ErrSecretNotFound
+	}
+
+	var attempts int
+	if err := tx.QueryRow(ctx, s.queries.selectFailedAttempts, id).Scan(&attempts); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, err
+		}
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return attempts, nil
+}
+
+// SetCode sets the hash and expiration time of the code sent for the
+// second factor of a secret. An empty hash removes the code.
+func (s secretStore) SetCode(ctx context.Context, id, hash string, expiresAt time.Time) error {
+	result, err := s.client.Exec(ctx, s.queries.setCode, hash, nullTime(expiresAt), id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return dberrors.ErrSecretNotFound
+	}
+
+	return nil
+}
+
 // DeleteExpired deletes all expired secrets.
 func (s secretStore) DeleteExpired(ctx context.Context) error {
 	result, err := s.client.Exec(ctx, s.queries.deleteExpired)
@@ -194,45 +329,148 @@ func (s secretStore) DeleteExpired(ctx context.Context) error {
 	return nil
 }
 
+// Iterate calls fn for every unexpired secret.
+func (s secretStore) Iterate(ctx context.Context, fn func(secret db.Secret) error) error {
+	rows, err := s.client.Query(ctx, s.queries.selectUnexpired)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		secret, err := scanSecret(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(secret); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Ping checks the connection to the store.
+func (s secretStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx)
+}
+
 // Close the store and its underlying connections.
 func (s secretStore) Close() error {
 	return s.client.Close()
 }
 
+// scanner is a row that can be scanned.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanSecret scans a row with the columns of a secret.
+func scanSecret(row scanner) (db.Secret, error) {
+	var secret db.Secret
+	var allowedNetworks string
+	var notBefore, codeExpiresAt sql.NullTime
+	if err := row.Scan(&secret.ID, &secret.Value, &secret.ExpiresAt, &secret.CreatedBy, &allowedNetworks, &notBefore, &secret.SecondFactor, &secret.SecondFactorData, &secret.Label, &secret.Note, &secret.FailedAttempts, &secret.CodeHash, &codeExpiresAt); err != nil {
+		return db.Secret{}, err
+	}
+	if len(allowedNetworks) > 0 {
+		secret.AllowedNetworks = strings.Split(allowedNetworks, ",")
+	}
+	if notBefore.Valid {
+		secret.NotBefore = notBefore.Time
+	}
+	if codeExpiresAt.Valid {
+		secret.CodeExpiresAt = codeExpiresAt.Time
+	}
+	return secret, nil
+}
+
+// nullTime returns a sql.NullTime that is NULL for the zero time.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
 // secretQueries contains queries used by the store.
 type secretQueries struct {
-	selectByID    string
-	insert        string
-	delete        string
-	deleteExpired string
+	selectByID              string
+	selectUnexpired         string
+	selectFailedAttempts    string
+	insert                  string
+	incrementFailedAttempts string
+	setCode                 string
+	delete                  string
+	deleteExpired           string
+	// added contains the columns added after the first version
+	// of the table.
+	added []column
 }
 
 // createSecretQueries creates the queries used by the store.
 func createSecretQueries(driver Driver, table string) (secretQueries, error) {
-	var columns, placeholders []string
+	var columns []string
+	var added []column
 	var now string
 	switch driver {
 	case DriverPostgres:
-		columns = []string{"id", "value", "expires_at"}
-		placeholders = []string{"$1", "$2", "$3"}
+		columns = []string{"id", "value", "expires_at", "created_by", "allowed_networks", "not_before", "second_factor", "second_factor_data", "label", "note", "failed_attempts", "code_hash", "code_expires_at"}
+		added = []column{
+			{name: "created_by", definition: "TEXT NOT NULL DEFAULT ''"},
+			{name: "allowed_networks", definition: "TEXT NOT NULL DEFAULT ''"},
+			{name: "not_before", definition: "TIMESTAMPTZ NULL"},
+			{name: "second_factor", definition: "TEXT NOT NULL DEFAULT ''"},
+			{name: "second_factor_data", definition: "TEXT NOT NULL DEFAULT ''"},
+			{name: "label", definition: "TEXT NOT NULL DEFAULT ''"},
+			{name: "note", definition: "TEXT NOT NULL DEFAULT ''"},
+			{name: "failed_attempts", definition: "INTEGER NOT NULL DEFAULT 0"},
+			{name: "code_hash", definition: "TEXT NOT NULL DEFAULT ''"},
+			{name: "code_expires_at", definition: "TIMESTAMPTZ NULL"},
+		}
 		now = "NOW() AT TIME ZONE 'UTC'"
 	case DriverMSSQL:
-		table = firstToUpper(table)
-		columns = []string{"ID", "Value", "ExpiresAt"}
-		placeholders = []string{"@p1", "@p2", "@p3"}
+		columns = []string{"ID", "Value", "ExpiresAt", "CreatedBy", "AllowedNetworks", "NotBefore", "SecondFactor", "SecondFactorData", "Label", "Note", "FailedAttempts", "CodeHash", "CodeExpiresAt"}
+		added = []column{
+			{name: "CreatedBy", definition: "NVARCHAR(255) NOT NULL DEFAULT ''"},
+			{name: "AllowedNetworks", definition: "NVARCHAR(MAX) NOT NULL DEFAULT ''"},
+			{name: "NotBefore", definition: "DATETIMEOFFSET NULL"},
+			{name: "SecondFactor", definition: "NVARCHAR(16) NOT NULL DEFAULT ''"},
+			{name: "SecondFactorData", definition: "NVARCHAR(MAX) NOT NULL DEFAULT ''"},
+			{name: "Label", definition: "NVARCHAR(255) NOT NULL DEFAULT ''"},
+			{name: "Note", definition: "NVARCHAR(MAX) NOT NULL DEFAULT ''"},
+			{name: "FailedAttempts", definition: "INT NOT NULL DEFAULT 0"},
+			{name: "CodeHash", definition: "NVARCHAR(64) NOT NULL DEFAULT ''"},
+			{name: "CodeExpiresAt", definition: "DATETIMEOFFSET NULL"},
+		}
 		now = "GETUTCDATE()"
 	case DriverSQLite:
-		columns = []string{"id", "value", "expires_at"}
-		placeholders = []string{"?1", "?2", "?3"}
+		columns = []string{"id", "value", "expires_at", "created_by", "allowed_networks", "not_before", "second_factor", "second_factor_data", "label", "note", "failed_attempts", "code_hash", "code_expires_at"}
+		added = []column{
+			{name: "created_by", definition: "TEXT NOT NULL DEFAULT ''"},
+			{name: "allowed_networks", definition: "TEXT NOT NULL DEFAULT ''"},
+			{name: "not_before", definition: "DATETIME NULL"},
+			{name: "second_factor", definition: "TEXT NOT NULL DEFAULT ''"},
+			{name: "second_factor_data", definition: "TEXT NOT NULL DEFAULT ''"},
+			{name: "label", definition: "TEXT NOT NULL DEFAULT ''"},
+			{name: "note", definition: "TEXT NOT NULL DEFAULT ''"},
+			{name: "failed_attempts", definition: "INTEGER NOT NULL DEFAULT 0"},
+			{name: "code_hash", definition: "TEXT NOT NULL DEFAULT ''"},
+			{name: "code_expires_at", definition: "DATETIME NULL"},
+		}
 		now = "DATETIME('now')"
 	default:
 		return secretQueries{}, fmt.Errorf("%w: %s", ErrDriverNotSupported, driver)
 	}
+	placeholders := createPlaceholders(driver, len(columns))
+	selectColumns := strings.Join(columns, ", ")
 
 	return secretQueries{
-		selectByID:    fmt.Sprintf("SELECT %s, %s, %s FROM %s WHERE %s = %s", columns[0], columns[1], columns[2], table, columns[0], placeholders[0]),
-		insert:        fmt.Sprintf("INSERT INTO %s (%s, %s, %s) VALUES (%s, %s, %s)", table, columns[0], columns[1], columns[2], placeholders[0], placeholders[1], placeholders[2]),
-		delete:        fmt.Sprintf("DELETE FROM %s WHERE %s = %s", table, columns[0], placeholders[0]),
-		deleteExpired: fmt.Sprintf("DELETE FROM %s WHERE %s < %s", table, columns[2], now),
+		selectByID:              fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s", selectColumns, table, columns[0], placeholders[0]),
+		selectUnexpired:         fmt.Sprintf("SELECT %s FROM %s WHERE %s >= %s", selectColumns, table, columns[2], now),
+		selectFailedAttempts:    fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s", columns[10], table, columns[0], placeholders[0]),
+		insert:                  fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, selectColumns, strings.Join(placeholders, ", ")),
+		incrementFailedAttempts: fmt.Sprintf("UPDATE %s SET %s = %s + 1 WHERE %s = %s", table, columns[10], columns[10], columns[0], placeholders[0]),
+		setCode:                 fmt.Sprintf("UPDATE %s SET %s = %s, %s = %s WHERE %s = %s", table, columns[11], placeholders[0], columns[12], placeholders[1], columns[0], placeholders[2]),
+		delete:                  fmt.Sprintf("DELETE FROM %s WHERE %s = %s", table, columns[0], placeholders[0]),
+		deleteExpired:           fmt.Sprintf("DELETE FROM %s WHERE %s < %s", table, columns[2], now),
+		added:                   added,
 	}, nil
 }
@@ -1,8 +1,14 @@
 package sql
 
 import (
+	"context"
+	"errors"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/RedeployAB/burnit/internal/db"
+	dberrors "github.com/RedeployAB/burnit/internal/db/errors"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 )
@@ -27,10 +33,26 @@ func TestCreateSecretQueries(t *testing.T) {
 				table:  "secrets",
 			},
 			want: secretQueries{
-				selectByID:    "SELECT id, value, expires_at FROM secrets WHERE id = $1",
-				insert:        "INSERT INTO secrets (id, value, expires_at) VALUES ($1, $2, $3)",
-				delete:        "DELETE FROM secrets WHERE id = $1",
-				deleteExpired: "DELETE FROM secrets WHERE expires_at < NOW() AT TIME ZONE 'UTC'",
+				selectByID:              "SELECT id, value, expires_at, created_by, allowed_networks, not_before, second_factor, second_factor_data, label, note, failed_attempts, code_hash, code_expires_at FROM secrets WHERE id = $1",
+				selectUnexpired:         "SELECT id, value, expires_at, created_by, allowed_networks, not_before, second_factor, second_factor_data, label, note, failed_attempts, code_hash, code_expires_at FROM secrets WHERE expires_at >= NOW() AT TIME ZONE 'UTC'",
+				selectFailedAttempts:    "SELECT failed_attempts FROM secrets WHERE id = $1",
+				insert:                  "INSERT INTO secrets (id, value, expires_at, created_by, allowed_networks, not_before, second_factor, second_factor_data, label, note, failed_attempts, code_hash, code_expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)",
+				incrementFailedAttempts: "UPDATE secrets SET failed_attempts = failed_attempts + 1 WHERE id = $1",
+				setCode:                 "UPDATE secrets SET code_hash = $1, code_expires_at = $2 WHERE id = $3",
+				delete:                  "DELETE FROM secrets WHERE id = $1",
+				deleteExpired:           "DELETE FROM secrets WHERE expires_at < NOW() AT TIME ZONE 'UTC'",
+				added: []column{
+					{name: "created_by", definition: "TEXT NOT NULL DEFAULT ''"},
+					{name: "allowed_networks", definition: "TEXT NOT NULL DEFAULT ''"},
+					{name: "not_before", definition: "TIMESTAMPTZ NULL"},
+					{name: "second_factor", definition: "TEXT NOT NULL DEFAULT ''"},
+					{name: "second_factor_data", definition: "TEXT NOT NULL DEFAULT ''"},
+					{name: "label", definition: "TEXT NOT NULL DEFAULT ''"},
+					{name: "note", definition: "TEXT NOT NULL DEFAULT ''"},
+					{name: "failed_attempts", definition: "INTEGER NOT NULL DEFAULT 0"},
+					{name: "code_hash", definition: "TEXT NOT NULL DEFAULT ''"},
+					{name: "code_expires_at", definition: "TIMESTAMPTZ NULL"},
+				},
 			},
 		},
 		{
@@ -40,13 +62,29 @@ func TestCreateSecretQueries(t *testing.T) {
 				table  string
 			}{
 				driver: DriverMSSQL,
-				table:  "secrets",
+				table:  "Secrets",
 			},
 			want: secretQueries{
-				selectByID:    "SELECT ID, Value, ExpiresAt FROM Secrets WHERE ID = @p1",
-				insert:        "INSERT INTO Secrets (ID, Value, ExpiresAt) VALUES (@p1, @p2, @p3)",
-				delete:        "DELETE FROM Secrets WHERE ID = @p1",
-				deleteExpired: "DELETE FROM Secrets WHERE ExpiresAt < GETUTCDATE()",
+				selectByID:              "SELECT ID, Value, ExpiresAt, CreatedBy, AllowedNetworks, NotBefore, SecondFactor, SecondFactorData, Label, Note, FailedAttempts, CodeHash, CodeExpiresAt FROM Secrets WHERE ID = @p1",
+				selectUnexpired:         "SELECT ID, Value, ExpiresAt, CreatedBy, AllowedNetworks, NotBefore, SecondFactor, SecondFactorData, Label, Note, FailedAttempts, CodeHash, CodeExpiresAt FROM Secrets WHERE ExpiresAt >= GETUTCDATE()",
+				selectFailedAttempts:    "SELECT FailedAttempts FROM Secrets WHERE ID = @p1",
+				insert:                  "INSERT INTO Secrets (ID, Value, ExpiresAt, CreatedBy, AllowedNetworks, NotBefore, SecondFactor, SecondFactorData, Label, Note, FailedAttempts, CodeHash, CodeExpiresAt) VALUES (@p1, @p2, @p3, @p4, @p5, @p6, @p7, @p8, @p9, @p10, @p11, @p12, @p13)",
+				incrementFailedAttempts: "UPDATE Secrets SET FailedAttempts = FailedAttempts + 1 WHERE ID = @p1",
+				setCode:                 "UPDATE Secrets SET CodeHash = @p1, CodeExpiresAt = @p2 WHERE ID = @p3",
+				delete:                  "DELETE FROM Secrets WHERE ID = @p1",
+				deleteExpired:           "DELETE FROM Secrets WHERE ExpiresAt < GETUTCDATE()",
+				added: []column{
+					{name: "CreatedBy", definition: "NVARCHAR(255) NOT NULL DEFAULT ''"},
+					{name: "AllowedNetworks", definition: "NVARCHAR(MAX) NOT NULL DEFAULT ''"},
+					{name: "NotBefore", definition: "DATETIMEOFFSET NULL"},
+					{name: "SecondFactor", definition: "NVARCHAR(16) NOT NULL DEFAULT ''"},
+					{name: "SecondFactorData", definition: "NVARCHAR(MAX) NOT NULL DEFAULT ''"},
+					{name: "Label", definition: "NVARCHAR(255) NOT NULL DEFAULT ''"},
+					{name: "Note", definition: "NVARCHAR(MAX) NOT NULL DEFAULT ''"},
+					{name: "FailedAttempts", definition: "INT NOT NULL DEFAULT 0"},
+					{name: "CodeHash", definition: "NVARCHAR(64) NOT NULL DEFAULT ''"},
+					{name: "CodeExpiresAt", definition: "DATETIMEOFFSET NULL"},
+				},
 			},
 		},
 		{
@@ -59,10 +97,26 @@ func TestCreateSecretQueries(t *testing.T) {
 				table:  "secrets",
 			},
 			want: secretQueries{
-				selectByID:    "SELECT id, value, expires_at FROM secrets WHERE id = ?1",
-				insert:        "INSERT INTO secrets (id, value, expires_at) VALUES (?1, ?2, ?3)",
-				delete:        "DELETE FROM secrets WHERE id = ?1",
-				deleteExpired: "DELETE FROM secrets WHERE expires_at < DATETIME('now')",
+				selectByID:              "SELECT id, value, expires_at, created_by, allowed_networks, not_before, second_factor, second_factor_data, label, note, failed_attempts, code_hash, code_expires_at FROM secrets WHERE id = ?1",
+				selectUnexpired:         "SELECT id, value, expires_at, created_by, allowed_networks, not_before, second_factor, second_factor_data, label, note, failed_attempts, code_hash, code_expires_at FROM secrets WHERE expires_at >= DATETIME('now')",
+				selectFailedAttempts:    "SELECT failed_attempts FROM secrets WHERE id = ?1",
+				insert:                  "INSERT INTO secrets (id, value, expires_at, created_by, allowed_networks, not_before, second_factor, second_factor_data, label, note, failed_attempts, code_hash, code_expires_at) VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13)",
+				incrementFailedAttempts: "UPDATE secrets SET failed_attempts = failed_attempts + 1 WHERE id = ?1",
+				setCode:                 "UPDATE secrets SET code_hash = ?1, code_expires_at = ?2 WHERE id = ?3",
+				delete:                  "DELETE FROM secrets WHERE id = ?1",
+				deleteExpired:           "DELETE FROM secrets WHERE expires_at < DATETIME('now')",
+				added: []column{
+					{name: "created_by", definition: "TEXT NOT NULL DEFAULT ''"},
+					{name: "allowed_networks", definition: "TEXT NOT NULL DEFAULT ''"},
+					{name: "not_before", definition: "DATETIME NULL"},
+					{name: "second_factor", definition: "TEXT NOT NULL DEFAULT ''"},
+					{name: "second_factor_data", definition: "TEXT NOT NULL DEFAULT ''"},
+					{name: "label", definition: "TEXT NOT NULL DEFAULT ''"},
+					{name: "note", definition: "TEXT NOT NULL DEFAULT ''"},
+					{name: "failed_attempts", definition: "INTEGER NOT NULL DEFAULT 0"},
+					{name: "code_hash", definition: "TEXT NOT NULL DEFAULT ''"},
+					{name: "code_expires_at", definition: "DATETIME NULL"},
+				},
 			},
 		},
 	}
@@ -71,7 +125,7 @@ func TestCreateSecretQueries(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			got, gotErr := createSecretQueries(test.input.driver, test.input.table)
 
-			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(secretQueries{})); diff != "" {
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(secretQueries{}, column{})); diff != "" {
 				t.Errorf("createSecretQueries() = unexpected result (-want +got)\n%s\n", diff)
 			}
 
@@ -81,3 +135,110 @@ func TestCreateSecretQueries(t *testing.T) {
 		})
 	}
 }
+
+func TestSecretStore_CreateMany(t *testing.T) {
+	client, err := NewClient(func(o *ClientOptions) {
+		o.Driver = DriverSQLite
+		o.SQLite.File = filepath.Join(t.TempDir(), "burnit.db")
+	})
+	if err != nil {
+		t.Fatalf("NewClient() = unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	store, err := NewSecretStore(client)
+	if err != nil {
+		t.Fatalf("NewSecretStore() = unexpected error: %v", err)
+	}
+
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	want := []db.Secret{
+		{ID: "1", Value: "secret", ExpiresAt: expiresAt, Label: "first"},
+		{ID: "2", Value: "secret", ExpiresAt: expiresAt, Label: "second"},
+	}
+	got, err := store.CreateMany(ctx, want)
+	if err != nil {
+		t.Fatalf("CreateMany() = unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("CreateMany() = unexpected result (-want +got)\n%s\n", diff)
+	}
+
+	// A duplicate ID fails the transaction and none of the secrets
+	// are created.
+	if _, err := store.CreateMany(ctx, []db.Secret{{ID: "3", Value: "secret", ExpiresAt: expiresAt}, {ID: "1", Value: "secret", ExpiresAt: expiresAt}}); err == nil {
+		t.Fatalf("CreateMany() = expected error for duplicate ID\n")
+	}
+	if _, err := store.Get(ctx, "3"); !errors.Is(err, dberrors.ErrSecretNotFound) {
+		t.Errorf("Get() = expected secret to not be created, got: %v\n", err)
+	}
+}
+
+func TestSecretStore_SecondFactor(t *testing.T) {
+	client, err := NewClient(func(o *ClientOptions) {
+		o.Driver = DriverSQLite
+		o.SQLite.File = filepath.Join(t.TempDir(), "burnit.db")
+	})
+	if err != nil {
+		t.Fatalf("NewClient() = unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	store, err := NewSecretStore(client)
+	if err != nil {
+		t.Fatalf("NewSecretStore() = unexpected error: %v", err)
+	}
+
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	if _, err := store.Create(ctx, db.Secret{ID: "1", Value: "secret", ExpiresAt: expiresAt}); err != nil {
+		t.Fatalf("Create() = unexpected error: %v", err)
+	}
+
+	for want := 1; want <= 2; want++ {
+		got, err := store.IncrementFailedAttempts(ctx, "1")
+		if err != nil {
+			t.Fatalf("IncrementFailedAttempts() = unexpected error: %v", err)
+		}
+		if want != got {
+			t.Errorf("IncrementFailedAttempts() = unexpected result, want: %d, got: %d\n", want, got)
+		}
+	}
+
+	codeExpiresAt := expiresAt.Add(-50 * time.Minute)
+	if err := store.SetCode(ctx, "1", "hash", codeExpiresAt); err != nil {
+		t.Fatalf("SetCode() = unexpected error: %v", err)
+	}
+
+	got, err := store.Get(ctx, "1")
+	if err != nil {
+		t.Fatalf("Get() = unexpected error: %v", err)
+	}
+	want := db.Secret{ID: "1", Value: "secret", ExpiresAt: expiresAt, FailedAttempts: 2, CodeHash: "hash", CodeExpiresAt: codeExpiresAt}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Get() = unexpected result (-want +got)\n%s\n", diff)
+	}
+
+	// An empty hash removes the code.
+	if err := store.SetCode(ctx, "1", "", time.Time{}); err != nil {
+		t.Fatalf("SetCode() = unexpected error: %v", err)
+	}
+	got, err = store.Get(ctx, "1")
+	if err != nil {
+		t.Fatalf("Get() = unexpected error: %v", err)
+	}
+	want.CodeHash, want.CodeExpiresAt = "", time.Time{}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Get() = unexpected result (-want +got)\n%s\n", diff)
+	}
+
+	if _, err := store.IncrementFailedAttempts(ctx, "2"); !errors.Is(err, dberrors.ErrSecretNotFound) {
+		t.Errorf("IncrementFailedAttempts() = expected ErrSecretNotFound, got: %v\n", err)
+	}
+	if err := store.SetCode(ctx, "2", "hash", codeExpiresAt); !errors.Is(err, dberrors.ErrSecretNotFound) {
+		t.Errorf("SetCode() = expected ErrSecretNotFound, got: %v\n", err)
+	}
+}
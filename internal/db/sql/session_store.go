@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/RedeployAB/burnit/internal/db"
@@ -29,6 +30,7 @@ type sessionStore struct {
 
 // SessionStoreOptions is the options for the SessionStore.
 type SessionStoreOptions struct {
+	Schema  string
 	Table   string
 	Timeout time.Duration
 }
@@ -51,7 +53,12 @@ func NewSessionStore(client Client, options ...SessionStoreOption) (*sessionStor
 	}
 
 	driver := client.Driver()
-	queries, err := createSessionQueries(driver, opts.Table)
+	table, err := qualifiedTable(driver, opts.Schema, opts.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	queries, err := createSessionQueries(driver, table)
 	if err != nil {
 		return nil, err
 	}
@@ -59,7 +66,7 @@ func NewSessionStore(client Client, options ...SessionStoreOption) (*sessionStor
 	s := &sessionStore{
 		client:  client,
 		driver:  driver,
-		table:   opts.Table,
+		table:   table,
 		queries: queries,
 		timeout: opts.Timeout,
 	}
@@ -81,14 +88,13 @@ func (s sessionStore) createTableIfNotExists(ctx context.Context) error {
 
 	switch s.driver {
 	case DriverPostgres:
-		query = "CREATE TABLE IF NOT EXISTS %s (id UUID PRIMARY KEY DEFAULT gen_random_uuid(), expires_at TIMESTAMPTZ NOT NULL, csrf_token VARCHAR(43), csrf_expires_at TIMESTAMPTZ NOT NULL)"
+		query = "CREATE TABLE IF NOT EXISTS %s (id UUID PRIMARY KEY DEFAULT gen_random_uuid(), expires_at TIMESTAMPTZ NOT NULL, csrf_token VARCHAR(43), csrf_expires_at TIMESTAMPTZ NOT NULL, user_id TEXT NOT NULL DEFAULT '')"
 		args = append(args, s.table)
 	case DriverMSSQL:
-		table := firstToUpper(s.table)
-		query = "IF NOT EXISTS (SELECT * FROM sysobjects WHERE name='%s' and xtype='U') CREATE TABLE %s (ID VARCHAR(36) NOT NULL PRIMARY KEY, ExpiresAt DATETIMEOFFSET NOT NULL, CSRFToken VARCHAR(43), CSRFExpiresAt DATETIMEOFFSET NOT NULL)"
-		args = append(args, table, table)
+		query = "IF OBJECT_ID(N'%s', N'U') IS NULL CREATE TABLE %s (ID VARCHAR(36) NOT NULL PRIMARY KEY, ExpiresAt DATETIMEOFFSET NOT NULL, CSRFToken VARCHAR(43), CSRFExpiresAt DATETIMEOFFSET NOT NULL, UserID NVARCHAR(255) NOT NULL DEFAULT '')"
+		args = append(args, s.table, s.table)
 	case DriverSQLite:
-		query = "CREATE TABLE IF NOT EXISTS %s (id TEXT NOT NULL PRIMARY KEY, expires_at DATETIME NOT NULL, csrf_token TEXT NOT NULL, csrf_expires_at DATETIME NOT NULL)"
+		query = "CREATE TABLE IF NOT EXISTS %s (id TEXT NOT NULL PRIMARY KEY, expires_at DATETIME NOT NULL, csrf_token TEXT NOT NULL, csrf_expires_at DATETIME NOT NULL, user_id TEXT NOT NULL DEFAULT '')"
 		args = append(args, s.table)
 	default:
 		return fmt.Errorf("%w: %s", ErrDriverNotSupported, s.driver)
@@ -97,13 +103,13 @@ func (s sessionStore) createTableIfNotExists(ctx context.Context) error {
 	if _, err := s.client.Exec(ctx, fmt.Sprintf(query, args...)); err != nil {
 		return err
 	}
-	return nil
+	return addColumnsIfNotExist(ctx, s.client, s.driver, s.table, s.queries.added...)
 }
 
 // Get a session by its ID.
 func (s sessionStore) Get(ctx context.Context, id string) (db.Session, error) {
 	var session db.Session
-	if err := s.client.QueryRow(ctx, s.queries.selectByID, id).Scan(&session.ID, &session.ExpiresAt, &session.CSRF.Token, &session.CSRF.ExpiresAt); err != nil {
+	if err := s.client.QueryRow(ctx, s.queries.selectByID, id).Scan(&session.ID, &session.ExpiresAt, &session.CSRF.Token, &session.CSRF.ExpiresAt, &session.User); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return db.Session{}, dberrors.ErrSessionNotFound
 		}
@@ -115,7 +121,7 @@ func (s sessionStore) Get(ctx context.Context, id string) (db.Session, error) {
 // Get a session by its CSRF token.
 func (s sessionStore) GetByCSRFToken(ctx context.Context, token string) (db.Session, error) {
 	var session db.Session
-	if err := s.client.QueryRow(ctx, s.queries.selectByCSRFToken, token).Scan(&session.ID, &session.ExpiresAt, &session.CSRF.Token, &session.CSRF.ExpiresAt); err != nil {
+	if err := s.client.QueryRow(ctx, s.queries.selectByCSRFToken, token).Scan(&session.ID, &session.ExpiresAt, &session.CSRF.Token, &session.CSRF.ExpiresAt, &session.User); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return db.Session{}, dberrors.ErrSessionNotFound
 		}
@@ -129,17 +135,17 @@ func (s sessionStore) GetByCSRFToken(ctx context.Context, token string) (db.Sess
 func (s sessionStore) Upsert(ctx context.Context, session db.Session) (db.Session, error) {
 	tx, err := s.client.Transaction(ctx)
 	if err != nil {
-		return db.Session{}, nil
+		return db.Session{}, err
 	}
 
-	if _, err := tx.Exec(ctx, s.queries.upsert, session.ID, session.ExpiresAt, session.CSRF.Token, session.CSRF.ExpiresAt); err != nil {
+	if _, err := tx.Exec(ctx, s.queries.upsert, session.ID, session.ExpiresAt, session.CSRF.Token, session.CSRF.ExpiresAt, session.User); err != nil {
 		if err := tx.Rollback(); err != nil {
 			return db.Session{}, err
 		}
 		return db.Session{}, err
 	}
 
-	if err := tx.QueryRow(ctx, s.queries.selectByID, session.ID).Scan(&session.ID, &session.ExpiresAt, &session.CSRF.Token, &session.CSRF.ExpiresAt); err != nil {
+	if err := tx.QueryRow(ctx, s.queries.selectByID, session.ID).Scan(&session.ID, &session.ExpiresAt, &session.CSRF.Token, &session.CSRF.ExpiresAt, &session.User); err != nil {
 		if err := tx.Rollback(); err != nil {
 			return db.Session{}, err
 		}
@@ -210,6 +216,32 @@ func (s sessionStore) DeleteExpired(ctx context.Context) error {
 	return nil
 }
 
+// Iterate calls fn for every unexpired session.
+func (s sessionStore) Iterate(ctx context.Context, fn func(session db.Session) error) error {
+	rows, err := s.client.Query(ctx, s.queries.selectUnexpired)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var session db.Session
+		if err := rows.Scan(&session.ID, &session.ExpiresAt, &session.CSRF.Token, &session.CSRF.ExpiresAt, &session.User); err != nil {
+			return err
+		}
+		if err := fn(session); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Ping checks the connection to the store.
+func (s sessionStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx)
+}
+
 // Close the store and its underlying connections.
 func (s sessionStore) Close() error {
 	return s.client.Close()
@@ -219,43 +251,51 @@ func (s sessionStore) Close() error {
 type sessionQueries struct {
 	selectByID        string
 	selectByCSRFToken string
+	selectUnexpired   string
 	upsert            string
 	delete            string
 	deleteByCSRFToken string
 	deleteExpired     string
+	// added contains the columns added after the first version
+	// of the table.
+	added []column
 }
 
 // createSessionQueries creates the queries used by the Store.
 func createSessionQueries(driver Driver, table string) (sessionQueries, error) {
-	var columns, placeholders []string
+	var columns []string
+	var added []column
 	var now, upsert string
 	switch driver {
 	case DriverPostgres:
-		columns = []string{"id", "expires_at", "csrf_token", "csrf_expires_at"}
-		placeholders = []string{"$1", "$2", "$3", "$4"}
+		columns = []string{"id", "expires_at", "csrf_token", "csrf_expires_at", "user_id"}
+		added = []column{{name: "user_id", definition: "TEXT NOT NULL DEFAULT ''"}}
 		now = "NOW() AT TIME ZONE 'UTC'"
-		upsert = "INSERT INTO %s (id, expires_at, csrf_token, csrf_expires_at) VALUES ($1, $2, $3, $4) ON CONFLICT (id) DO UPDATE SET expires_at = EXCLUDED.expires_at, csrf_token = EXCLUDED.csrf_token, csrf_expires_at = EXCLUDED.csrf_expires_at"
+		upsert = "INSERT INTO %s (id, expires_at, csrf_token, csrf_expires_at, user_id) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (id) DO UPDATE SET expires_at = EXCLUDED.expires_at, csrf_token = EXCLUDED.csrf_token, csrf_expires_at = EXCLUDED.csrf_expires_at, user_id = EXCLUDED.user_id"
 	case DriverMSSQL:
-		table = firstToUpper(table)
-		columns = []string{"ID", "ExpiresAt", "CSRFToken", "CSRFExpiresAt"}
-		placeholders = []string{"@p1", "@p2", "@p3", "@p4"}
+		columns = []string{"ID", "ExpiresAt", "CSRFToken", "CSRFExpiresAt", "UserID"}
+		added = []column{{name: "UserID", definition: "NVARCHAR(255) NOT NULL DEFAULT ''"}}
 		now = "GETUTCDATE()"
-		upsert = "MERGE INTO %s AS target USING (VALUES (@p1, @p2, @p3, @p4)) AS source (ID, ExpiresAt, CSRFToken, CSRFExpiresAt) ON target.ID = source.ID WHEN MATCHED THEN UPDATE SET target.ExpiresAt = source.ExpiresAt, target.CSRFToken = source.CSRFToken, target.CSRFExpiresAt = source.CSRFExpiresAt WHEN NOT MATCHED THEN INSERT (ID, ExpiresAt, CSRFToken, CSRFExpiresAt) VALUES (source.ID, source.ExpiresAt, source.CSRFToken, source.CSRFExpiresAt);"
+		upsert = "MERGE INTO %s AS target USING (VALUES (@p1, @p2, @p3, @p4, @p5)) AS source (ID, ExpiresAt, CSRFToken, CSRFExpiresAt, UserID) ON target.ID = source.ID WHEN MATCHED THEN UPDATE SET target.ExpiresAt = source.ExpiresAt, target.CSRFToken = source.CSRFToken, target.CSRFExpiresAt = source.CSRFExpiresAt, target.UserID = source.UserID WHEN NOT MATCHED THEN INSERT (ID, ExpiresAt, CSRFToken, CSRFExpiresAt, UserID) VALUES (source.ID, source.ExpiresAt, source.CSRFToken, source.CSRFExpiresAt, source.UserID);"
 	case DriverSQLite:
-		columns = []string{"id", "expires_at", "csrf_token", "csrf_expires_at"}
-		placeholders = []string{"?1", "?2", "?3", "?4"}
+		columns = []string{"id", "expires_at", "csrf_token", "csrf_expires_at", "user_id"}
+		added = []column{{name: "user_id", definition: "TEXT NOT NULL DEFAULT ''"}}
 		now = "DATETIME('now')"
-		upsert = "INSERT INTO %s (id, expires_at, csrf_token, csrf_expires_at) VALUES (?1, ?2, ?3, ?4) ON CONFLICT(id) DO UPDATE SET expires_at = excluded.expires_at, csrf_token = excluded.csrf_token, csrf_expires_at = excluded.csrf_expires_at"
+		upsert = "INSERT INTO %s (id, expires_at, csrf_token, csrf_expires_at, user_id) VALUES (?1, ?2, ?3, ?4, ?5) ON CONFLICT(id) DO UPDATE SET expires_at = excluded.expires_at, csrf_token = excluded.csrf_token, csrf_expires_at = excluded.csrf_expires_at, user_id = excluded.user_id"
 	default:
 		return sessionQueries{}, fmt.Errorf("%w: %s", ErrDriverNotSupported, driver)
 	}
+	placeholders := createPlaceholders(driver, 1)
+	selectColumns := strings.Join(columns, ", ")
 
 	return sessionQueries{
-		selectByID:        fmt.Sprintf("SELECT %s, %s, %s, %s FROM %s WHERE %s = %s", columns[0], columns[1], columns[2], columns[3], table, columns[0], placeholders[0]),
-		selectByCSRFToken: fmt.Sprintf("SELECT %s, %s, %s, %s FROM %s WHERE %s = %s", columns[0], columns[1], columns[2], columns[3], table, columns[2], placeholders[0]),
+		selectByID:        fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s", selectColumns, table, columns[0], placeholders[0]),
+		selectByCSRFToken: fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s", selectColumns, table, columns[2], placeholders[0]),
+		selectUnexpired:   fmt.Sprintf("SELECT %s FROM %s WHERE %s >= %s", selectColumns, table, columns[1], now),
 		upsert:            fmt.Sprintf(upsert, table),
 		delete:            fmt.Sprintf("DELETE FROM %s WHERE %s = %s", table, columns[0], placeholders[0]),
 		deleteByCSRFToken: fmt.Sprintf("DELETE FROM %s WHERE %s = %s", table, columns[2], placeholders[0]),
 		deleteExpired:     fmt.Sprintf("DELETE FROM %s WHERE %s < %s", table, columns[1], now),
+		added:             added,
 	}, nil
 }
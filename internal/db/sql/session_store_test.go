@@ -27,12 +27,14 @@ func TestCreateSessionQueries(t *testing.T) {
 				table:  "sessions",
 			},
 			want: sessionQueries{
-				selectByID:        "SELECT id, expires_at, csrf_token, csrf_expires_at FROM sessions WHERE id = $1",
-				selectByCSRFToken: "SELECT id, expires_at, csrf_token, csrf_expires_at FROM sessions WHERE csrf_token = $1",
-				upsert:            "INSERT INTO sessions (id, expires_at, csrf_token, csrf_expires_at) VALUES ($1, $2, $3, $4) ON CONFLICT (id) DO UPDATE SET expires_at = EXCLUDED.expires_at, csrf_token = EXCLUDED.csrf_token, csrf_expires_at = EXCLUDED.csrf_expires_at",
+				selectByID:        "SELECT id, expires_at, csrf_token, csrf_expires_at, user_id FROM sessions WHERE id = $1",
+				selectByCSRFToken: "SELECT id, expires_at, csrf_token, csrf_expires_at, user_id FROM sessions WHERE csrf_token = $1",
+				selectUnexpired:   "SELECT id, expires_at, csrf_token, csrf_expires_at, user_id FROM sessions WHERE expires_at >= NOW() AT TIME ZONE 'UTC'",
+				upsert:            "INSERT INTO sessions (id, expires_at, csrf_token, csrf_expires_at, user_id) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (id) DO UPDATE SET expires_at = EXCLUDED.expires_at, csrf_token = EXCLUDED.csrf_token, csrf_expires_at = EXCLUDED.csrf_expires_at, user_id = EXCLUDED.user_id",
 				delete:            "DELETE FROM sessions WHERE id = $1",
 				deleteByCSRFToken: "DELETE FROM sessions WHERE csrf_token = $1",
 				deleteExpired:     "DELETE FROM sessions WHERE expires_at < NOW() AT TIME ZONE 'UTC'",
+				added:             []column{{name: "user_id", definition: "TEXT NOT NULL DEFAULT ''"}},
 			},
 		},
 		{
@@ -42,15 +44,17 @@ func TestCreateSessionQueries(t *testing.T) {
 				table  string
 			}{
 				driver: DriverMSSQL,
-				table:  "sessions",
+				table:  "Sessions",
 			},
 			want: sessionQueries{
-				selectByID:        "SELECT ID, ExpiresAt, CSRFToken, CSRFExpiresAt FROM Sessions WHERE ID = @p1",
-				selectByCSRFToken: "SELECT ID, ExpiresAt, CSRFToken, CSRFExpiresAt FROM Sessions WHERE CSRFToken = @p1",
-				upsert:            "MERGE INTO Sessions AS target USING (VALUES (@p1, @p2, @p3, @p4)) AS source (ID, ExpiresAt, CSRFToken, CSRFExpiresAt) ON target.ID = source.ID WHEN MATCHED THEN UPDATE SET target.ExpiresAt = source.ExpiresAt, target.CSRFToken = source.CSRFToken, target.CSRFExpiresAt = source.CSRFExpiresAt WHEN NOT MATCHED THEN INSERT (ID, ExpiresAt, CSRFToken, CSRFExpiresAt) VALUES (source.ID, source.ExpiresAt, source.CSRFToken, source.CSRFExpiresAt);",
+				selectByID:        "SELECT ID, ExpiresAt, CSRFToken, CSRFExpiresAt, UserID FROM Sessions WHERE ID = @p1",
+				selectByCSRFToken: "SELECT ID, ExpiresAt, CSRFToken, CSRFExpiresAt, UserID FROM Sessions WHERE CSRFToken = @p1",
+				selectUnexpired:   "SELECT ID, ExpiresAt, CSRFToken, CSRFExpiresAt, UserID FROM Sessions WHERE ExpiresAt >= GETUTCDATE()",
+				upsert:            "MERGE INTO Sessions AS target USING (VALUES (@p1, @p2, @p3, @p4, @p5)) AS source (ID, ExpiresAt, CSRFToken, CSRFExpiresAt, UserID) ON target.ID = source.ID WHEN MATCHED THEN UPDATE SET target.ExpiresAt = source.ExpiresAt, target.CSRFToken = source.CSRFToken, target.CSRFExpiresAt = source.CSRFExpiresAt, target.UserID = source.UserID WHEN NOT MATCHED THEN INSERT (ID, ExpiresAt, CSRFToken, CSRFExpiresAt, UserID) VALUES (source.ID, source.ExpiresAt, source.CSRFToken, source.CSRFExpiresAt, source.UserID);",
 				delete:            "DELETE FROM Sessions WHERE ID = @p1",
 				deleteByCSRFToken: "DELETE FROM Sessions WHERE CSRFToken = @p1",
 				deleteExpired:     "DELETE FROM Sessions WHERE ExpiresAt < GETUTCDATE()",
+				added:             []column{{name: "UserID", definition: "NVARCHAR(255) NOT NULL DEFAULT ''"}},
 			},
 		},
 		{
@@ -63,12 +67,14 @@ func TestCreateSessionQueries(t *testing.T) {
 				table:  "sessions",
 			},
 			want: sessionQueries{
-				selectByID:        "SELECT id, expires_at, csrf_token, csrf_expires_at FROM sessions WHERE id = ?1",
-				selectByCSRFToken: "SELECT id, expires_at, csrf_token, csrf_expires_at FROM sessions WHERE csrf_token = ?1",
-				upsert:            "INSERT INTO sessions (id, expires_at, csrf_token, csrf_expires_at) VALUES (?1, ?2, ?3, ?4) ON CONFLICT(id) DO UPDATE SET expires_at = excluded.expires_at, csrf_token = excluded.csrf_token, csrf_expires_at = excluded.csrf_expires_at",
+				selectByID:        "SELECT id, expires_at, csrf_token, csrf_expires_at, user_id FROM sessions WHERE id = ?1",
+				selectByCSRFToken: "SELECT id, expires_at, csrf_token, csrf_expires_at, user_id FROM sessions WHERE csrf_token = ?1",
+				selectUnexpired:   "SELECT id, expires_at, csrf_token, csrf_expires_at, user_id FROM sessions WHERE expires_at >= DATETIME('now')",
+				upsert:            "INSERT INTO sessions (id, expires_at, csrf_token, csrf_expires_at, user_id) VALUES (?1, ?2, ?3, ?4, ?5) ON CONFLICT(id) DO UPDATE SET expires_at = excluded.expires_at, csrf_token = excluded.csrf_token, csrf_expires_at = excluded.csrf_expires_at, user_id = excluded.user_id",
 				delete:            "DELETE FROM sessions WHERE id = ?1",
 				deleteByCSRFToken: "DELETE FROM sessions WHERE csrf_token = ?1",
 				deleteExpired:     "DELETE FROM sessions WHERE expires_at < DATETIME('now')",
+				added:             []column{{name: "user_id", definition: "TEXT NOT NULL DEFAULT ''"}},
 			},
 		},
 	}
@@ -77,7 +83,7 @@ func TestCreateSessionQueries(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			got, gotErr := createSessionQueries(test.input.driver, test.input.table)
 
-			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(sessionQueries{})); diff != "" {
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(sessionQueries{}, column{})); diff != "" {
 				t.Errorf("createSessionQueries() = unexpected result (-want +got)\n%s\n", diff)
 			}
 
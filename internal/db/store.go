@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
 // SecretStore defines the methods needed for persisting
@@ -11,10 +12,25 @@ type SecretStore interface {
 	Get(ctx context.Context, id string) (Secret, error)
 	// Create a secret.
 	Create(ctx context.Context, secret Secret) (Secret, error)
+	// CreateMany creates secrets. The secrets are created in a transaction
+	// where the store supports it, so that either all or none of them
+	// are created.
+	CreateMany(ctx context.Context, secrets []Secret) ([]Secret, error)
 	// Delete a secret by its ID.
 	Delete(ctx context.Context, id string) error
+	// IncrementFailedAttempts increments the number of failed attempts
+	// to verify the second factor of a secret and returns the new number.
+	IncrementFailedAttempts(ctx context.Context, id string) (int, error)
+	// SetCode sets the hash and expiration time of the code sent for the
+	// second factor of a secret. An empty hash removes the code.
+	SetCode(ctx context.Context, id, hash string, expiresAt time.Time) error
 	// DeleteExpired deletes all expired secrets.
 	DeleteExpired(ctx context.Context) error
+	// Iterate calls fn for every unexpired secret. Iteration stops
+	// at the first error returned by fn.
+	Iterate(ctx context.Context, fn func(secret Secret) error) error
+	// Ping checks the connection to the SecretStore.
+	Ping(ctx context.Context) error
 	// Close the SecretStore and its underlying connections.
 	Close() error
 }
@@ -35,6 +51,24 @@ type SessionStore interface {
 	DeleteByCSRFToken(ctx context.Context, token string) error
 	// DeleteExpired deletes all expired sessions.
 	DeleteExpired(ctx context.Context) error
-	// Close the SecretStore and its underlying connections.
+	// Iterate calls fn for every unexpired session. Iteration stops
+	// at the first error returned by fn.
+	Iterate(ctx context.Context, fn func(session Session) error) error
+	// Ping checks the connection to the SessionStore.
+	Ping(ctx context.Context) error
+	// Close the SessionStore and its underlying connections.
+	Close() error
+}
+
+// RateLimitStore defines the methods needed for rate limiting
+// requests by key.
+type RateLimitStore interface {
+	// Allow reports whether a request for the key is allowed within
+	// the limit. An allowed request is counted against the limit.
+	Allow(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+	// Refund a request for the key that has been counted against the limit
+	// with Allow, for requests that turn out not to count against it.
+	Refund(ctx context.Context, key string, limit RateLimit) error
+	// Close the RateLimitStore and its underlying connections.
 	Close() error
 }
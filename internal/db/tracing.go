@@ -0,0 +1,224 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	dberrors "github.com/RedeployAB/burnit/internal/db/errors"
+	"github.com/RedeployAB/burnit/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingSecretStore wraps a SecretStore and records a span
+// for every store call.
+type tracingSecretStore struct {
+	store  SecretStore
+	system string
+}
+
+// NewTracingSecretStore returns a SecretStore that records a span for
+// every call to the provided store. The system is the database system
+// (driver) of the store, and is set as an attribute on the spans.
+func NewTracingSecretStore(store SecretStore, system string) SecretStore {
+	return &tracingSecretStore{
+		store:  store,
+		system: system,
+	}
+}
+
+// Get a secret by its ID.
+func (s *tracingSecretStore) Get(ctx context.Context, id string) (Secret, error) {
+	ctx, span := startSpan(ctx, "SecretStore.Get", s.system)
+	secret, err := s.store.Get(ctx, id)
+	endSpan(span, err)
+	return secret, err
+}
+
+// Create a secret.
+func (s *tracingSecretStore) Create(ctx context.Context, secret Secret) (Secret, error) {
+	ctx, span := startSpan(ctx, "SecretStore.Create", s.system)
+	secret, err := s.store.Create(ctx, secret)
+	endSpan(span, err)
+	return secret, err
+}
+
+// CreateMany creates secrets.
+func (s *tracingSecretStore) CreateMany(ctx context.Context, secrets []Secret) ([]Secret, error) {
+	ctx, span := startSpan(ctx, "SecretStore.CreateMany", s.system)
+	secrets, err := s.store.CreateMany(ctx, secrets)
+	endSpan(span, err)
+	return secrets, err
+}
+
+// Delete a secret by its ID.
+func (s *tracingSecretStore) Delete(ctx context.Context, id string) error {
+	ctx, span := startSpan(ctx, "SecretStore.Delete", s.system)
+	err := s.store.Delete(ctx, id)
+	endSpan(span, err)
+	return err
+}
+
+// IncrementFailedAttempts increments the number of failed attempts
+// to verify the second factor of a secret.
+func (s *tracingSecretStore) IncrementFailedAttempts(ctx context.Context, id string) (int, error) {
+	ctx, span := startSpan(ctx, "SecretStore.IncrementFailedAttempts", s.system)
+	attempts, err := s.store.IncrementFailedAttempts(ctx, id)
+	endSpan(span, err)
+	return attempts, err
+}
+
+// SetCode sets the hash and expiration time of the code sent for the
+// second factor of a secret.
+func (s *tracingSecretStore) SetCode(ctx context.Context, id, hash string, expiresAt time.Time) error {
+	ctx, span := startSpan(ctx, "SecretStore.SetCode", s.system)
+	err := s.store.SetCode(ctx, id, hash, expiresAt)
+	endSpan(span, err)
+	return err
+}
+
+// DeleteExpired deletes all expired secrets.
+func (s *tracingSecretStore) DeleteExpired(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "SecretStore.DeleteExpired", s.system)
+	err := s.store.DeleteExpired(ctx)
+	endSpan(span, err)
+	return err
+}
+
+// Iterate calls fn for every unexpired secret.
+func (s *tracingSecretStore) Iterate(ctx context.Context, fn func(secret Secret) error) error {
+	ctx, span := startSpan(ctx, "SecretStore.Iterate", s.system)
+	err := s.store.Iterate(ctx, fn)
+	endSpan(span, err)
+	return err
+}
+
+// Ping checks the connection to the SecretStore.
+func (s *tracingSecretStore) Ping(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "SecretStore.Ping", s.system)
+	err := s.store.Ping(ctx)
+	endSpan(span, err)
+	return err
+}
+
+// Close the SecretStore and its underlying connections.
+func (s *tracingSecretStore) Close() error {
+	return s.store.Close()
+}
+
+// tracingSessionStore wraps a SessionStore and records a span
+// for every store call.
+type tracingSessionStore struct {
+	store  SessionStore
+	system string
+}
+
+// NewTracingSessionStore returns a SessionStore that records a span for
+// every call to the provided store. The system is the database system
+// (driver) of the store, and is set as an attribute on the spans.
+func NewTracingSessionStore(store SessionStore, system string) SessionStore {
+	return &tracingSessionStore{
+		store:  store,
+		system: system,
+	}
+}
+
+// Get a session by its ID.
+func (s *tracingSessionStore) Get(ctx context.Context, id string) (Session, error) {
+	ctx, span := startSpan(ctx, "SessionStore.Get", s.system)
+	session, err := s.store.Get(ctx, id)
+	endSpan(span, err)
+	return session, err
+}
+
+// GetByCSRFToken gets a session by its CSRF token.
+func (s *tracingSessionStore) GetByCSRFToken(ctx context.Context, token string) (Session, error) {
+	ctx, span := startSpan(ctx, "SessionStore.GetByCSRFToken", s.system)
+	session, err := s.store.GetByCSRFToken(ctx, token)
+	endSpan(span, err)
+	return session, err
+}
+
+// Upsert a session.
+func (s *tracingSessionStore) Upsert(ctx context.Context, session Session) (Session, error) {
+	ctx, span := startSpan(ctx, "SessionStore.Upsert", s.system)
+	session, err := s.store.Upsert(ctx, session)
+	endSpan(span, err)
+	return session, err
+}
+
+// Delete a session by its ID.
+func (s *tracingSessionStore) Delete(ctx context.Context, id string) error {
+	ctx, span := startSpan(ctx, "SessionStore.Delete", s.system)
+	err := s.store.Delete(ctx, id)
+	endSpan(span, err)
+	return err
+}
+
+// DeleteByCSRFToken deletes a session by its CSRF token.
+func (s *tracingSessionStore) DeleteByCSRFToken(ctx context.Context, token string) error {
+	ctx, span := startSpan(ctx, "SessionStore.DeleteByCSRFToken", s.system)
+	err := s.store.DeleteByCSRFToken(ctx, token)
+	endSpan(span, err)
+	return err
+}
+
+// DeleteExpired deletes all expired sessions.
+func (s *tracingSessionStore) DeleteExpired(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "SessionStore.DeleteExpired", s.system)
+	err := s.store.DeleteExpired(ctx)
+	endSpan(span, err)
+	return err
+}
+
+// Iterate calls fn for every unexpired session.
+func (s *tracingSessionStore) Iterate(ctx context.Context, fn func(session Session) error) error {
+	ctx, span := startSpan(ctx, "SessionStore.Iterate", s.system)
+	err := s.store.Iterate(ctx, fn)
+	endSpan(span, err)
+	return err
+}
+
+// Ping checks the connection to the SessionStore.
+func (s *tracingSessionStore) Ping(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "SessionStore.Ping", s.system)
+	err := s.store.Ping(ctx)
+	endSpan(span, err)
+	return err
+}
+
+// Close the SessionStore and its underlying connections.
+func (s *tracingSessionStore) Close() error {
+	return s.store.Close()
+}
+
+// startSpan starts a client span for a store call.
+func startSpan(ctx context.Context, name, system string) (context.Context, trace.Span) {
+	return tracing.Start(
+		ctx,
+		name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", system)),
+	)
+}
+
+// endSpan ends the span for a store call. Errors that are expected
+// results of a call (such as not found) are not recorded as errors.
+func endSpan(span trace.Span, err error) {
+	if isExpectedError(err) {
+		err = nil
+	}
+	tracing.End(span, err)
+}
+
+// isExpectedError returns true if the error is an expected result
+// of a store call.
+func isExpectedError(err error) bool {
+	return errors.Is(err, dberrors.ErrSecretNotFound) ||
+		errors.Is(err, dberrors.ErrSecretNotDeleted) ||
+		errors.Is(err, dberrors.ErrSecretsNotDeleted) ||
+		errors.Is(err, dberrors.ErrSessionNotFound) ||
+		errors.Is(err, dberrors.ErrSessionNotDeleted) ||
+		errors.Is(err, dberrors.ErrSessionsNotDeleted)
+}
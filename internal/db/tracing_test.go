@@ -0,0 +1,177 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	dberrors "github.com/RedeployAB/burnit/internal/db/errors"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingSecretStore(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input error
+		want  struct {
+			name   string
+			system string
+			code   codes.Code
+			err    error
+		}
+	}{
+		{
+			name: "get secret",
+			want: struct {
+				name   string
+				system string
+				code   codes.Code
+				err    error
+			}{
+				name:   "SecretStore.Get",
+				system: "postgres",
+				code:   codes.Unset,
+			},
+		},
+		{
+			name:  "get secret - not found",
+			input: dberrors.ErrSecretNotFound,
+			want: struct {
+				name   string
+				system string
+				code   codes.Code
+				err    error
+			}{
+				name:   "SecretStore.Get",
+				system: "postgres",
+				code:   codes.Unset,
+				err:    dberrors.ErrSecretNotFound,
+			},
+		},
+		{
+			name:  "get secret - error",
+			input: errStoreTest,
+			want: struct {
+				name   string
+				system string
+				code   codes.Code
+				err    error
+			}{
+				name:   "SecretStore.Get",
+				system: "postgres",
+				code:   codes.Error,
+				err:    errStoreTest,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := testSetupTracing(t)
+			store := NewTracingSecretStore(&stubSecretStore{err: test.input}, "postgres")
+
+			ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+			_, gotErr := store.Get(ctx, "1")
+			parent.End()
+
+			if diff := cmp.Diff(test.want.err, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Get() = unexpected error (-want +got)\n%s\n", diff)
+			}
+
+			spans := recorder.Ended()
+			if len(spans) != 2 {
+				t.Fatalf("Get() = expected 2 spans, got %d", len(spans))
+			}
+			span := spans[0]
+
+			if diff := cmp.Diff(test.want.name, span.Name()); diff != "" {
+				t.Errorf("Get() = unexpected result for name (-want +got)\n%s\n", diff)
+			}
+			if diff := cmp.Diff(parent.SpanContext().SpanID(), span.Parent().SpanID()); diff != "" {
+				t.Errorf("Get() = unexpected result for parent (-want +got)\n%s\n", diff)
+			}
+			if diff := cmp.Diff(trace.SpanKindClient, span.SpanKind()); diff != "" {
+				t.Errorf("Get() = unexpected result for kind (-want +got)\n%s\n", diff)
+			}
+			if diff := cmp.Diff([]attribute.KeyValue{attribute.String("db.system", test.want.system)}, span.Attributes(), cmp.AllowUnexported(attribute.Value{})); diff != "" {
+				t.Errorf("Get() = unexpected result for attributes (-want +got)\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.want.code, span.Status().Code); diff != "" {
+				t.Errorf("Get() = unexpected result for status (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+// testSetupTracing sets a tracer provider that records spans, and
+// restores the global tracer provider after the test.
+func testSetupTracing(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	provider := otel.GetTracerProvider()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+	})
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	return recorder
+}
+
+type stubSecretStore struct {
+	err error
+}
+
+func (s stubSecretStore) Get(ctx context.Context, id string) (Secret, error) {
+	if s.err != nil {
+		return Secret{}, s.err
+	}
+	return Secret{ID: id}, nil
+}
+
+func (s stubSecretStore) Create(ctx context.Context, secret Secret) (Secret, error) {
+	return secret, s.err
+}
+
+func (s stubSecretStore) CreateMany(ctx context.Context, secrets []Secret) ([]Secret, error) {
+	return secrets, s.err
+}
+
+func (s stubSecretStore) Delete(ctx context.Context, id string) error {
+	return s.err
+}
+
+func (s stubSecretStore) IncrementFailedAttempts(ctx context.Context, id string) (int, error) {
+	return 1, s.err
+}
+
+func (s stubSecretStore) SetCode(ctx context.Context, id, hash string, expiresAt time.Time) error {
+	return s.err
+}
+
+func (s stubSecretStore) DeleteExpired(ctx context.Context) error {
+	return s.err
+}
+
+func (s stubSecretStore) Iterate(ctx context.Context, fn func(secret Secret) error) error {
+	return s.err
+}
+
+func (s stubSecretStore) Ping(ctx context.Context) error {
+	return s.err
+}
+
+func (s stubSecretStore) Close() error {
+	return nil
+}
+
+var (
+	errStoreTest = errors.New("store error")
+)
@@ -0,0 +1,18 @@
+package mail
+
+import (
+	"context"
+)
+
+// Message is an email message.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer is the interface that wraps around method Send.
+type Mailer interface {
+	// Send a message.
+	Send(ctx context.Context, message Message) error
+}
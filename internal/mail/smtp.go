@@ -0,0 +1,164 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultSMTPPort is the default port of the SMTP server.
+	defaultSMTPPort = 587
+	// defaultSMTPTimeout is the default timeout for sending a message.
+	defaultSMTPTimeout = 10 * time.Second
+)
+
+// smtpMailer sends messages through an SMTP server. STARTTLS is used
+// when the server supports it.
+type smtpMailer struct {
+	addr      string
+	host      string
+	auth      smtp.Auth
+	from      string
+	timeout   time.Duration
+	tlsConfig *tls.Config
+}
+
+// SMTPOptions contains options for the SMTP mailer.
+type SMTPOptions struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// From is the address messages are sent from.
+	From    string
+	Timeout time.Duration
+	// TLSConfig is the TLS configuration used for STARTTLS. The
+	// server name is set to the host if not set.
+	TLSConfig *tls.Config
+}
+
+// SMTPOption is a function that sets options for the SMTP mailer.
+type SMTPOption func(o *SMTPOptions)
+
+// NewSMTP returns a new SMTP mailer.
+func NewSMTP(options ...SMTPOption) (*smtpMailer, error) {
+	opts := SMTPOptions{
+		Port:    defaultSMTPPort,
+		Timeout: defaultSMTPTimeout,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	if len(opts.Host) == 0 {
+		return nil, errors.New("smtp: host is required")
+	}
+	if _, err := mail.ParseAddress(opts.From); err != nil {
+		return nil, fmt.Errorf("smtp: invalid from address: %w", err)
+	}
+
+	tlsConfig := opts.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	if len(tlsConfig.ServerName) == 0 {
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.ServerName = opts.Host
+	}
+
+	var auth smtp.Auth
+	if len(opts.Username) > 0 {
+		auth = smtp.PlainAuth("", opts.Username, opts.Password, opts.Host)
+	}
+
+	return &smtpMailer{
+		addr:      net.JoinHostPort(opts.Host, strconv.Itoa(opts.Port)),
+		host:      opts.Host,
+		auth:      auth,
+		from:      opts.From,
+		timeout:   opts.Timeout,
+		tlsConfig: tlsConfig,
+	}, nil
+}
+
+// Send a message.
+func (m smtpMailer) Send(ctx context.Context, message Message) error {
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return fmt.Errorf("smtp: invalid to address: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(m.tlsConfig); err != nil {
+			return fmt.Errorf("smtp: %w", err)
+		}
+	}
+	if m.auth != nil {
+		if err := client.Auth(m.auth); err != nil {
+			return fmt.Errorf("smtp: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.from); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	if _, err := w.Write(newMessage(m.from, to.Address, message)); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	return client.Quit()
+}
+
+// newMessage returns the message with headers and CRLF line endings.
+func newMessage(from, to string, message Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", message.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().UTC().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(message.Body, "\r\n", "\n"), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
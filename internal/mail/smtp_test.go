@@ -0,0 +1,226 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSMTPMailer_Send(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   Message
+		want    stubSMTPTransaction
+		wantErr bool
+	}{
+		{
+			name: "send message",
+			input: Message{
+				To:      "recipient@example.com",
+				Subject: "Code",
+				Body:    "Your code is 123456.",
+			},
+			want: stubSMTPTransaction{
+				from:    "<burnit@example.com>",
+				to:      "<recipient@example.com>",
+				subject: "Code",
+				body:    "Your code is 123456.",
+			},
+		},
+		{
+			name: "send message - invalid to address",
+			input: Message{
+				To:      "recipient",
+				Subject: "Code",
+				Body:    "Your code is 123456.",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newStubSMTPServer(t)
+
+			mailer, err := NewSMTP(func(o *SMTPOptions) {
+				o.Host = server.host
+				o.Port = server.port
+				o.From = "burnit@example.com"
+			})
+			if err != nil {
+				t.Fatalf("NewSMTP() = unexpected error: %v", err)
+			}
+
+			gotErr := mailer.Send(context.Background(), test.input)
+			if (gotErr != nil) != test.wantErr {
+				t.Fatalf("Send() = unexpected error: %v", gotErr)
+			}
+			if test.wantErr {
+				return
+			}
+
+			got := <-server.transactions
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(stubSMTPTransaction{})); diff != "" {
+				t.Errorf("Send() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestNewSMTP(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   []SMTPOption
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			input: []SMTPOption{
+				func(o *SMTPOptions) {
+					o.Host = "smtp.example.com"
+					o.From = "burnit@example.com"
+				},
+			},
+		},
+		{
+			name: "missing host",
+			input: []SMTPOption{
+				func(o *SMTPOptions) {
+					o.From = "burnit@example.com"
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid from address",
+			input: []SMTPOption{
+				func(o *SMTPOptions) {
+					o.Host = "smtp.example.com"
+					o.From = "burnit"
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, gotErr := NewSMTP(test.input...)
+			if (gotErr != nil) != test.wantErr {
+				t.Errorf("NewSMTP() = unexpected error: %v", gotErr)
+			}
+		})
+	}
+}
+
+// stubSMTPTransaction is a message received by the stub SMTP server.
+type stubSMTPTransaction struct {
+	from    string
+	to      string
+	subject string
+	body    string
+}
+
+// stubSMTPServer is a minimal SMTP server that accepts one message
+// per connection.
+type stubSMTPServer struct {
+	host         string
+	port         int
+	transactions chan stubSMTPTransaction
+}
+
+func newStubSMTPServer(t *testing.T) *stubSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() = unexpected error: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	addr := listener.Addr().(*net.TCPAddr)
+	server := &stubSMTPServer{
+		host:         addr.IP.String(),
+		port:         addr.Port,
+		transactions: make(chan stubSMTPTransaction, 1),
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.handle(conn)
+		}
+	}()
+	return server
+}
+
+func (s *stubSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		conn.Write([]byte(line + "\r\n"))
+	}
+
+	reply("220 localhost ESMTP")
+	var transaction stubSMTPTransaction
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			transaction.from = line[len("MAIL FROM:"):]
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			transaction.to = line[len("RCPT TO:"):]
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 Start mail input")
+			var headers, body bool
+			var lines []string
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				line = strings.TrimRight(line, "\r\n")
+				if line == "." {
+					break
+				}
+				if !headers {
+					if line == "" {
+						headers, body = true, true
+						continue
+					}
+					if subject, ok := strings.CutPrefix(line, "Subject: "); ok {
+						transaction.subject = subject
+					}
+					continue
+				}
+				if body {
+					lines = append(lines, line)
+				}
+			}
+			transaction.body = strings.Join(lines, "\n")
+			s.transactions <- transaction
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
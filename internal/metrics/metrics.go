@@ -0,0 +1,201 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// namespace is the namespace for all metrics.
+	namespace = "burnit"
+)
+
+// Metrics contains the collectors for the application metrics. All
+// methods are safe to call on a nil *Metrics, which makes it possible
+// to pass it along when metrics are disabled.
+type Metrics struct {
+	registry                *prometheus.Registry
+	requests                *prometheus.CounterVec
+	requestDuration         *prometheus.HistogramVec
+	secretsCreated          prometheus.Counter
+	secretsRead             prometheus.Counter
+	secretsDeleted          prometheus.Counter
+	secretsExpired          prometheus.Counter
+	secretsFailedPassphrase prometheus.Counter
+	rateLimiterRejections   prometheus.Counter
+	cleanupDuration         *prometheus.HistogramVec
+	storeErrors             *prometheus.CounterVec
+}
+
+// New creates a new Metrics with its collectors registered
+// to a new registry.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of HTTP requests by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		secretsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "secrets",
+			Name:      "created_total",
+			Help:      "Number of created secrets.",
+		}),
+		secretsRead: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "secrets",
+			Name:      "read_total",
+			Help:      "Number of read (and burned) secrets.",
+		}),
+		secretsDeleted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "secrets",
+			Name:      "deleted_total",
+			Help:      "Number of secrets deleted without being read.",
+		}),
+		secretsExpired: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "secrets",
+			Name:      "expired_total",
+			Help:      "Number of expired secrets encountered when retrieving secrets.",
+		}),
+		secretsFailedPassphrase: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "secrets",
+			Name:      "failed_passphrase_total",
+			Help:      "Number of attempts to retrieve or delete a secret with an invalid passphrase.",
+		}),
+		rateLimiterRejections: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "rate_limiter",
+			Name:      "rejections_total",
+			Help:      "Number of requests rejected by the rate limiter.",
+		}),
+		cleanupDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "cleanup",
+			Name:      "duration_seconds",
+			Help:      "Duration of cleanups of expired entries by store.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"store"}),
+		storeErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "store",
+			Name:      "errors_total",
+			Help:      "Number of store errors by store and operation.",
+		}, []string{"store", "operation"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.secretsCreated,
+		m.secretsRead,
+		m.secretsDeleted,
+		m.secretsExpired,
+		m.secretsFailedPassphrase,
+		m.rateLimiterRejections,
+		m.cleanupDuration,
+		m.storeErrors,
+	)
+
+	return m
+}
+
+// Handler returns a handler that serves the metrics.
+func (m *Metrics) Handler() http.Handler {
+	if m == nil {
+		return http.NotFoundHandler()
+	}
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records a handled HTTP request.
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.requestDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// SecretCreated records a created secret.
+func (m *Metrics) SecretCreated() {
+	if m == nil {
+		return
+	}
+	m.secretsCreated.Inc()
+}
+
+// SecretRead records a read secret.
+func (m *Metrics) SecretRead() {
+	if m == nil {
+		return
+	}
+	m.secretsRead.Inc()
+}
+
+// SecretDeleted records a deleted secret.
+func (m *Metrics) SecretDeleted() {
+	if m == nil {
+		return
+	}
+	m.secretsDeleted.Inc()
+}
+
+// SecretExpired records an expired secret.
+func (m *Metrics) SecretExpired() {
+	if m == nil {
+		return
+	}
+	m.secretsExpired.Inc()
+}
+
+// SecretFailedPassphrase records an attempt with an invalid passphrase.
+func (m *Metrics) SecretFailedPassphrase() {
+	if m == nil {
+		return
+	}
+	m.secretsFailedPassphrase.Inc()
+}
+
+// RateLimiterRejection records a request rejected by the rate limiter.
+func (m *Metrics) RateLimiterRejection() {
+	if m == nil {
+		return
+	}
+	m.rateLimiterRejections.Inc()
+}
+
+// ObserveCleanup records the duration of a cleanup for the store.
+func (m *Metrics) ObserveCleanup(store string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.cleanupDuration.WithLabelValues(store).Observe(duration.Seconds())
+}
+
+// StoreError records an error from the store for the operation.
+func (m *Metrics) StoreError(store, operation string) {
+	if m == nil {
+		return
+	}
+	m.storeErrors.WithLabelValues(store, operation).Inc()
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMetrics(t *testing.T) {
+	m := New()
+
+	m.ObserveRequest(http.MethodGet, "/secrets/{id}", http.StatusOK, 10*time.Millisecond)
+	m.SecretCreated()
+	m.SecretCreated()
+	m.SecretRead()
+	m.SecretDeleted()
+	m.SecretExpired()
+	m.SecretFailedPassphrase()
+	m.RateLimiterRejection()
+	m.ObserveCleanup("secrets", 5*time.Millisecond)
+	m.StoreError("secrets", "get")
+
+	want := []string{
+		`burnit_http_requests_total{method="GET",route="/secrets/{id}",status="200"} 1`,
+		`burnit_http_request_duration_seconds_count{method="GET",route="/secrets/{id}"} 1`,
+		`burnit_secrets_created_total 2`,
+		`burnit_secrets_read_total 1`,
+		`burnit_secrets_deleted_total 1`,
+		`burnit_secrets_expired_total 1`,
+		`burnit_secrets_failed_passphrase_total 1`,
+		`burnit_rate_limiter_rejections_total 1`,
+		`burnit_cleanup_duration_seconds_count{store="secrets"} 1`,
+		`burnit_store_errors_total{operation="get",store="secrets"} 1`,
+		`go_goroutines`,
+	}
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	b, err := io.ReadAll(rr.Body)
+	if err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+
+	for _, w := range want {
+		if !strings.Contains(string(b), w) {
+			t.Errorf("Handler() = expected %q in metrics", w)
+		}
+	}
+}
+
+func TestMetrics_Nil(t *testing.T) {
+	var m *Metrics
+
+	m.ObserveRequest(http.MethodGet, "/", http.StatusOK, time.Millisecond)
+	m.SecretCreated()
+	m.SecretRead()
+	m.SecretDeleted()
+	m.SecretExpired()
+	m.SecretFailedPassphrase()
+	m.RateLimiterRejection()
+	m.ObserveCleanup("secrets", time.Millisecond)
+	m.StoreError("secrets", "get")
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if diff := cmp.Diff(http.StatusNotFound, rr.Code); diff != "" {
+		t.Errorf("Handler() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
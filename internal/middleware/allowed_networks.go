@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/netip"
+)
+
+var (
+	// ErrNetworkNotAllowed is returned when a request is made from an
+	// address outside of the allowed networks.
+	ErrNetworkNotAllowed = errors.New("network not allowed")
+)
+
+// AllowedNetworks contains the address ranges that are allowed to make
+// requests to a route.
+type AllowedNetworks []netip.Prefix
+
+// ParseAllowedNetworks parses allowed networks from CIDRs (192.168.0.0/16) or
+// single IP addresses (10.0.0.1).
+func ParseAllowedNetworks(networks []string) (AllowedNetworks, error) {
+	prefixes, err := parsePrefixes(networks, "allowed network")
+	if err != nil {
+		return nil, err
+	}
+	return AllowedNetworks(prefixes), nil
+}
+
+// contains returns true if the address is within any of the allowed networks.
+func (n AllowedNetworks) contains(addr string) bool {
+	ip, ok := parseAddr(addr)
+	if !ok {
+		return false
+	}
+	for _, prefix := range n {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedNetworksOptions contains options for the AllowNetworks middleware.
+type AllowedNetworksOptions struct {
+	// Rejected is the handler for requests from addresses outside of
+	// the allowed networks. Defaults to a JSON error response.
+	Rejected http.Handler
+}
+
+// AllowedNetworksOption is a function that sets options for the
+// AllowNetworks middleware.
+type AllowedNetworksOption func(o *AllowedNetworksOptions)
+
+// AllowNetworks is a middleware that only allows requests from addresses
+// within the allowed networks. The address is the source IP set by the
+// SourceIP middleware, which must run before this middleware. Other
+// requests get the status 403 Forbidden.
+func AllowNetworks(networks AllowedNetworks, options ...AllowedNetworksOption) func(next http.Handler) http.Handler {
+	opts := AllowedNetworksOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	rejected := opts.Rejected
+	if rejected == nil {
+		rejected = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, r, http.StatusForbidden, "NetworkNotAllowed", ErrNetworkNotAllowed)
+		})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !networks.contains(getSourceIP(r.Context())) {
+				rejected.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WithAllowedNetworksRejectedHandler sets the handler for requests from
+// addresses outside of the allowed networks.
+func WithAllowedNetworksRejectedHandler(h http.Handler) AllowedNetworksOption {
+	return func(o *AllowedNetworksOptions) {
+		o.Rejected = h
+	}
+}
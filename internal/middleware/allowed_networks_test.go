@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseAllowedNetworks(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   []string
+		want    AllowedNetworks
+		wantErr bool
+	}{
+		{
+			name:  "CIDRs and addresses",
+			input: []string{"10.8.0.0/16", "192.168.1.1", "fd00::/8"},
+			want: AllowedNetworks{
+				netip.MustParsePrefix("10.8.0.0/16"),
+				netip.MustParsePrefix("192.168.1.1/32"),
+				netip.MustParsePrefix("fd00::/8"),
+			},
+		},
+		{
+			name:    "invalid network",
+			input:   []string{"vpn.example.com"},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := ParseAllowedNetworks(test.input)
+			if (gotErr != nil) != test.wantErr {
+				t.Fatalf("ParseAllowedNetworks() = unexpected error: %v", gotErr)
+			}
+
+			if diff := cmp.Diff(test.want, got, cmp.Comparer(func(x, y netip.Prefix) bool { return x == y })); diff != "" {
+				t.Errorf("ParseAllowedNetworks() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestAllowNetworks(t *testing.T) {
+	networks, err := ParseAllowedNetworks([]string{"10.8.0.0/16", "fd00::/8"})
+	if err != nil {
+		t.Fatalf("ParseAllowedNetworks() = unexpected error: %v", err)
+	}
+
+	var tests = []struct {
+		name  string
+		input struct {
+			sourceIP string
+			rejected http.Handler
+		}
+		want int
+	}{
+		{
+			name: "allowed IPv4",
+			input: struct {
+				sourceIP string
+				rejected http.Handler
+			}{
+				sourceIP: "10.8.1.2",
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "allowed IPv6",
+			input: struct {
+				sourceIP string
+				rejected http.Handler
+			}{
+				sourceIP: "fd00::1",
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "not allowed",
+			input: struct {
+				sourceIP string
+				rejected http.Handler
+			}{
+				sourceIP: "192.168.1.1",
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name: "source IP not available",
+			input: struct {
+				sourceIP string
+				rejected http.Handler
+			}{
+				sourceIP: SourceIPNotAvailable,
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name: "not allowed - rejected handler",
+			input: struct {
+				sourceIP string
+				rejected http.Handler
+			}{
+				sourceIP: "192.168.1.1",
+				rejected: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusTeapot)
+				}),
+			},
+			want: http.StatusTeapot,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := AllowNetworks(networks, WithAllowedNetworksRejectedHandler(test.input.rejected))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/secrets", nil)
+			req = req.WithContext(setSourceIP(req.Context(), test.input.sourceIP))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if test.want != rr.Code {
+				t.Errorf("AllowNetworks() = unexpected status code, want: %d, got: %d\n", test.want, rr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/RedeployAB/burnit/internal/api"
+	"github.com/RedeployAB/burnit/internal/auth"
+	"github.com/RedeployAB/burnit/internal/db"
+	"github.com/RedeployAB/burnit/internal/metrics"
+)
+
+var (
+	// ErrAPIKeyRequired is returned when a request has no API key.
+	ErrAPIKeyRequired = errors.New("api key required")
+	// ErrInvalidAPIKey is returned when the API key of a request is invalid.
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrInsufficientScope is returned when the API key of a request does not
+	// have the required scope.
+	ErrInsufficientScope = errors.New("insufficient scope")
+	// ErrQuotaExceeded is returned when the quota of an API key is exceeded.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+)
+
+const (
+	// quotaKeyPrefix is the prefix for quota keys in the rate limit store.
+	quotaKeyPrefix = "quota:apikey:"
+)
+
+// APIKeyOptions contains options for the APIKey middleware.
+type APIKeyOptions struct {
+	// Store is the store for the quotas of the API keys. Quotas are
+	// not applied if no store is set.
+	Store   db.RateLimitStore
+	Metrics *metrics.Metrics
+}
+
+// APIKeyOption is a function that sets options for the APIKey middleware.
+type APIKeyOption func(o *APIKeyOptions)
+
+// APIKey is a middleware that authenticates requests with API keys. The key
+// is read from the X-API-Key header, or the Authorization header with the
+// Bearer scheme, and must have the provided scope. The quota of the key is
+// shared between all routes it is used on. The authenticated key is set in
+// the request context.
+func APIKey(keys auth.APIKeyStore, scope auth.Scope, options ...APIKeyOption) func(next http.Handler) http.Handler {
+	opts := APIKeyOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apiKey := apiKeyFromRequest(r)
+			if len(apiKey) == 0 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, r, http.StatusUnauthorized, "APIKeyRequired", ErrAPIKeyRequired)
+				return
+			}
+
+			key, err := keys.Get(r.Context(), auth.HashAPIKey(apiKey))
+			if err != nil {
+				if errors.Is(err, auth.ErrAPIKeyNotFound) {
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+					writeError(w, r, http.StatusUnauthorized, "InvalidAPIKey", ErrInvalidAPIKey)
+					return
+				}
+				writeError(w, r, http.StatusInternalServerError, "ServerError", errors.New("internal server error"))
+				return
+			}
+			if !key.HasScope(scope) {
+				writeError(w, r, http.StatusForbidden, "InsufficientScope", ErrInsufficientScope)
+				return
+			}
+
+			if key.Quota.Requests > 0 && opts.Store != nil {
+				limit := db.RateLimit{
+					Rate:  float64(key.Quota.Requests) / key.Quota.Period.Seconds(),
+					Burst: key.Quota.Requests,
+				}
+				result, err := opts.Store.Allow(r.Context(), quotaKeyPrefix+key.Name, limit)
+				if err != nil {
+					// Requests are allowed if the store is unavailable, in line
+					// with the rate limiter.
+					opts.Metrics.StoreError(metricsStoreRateLimit, "allow")
+				} else if !result.Allowed {
+					opts.Metrics.RateLimiterRejection()
+					setRateLimitHeaders(w.Header(), limit, result)
+					w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+					writeError(w, r, http.StatusTooManyRequests, "QuotaExceeded", ErrQuotaExceeded)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), key)))
+		})
+	}
+}
+
+// WithAPIKeyStore sets the store for the quotas of the API keys.
+func WithAPIKeyStore(store db.RateLimitStore) APIKeyOption {
+	return func(o *APIKeyOptions) {
+		o.Store = store
+	}
+}
+
+// WithAPIKeyMetrics sets the metrics for the APIKey middleware.
+func WithAPIKeyMetrics(m *metrics.Metrics) APIKeyOption {
+	return func(o *APIKeyOptions) {
+		o.Metrics = m
+	}
+}
+
+// writeError writes a JSON error response.
+func writeError(w http.ResponseWriter, r *http.Request, statusCode int, code string, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(api.Error{StatusCode: statusCode, Code: code, Err: err.Error(), RequestID: getRequestID(r.Context())}.JSON())
+}
@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/auth"
+	"github.com/RedeployAB/burnit/internal/db"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAPIKey(t *testing.T) {
+	keys, err := auth.NewAPIKeyStore(
+		auth.APIKey{Name: "ci", Hash: auth.HashAPIKey("key1"), Scopes: []auth.Scope{auth.ScopeCreate}},
+		auth.APIKey{Name: "admin", Hash: auth.HashAPIKey("key2"), Scopes: []auth.Scope{auth.ScopeAdmin}, Quota: auth.Quota{Requests: 100, Period: 24 * time.Hour}},
+	)
+	if err != nil {
+		t.Fatalf("NewAPIKeyStore() = unexpected error: %v", err)
+	}
+
+	var tests = []struct {
+		name  string
+		input struct {
+			header http.Header
+			scope  auth.Scope
+			store  *stubRateLimitStore
+		}
+		want struct {
+			code     int
+			keyName  string
+			storeKey string
+			limit    db.RateLimit
+		}
+	}{
+		{
+			name: "authenticated with X-API-Key",
+			input: struct {
+				header http.Header
+				scope  auth.Scope
+				store  *stubRateLimitStore
+			}{
+				header: http.Header{"X-Api-Key": []string{"key1"}},
+				scope:  auth.ScopeCreate,
+				store:  &stubRateLimitStore{},
+			},
+			want: struct {
+				code     int
+				keyName  string
+				storeKey string
+				limit    db.RateLimit
+			}{
+				code:    http.StatusOK,
+				keyName: "ci",
+			},
+		},
+		{
+			name: "authenticated with bearer token and quota",
+			input: struct {
+				header http.Header
+				scope  auth.Scope
+				store  *stubRateLimitStore
+			}{
+				header: http.Header{"Authorization": []string{"Bearer key2"}},
+				scope:  auth.ScopeDelete,
+				store:  &stubRateLimitStore{result: db.RateLimitResult{Allowed: true, Remaining: 99}},
+			},
+			want: struct {
+				code     int
+				keyName  string
+				storeKey string
+				limit    db.RateLimit
+			}{
+				code:     http.StatusOK,
+				keyName:  "admin",
+				storeKey: "quota:apikey:admin",
+				limit:    db.RateLimit{Rate: 100.0 / 86400, Burst: 100},
+			},
+		},
+		{
+			name: "quota exceeded",
+			input: struct {
+				header http.Header
+				scope  auth.Scope
+				store  *stubRateLimitStore
+			}{
+				header: http.Header{"X-Api-Key": []string{"key2"}},
+				scope:  auth.ScopeCreate,
+				store:  &stubRateLimitStore{result: db.RateLimitResult{RetryAfter: time.Minute}},
+			},
+			want: struct {
+				code     int
+				keyName  string
+				storeKey string
+				limit    db.RateLimit
+			}{
+				code:     http.StatusTooManyRequests,
+				storeKey: "quota:apikey:admin",
+				limit:    db.RateLimit{Rate: 100.0 / 86400, Burst: 100},
+			},
+		},
+		{
+			name: "quota store error",
+			input: struct {
+				header http.Header
+				scope  auth.Scope
+				store  *stubRateLimitStore
+			}{
+				header: http.Header{"X-Api-Key": []string{"key2"}},
+				scope:  auth.ScopeCreate,
+				store:  &stubRateLimitStore{err: errors.New("error")},
+			},
+			want: struct {
+				code     int
+				keyName  string
+				storeKey string
+				limit    db.RateLimit
+			}{
+				code:     http.StatusOK,
+				keyName:  "admin",
+				storeKey: "quota:apikey:admin",
+				limit:    db.RateLimit{Rate: 100.0 / 86400, Burst: 100},
+			},
+		},
+		{
+			name: "missing api key",
+			input: struct {
+				header http.Header
+				scope  auth.Scope
+				store  *stubRateLimitStore
+			}{
+				header: http.Header{},
+				scope:  auth.ScopeCreate,
+				store:  &stubRateLimitStore{},
+			},
+			want: struct {
+				code     int
+				keyName  string
+				storeKey string
+				limit    db.RateLimit
+			}{
+				code: http.StatusUnauthorized,
+			},
+		},
+		{
+			name: "invalid api key",
+			input: struct {
+				header http.Header
+				scope  auth.Scope
+				store  *stubRateLimitStore
+			}{
+				header: http.Header{"X-Api-Key": []string{"key3"}},
+				scope:  auth.ScopeCreate,
+				store:  &stubRateLimitStore{},
+			},
+			want: struct {
+				code     int
+				keyName  string
+				storeKey string
+				limit    db.RateLimit
+			}{
+				code: http.StatusUnauthorized,
+			},
+		},
+		{
+			name: "insufficient scope",
+			input: struct {
+				header http.Header
+				scope  auth.Scope
+				store  *stubRateLimitStore
+			}{
+				header: http.Header{"X-Api-Key": []string{"key1"}},
+				scope:  auth.ScopeDelete,
+				store:  &stubRateLimitStore{},
+			},
+			want: struct {
+				code     int
+				keyName  string
+				storeKey string
+				limit    db.RateLimit
+			}{
+				code: http.StatusForbidden,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotKeyName string
+			handler := APIKey(keys, test.input.scope, WithAPIKeyStore(test.input.store))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				key, _ := auth.FromContext(r.Context())
+				gotKeyName = key.Name
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/secrets", nil)
+			req.Header = test.input.header
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if test.want.code != rr.Code {
+				t.Errorf("APIKey() = unexpected status code, want: %d, got: %d\n", test.want.code, rr.Code)
+			}
+
+			if test.want.keyName != gotKeyName {
+				t.Errorf("APIKey() = unexpected key, want: %s, got: %s\n", test.want.keyName, gotKeyName)
+			}
+
+			if test.want.storeKey != test.input.store.key {
+				t.Errorf("APIKey() = unexpected store key, want: %s, got: %s\n", test.want.storeKey, test.input.store.key)
+			}
+
+			if diff := cmp.Diff(test.want.limit, test.input.store.limit); diff != "" {
+				t.Errorf("APIKey() = unexpected limit (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
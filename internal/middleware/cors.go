@@ -8,7 +8,7 @@ var (
 	// corsAllowMethods is the allowed methods for CORS.
 	corsAllowMethods = "GET, POST, DELETE"
 	// corsAllowHeaders is the allowed headers for CORS.
-	corsAllowHeaders = "Content-Type, Passphrase"
+	corsAllowHeaders = "Content-Type, Passphrase, Second-Factor-Code"
 )
 
 // CORS is a middleware that sets the CORS headers.
@@ -37,7 +37,7 @@ func TestCORS(t *testing.T) {
 				headers: http.Header{
 					"Access-Control-Allow-Origin":  []string{"http://localhost:3000"},
 					"Access-Control-Allow-Methods": []string{"GET, POST, DELETE"},
-					"Access-Control-Allow-Headers": []string{"Content-Type, Passphrase"},
+					"Access-Control-Allow-Headers": []string{"Content-Type, Passphrase, Second-Factor-Code"},
 				},
 			},
 		},
@@ -58,7 +58,7 @@ func TestCORS(t *testing.T) {
 				headers: http.Header{
 					"Access-Control-Allow-Origin":  []string{"http://localhost:3000"},
 					"Access-Control-Allow-Methods": []string{"GET, POST, DELETE"},
-					"Access-Control-Allow-Headers": []string{"Content-Type, Passphrase"},
+					"Access-Control-Allow-Headers": []string{"Content-Type, Passphrase, Second-Factor-Code"},
 				},
 			},
 		},
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/RedeployAB/burnit/internal/log"
+	"github.com/RedeployAB/burnit/internal/tracing"
 )
 
 // loggingResponseWriter is a wrapper around an http.ResponseWriter that keeps
@@ -48,10 +49,15 @@ func Logger(log log.Logger) func(next http.Handler) http.Handler {
 
 			sourceIP := getSourceIP(r.Context())
 			if sourceIP == SourceIPNotAvailable {
-				sourceIP = resolveIP(r)
+				// Without the SourceIP middleware no proxies are trusted.
+				sourceIP = resolveIP(r, nil)
 			}
 
-			log.Info("Request received.", "type", "request", "status", lw.status, "path", maskSecretHash(r.URL.Path), "method", r.Method, "requestId", requestID, "sourceIp", sourceIP)
+			args := []any{"type", "request", "status", lw.status, "path", maskSecretHash(r.URL.Path), "method", r.Method, "requestId", requestID, "sourceIp", sourceIP}
+			if traceID := tracing.TraceID(r.Context()); len(traceID) > 0 {
+				args = append(args, "traceId", traceID)
+			}
+			log.Info("Request received.", args...)
 		})
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestLogger(t *testing.T) {
@@ -31,7 +32,7 @@ func TestLogger(t *testing.T) {
 				status: http.StatusOK,
 				req: func() *http.Request {
 					req := httptest.NewRequest("GET", "/", nil)
-					req.Header.Set("Forwarded", "for=192.168.1.1:1234")
+					req.RemoteAddr = "192.168.1.1:1234"
 					return req
 				},
 			},
@@ -46,12 +47,33 @@ func TestLogger(t *testing.T) {
 				status: 0,
 				req: func() *http.Request {
 					req := httptest.NewRequest("GET", "/", nil)
-					req.Header.Set("Forwarded", "for=192.168.1.1:1234")
+					req.RemoteAddr = "192.168.1.1:1234"
 					return req
 				},
 			},
 			want: []string{"Request received.", "type", "request", "status", "200", "path", "/", "method", "GET", "requestId", "test", "sourceIp", "192.168.1.1"},
 		},
+		{
+			name: "log requests with trace ID",
+			input: struct {
+				status int
+				req    func() *http.Request
+			}{
+				status: http.StatusOK,
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "192.168.1.1:1234"
+					traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+					spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+					ctx := trace.ContextWithSpanContext(req.Context(), trace.NewSpanContext(trace.SpanContextConfig{
+						TraceID: traceID,
+						SpanID:  spanID,
+					}))
+					return req.WithContext(ctx)
+				},
+			},
+			want: []string{"Request received.", "type", "request", "status", "200", "path", "/", "method", "GET", "requestId", "test", "sourceIp", "192.168.1.1", "traceId", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		},
 	}
 
 	for _, test := range tests {
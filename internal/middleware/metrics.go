@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/metrics"
+)
+
+const (
+	// routeUnmatched is the route label for requests that did not match a route.
+	routeUnmatched = "unmatched"
+)
+
+// Metrics is a middleware that records the count and duration of requests
+// per route. The route is the pattern of the matched handler to keep the
+// number of label values bounded.
+func Metrics(m *metrics.Metrics) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			lw := &loggingResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(lw, r)
+
+			status := lw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			m.ObserveRequest(r.Method, routeFromPattern(r.Pattern), status, time.Since(start))
+		})
+	}
+}
+
+// routeFromPattern returns the route from a pattern, with the
+// method removed.
+func routeFromPattern(pattern string) string {
+	if len(pattern) == 0 {
+		return routeUnmatched
+	}
+	if i := strings.Index(pattern, " "); i >= 0 {
+		return pattern[i+1:]
+	}
+	return pattern
+}
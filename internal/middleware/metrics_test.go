@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RedeployAB/burnit/internal/metrics"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMetrics(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			method string
+			target string
+		}
+		want string
+	}{
+		{
+			name: "record request with route",
+			input: struct {
+				method string
+				target string
+			}{
+				method: http.MethodGet,
+				target: "/secrets/1",
+			},
+			want: `burnit_http_requests_total{method="GET",route="/secrets/{id}",status="200"} 1`,
+		},
+		{
+			name: "record request with status",
+			input: struct {
+				method string
+				target string
+			}{
+				method: http.MethodPost,
+				target: "/secrets",
+			},
+			want: `burnit_http_requests_total{method="POST",route="/secrets",status="201"} 1`,
+		},
+		{
+			name: "record unmatched request",
+			input: struct {
+				method string
+				target string
+			}{
+				method: http.MethodGet,
+				target: "/unknown",
+			},
+			want: `burnit_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := metrics.New()
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /secrets/{id}", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("secret"))
+			})
+			mux.HandleFunc("POST /secrets", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+			})
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(test.input.method, test.input.target, nil)
+			Metrics(m)(mux).ServeHTTP(rr, req)
+
+			got := testScrapeMetrics(t, m)
+			if !strings.Contains(got, test.want) {
+				t.Errorf("Metrics() = expected %q in metrics, got:\n%s\n", test.want, got)
+			}
+		})
+	}
+}
+
+func TestRouteFromPattern(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "pattern with method",
+			input: "GET /secrets/{id}",
+			want:  "/secrets/{id}",
+		},
+		{
+			name:  "pattern without method",
+			input: "/ui/",
+			want:  "/ui/",
+		},
+		{
+			name:  "empty pattern",
+			input: "",
+			want:  routeUnmatched,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := routeFromPattern(test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("routeFromPattern() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+// testScrapeMetrics returns the metrics in text format.
+func testScrapeMetrics(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	b, err := io.ReadAll(rr.Body)
+	if err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+	return string(b)
+}
@@ -5,11 +5,14 @@ import (
 	"math"
 	"net/http"
 	"strconv"
-	"sync"
+	"strings"
 	"time"
 
 	"github.com/RedeployAB/burnit/internal/api"
-	"golang.org/x/time/rate"
+	"github.com/RedeployAB/burnit/internal/auth"
+	"github.com/RedeployAB/burnit/internal/db"
+	"github.com/RedeployAB/burnit/internal/db/inmem"
+	"github.com/RedeployAB/burnit/internal/metrics"
 )
 
 var (
@@ -26,81 +29,58 @@ const (
 	defaultRateLimiterTTL = 5 * time.Minute
 	// defaultRateLimiterCleanupInterval is the default rate limiter cleanup interval.
 	defaultRateLimiterCleanupInterval = 10 * time.Second
+	// defaultRateLimitPolicy is the name of the policy of the RateLimiter middleware.
+	defaultRateLimitPolicy = "default"
+	// metricsStoreRateLimit is the store label for rate limiter metrics.
+	metricsStoreRateLimit = "ratelimit"
 )
 
-// rateLimiter represents a rate limiter for an IP address.
-type rateLimiter struct {
-	limiter *rate.Limiter
-	created time.Time
-}
-
-// rateLimiters contains a map of rate limiters for each IP address,
-// and the rate limit options.
-type rateLimiters struct {
-	limiters        map[string]*rateLimiter
-	rate            rate.Limit
-	burst           int
-	ttl             time.Duration
-	cleanupInterval time.Duration
-	stop            chan struct{}
-	mu              sync.Mutex
-}
-
-// get a rate limiter for the given IP address. If a rate limiter does not exist for the IP address,
-// a new one is created.
-func (c *rateLimiters) get(ip string) *rateLimiter {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// RateLimitKey is what requests are rate limited by.
+type RateLimitKey string
 
-	rl, ok := c.limiters[ip]
-	if !ok {
-		c.limiters[ip] = &rateLimiter{
-			limiter: rate.NewLimiter(c.rate, c.burst),
-			created: time.Now(),
-		}
-		return c.limiters[ip]
-	}
-	return rl
-}
+const (
+	// RateLimitKeyIP rate limits requests by source IP.
+	RateLimitKeyIP RateLimitKey = "ip"
+	// RateLimitKeyAPIKey rate limits requests by API key.
+	RateLimitKeyAPIKey RateLimitKey = "apiKey"
+	// RateLimitKeySecret rate limits requests by secret ID.
+	RateLimitKeySecret RateLimitKey = "secret"
+)
 
-// close the rate limiters and stop the cleanup goroutine.
-func (c *rateLimiters) close() error {
-	c.stop <- struct{}{}
-	return nil
-}
-
-// cleanup removes rate limiters that have expired.
-func (c *rateLimiters) cleanup() {
-	for {
-		select {
-		case <-time.After(c.cleanupInterval):
-			c.mu.Lock()
-			for ip, limiter := range c.limiters {
-				if time.Since(limiter.created) > c.ttl {
-					delete(c.limiters, ip)
-				}
-			}
-			c.mu.Unlock()
-		case <-c.stop:
-			close(c.stop)
-			return
-		}
-	}
+// RateLimitPolicy is a named rate limit.
+type RateLimitPolicy struct {
+	// Name of the policy. Requests are counted separately per policy.
+	Name string
+	// Rate is the average number of requests per second.
+	Rate float64
+	// Burst is the maximum number of requests in a burst.
+	Burst int
+	// Key is what requests are rate limited by. Default: ip.
+	Key RateLimitKey
+	// FailuresOnly counts only failed requests (responses with status
+	// 401 Unauthorized) against the limit. Requests are rejected when
+	// the limit of failures has been reached. Every request is counted
+	// before it is handled, and refunded if it did not fail, so that
+	// concurrent requests cannot exceed the limit.
+	FailuresOnly bool
 }
 
 // rateLimiterOptions contains the options for the rate limiter middleware.
 type rateLimiterOptions struct {
-	rate            rate.Limit
+	rate            float64
 	burst           int
 	ttl             time.Duration
 	cleanupInterval time.Duration
+	store           db.RateLimitStore
+	rejected        http.Handler
+	metrics         *metrics.Metrics
 }
 
 // rateLimiterOption is a function that configures the rate limiter options.
 type rateLimiterOption func(o *rateLimiterOptions)
 
 // RateLimiter is a middleware that limits the number of requests that can be made to the server
-// on a per-IP basis.
+// on a per-IP basis. The limits are kept in the configured store.
 func RateLimiter(options ...rateLimiterOption) (func(next http.Handler) http.Handler, func() error) {
 	opts := rateLimiterOptions{
 		rate:            defaultRateLimiterRate,
@@ -112,42 +92,161 @@ func RateLimiter(options ...rateLimiterOption) (func(next http.Handler) http.Han
 		option(&opts)
 	}
 
-	rateLimiters := &rateLimiters{
-		limiters:        make(map[string]*rateLimiter),
-		rate:            opts.rate,
-		burst:           opts.burst,
-		ttl:             opts.ttl,
-		cleanupInterval: opts.cleanupInterval,
-		stop:            make(chan struct{}),
-		mu:              sync.Mutex{},
+	store := opts.store
+	if store == nil {
+		store = inmem.NewRateLimitStore(func(o *inmem.RateLimitStoreOptions) {
+			o.TTL = opts.ttl
+			o.CleanupInterval = opts.cleanupInterval
+		})
+	}
+
+	policy := RateLimitPolicy{
+		Name:  defaultRateLimitPolicy,
+		Rate:  opts.rate,
+		Burst: opts.burst,
+		Key:   RateLimitKeyIP,
+	}
+	return rateLimit(store, policy, &opts), store.Close
+}
+
+// RateLimit is a middleware that limits the number of requests according to the
+// policy. The limits are kept in the provided store, which can be shared between
+// policies. Rejected requests get the status 429 Too Many Requests.
+func RateLimit(store db.RateLimitStore, policy RateLimitPolicy, options ...rateLimiterOption) func(next http.Handler) http.Handler {
+	opts := rateLimiterOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+	if policy.Burst <= 0 {
+		policy.Burst = 1
+	}
+	if len(policy.Key) == 0 {
+		policy.Key = RateLimitKeyIP
+	}
+	return rateLimit(store, policy, &opts)
+}
+
+// rateLimit returns a middleware that limits requests according to the policy.
+func rateLimit(store db.RateLimitStore, policy RateLimitPolicy, opts *rateLimiterOptions) func(next http.Handler) http.Handler {
+	limit := db.RateLimit{Rate: policy.Rate, Burst: policy.Burst}
+	rejected := opts.rejected
+	if rejected == nil {
+		rejected = http.HandlerFunc(tooManyRequests)
 	}
 
-	go rateLimiters.cleanup()
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			sourceIP := getSourceIP(r.Context())
-			if sourceIP == SourceIPNotAvailable {
-				sourceIP = resolveIP(r)
+			key := rateLimitKey(r, policy)
+
+			result, err := store.Allow(r.Context(), key, limit)
+			if err != nil {
+				// Requests are allowed if the store is unavailable, to not
+				// take the service down together with the store.
+				opts.metrics.StoreError(metricsStoreRateLimit, "allow")
+				next.ServeHTTP(w, r)
+				return
 			}
 
-			rl := rateLimiters.get(sourceIP)
-			if !rl.limiter.Allow() {
-				seconds := int(math.Ceil(rl.limiter.Reserve().DelayFrom(time.Now()).Seconds()))
-				w.Header().Set("Retry-After", strconv.Itoa(seconds))
-				w.WriteHeader(http.StatusTooManyRequests)
-				w.Write(api.Error{StatusCode: http.StatusTooManyRequests, Err: ErrTooManyRequests.Error()}.JSON())
+			if !result.Allowed {
+				opts.metrics.RateLimiterRejection()
+				setRateLimitHeaders(w.Header(), limit, result)
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				rejected.ServeHTTP(w, r)
 				return
 			}
-			next.ServeHTTP(w, r)
+
+			if !policy.FailuresOnly {
+				setRateLimitHeaders(w.Header(), limit, result)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			lw := &loggingResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(lw, r)
+			if lw.status == http.StatusUnauthorized {
+				return
+			}
+			if err := store.Refund(r.Context(), key, limit); err != nil {
+				opts.metrics.StoreError(metricsStoreRateLimit, "refund")
+			}
 		})
-	}, rateLimiters.close
+	}
+}
+
+// tooManyRequests writes a 429 Too Many Requests response.
+func tooManyRequests(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	w.Write(api.Error{StatusCode: http.StatusTooManyRequests, Err: ErrTooManyRequests.Error()}.JSON())
+}
+
+// rateLimitKey returns the key for the request according to the policy.
+// Requests are keyed by API key only if they have been authenticated with
+// it, by the name of the key. A key presented by the request is not used
+// otherwise, since a new random key would get a new limit. Keys by API key
+// and secret fall back to the source IP if the request has no authenticated
+// API key or secret ID.
+func rateLimitKey(r *http.Request, policy RateLimitPolicy) string {
+	switch policy.Key {
+	case RateLimitKeyAPIKey:
+		if key, ok := auth.FromContext(r.Context()); ok {
+			return policy.Name + ":apikey:" + key.Name
+		}
+	case RateLimitKeySecret:
+		id := r.PathValue("id")
+		if len(id) == 0 {
+			id = r.FormValue("id")
+		}
+		if len(id) > 0 {
+			return policy.Name + ":secret:" + id
+		}
+	}
+
+	sourceIP := getSourceIP(r.Context())
+	if sourceIP == SourceIPNotAvailable {
+		// Without the SourceIP middleware no proxies are trusted.
+		sourceIP = resolveIP(r, nil)
+	}
+	return policy.Name + ":ip:" + sourceIP
+}
+
+// apiKeyFromRequest returns the API key from the X-API-Key header, or
+// from the Authorization header with the Bearer scheme.
+func apiKeyFromRequest(r *http.Request) string {
+	if apiKey := r.Header.Get("X-API-Key"); len(apiKey) > 0 {
+		return apiKey
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// setRateLimitHeaders sets the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers. If the headers have already been set by another
+// policy, they are only replaced if fewer requests remain for this policy.
+func setRateLimitHeaders(header http.Header, limit db.RateLimit, result db.RateLimitResult) {
+	if current := header.Get("RateLimit-Remaining"); len(current) > 0 {
+		if remaining, err := strconv.Atoi(current); err == nil && remaining <= result.Remaining {
+			return
+		}
+	}
+	header.Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+	header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+}
+
+// ceilSeconds returns the duration in whole seconds, rounded up.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
 }
 
 // WithRateLimiterRate sets the rate limiter rate.
 func WithRateLimiterRate(r float64) rateLimiterOption {
 	return func(o *rateLimiterOptions) {
 		if r != 0 {
-			o.rate = rate.Limit(r)
+			o.rate = r
 		}
 	}
 }
@@ -178,3 +277,29 @@ func WithRateLimiterCleanupInterval(interval time.Duration) rateLimiterOption {
 		}
 	}
 }
+
+// WithRateLimiterStore sets the store for the rate limiter. Defaults
+// to an in-memory store, local to the process.
+func WithRateLimiterStore(store db.RateLimitStore) rateLimiterOption {
+	return func(o *rateLimiterOptions) {
+		if store != nil {
+			o.store = store
+		}
+	}
+}
+
+// WithRateLimiterRejectedHandler sets the handler for rejected requests.
+// The Retry-After and RateLimit headers are set before the handler is
+// called. Defaults to a JSON error response.
+func WithRateLimiterRejectedHandler(h http.Handler) rateLimiterOption {
+	return func(o *rateLimiterOptions) {
+		o.rejected = h
+	}
+}
+
+// WithRateLimiterMetrics sets the metrics for the rate limiter.
+func WithRateLimiterMetrics(m *metrics.Metrics) rateLimiterOption {
+	return func(o *rateLimiterOptions) {
+		o.metrics = m
+	}
+}
@@ -0,0 +1,612 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/auth"
+	"github.com/RedeployAB/burnit/internal/db"
+	"github.com/RedeployAB/burnit/internal/db/inmem"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRateLimiter(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input *stubRateLimitStore
+		want  struct {
+			code   int
+			header http.Header
+			key    string
+			limit  db.RateLimit
+		}
+	}{
+		{
+			name: "allowed",
+			input: &stubRateLimitStore{
+				result: db.RateLimitResult{Allowed: true, Remaining: 2, ResetAfter: 1500 * time.Millisecond},
+			},
+			want: struct {
+				code   int
+				header http.Header
+				key    string
+				limit  db.RateLimit
+			}{
+				code: http.StatusOK,
+				header: http.Header{
+					"Ratelimit-Limit":     []string{"3"},
+					"Ratelimit-Remaining": []string{"2"},
+					"Ratelimit-Reset":     []string{"2"},
+				},
+				key:   "default:ip:192.168.1.1",
+				limit: db.RateLimit{Rate: 1, Burst: 3},
+			},
+		},
+		{
+			name: "not allowed",
+			input: &stubRateLimitStore{
+				result: db.RateLimitResult{RetryAfter: 1500 * time.Millisecond, ResetAfter: 3 * time.Second},
+			},
+			want: struct {
+				code   int
+				header http.Header
+				key    string
+				limit  db.RateLimit
+			}{
+				code: http.StatusTooManyRequests,
+				header: http.Header{
+					"Content-Type":        []string{"application/json"},
+					"Retry-After":         []string{"2"},
+					"Ratelimit-Limit":     []string{"3"},
+					"Ratelimit-Remaining": []string{"0"},
+					"Ratelimit-Reset":     []string{"3"},
+				},
+				key:   "default:ip:192.168.1.1",
+				limit: db.RateLimit{Rate: 1, Burst: 3},
+			},
+		},
+		{
+			name: "store error",
+			input: &stubRateLimitStore{
+				err: errors.New("error"),
+			},
+			want: struct {
+				code   int
+				header http.Header
+				key    string
+				limit  db.RateLimit
+			}{
+				code:   http.StatusOK,
+				header: http.Header{},
+				key:    "default:ip:192.168.1.1",
+				limit:  db.RateLimit{Rate: 1, Burst: 3},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mw, closeRateLimiter := RateLimiter(WithRateLimiterStore(test.input))
+			defer closeRateLimiter()
+
+			handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "192.168.1.1:1234"
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if diff := cmp.Diff(test.want.code, rr.Code); diff != "" {
+				t.Errorf("RateLimiter() = unexpected status code (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.want.header, rr.Header()); diff != "" {
+				t.Errorf("RateLimiter() = unexpected headers (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.want.key, test.input.key); diff != "" {
+				t.Errorf("RateLimiter() = unexpected key (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.want.limit, test.input.limit); diff != "" {
+				t.Errorf("RateLimiter() = unexpected limit (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			store   *stubRateLimitStore
+			policy  RateLimitPolicy
+			req     func() *http.Request
+			status  int
+			options []rateLimiterOption
+		}
+		want struct {
+			code    int
+			key     string
+			limit   db.RateLimit
+			allows  int
+			refunds int
+		}
+	}{
+		{
+			name: "key by IP",
+			input: struct {
+				store   *stubRateLimitStore
+				policy  RateLimitPolicy
+				req     func() *http.Request
+				status  int
+				options []rateLimiterOption
+			}{
+				store:  &stubRateLimitStore{result: db.RateLimitResult{Allowed: true}},
+				policy: RateLimitPolicy{Name: "create", Rate: 1},
+				req: func() *http.Request {
+					req := httptest.NewRequest(http.MethodPost, "/secrets", nil)
+					req.RemoteAddr = "192.168.1.1:1234"
+					return req
+				},
+				status: http.StatusCreated,
+			},
+			want: struct {
+				code    int
+				key     string
+				limit   db.RateLimit
+				allows  int
+				refunds int
+			}{
+				code:   http.StatusCreated,
+				key:    "create:ip:192.168.1.1",
+				limit:  db.RateLimit{Rate: 1, Burst: 1},
+				allows: 1,
+			},
+		},
+		{
+			name: "key by authenticated API key",
+			input: struct {
+				store   *stubRateLimitStore
+				policy  RateLimitPolicy
+				req     func() *http.Request
+				status  int
+				options []rateLimiterOption
+			}{
+				store:  &stubRateLimitStore{result: db.RateLimitResult{Allowed: true}},
+				policy: RateLimitPolicy{Name: "create", Rate: 1, Burst: 5, Key: RateLimitKeyAPIKey},
+				req: func() *http.Request {
+					req := httptest.NewRequest(http.MethodPost, "/secrets", nil)
+					req.Header.Set("X-API-Key", "key")
+					return req.WithContext(auth.NewContext(req.Context(), auth.APIKey{Name: "ci"}))
+				},
+				status: http.StatusCreated,
+			},
+			want: struct {
+				code    int
+				key     string
+				limit   db.RateLimit
+				allows  int
+				refunds int
+			}{
+				code:   http.StatusCreated,
+				key:    "create:apikey:ci",
+				limit:  db.RateLimit{Rate: 1, Burst: 5},
+				allows: 1,
+			},
+		},
+		{
+			name: "key by API key - unauthenticated API key",
+			input: struct {
+				store   *stubRateLimitStore
+				policy  RateLimitPolicy
+				req     func() *http.Request
+				status  int
+				options []rateLimiterOption
+			}{
+				store:  &stubRateLimitStore{result: db.RateLimitResult{Allowed: true}},
+				policy: RateLimitPolicy{Name: "create", Rate: 1, Burst: 5, Key: RateLimitKeyAPIKey},
+				req: func() *http.Request {
+					req := httptest.NewRequest(http.MethodPost, "/secrets", nil)
+					req.RemoteAddr = "192.168.1.1:1234"
+					req.Header.Set("Authorization", "Bearer key")
+					return req
+				},
+				status: http.StatusCreated,
+			},
+			want: struct {
+				code    int
+				key     string
+				limit   db.RateLimit
+				allows  int
+				refunds int
+			}{
+				code:   http.StatusCreated,
+				key:    "create:ip:192.168.1.1",
+				limit:  db.RateLimit{Rate: 1, Burst: 5},
+				allows: 1,
+			},
+		},
+		{
+			name: "key by API key - no API key",
+			input: struct {
+				store   *stubRateLimitStore
+				policy  RateLimitPolicy
+				req     func() *http.Request
+				status  int
+				options []rateLimiterOption
+			}{
+				store:  &stubRateLimitStore{result: db.RateLimitResult{Allowed: true}},
+				policy: RateLimitPolicy{Name: "create", Rate: 1, Burst: 5, Key: RateLimitKeyAPIKey},
+				req: func() *http.Request {
+					req := httptest.NewRequest(http.MethodPost, "/secrets", nil)
+					req.RemoteAddr = "192.168.1.1:1234"
+					return req
+				},
+				status: http.StatusCreated,
+			},
+			want: struct {
+				code    int
+				key     string
+				limit   db.RateLimit
+				allows  int
+				refunds int
+			}{
+				code:   http.StatusCreated,
+				key:    "create:ip:192.168.1.1",
+				limit:  db.RateLimit{Rate: 1, Burst: 5},
+				allows: 1,
+			},
+		},
+		{
+			name: "key by secret from form",
+			input: struct {
+				store   *stubRateLimitStore
+				policy  RateLimitPolicy
+				req     func() *http.Request
+				status  int
+				options []rateLimiterOption
+			}{
+				store:  &stubRateLimitStore{result: db.RateLimitResult{Allowed: true}},
+				policy: RateLimitPolicy{Name: "ui", Rate: 1, Burst: 5, Key: RateLimitKeySecret},
+				req: func() *http.Request {
+					req := httptest.NewRequest(http.MethodPost, "/ui/handlers/secret/get", strings.NewReader("id=1"))
+					req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+					return req
+				},
+				status: http.StatusOK,
+			},
+			want: struct {
+				code    int
+				key     string
+				limit   db.RateLimit
+				allows  int
+				refunds int
+			}{
+				code:   http.StatusOK,
+				key:    "ui:secret:1",
+				limit:  db.RateLimit{Rate: 1, Burst: 5},
+				allows: 1,
+			},
+		},
+		{
+			name: "failures only - success",
+			input: struct {
+				store   *stubRateLimitStore
+				policy  RateLimitPolicy
+				req     func() *http.Request
+				status  int
+				options []rateLimiterOption
+			}{
+				store:  &stubRateLimitStore{result: db.RateLimitResult{Allowed: true}},
+				policy: RateLimitPolicy{Name: "failedPassphrase", Rate: 1, Burst: 3, FailuresOnly: true},
+				req: func() *http.Request {
+					req := httptest.NewRequest(http.MethodGet, "/secrets/1", nil)
+					req.RemoteAddr = "192.168.1.1:1234"
+					return req
+				},
+				status: http.StatusOK,
+			},
+			want: struct {
+				code    int
+				key     string
+				limit   db.RateLimit
+				allows  int
+				refunds int
+			}{
+				code:    http.StatusOK,
+				key:     "failedPassphrase:ip:192.168.1.1",
+				limit:   db.RateLimit{Rate: 1, Burst: 3},
+				allows:  1,
+				refunds: 1,
+			},
+		},
+		{
+			name: "failures only - failure",
+			input: struct {
+				store   *stubRateLimitStore
+				policy  RateLimitPolicy
+				req     func() *http.Request
+				status  int
+				options []rateLimiterOption
+			}{
+				store:  &stubRateLimitStore{result: db.RateLimitResult{Allowed: true}},
+				policy: RateLimitPolicy{Name: "failedPassphrase", Rate: 1, Burst: 3, FailuresOnly: true},
+				req: func() *http.Request {
+					req := httptest.NewRequest(http.MethodGet, "/secrets/1", nil)
+					req.RemoteAddr = "192.168.1.1:1234"
+					return req
+				},
+				status: http.StatusUnauthorized,
+			},
+			want: struct {
+				code    int
+				key     string
+				limit   db.RateLimit
+				allows  int
+				refunds int
+			}{
+				code:   http.StatusUnauthorized,
+				key:    "failedPassphrase:ip:192.168.1.1",
+				limit:  db.RateLimit{Rate: 1, Burst: 3},
+				allows: 1,
+			},
+		},
+		{
+			name: "failures only - limit reached",
+			input: struct {
+				store   *stubRateLimitStore
+				policy  RateLimitPolicy
+				req     func() *http.Request
+				status  int
+				options []rateLimiterOption
+			}{
+				store:  &stubRateLimitStore{result: db.RateLimitResult{RetryAfter: time.Second}},
+				policy: RateLimitPolicy{Name: "failedPassphrase", Rate: 1, Burst: 3, FailuresOnly: true},
+				req: func() *http.Request {
+					req := httptest.NewRequest(http.MethodGet, "/secrets/1", nil)
+					req.RemoteAddr = "192.168.1.1:1234"
+					return req
+				},
+				status: http.StatusUnauthorized,
+			},
+			want: struct {
+				code    int
+				key     string
+				limit   db.RateLimit
+				allows  int
+				refunds int
+			}{
+				code:   http.StatusTooManyRequests,
+				key:    "failedPassphrase:ip:192.168.1.1",
+				limit:  db.RateLimit{Rate: 1, Burst: 3},
+				allows: 1,
+			},
+		},
+		{
+			name: "rejected with handler",
+			input: struct {
+				store   *stubRateLimitStore
+				policy  RateLimitPolicy
+				req     func() *http.Request
+				status  int
+				options []rateLimiterOption
+			}{
+				store:  &stubRateLimitStore{result: db.RateLimitResult{RetryAfter: time.Second}},
+				policy: RateLimitPolicy{Name: "ui", Rate: 1, Burst: 3},
+				req: func() *http.Request {
+					req := httptest.NewRequest(http.MethodPost, "/ui/handlers/secret/create", nil)
+					req.RemoteAddr = "192.168.1.1:1234"
+					return req
+				},
+				status: http.StatusCreated,
+				options: []rateLimiterOption{
+					WithRateLimiterRejectedHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(http.StatusServiceUnavailable)
+					})),
+				},
+			},
+			want: struct {
+				code    int
+				key     string
+				limit   db.RateLimit
+				allows  int
+				refunds int
+			}{
+				code:   http.StatusServiceUnavailable,
+				key:    "ui:ip:192.168.1.1",
+				limit:  db.RateLimit{Rate: 1, Burst: 3},
+				allows: 1,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := RateLimit(test.input.store, test.input.policy, test.input.options...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.input.status)
+			}))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, test.input.req())
+
+			if diff := cmp.Diff(test.want.code, rr.Code); diff != "" {
+				t.Errorf("RateLimit() = unexpected status code (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.want.key, test.input.store.key); diff != "" {
+				t.Errorf("RateLimit() = unexpected key (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.want.limit, test.input.store.limit); diff != "" {
+				t.Errorf("RateLimit() = unexpected limit (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.want.allows, test.input.store.allows); diff != "" {
+				t.Errorf("RateLimit() = unexpected number of counted requests (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.want.refunds, test.input.store.refunds); diff != "" {
+				t.Errorf("RateLimit() = unexpected number of refunded requests (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestRateLimit_FailuresOnlyConcurrent(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			status   int
+			requests int
+		}
+		want struct {
+			handled  int
+			rejected int
+		}
+	}{
+		{
+			name: "failures",
+			input: struct {
+				status   int
+				requests int
+			}{
+				status:   http.StatusUnauthorized,
+				requests: 10,
+			},
+			want: struct {
+				handled  int
+				rejected int
+			}{
+				handled:  3,
+				rejected: 7,
+			},
+		},
+		{
+			name: "successes",
+			input: struct {
+				status   int
+				requests int
+			}{
+				status:   http.StatusOK,
+				requests: 3,
+			},
+			want: struct {
+				handled  int
+				rejected int
+			}{
+				handled: 3,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := inmem.NewRateLimitStore()
+			defer store.Close()
+
+			var handled atomic.Int32
+			release := make(chan struct{})
+			policy := RateLimitPolicy{Name: "failedPassphrase", Rate: 0.001, Burst: 3, FailuresOnly: true}
+			handler := RateLimit(store, policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handled.Add(1)
+				// Hold the request until all requests have been made, so
+				// that they are handled at the same time.
+				<-release
+				w.WriteHeader(test.input.status)
+			}))
+
+			var wg sync.WaitGroup
+			var rejected atomic.Int32
+			for range test.input.requests {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					req := httptest.NewRequest(http.MethodGet, "/secrets/1", nil)
+					req.RemoteAddr = "192.168.1.1:1234"
+					rr := httptest.NewRecorder()
+					handler.ServeHTTP(rr, req)
+					if rr.Code == http.StatusTooManyRequests {
+						rejected.Add(1)
+					}
+				}()
+			}
+			for handled.Load()+rejected.Load() < int32(test.input.requests) {
+				time.Sleep(time.Millisecond)
+			}
+			close(release)
+			wg.Wait()
+
+			if diff := cmp.Diff(test.want.handled, int(handled.Load())); diff != "" {
+				t.Errorf("RateLimit() = unexpected number of handled requests (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.want.rejected, int(rejected.Load())); diff != "" {
+				t.Errorf("RateLimit() = unexpected number of rejected requests (-want +got)\n%s\n", diff)
+			}
+
+			result, _ := store.Allow(context.Background(), "failedPassphrase:ip:192.168.1.1", db.RateLimit{Rate: policy.Rate, Burst: policy.Burst})
+			if diff := cmp.Diff(test.want.rejected == 0, result.Allowed); diff != "" {
+				t.Errorf("RateLimit() = unexpected limit after requests (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestSetRateLimitHeaders(t *testing.T) {
+	header := http.Header{}
+	limit := db.RateLimit{Rate: 1, Burst: 10}
+
+	setRateLimitHeaders(header, limit, db.RateLimitResult{Remaining: 5, ResetAfter: 5 * time.Second})
+	setRateLimitHeaders(header, db.RateLimit{Rate: 1, Burst: 3}, db.RateLimitResult{Remaining: 2, ResetAfter: time.Second})
+	setRateLimitHeaders(header, limit, db.RateLimitResult{Remaining: 8, ResetAfter: 2 * time.Second})
+
+	want := http.Header{
+		"Ratelimit-Limit":     []string{"3"},
+		"Ratelimit-Remaining": []string{"2"},
+		"Ratelimit-Reset":     []string{"1"},
+	}
+
+	if diff := cmp.Diff(want, header); diff != "" {
+		t.Errorf("setRateLimitHeaders() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
+
+type stubRateLimitStore struct {
+	key     string
+	limit   db.RateLimit
+	result  db.RateLimitResult
+	err     error
+	allows  int
+	refunds int
+}
+
+func (s *stubRateLimitStore) Allow(ctx context.Context, key string, limit db.RateLimit) (db.RateLimitResult, error) {
+	s.key = key
+	s.limit = limit
+	s.allows++
+	if s.err != nil {
+		return db.RateLimitResult{}, s.err
+	}
+	return s.result, nil
+}
+
+func (s *stubRateLimitStore) Refund(ctx context.Context, key string, limit db.RateLimit) error {
+	s.key = key
+	s.limit = limit
+	s.refunds++
+	return s.err
+}
+
+func (s *stubRateLimitStore) Close() error {
+	return nil
+}
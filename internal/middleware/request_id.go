@@ -10,15 +10,75 @@ const (
 	contextKeyRequestID contextKey = 0
 )
 
-// RequestID is a middleware that sets a unique request ID in the request context.
-func RequestID() func(next http.Handler) http.Handler {
+const (
+	// DefaultRequestIDHeader is the default header for request IDs.
+	DefaultRequestIDHeader = "X-Request-ID"
+	// requestIDMaxLength is the maximum length of an incoming request ID.
+	requestIDMaxLength = 128
+)
+
+// RequestIDOptions represents the options for the RequestID middleware.
+type RequestIDOptions struct {
+	// Header is the header to read incoming request IDs from and to
+	// set the request ID on in responses.
+	Header string
+	// TrustedProxies are the proxies that are trusted to set the
+	// request ID. Incoming request IDs from other peers are ignored.
+	TrustedProxies TrustedProxies
+}
+
+// RequestIDOption is a function that sets an option for the RequestID middleware.
+type RequestIDOption func(o *RequestIDOptions)
+
+// RequestID is a middleware that sets a unique request ID in the request context
+// and on the response. An incoming request ID is used if the request is from a
+// trusted proxy and the ID is valid, otherwise a new request ID is generated.
+func RequestID(options ...RequestIDOption) func(next http.Handler) http.Handler {
+	opts := RequestIDOptions{
+		Header: DefaultRequestIDHeader,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+	if len(opts.Header) == 0 {
+		opts.Header = DefaultRequestIDHeader
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			next.ServeHTTP(w, r.WithContext(setRequestID(r.Context(), newUUID())))
+			var id string
+			if opts.TrustedProxies.contains(r.RemoteAddr) {
+				if header := r.Header.Get(opts.Header); validRequestID(header) {
+					id = header
+				}
+			}
+			if len(id) == 0 {
+				id = newUUID()
+			}
+
+			w.Header().Set(opts.Header, id)
+			next.ServeHTTP(w, r.WithContext(setRequestID(r.Context(), id)))
 		})
 	}
 }
 
+// validRequestID returns true if the request ID is not empty, not too long
+// and only contains characters that are safe to log and echo in headers.
+func validRequestID(id string) bool {
+	if len(id) == 0 || len(id) > requestIDMaxLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':', c == '+', c == '/', c == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // setRequestID sets the request ID in the request context.
 func setRequestID(ctx context.Context, id string) context.Context {
 	return context.WithValue(ctx, contextKeyRequestID, id)
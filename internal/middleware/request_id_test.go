@@ -3,7 +3,11 @@ package middleware
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
+	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestRequestID(t *testing.T) {
@@ -11,33 +15,205 @@ func TestRequestID(t *testing.T) {
 		return "test"
 	}
 
+	trustedProxies := TrustedProxies{netip.MustParsePrefix("10.0.0.0/8")}
+
 	var tests = []struct {
 		name  string
-		input *http.Request
-		want  string
+		input struct {
+			req     func() *http.Request
+			options []RequestIDOption
+		}
+		want struct {
+			id     string
+			header http.Header
+		}
 	}{
 		{
 			name: "With request ID",
-			input: func() *http.Request {
-				req := httptest.NewRequest("GET", "/", nil)
-				return req
-			}(),
-			want: "test",
+			input: struct {
+				req     func() *http.Request
+				options []RequestIDOption
+			}{
+				req: func() *http.Request {
+					return httptest.NewRequest("GET", "/", nil)
+				},
+			},
+			want: struct {
+				id     string
+				header http.Header
+			}{
+				id:     "test",
+				header: http.Header{"X-Request-Id": []string{"test"}},
+			},
+		},
+		{
+			name: "With incoming request ID from trusted proxy",
+			input: struct {
+				req     func() *http.Request
+				options []RequestIDOption
+			}{
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "10.0.0.1:1234"
+					req.Header.Set("X-Request-ID", "upstream-id")
+					return req
+				},
+				options: []RequestIDOption{
+					func(o *RequestIDOptions) {
+						o.TrustedProxies = trustedProxies
+					},
+				},
+			},
+			want: struct {
+				id     string
+				header http.Header
+			}{
+				id:     "upstream-id",
+				header: http.Header{"X-Request-Id": []string{"upstream-id"}},
+			},
+		},
+		{
+			name: "With incoming request ID from untrusted peer",
+			input: struct {
+				req     func() *http.Request
+				options []RequestIDOption
+			}{
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "192.168.1.1:1234"
+					req.Header.Set("X-Request-ID", "upstream-id")
+					return req
+				},
+				options: []RequestIDOption{
+					func(o *RequestIDOptions) {
+						o.TrustedProxies = trustedProxies
+					},
+				},
+			},
+			want: struct {
+				id     string
+				header http.Header
+			}{
+				id:     "test",
+				header: http.Header{"X-Request-Id": []string{"test"}},
+			},
+		},
+		{
+			name: "With invalid incoming request ID from trusted proxy",
+			input: struct {
+				req     func() *http.Request
+				options []RequestIDOption
+			}{
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "10.0.0.1:1234"
+					req.Header.Set("X-Request-ID", "upstream id\"")
+					return req
+				},
+				options: []RequestIDOption{
+					func(o *RequestIDOptions) {
+						o.TrustedProxies = trustedProxies
+					},
+				},
+			},
+			want: struct {
+				id     string
+				header http.Header
+			}{
+				id:     "test",
+				header: http.Header{"X-Request-Id": []string{"test"}},
+			},
+		},
+		{
+			name: "With incoming request ID in custom header",
+			input: struct {
+				req     func() *http.Request
+				options []RequestIDOption
+			}{
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "10.0.0.1:1234"
+					req.Header.Set("X-Correlation-ID", "upstream-id")
+					return req
+				},
+				options: []RequestIDOption{
+					func(o *RequestIDOptions) {
+						o.Header = "X-Correlation-ID"
+						o.TrustedProxies = trustedProxies
+					},
+				},
+			},
+			want: struct {
+				id     string
+				header http.Header
+			}{
+				id:     "upstream-id",
+				header: http.Header{"X-Correlation-Id": []string{"upstream-id"}},
+			},
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
+			var got string
 			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				got := getRequestID(r.Context())
-				if test.want != got {
-					t.Errorf("RequestID() = unexpected result, want %s, got: %s", test.want, got)
-				}
+				got = getRequestID(r.Context())
 			})
 
 			rr := httptest.NewRecorder()
-			req := test.input
-			RequestID()(handler).ServeHTTP(rr, req)
+			RequestID(test.input.options...)(handler).ServeHTTP(rr, test.input.req())
+
+			if diff := cmp.Diff(test.want.id, got); diff != "" {
+				t.Errorf("RequestID() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.want.header, rr.Header()); diff != "" {
+				t.Errorf("RequestID() = unexpected result for headers (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{
+			name:  "UUID",
+			input: "0192d0a5-7b3c-7c3e-9f5a-3c2e1d0f4b6a",
+			want:  true,
+		},
+		{
+			name:  "hex",
+			input: "4bf92f3577b34da6a3ce929d0e0e4736",
+			want:  true,
+		},
+		{
+			name:  "empty",
+			input: "",
+			want:  false,
+		},
+		{
+			name:  "too long",
+			input: strings.Repeat("a", requestIDMaxLength+1),
+			want:  false,
+		},
+		{
+			name:  "invalid characters",
+			input: "id\r\nX-Injected: true",
+			want:  false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := validRequestID(test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("validRequestID() = unexpected result (-want +got)\n%s\n", diff)
+			}
 		})
 	}
 }
@@ -2,8 +2,8 @@ package middleware
 
 import (
 	"context"
-	"net"
 	"net/http"
+	"net/netip"
 	"strings"
 )
 
@@ -17,44 +17,155 @@ const (
 	SourceIPNotAvailable = "N/A"
 )
 
+// SourceIPOptions represents the options for the SourceIP middleware.
+type SourceIPOptions struct {
+	// TrustedProxies are the proxies that are trusted to set the
+	// Forwarded, X-Forwarded-For and X-Real-Ip headers. The headers
+	// are ignored for requests from other peers.
+	TrustedProxies TrustedProxies
+}
+
+// SourceIPOption is a function that sets an option for the SourceIP middleware.
+type SourceIPOption func(o *SourceIPOptions)
+
 // SourceIP is a middleware that sets the source IP address in the request context.
-func SourceIP() func(next http.Handler) http.Handler {
+func SourceIP(options ...SourceIPOption) func(next http.Handler) http.Handler {
+	opts := SourceIPOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ip := resolveIP(r)
+			ip := resolveIP(r, opts.TrustedProxies)
 			next.ServeHTTP(w, r.WithContext(setSourceIP(r.Context(), ip)))
 		})
 	}
 }
 
-// resolveIP checks request for headers Forwarded, X-Forwarded-For, and X-Real-Ip
-// and falls back to the RemoteAddr if none are found.
-func resolveIP(r *http.Request) string {
-	var addr string
-	if f := r.Header.Get("Forwarded"); f != "" {
-		for _, segment := range strings.Split(f, ",") {
-			addr = strings.TrimPrefix(segment, "for=")
-			break
+// resolveIP resolves the source IP address of the request. The headers Forwarded,
+// X-Forwarded-For and X-Real-Ip (in that order) are only used if the request is
+// from a trusted proxy. The chain of addresses in Forwarded and X-Forwarded-For
+// is walked from right to left, and the first address that is not a trusted proxy
+// is returned. Falls back to the RemoteAddr if no header is used.
+func resolveIP(r *http.Request, proxies TrustedProxies) string {
+	remote, ok := parseAddr(r.RemoteAddr)
+	if !ok {
+		return SourceIPNotAvailable
+	}
+	if !proxies.containsAddr(remote) {
+		return remote.String()
+	}
+
+	if f := r.Header.Values("Forwarded"); len(f) > 0 {
+		return resolveChain(remote, parseForwarded(f), proxies).String()
+	}
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		return resolveChain(remote, splitList(xff), proxies).String()
+	}
+	if xrip := r.Header.Get("X-Real-Ip"); len(xrip) > 0 {
+		if ip, ok := parseAddr(xrip); ok {
+			return ip.String()
 		}
-	} else if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		addr = strings.Split(xff, ",")[0]
-	} else if xrip := r.Header.Get("X-Real-Ip"); xrip != "" {
-		addr = xrip
-	} else {
-		addr = r.RemoteAddr
 	}
-	ip := strings.Split(addr, ":")[0]
-	if net.ParseIP(ip) == nil {
-		return SourceIPNotAvailable
+	return remote.String()
+}
+
+// resolveChain walks the chain of addresses from right to left (closest
+// proxy first) and returns the first address that is not a trusted proxy.
+// If an address cannot be parsed (such as "unknown" or an obfuscated
+// identifier) the walk stops, and the last trusted address is returned.
+func resolveChain(remote netip.Addr, chain []string, proxies TrustedProxies) netip.Addr {
+	ip := remote
+	for i := len(chain) - 1; i >= 0; i-- {
+		addr, ok := parseAddr(chain[i])
+		if !ok {
+			break
+		}
+		ip = addr
+		if !proxies.containsAddr(addr) {
+			break
+		}
 	}
 	return ip
 }
 
+// splitList splits the values of a header with comma-separated lists
+// into a single list.
+func splitList(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, v := range strings.Split(value, ",") {
+			list = append(list, strings.TrimSpace(v))
+		}
+	}
+	return list
+}
+
+// parseForwarded parses the values of Forwarded headers (RFC 7239) and
+// returns the value of the for parameter of every element, in order.
+// Elements without a for parameter result in an empty value, to keep
+// the positions of the hops in the chain.
+func parseForwarded(values []string) []string {
+	var list []string
+	for _, value := range values {
+		var forValue string
+		var key, val strings.Builder
+		inValue, inQuotes, escaped := false, false, false
+
+		endPair := func() {
+			if strings.EqualFold(strings.TrimSpace(key.String()), "for") {
+				forValue = strings.TrimSpace(val.String())
+			}
+			key.Reset()
+			val.Reset()
+			inValue = false
+		}
+		endElement := func() {
+			endPair()
+			list = append(list, forValue)
+			forValue = ""
+		}
+
+		for _, c := range value {
+			switch {
+			case escaped:
+				val.WriteRune(c)
+				escaped = false
+			case inQuotes && c == '\\':
+				escaped = true
+			case c == '"' && inValue:
+				inQuotes = !inQuotes
+			case inQuotes:
+				val.WriteRune(c)
+			case c == ';':
+				endPair()
+			case c == ',':
+				endElement()
+			case c == '=' && !inValue:
+				inValue = true
+			case inValue:
+				val.WriteRune(c)
+			default:
+				key.WriteRune(c)
+			}
+		}
+		endElement()
+	}
+	return list
+}
+
 // setSourceIP sets the source IP address in the request context.
 func setSourceIP(ctx context.Context, ip string) context.Context {
 	return context.WithValue(ctx, contextKeySourceIP, ip)
 }
 
+// SourceIPFromContext returns the source IP address from the context.
+// It returns SourceIPNotAvailable if the SourceIP middleware has not run.
+func SourceIPFromContext(ctx context.Context) string {
+	return getSourceIP(ctx)
+}
+
 // getSourceIP returns the source IP address from the request context.
 func getSourceIP(ctx context.Context) string {
 	val := ctx.Value(contextKeySourceIP)
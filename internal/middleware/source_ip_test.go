@@ -3,90 +3,305 @@ package middleware
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestSourceIP(t *testing.T) {
 	var tests = []struct {
 		name  string
-		input *http.Request
-		want  string
+		input struct {
+			req     *http.Request
+			options []SourceIPOption
+		}
+		want string
 	}{
 		{
 			name: "Set source IP to context",
-			input: func() *http.Request {
-				req := httptest.NewRequest("GET", "/", nil)
-				req.Header.Set("Forwarded", "for=192.168.1.1:1234")
-				return req
-			}(),
+			input: struct {
+				req     *http.Request
+				options []SourceIPOption
+			}{
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "10.0.0.1:1234"
+					req.Header.Set("Forwarded", "for=192.168.1.1")
+					return req
+				}(),
+				options: []SourceIPOption{
+					func(o *SourceIPOptions) {
+						o.TrustedProxies = TrustedProxies{netip.MustParsePrefix("10.0.0.0/8")}
+					},
+				},
+			},
 			want: "192.168.1.1",
 		},
+		{
+			name: "Set source IP to context - no trusted proxies",
+			input: struct {
+				req     *http.Request
+				options []SourceIPOption
+			}{
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "10.0.0.1:1234"
+					req.Header.Set("Forwarded", "for=192.168.1.1")
+					return req
+				}(),
+			},
+			want: "10.0.0.1",
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
+			var got string
 			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				got := getSourceIP(r.Context())
-				if test.want != got {
-					t.Errorf("SourceIP() = unexpected result, want %s, got: %s", test.want, got)
-				}
+				got = getSourceIP(r.Context())
 			})
 
 			rr := httptest.NewRecorder()
-			req := test.input
-			SourceIP()(handler).ServeHTTP(rr, req)
+			SourceIP(test.input.options...)(handler).ServeHTTP(rr, test.input.req)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("SourceIP() = unexpected result (-want +got)\n%s\n", diff)
+			}
 		})
 	}
 }
 
 func TestResolveIP(t *testing.T) {
+	proxies := TrustedProxies{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("fd00::/8"),
+	}
+
 	var tests = []struct {
 		name  string
-		input func() *http.Request
-		want  string
+		input struct {
+			req     func() *http.Request
+			proxies TrustedProxies
+		}
+		want string
 	}{
 		{
 			name: "With Forwarded header",
-			input: func() *http.Request {
-				req := httptest.NewRequest("GET", "/", nil)
-				req.Header.Set("Forwarded", "for=192.168.1.1:1234")
-				return req
+			input: struct {
+				req     func() *http.Request
+				proxies TrustedProxies
+			}{
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "10.0.0.1:1234"
+					req.Header.Set("Forwarded", `for="192.168.1.1:1234"`)
+					return req
+				},
+				proxies: proxies,
 			},
 			want: "192.168.1.1",
 		},
+		{
+			name: "With Forwarded header - chain with trusted proxies",
+			input: struct {
+				req     func() *http.Request
+				proxies TrustedProxies
+			}{
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "10.0.0.1:1234"
+					req.Header.Add("Forwarded", `for=203.0.113.1;proto=https, for=192.168.1.1`)
+					req.Header.Add("Forwarded", `for=10.0.0.2;by=10.0.0.1`)
+					return req
+				},
+				proxies: proxies,
+			},
+			want: "192.168.1.1",
+		},
+		{
+			name: "With Forwarded header - IPv6",
+			input: struct {
+				req     func() *http.Request
+				proxies TrustedProxies
+			}{
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "[fd00::1]:1234"
+					req.Header.Set("Forwarded", `For="[2001:db8:cafe::17]:4711"`)
+					return req
+				},
+				proxies: proxies,
+			},
+			want: "2001:db8:cafe::17",
+		},
+		{
+			name: "With Forwarded header - unknown",
+			input: struct {
+				req     func() *http.Request
+				proxies TrustedProxies
+			}{
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "10.0.0.1:1234"
+					req.Header.Set("Forwarded", `for=192.168.1.1, for=unknown, for=10.0.0.2`)
+					return req
+				},
+				proxies: proxies,
+			},
+			want: "10.0.0.2",
+		},
+		{
+			name: "With Forwarded header - untrusted peer",
+			input: struct {
+				req     func() *http.Request
+				proxies TrustedProxies
+			}{
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "192.168.1.2:1234"
+					req.Header.Set("Forwarded", "for=192.168.1.1")
+					return req
+				},
+				proxies: proxies,
+			},
+			want: "192.168.1.2",
+		},
 		{
 			name: "With X-Forwarded-For header",
-			input: func() *http.Request {
-				req := httptest.NewRequest("GET", "/", nil)
-				req.Header.Set("X-Forwarded-For", "192.168.1.1:1234")
-				return req
+			input: struct {
+				req     func() *http.Request
+				proxies TrustedProxies
+			}{
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "10.0.0.1:1234"
+					req.Header.Set("X-Forwarded-For", "192.168.1.1")
+					return req
+				},
+				proxies: proxies,
+			},
+			want: "192.168.1.1",
+		},
+		{
+			name: "With X-Forwarded-For header - spoofed chain",
+			input: struct {
+				req     func() *http.Request
+				proxies TrustedProxies
+			}{
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "10.0.0.1:1234"
+					req.Header.Add("X-Forwarded-For", "203.0.113.1, 192.168.1.1")
+					req.Header.Add("X-Forwarded-For", "10.0.0.2")
+					return req
+				},
+				proxies: proxies,
 			},
 			want: "192.168.1.1",
 		},
+		{
+			name: "With X-Forwarded-For header - IPv6",
+			input: struct {
+				req     func() *http.Request
+				proxies TrustedProxies
+			}{
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "[fd00::1]:1234"
+					req.Header.Set("X-Forwarded-For", "2001:db8::1, fd00::2")
+					return req
+				},
+				proxies: proxies,
+			},
+			want: "2001:db8::1",
+		},
+		{
+			name: "With X-Forwarded-For header - untrusted peer",
+			input: struct {
+				req     func() *http.Request
+				proxies TrustedProxies
+			}{
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "192.168.1.2:1234"
+					req.Header.Set("X-Forwarded-For", "192.168.1.1")
+					return req
+				},
+			},
+			want: "192.168.1.2",
+		},
 		{
 			name: "With X-Real-IP header",
-			input: func() *http.Request {
-				req := httptest.NewRequest("GET", "/", nil)
-				req.Header.Set("X-Real-IP", "192.168.1.1:1234")
-				return req
+			input: struct {
+				req     func() *http.Request
+				proxies TrustedProxies
+			}{
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "10.0.0.1:1234"
+					req.Header.Set("X-Real-IP", "192.168.1.1")
+					return req
+				},
+				proxies: proxies,
 			},
 			want: "192.168.1.1",
 		},
+		{
+			name: "With X-Real-IP header - invalid",
+			input: struct {
+				req     func() *http.Request
+				proxies TrustedProxies
+			}{
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "10.0.0.1:1234"
+					req.Header.Set("X-Real-IP", "invalid")
+					return req
+				},
+				proxies: proxies,
+			},
+			want: "10.0.0.1",
+		},
 		{
 			name: "With RemoteAddr",
-			input: func() *http.Request {
-				req := httptest.NewRequest("GET", "/", nil)
-				req.RemoteAddr = "192.168.1.1:1234"
-				return req
+			input: struct {
+				req     func() *http.Request
+				proxies TrustedProxies
+			}{
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "192.168.1.1:1234"
+					return req
+				},
 			},
 			want: "192.168.1.1",
 		},
+		{
+			name: "With IPv6 RemoteAddr",
+			input: struct {
+				req     func() *http.Request
+				proxies TrustedProxies
+			}{
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "[2001:db8::1]:1234"
+					return req
+				},
+			},
+			want: "2001:db8::1",
+		},
 		{
 			name: "With invalid RemoteAddr",
-			input: func() *http.Request {
-				req := httptest.NewRequest("GET", "/", nil)
-				req.RemoteAddr = "1234"
-				return req
+			input: struct {
+				req     func() *http.Request
+				proxies TrustedProxies
+			}{
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/", nil)
+					req.RemoteAddr = "1234"
+					return req
+				},
 			},
 			want: SourceIPNotAvailable,
 		},
@@ -94,9 +309,64 @@ func TestResolveIP(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			got := resolveIP(test.input())
-			if test.want != got {
-				t.Errorf("Resolve() = unexpected result, want %s, got: %s", test.want, got)
+			got := resolveIP(test.input.req(), test.input.proxies)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("resolveIP() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestParseForwarded(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input []string
+		want  []string
+	}{
+		{
+			name:  "single element",
+			input: []string{"for=192.0.2.60;proto=http;by=203.0.113.43"},
+			want:  []string{"192.0.2.60"},
+		},
+		{
+			name:  "multiple elements",
+			input: []string{"for=192.0.2.43, for=198.51.100.17"},
+			want:  []string{"192.0.2.43", "198.51.100.17"},
+		},
+		{
+			name:  "multiple headers",
+			input: []string{"for=192.0.2.43", "for=198.51.100.17"},
+			want:  []string{"192.0.2.43", "198.51.100.17"},
+		},
+		{
+			name:  "quoted IPv6 with port",
+			input: []string{`for="[2001:db8:cafe::17]:4711"`},
+			want:  []string{"[2001:db8:cafe::17]:4711"},
+		},
+		{
+			name:  "quoted value with comma and semicolon",
+			input: []string{`for=192.0.2.43;ext="a,b;c", for=198.51.100.17`},
+			want:  []string{"192.0.2.43", "198.51.100.17"},
+		},
+		{
+			name:  "element without for",
+			input: []string{"proto=https, for=192.0.2.43"},
+			want:  []string{"", "192.0.2.43"},
+		},
+		{
+			name:  "case insensitive key",
+			input: []string{"For=192.0.2.43"},
+			want:  []string{"192.0.2.43"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := parseForwarded(test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("parseForwarded() = unexpected result (-want +got)\n%s\n", diff)
 			}
 		})
 	}
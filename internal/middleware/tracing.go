@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/RedeployAB/burnit/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing is a middleware that starts a server span for the request.
+// The trace context of the caller is extracted from the W3C traceparent
+// header if present. The span is named after the matched route, which
+// requires that the request passed to the router is the one created
+// by this middleware (no middlewares in between may replace it).
+func Tracing() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracing.Start(
+				ctx,
+				r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("url.path", maskSecretHash(r.URL.Path)),
+				),
+			)
+			defer span.End()
+
+			if requestID := getRequestID(ctx); len(requestID) > 0 {
+				span.SetAttributes(attribute.String("request.id", requestID))
+			}
+
+			lw := &loggingResponseWriter{ResponseWriter: w}
+			r = r.WithContext(ctx)
+			next.ServeHTTP(lw, r)
+
+			status := lw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			route := routeFromPattern(r.Pattern)
+
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(
+				attribute.String("http.route", route),
+				attribute.Int("http.response.status_code", status),
+			)
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		})
+	}
+}
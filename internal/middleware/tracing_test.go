@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			method      string
+			target      string
+			traceparent string
+		}
+		want struct {
+			name         string
+			traceID      string
+			parentSpanID string
+			route        string
+			status       int64
+			code         codes.Code
+		}
+	}{
+		{
+			name: "start span with route",
+			input: struct {
+				method      string
+				target      string
+				traceparent string
+			}{
+				method: http.MethodGet,
+				target: "/secrets/1",
+			},
+			want: struct {
+				name         string
+				traceID      string
+				parentSpanID string
+				route        string
+				status       int64
+				code         codes.Code
+			}{
+				name:         "GET /secrets/{id}",
+				parentSpanID: "0000000000000000",
+				route:        "/secrets/{id}",
+				status:       http.StatusOK,
+				code:         codes.Unset,
+			},
+		},
+		{
+			name: "start span with remote parent",
+			input: struct {
+				method      string
+				target      string
+				traceparent string
+			}{
+				method:      http.MethodGet,
+				target:      "/secrets/1",
+				traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			},
+			want: struct {
+				name         string
+				traceID      string
+				parentSpanID string
+				route        string
+				status       int64
+				code         codes.Code
+			}{
+				name:         "GET /secrets/{id}",
+				traceID:      "4bf92f3577b34da6a3ce929d0e0e4736",
+				parentSpanID: "00f067aa0ba902b7",
+				route:        "/secrets/{id}",
+				status:       http.StatusOK,
+				code:         codes.Unset,
+			},
+		},
+		{
+			name: "start span with server error",
+			input: struct {
+				method      string
+				target      string
+				traceparent string
+			}{
+				method: http.MethodPost,
+				target: "/secrets",
+			},
+			want: struct {
+				name         string
+				traceID      string
+				parentSpanID string
+				route        string
+				status       int64
+				code         codes.Code
+			}{
+				name:         "POST /secrets",
+				parentSpanID: "0000000000000000",
+				route:        "/secrets",
+				status:       http.StatusInternalServerError,
+				code:         codes.Error,
+			},
+		},
+		{
+			name: "start span for unmatched route",
+			input: struct {
+				method      string
+				target      string
+				traceparent string
+			}{
+				method: http.MethodGet,
+				target: "/unknown",
+			},
+			want: struct {
+				name         string
+				traceID      string
+				parentSpanID string
+				route        string
+				status       int64
+				code         codes.Code
+			}{
+				name:         "GET unmatched",
+				parentSpanID: "0000000000000000",
+				route:        routeUnmatched,
+				status:       http.StatusNotFound,
+				code:         codes.Unset,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := testSetupTracing(t)
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /secrets/{id}", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("secret"))
+			})
+			mux.HandleFunc("POST /secrets", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			})
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(test.input.method, test.input.target, nil)
+			if len(test.input.traceparent) > 0 {
+				req.Header.Set("traceparent", test.input.traceparent)
+			}
+			Tracing()(mux).ServeHTTP(rr, req)
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("Tracing() = expected 1 span, got %d", len(spans))
+			}
+			span := spans[0]
+
+			if diff := cmp.Diff(test.want.name, span.Name()); diff != "" {
+				t.Errorf("Tracing() = unexpected result for name (-want +got)\n%s\n", diff)
+			}
+			if len(test.want.traceID) > 0 {
+				if diff := cmp.Diff(test.want.traceID, span.SpanContext().TraceID().String()); diff != "" {
+					t.Errorf("Tracing() = unexpected result for trace ID (-want +got)\n%s\n", diff)
+				}
+			}
+			if diff := cmp.Diff(test.want.parentSpanID, span.Parent().SpanID().String()); diff != "" {
+				t.Errorf("Tracing() = unexpected result for parent span ID (-want +got)\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.want.code, span.Status().Code); diff != "" {
+				t.Errorf("Tracing() = unexpected result for status (-want +got)\n%s\n", diff)
+			}
+
+			attrs := map[attribute.Key]attribute.Value{}
+			for _, attr := range span.Attributes() {
+				attrs[attr.Key] = attr.Value
+			}
+			if diff := cmp.Diff(test.want.route, attrs["http.route"].AsString()); diff != "" {
+				t.Errorf("Tracing() = unexpected result for route (-want +got)\n%s\n", diff)
+			}
+			if diff := cmp.Diff(test.want.status, attrs["http.response.status_code"].AsInt64()); diff != "" {
+				t.Errorf("Tracing() = unexpected result for status code (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+// testSetupTracing sets a tracer provider that records spans, and
+// restores the global tracer provider and propagator after the test.
+func testSetupTracing(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return recorder
+}
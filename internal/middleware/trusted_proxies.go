@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// TrustedProxies contains the address ranges of proxies that are trusted
+// to set headers with information about the original request.
+type TrustedProxies []netip.Prefix
+
+// ParseTrustedProxies parses trusted proxies from CIDRs (192.168.0.0/16) or
+// single IP addresses (10.0.0.1).
+func ParseTrustedProxies(proxies []string) (TrustedProxies, error) {
+	prefixes, err := parsePrefixes(proxies, "trusted proxy")
+	if err != nil {
+		return nil, err
+	}
+	return TrustedProxies(prefixes), nil
+}
+
+// contains returns true if the address (with or without port) is
+// within any of the trusted proxies.
+func (p TrustedProxies) contains(addr string) bool {
+	if len(p) == 0 {
+		return false
+	}
+	ip, ok := parseAddr(addr)
+	if !ok {
+		return false
+	}
+	return p.containsAddr(ip)
+}
+
+// containsAddr returns true if the address is within any of
+// the trusted proxies.
+func (p TrustedProxies) containsAddr(addr netip.Addr) bool {
+	for _, prefix := range p {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePrefixes parses address ranges from CIDRs or single IP addresses.
+// Empty values are skipped. The name describes the values in errors.
+func parsePrefixes(values []string, name string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if len(value) == 0 {
+			continue
+		}
+
+		if strings.Contains(value, "/") {
+			prefix, err := netip.ParsePrefix(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", name, value, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", name, value, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// parseAddr parses an IP address with or without port. IPv6 addresses
+// with port must be enclosed in brackets. IPv4-mapped IPv6 addresses
+// are converted to IPv4.
+func parseAddr(addr string) (netip.Addr, bool) {
+	addr = strings.TrimSpace(addr)
+	if ap, err := netip.ParseAddrPort(addr); err == nil {
+		return ap.Addr().Unmap().WithZone(""), true
+	}
+	ip, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap().WithZone(""), true
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   []string
+		want    TrustedProxies
+		wantErr bool
+	}{
+		{
+			name:  "no proxies",
+			input: nil,
+			want:  nil,
+		},
+		{
+			name:  "CIDRs and addresses",
+			input: []string{"10.0.0.0/8", " 192.168.1.1 ", "fd00::/8", "::1", ""},
+			want: TrustedProxies{
+				netip.MustParsePrefix("10.0.0.0/8"),
+				netip.MustParsePrefix("192.168.1.1/32"),
+				netip.MustParsePrefix("fd00::/8"),
+				netip.MustParsePrefix("::1/128"),
+			},
+		},
+		{
+			name:  "CIDR with host bits",
+			input: []string{"10.1.2.3/8"},
+			want: TrustedProxies{
+				netip.MustParsePrefix("10.0.0.0/8"),
+			},
+		},
+		{
+			name:    "invalid CIDR",
+			input:   []string{"10.0.0.0/33"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid address",
+			input:   []string{"proxy.example.com"},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := ParseTrustedProxies(test.input)
+			if (gotErr != nil) != test.wantErr {
+				t.Fatalf("ParseTrustedProxies() = unexpected error: %v", gotErr)
+			}
+
+			if diff := cmp.Diff(test.want, got, cmp.Comparer(func(x, y netip.Prefix) bool { return x == y })); diff != "" {
+				t.Errorf("ParseTrustedProxies() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestTrustedProxies_contains(t *testing.T) {
+	proxies := TrustedProxies{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("fd00::/8"),
+	}
+
+	var tests = []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{
+			name:  "IPv4 with port",
+			input: "10.1.2.3:1234",
+			want:  true,
+		},
+		{
+			name:  "IPv4 without port",
+			input: "10.1.2.3",
+			want:  true,
+		},
+		{
+			name:  "IPv4-mapped IPv6",
+			input: "[::ffff:10.1.2.3]:1234",
+			want:  true,
+		},
+		{
+			name:  "IPv6 with port",
+			input: "[fd00::1]:1234",
+			want:  true,
+		},
+		{
+			name:  "IPv6 without port",
+			input: "fd00::1",
+			want:  true,
+		},
+		{
+			name:  "IPv6 with zone",
+			input: "[fd00::1%eth0]:1234",
+			want:  true,
+		},
+		{
+			name:  "untrusted",
+			input: "192.168.1.1:1234",
+			want:  false,
+		},
+		{
+			name:  "invalid",
+			input: "invalid",
+			want:  false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := proxies.contains(test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("contains() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
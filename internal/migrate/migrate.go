@@ -0,0 +1,62 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/RedeployAB/burnit/internal/db"
+	dberrors "github.com/RedeployAB/burnit/internal/db/errors"
+)
+
+// Result contains the result of a migration.
+type Result struct {
+	// Migrated is the number of entries written to the destination.
+	Migrated int
+	// Skipped is the number of entries that already existed in
+	// the destination.
+	Skipped int
+}
+
+// Secrets copies all unexpired secrets from src to dst. Secrets are copied
+// as is (still encrypted) with their expiration time preserved. Secrets
+// that already exist in dst are skipped, which makes it safe to run the
+// migration more than once.
+func Secrets(ctx context.Context, src, dst db.SecretStore) (Result, error) {
+	var result Result
+	err := src.Iterate(ctx, func(secret db.Secret) error {
+		if _, err := dst.Get(ctx, secret.ID); err == nil {
+			result.Skipped++
+			return nil
+		} else if !errors.Is(err, dberrors.ErrSecretNotFound) {
+			return fmt.Errorf("secret %s: %w", secret.ID, err)
+		}
+
+		if _, err := dst.Create(ctx, secret); err != nil {
+			return fmt.Errorf("secret %s: %w", secret.ID, err)
+		}
+		result.Migrated++
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// Sessions copies all unexpired sessions from src to dst. Sessions
+// are upserted with their expiration times preserved.
+func Sessions(ctx context.Context, src, dst db.SessionStore) (Result, error) {
+	var result Result
+	err := src.Iterate(ctx, func(session db.Session) error {
+		if _, err := dst.Upsert(ctx, session); err != nil {
+			return fmt.Errorf("session %s: %w", session.ID, err)
+		}
+		result.Migrated++
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
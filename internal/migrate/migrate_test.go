@@ -0,0 +1,161 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/db"
+	"github.com/RedeployAB/burnit/internal/db/inmem"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestSecrets(t *testing.T) {
+	n := time.Now().UTC()
+
+	var tests = []struct {
+		name  string
+		input struct {
+			src []db.Secret
+			dst []db.Secret
+		}
+		want       Result
+		wantStored []db.Secret
+		wantErr    error
+	}{
+		{
+			name: "migrate secrets",
+			input: struct {
+				src []db.Secret
+				dst []db.Secret
+			}{
+				src: []db.Secret{
+					{ID: "1", Value: "encrypted1", ExpiresAt: n.Add(time.Hour)},
+					{ID: "2", Value: "encrypted2", ExpiresAt: n.Add(2 * time.Hour)},
+					{ID: "3", Value: "encrypted3", ExpiresAt: n.Add(-time.Hour)},
+				},
+			},
+			want: Result{Migrated: 2},
+			wantStored: []db.Secret{
+				{ID: "1", Value: "encrypted1", ExpiresAt: n.Add(time.Hour)},
+				{ID: "2", Value: "encrypted2", ExpiresAt: n.Add(2 * time.Hour)},
+			},
+		},
+		{
+			name: "migrate secrets - skip existing",
+			input: struct {
+				src []db.Secret
+				dst []db.Secret
+			}{
+				src: []db.Secret{
+					{ID: "1", Value: "encrypted1", ExpiresAt: n.Add(time.Hour)},
+					{ID: "2", Value: "encrypted2", ExpiresAt: n.Add(2 * time.Hour)},
+				},
+				dst: []db.Secret{
+					{ID: "1", Value: "encrypted1", ExpiresAt: n.Add(time.Hour)},
+				},
+			},
+			want: Result{Migrated: 1, Skipped: 1},
+			wantStored: []db.Secret{
+				{ID: "1", Value: "encrypted1", ExpiresAt: n.Add(time.Hour)},
+				{ID: "2", Value: "encrypted2", ExpiresAt: n.Add(2 * time.Hour)},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			src, dst := inmem.NewSecretStore(), inmem.NewSecretStore()
+			for _, secret := range test.input.src {
+				_, _ = src.Create(ctx, secret)
+			}
+			for _, secret := range test.input.dst {
+				_, _ = dst.Create(ctx, secret)
+			}
+
+			got, gotErr := Secrets(ctx, src, dst)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Secrets() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Secrets() = unexpected error (-want +got)\n%s\n", diff)
+			}
+
+			var gotStored []db.Secret
+			_ = dst.Iterate(ctx, func(secret db.Secret) error {
+				gotStored = append(gotStored, secret)
+				return nil
+			})
+
+			if diff := cmp.Diff(test.wantStored, gotStored, cmpopts.SortSlices(func(x, y db.Secret) bool {
+				return x.ID < y.ID
+			})); diff != "" {
+				t.Errorf("Secrets() = unexpected stored secrets (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestSessions(t *testing.T) {
+	n := time.Now().UTC()
+
+	var tests = []struct {
+		name  string
+		input struct {
+			src []db.Session
+		}
+		want       Result
+		wantStored []db.Session
+		wantErr    error
+	}{
+		{
+			name: "migrate sessions",
+			input: struct {
+				src []db.Session
+			}{
+				src: []db.Session{
+					{ID: "1", ExpiresAt: n.Add(time.Hour), CSRF: db.CSRF{Token: "token", ExpiresAt: n.Add(time.Hour)}},
+					{ID: "2", ExpiresAt: n.Add(-time.Hour)},
+				},
+			},
+			want: Result{Migrated: 1},
+			wantStored: []db.Session{
+				{ID: "1", ExpiresAt: n.Add(time.Hour), CSRF: db.CSRF{Token: "token", ExpiresAt: n.Add(time.Hour)}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			src, dst := inmem.NewSessionStore(), inmem.NewSessionStore()
+			for _, session := range test.input.src {
+				_, _ = src.Upsert(ctx, session)
+			}
+
+			got, gotErr := Sessions(ctx, src, dst)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Sessions() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Sessions() = unexpected error (-want +got)\n%s\n", diff)
+			}
+
+			var gotStored []db.Session
+			_ = dst.Iterate(ctx, func(session db.Session) error {
+				gotStored = append(gotStored, session)
+				return nil
+			})
+
+			if diff := cmp.Diff(test.wantStored, gotStored); diff != "" {
+				t.Errorf("Sessions() = unexpected stored sessions (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
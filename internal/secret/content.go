@@ -0,0 +1,109 @@
+package secret
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ContentType is the type of the content of a secret.
+type ContentType string
+
+const (
+	// ContentTypeText is free text, provided as the value of the secret.
+	ContentTypeText ContentType = "text"
+	// ContentTypeCredentials is a username, password, URL and notes.
+	ContentTypeCredentials ContentType = "credentials"
+	// ContentTypeKeyValue is a map of keys and values.
+	ContentTypeKeyValue ContentType = "keyValue"
+)
+
+const (
+	// contentPrefix marks a decrypted value as structured content. It
+	// starts with a null byte so that it is not mistaken for text.
+	contentPrefix = "\x00burnit-content\x00"
+	// maxContentKeys is the maximum number of keys of key-value content.
+	maxContentKeys = 50
+	// maxContentKeyCharacters is the maximum number of characters of a key
+	// of key-value content.
+	maxContentKeyCharacters = 100
+)
+
+// Content is the structured content of a secret. It is serialized and
+// encrypted as the value of the secret.
+type Content struct {
+	Type        ContentType       `json:"type"`
+	Credentials *Credentials      `json:"credentials,omitempty"`
+	KeyValue    map[string]string `json:"keyValue,omitempty"`
+}
+
+// Credentials is the content of a secret of the type credentials.
+type Credentials struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Notes    string `json:"notes,omitempty"`
+}
+
+// encodeContent validates the content and encodes it to be encrypted
+// as the value of a secret.
+func encodeContent(content Content, maxCharacters int) (string, error) {
+	switch content.Type {
+	case ContentTypeCredentials:
+		if content.Credentials == nil || *content.Credentials == (Credentials{}) {
+			return "", fmt.Errorf("%w: credentials must not be empty", ErrInvalidContent)
+		}
+		if len(content.KeyValue) > 0 {
+			return "", fmt.Errorf("%w: key-value can not be provided with credentials", ErrInvalidContent)
+		}
+		for _, field := range []string{content.Credentials.Username, content.Credentials.Password, content.Credentials.URL, content.Credentials.Notes} {
+			if !utf8.ValidString(field) || strings.ContainsRune(field, 0) {
+				return "", fmt.Errorf("%w: credentials must be valid UTF-8 encoded strings", ErrInvalidContent)
+			}
+		}
+	case ContentTypeKeyValue:
+		if len(content.KeyValue) == 0 {
+			return "", fmt.Errorf("%w: key-value must not be empty", ErrInvalidContent)
+		}
+		if len(content.KeyValue) > maxContentKeys {
+			return "", fmt.Errorf("%w: max keys are %d", ErrInvalidContent, maxContentKeys)
+		}
+		if content.Credentials != nil {
+			return "", fmt.Errorf("%w: credentials can not be provided with key-value", ErrInvalidContent)
+		}
+		for key, value := range content.KeyValue {
+			if len(strings.TrimSpace(key)) == 0 || utf8.RuneCountInString(key) > maxContentKeyCharacters {
+				return "", fmt.Errorf("%w: keys must be between 1 and %d characters", ErrInvalidContent, maxContentKeyCharacters)
+			}
+			if !utf8.ValidString(key) || !utf8.ValidString(value) || strings.ContainsRune(key, 0) || strings.ContainsRune(value, 0) {
+				return "", fmt.Errorf("%w: keys and values must be valid UTF-8 encoded strings", ErrInvalidContent)
+			}
+		}
+	default:
+		return "", fmt.Errorf("%w: unsupported type %q", ErrInvalidContent, content.Type)
+	}
+
+	b, err := json.Marshal(content)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidContent, err)
+	}
+	if utf8.RuneCount(b) > maxCharacters {
+		return "", fmt.Errorf("%w: secret content max characters are %d", ErrValueTooManyCharacters, maxCharacters)
+	}
+	return contentPrefix + string(b), nil
+}
+
+// decodeContent decodes the structured content of a decrypted value.
+// It returns false if the value is text.
+func decodeContent(value string) (Content, bool, error) {
+	data, ok := strings.CutPrefix(value, contentPrefix)
+	if !ok {
+		return Content{}, false, nil
+	}
+	var content Content
+	if err := json.Unmarshal([]byte(data), &content); err != nil {
+		return Content{}, false, fmt.Errorf("decode content: %w", err)
+	}
+	return content, true, nil
+}
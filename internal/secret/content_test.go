@@ -0,0 +1,116 @@
+package secret
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestEncodeContent(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   Content
+		want    Content
+		wantErr error
+	}{
+		{
+			name: "credentials",
+			input: Content{
+				Type: ContentTypeCredentials,
+				Credentials: &Credentials{
+					Username: "user",
+					Password: "pass",
+					URL:      "https://example.com",
+					Notes:    "line 1\nline 2",
+				},
+			},
+			want: Content{
+				Type: ContentTypeCredentials,
+				Credentials: &Credentials{
+					Username: "user",
+					Password: "pass",
+					URL:      "https://example.com",
+					Notes:    "line 1\nline 2",
+				},
+			},
+		},
+		{
+			name: "key-value",
+			input: Content{
+				Type:     ContentTypeKeyValue,
+				KeyValue: map[string]string{"API_KEY": "key", "API_SECRET": "secret"},
+			},
+			want: Content{
+				Type:     ContentTypeKeyValue,
+				KeyValue: map[string]string{"API_KEY": "key", "API_SECRET": "secret"},
+			},
+		},
+		{
+			name: "key-value - empty key",
+			input: Content{
+				Type:     ContentTypeKeyValue,
+				KeyValue: map[string]string{" ": "value"},
+			},
+			wantErr: ErrInvalidContent,
+		},
+		{
+			name: "key-value - too many keys",
+			input: Content{
+				Type: ContentTypeKeyValue,
+				KeyValue: func() map[string]string {
+					m := make(map[string]string)
+					for i := 0; i <= maxContentKeys; i++ {
+						m[strconv.Itoa(i)] = "value"
+					}
+					return m
+				}(),
+			},
+			wantErr: ErrInvalidContent,
+		},
+		{
+			name: "key-value - too many characters",
+			input: Content{
+				Type:     ContentTypeKeyValue,
+				KeyValue: map[string]string{"key": strings.Repeat("a", 200)},
+			},
+			wantErr: ErrValueTooManyCharacters,
+		},
+		{
+			name: "text",
+			input: Content{
+				Type: ContentTypeText,
+			},
+			wantErr: ErrInvalidContent,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encoded, gotErr := encodeContent(test.input, 200)
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Fatalf("encodeContent() = unexpected error (-want +got)\n%s\n", diff)
+			}
+			if test.wantErr != nil {
+				return
+			}
+
+			got, ok, err := decodeContent(encoded)
+			if !ok || err != nil {
+				t.Fatalf("decodeContent() = unexpected result, ok: %t, err: %v\n", ok, err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("decodeContent() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestDecodeContent_text(t *testing.T) {
+	_, ok, err := decodeContent(`{"type":"credentials"}`)
+	if ok || err != nil {
+		t.Errorf("decodeContent() = unexpected result, ok: %t, err: %v\n", ok, err)
+	}
+}
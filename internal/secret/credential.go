@@ -0,0 +1,321 @@
+package secret
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/ssh"
+)
+
+// CredentialType is the type of a generated credential.
+type CredentialType string
+
+const (
+	// CredentialTypeKey is a symmetric key.
+	CredentialTypeKey CredentialType = "key"
+	// CredentialTypeUUID is a random (version 4) UUID.
+	CredentialTypeUUID CredentialType = "uuid"
+	// CredentialTypeSSHEd25519 is an SSH Ed25519 key pair.
+	CredentialTypeSSHEd25519 CredentialType = "ssh-ed25519"
+	// CredentialTypeSSHRSA is an SSH RSA key pair.
+	CredentialTypeSSHRSA CredentialType = "ssh-rsa"
+	// CredentialTypeWireGuard is a WireGuard key pair.
+	CredentialTypeWireGuard CredentialType = "wireguard"
+	// CredentialTypeTLS is a self-signed TLS certificate and its key.
+	CredentialTypeTLS CredentialType = "tls"
+)
+
+const (
+	// KeyEncodingBase64 is base64 (standard) encoding of symmetric keys.
+	KeyEncodingBase64 = "base64"
+	// KeyEncodingHex is hex encoding of symmetric keys.
+	KeyEncodingHex = "hex"
+)
+
+const (
+	// defaultKeyBits is the default size of a symmetric key.
+	defaultKeyBits = 256
+	// minKeyBits is the minimum size of a symmetric key.
+	minKeyBits = 128
+	// maxKeyBits is the maximum size of a symmetric key.
+	maxKeyBits = 4096
+	// defaultRSABits is the default size of an RSA key.
+	defaultRSABits = 3072
+	// defaultCertificateCommonName is the default common name of a
+	// self-signed certificate.
+	defaultCertificateCommonName = "localhost"
+	// defaultCertificateValidFor is the default validity of a self-signed
+	// certificate.
+	defaultCertificateValidFor = 365 * 24 * time.Hour
+	// maxCertificateValidFor is the maximum validity of a self-signed
+	// certificate.
+	maxCertificateValidFor = 10 * 365 * 24 * time.Hour
+	// maxCertificateDNSNames is the maximum number of DNS names (and IP
+	// addresses) of a self-signed certificate.
+	maxCertificateDNSNames = 20
+)
+
+// rsaBits contains the allowed sizes of RSA keys.
+var rsaBits = []int{2048, 3072, 4096}
+
+// Credential contains a generated credential.
+type Credential struct {
+	Type CredentialType
+	// Private is the private part of the credential, such as a symmetric
+	// key, a UUID or a private key.
+	Private string
+	// Public is the public part of the credential, if any, such as a
+	// public key or a certificate.
+	Public string
+}
+
+// GenerateCredentialOptions contains the options for generating a credential.
+type GenerateCredentialOptions struct {
+	// Bits is the size of symmetric keys (default 256) and RSA keys
+	// (default 3072).
+	Bits int
+	// Encoding is the encoding of symmetric keys, base64 (default) or hex.
+	Encoding string
+	// Comment is the comment of SSH keys.
+	Comment string
+	// CommonName is the common name of self-signed certificates.
+	// Default: localhost.
+	CommonName string
+	// DNSNames contains the DNS names and IP addresses of self-signed
+	// certificates. Default: the common name.
+	DNSNames []string
+	// ValidFor is the validity of self-signed certificates. Default: 365 days.
+	ValidFor time.Duration
+}
+
+// GenerateCredentialOption is a function that sets options for generating
+// a credential.
+type GenerateCredentialOption func(o *GenerateCredentialOptions)
+
+// GenerateCredential generates a credential of the provided type with crypto/rand.
+func GenerateCredential(typ CredentialType, options ...GenerateCredentialOption) (Credential, error) {
+	opts := GenerateCredentialOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	var credential Credential
+	var err error
+	switch typ {
+	case CredentialTypeKey:
+		credential, err = generateKey(opts)
+	case CredentialTypeUUID:
+		credential, err = generateUUID()
+	case CredentialTypeSSHEd25519:
+		credential, err = generateSSHEd25519(opts)
+	case CredentialTypeSSHRSA:
+		credential, err = generateSSHRSA(opts)
+	case CredentialTypeWireGuard:
+		credential, err = generateWireGuard()
+	case CredentialTypeTLS:
+		credential, err = generateTLS(opts)
+	default:
+		return Credential{}, fmt.Errorf("%w: %q", ErrInvalidCredentialType, typ)
+	}
+	if err != nil {
+		return Credential{}, err
+	}
+	credential.Type = typ
+	return credential, nil
+}
+
+// generateCredential generates a credential. Used to be able to replace
+// the generation of credentials in tests.
+var generateCredential = GenerateCredential
+
+// generateKey generates a symmetric key.
+func generateKey(opts GenerateCredentialOptions) (Credential, error) {
+	bits := opts.Bits
+	if bits == 0 {
+		bits = defaultKeyBits
+	}
+	if bits < minKeyBits || bits > maxKeyBits || bits%8 != 0 {
+		return Credential{}, fmt.Errorf("%w: bits must be a multiple of 8 between %d and %d", ErrInvalidGenerateOptions, minKeyBits, maxKeyBits)
+	}
+
+	b := make([]byte, bits/8)
+	if _, err := rand.Read(b); err != nil {
+		return Credential{}, fmt.Errorf("generate key: %w", err)
+	}
+
+	switch opts.Encoding {
+	case "", KeyEncodingBase64:
+		return Credential{Private: base64.StdEncoding.EncodeToString(b)}, nil
+	case KeyEncodingHex:
+		return Credential{Private: hex.EncodeToString(b)}, nil
+	default:
+		return Credential{}, fmt.Errorf("%w: encoding must be %s or %s", ErrInvalidGenerateOptions, KeyEncodingBase64, KeyEncodingHex)
+	}
+}
+
+// generateUUID generates a random (version 4) UUID.
+func generateUUID() (Credential, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return Credential{}, fmt.Errorf("generate uuid: %w", err)
+	}
+	return Credential{Private: id.String()}, nil
+}
+
+// generateSSHEd25519 generates an SSH Ed25519 key pair in OpenSSH format.
+func generateSSHEd25519(opts GenerateCredentialOptions) (Credential, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return Credential{}, fmt.Errorf("generate ssh key: %w", err)
+	}
+	return sshCredential(pub, priv, opts.Comment)
+}
+
+// generateSSHRSA generates an SSH RSA key pair in OpenSSH format.
+func generateSSHRSA(opts GenerateCredentialOptions) (Credential, error) {
+	bits := opts.Bits
+	if bits == 0 {
+		bits = defaultRSABits
+	}
+	if !slices.Contains(rsaBits, bits) {
+		return Credential{}, fmt.Errorf("%w: bits must be one of 2048, 3072 or 4096", ErrInvalidGenerateOptions)
+	}
+
+	priv, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return Credential{}, fmt.Errorf("generate ssh key: %w", err)
+	}
+	return sshCredential(&priv.PublicKey, priv, opts.Comment)
+}
+
+// sshCredential returns the private key in OpenSSH format and the public
+// key in authorized keys format.
+func sshCredential(pub, priv any, comment string) (Credential, error) {
+	if strings.ContainsAny(comment, "\r\n") {
+		return Credential{}, fmt.Errorf("%w: comment must be a single line", ErrInvalidGenerateOptions)
+	}
+
+	block, err := ssh.MarshalPrivateKey(priv, comment)
+	if err != nil {
+		return Credential{}, fmt.Errorf("generate ssh key: %w", err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return Credential{}, fmt.Errorf("generate ssh key: %w", err)
+	}
+
+	public := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(sshPub)), "\n")
+	if len(comment) > 0 {
+		public += " " + comment
+	}
+	return Credential{Private: string(pem.EncodeToMemory(block)), Public: public}, nil
+}
+
+// generateWireGuard generates a WireGuard key pair, base64 encoded
+// as by wg genkey and wg pubkey.
+func generateWireGuard() (Credential, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return Credential{}, fmt.Errorf("generate wireguard key: %w", err)
+	}
+	// Clamp the private key (RFC 7748).
+	b[0] &= 248
+	b[31] = (b[31] & 127) | 64
+
+	priv, err := ecdh.X25519().NewPrivateKey(b)
+	if err != nil {
+		return Credential{}, fmt.Errorf("generate wireguard key: %w", err)
+	}
+	return Credential{
+		Private: base64.StdEncoding.EncodeToString(priv.Bytes()),
+		Public:  base64.StdEncoding.EncodeToString(priv.PublicKey().Bytes()),
+	}, nil
+}
+
+// generateTLS generates a self-signed TLS certificate with an ECDSA P-256
+// key. The key is PKCS #8 and the certificate PEM encoded.
+func generateTLS(opts GenerateCredentialOptions) (Credential, error) {
+	commonName := opts.CommonName
+	if len(commonName) == 0 {
+		commonName = defaultCertificateCommonName
+	}
+	names := opts.DNSNames
+	if len(names) == 0 {
+		names = []string{commonName}
+	}
+	if len(names) > maxCertificateDNSNames {
+		return Credential{}, fmt.Errorf("%w: max DNS names are %d", ErrInvalidGenerateOptions, maxCertificateDNSNames)
+	}
+	validFor := opts.ValidFor
+	if validFor == 0 {
+		validFor = defaultCertificateValidFor
+	}
+	if validFor < 0 || validFor > maxCertificateValidFor {
+		return Credential{}, fmt.Errorf("%w: certificate must be valid for at most %s", ErrInvalidGenerateOptions, maxCertificateValidFor)
+	}
+
+	var dnsNames []string
+	var ipAddresses []net.IP
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if ip := net.ParseIP(name); ip != nil {
+			ipAddresses = append(ipAddresses, ip)
+			continue
+		}
+		if len(name) == 0 || strings.ContainsAny(name, " \t\r\n/") {
+			return Credential{}, fmt.Errorf("%w: %q is not a valid DNS name", ErrInvalidGenerateOptions, name)
+		}
+		dnsNames = append(dnsNames, name)
+	}
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return Credential{}, fmt.Errorf("generate certificate: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return Credential{}, fmt.Errorf("generate certificate: %w", err)
+	}
+
+	notBefore := now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(validFor),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              dnsNames,
+		IPAddresses:           ipAddresses,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	if err != nil {
+		return Credential{}, fmt.Errorf("generate certificate: %w", err)
+	}
+	key, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return Credential{}, fmt.Errorf("generate certificate: %w", err)
+	}
+
+	return Credential{
+		Private: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key})),
+		Public:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+	}, nil
+}
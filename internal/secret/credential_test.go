@@ -0,0 +1,302 @@
+package secret
+
+import (
+	"crypto/ecdh"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestGenerateCredential(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			typ     CredentialType
+			options []GenerateCredentialOption
+		}
+		want    func(t *testing.T, credential Credential)
+		wantErr error
+	}{
+		{
+			name: "key",
+			input: struct {
+				typ     CredentialType
+				options []GenerateCredentialOption
+			}{
+				typ: CredentialTypeKey,
+			},
+			want: func(t *testing.T, credential Credential) {
+				key, err := base64.StdEncoding.DecodeString(credential.Private)
+				if err != nil || len(key) != 32 {
+					t.Errorf("GenerateCredential() = unexpected key %q, err: %v\n", credential.Private, err)
+				}
+			},
+		},
+		{
+			name: "key - hex 128 bits",
+			input: struct {
+				typ     CredentialType
+				options []GenerateCredentialOption
+			}{
+				typ: CredentialTypeKey,
+				options: []GenerateCredentialOption{
+					func(o *GenerateCredentialOptions) {
+						o.Bits = 128
+						o.Encoding = KeyEncodingHex
+					},
+				},
+			},
+			want: func(t *testing.T, credential Credential) {
+				key, err := hex.DecodeString(credential.Private)
+				if err != nil || len(key) != 16 {
+					t.Errorf("GenerateCredential() = unexpected key %q, err: %v\n", credential.Private, err)
+				}
+			},
+		},
+		{
+			name: "uuid",
+			input: struct {
+				typ     CredentialType
+				options []GenerateCredentialOption
+			}{
+				typ: CredentialTypeUUID,
+			},
+			want: func(t *testing.T, credential Credential) {
+				id, err := uuid.Parse(credential.Private)
+				if err != nil || id.Version() != 4 {
+					t.Errorf("GenerateCredential() = unexpected uuid %q, err: %v\n", credential.Private, err)
+				}
+			},
+		},
+		{
+			name: "ssh-ed25519",
+			input: struct {
+				typ     CredentialType
+				options []GenerateCredentialOption
+			}{
+				typ: CredentialTypeSSHEd25519,
+				options: []GenerateCredentialOption{
+					func(o *GenerateCredentialOptions) {
+						o.Comment = "user@example.com"
+					},
+				},
+			},
+			want: func(t *testing.T, credential Credential) {
+				verifySSHKeyPair(t, credential, ssh.KeyAlgoED25519, "user@example.com")
+			},
+		},
+		{
+			name: "ssh-rsa",
+			input: struct {
+				typ     CredentialType
+				options []GenerateCredentialOption
+			}{
+				typ: CredentialTypeSSHRSA,
+				options: []GenerateCredentialOption{
+					func(o *GenerateCredentialOptions) {
+						o.Bits = 2048
+					},
+				},
+			},
+			want: func(t *testing.T, credential Credential) {
+				verifySSHKeyPair(t, credential, ssh.KeyAlgoRSA, "")
+			},
+		},
+		{
+			name: "wireguard",
+			input: struct {
+				typ     CredentialType
+				options []GenerateCredentialOption
+			}{
+				typ: CredentialTypeWireGuard,
+			},
+			want: func(t *testing.T, credential Credential) {
+				b, err := base64.StdEncoding.DecodeString(credential.Private)
+				if err != nil {
+					t.Fatalf("GenerateCredential() = unexpected private key: %v", err)
+				}
+				priv, err := ecdh.X25519().NewPrivateKey(b)
+				if err != nil {
+					t.Fatalf("GenerateCredential() = unexpected private key: %v", err)
+				}
+				if b[0]&7 != 0 || b[31]&128 != 0 || b[31]&64 == 0 {
+					t.Errorf("GenerateCredential() = private key is not clamped\n")
+				}
+				if diff := cmp.Diff(base64.StdEncoding.EncodeToString(priv.PublicKey().Bytes()), credential.Public); diff != "" {
+					t.Errorf("GenerateCredential() = unexpected public key (-want +got)\n%s\n", diff)
+				}
+			},
+		},
+		{
+			name: "tls",
+			input: struct {
+				typ     CredentialType
+				options []GenerateCredentialOption
+			}{
+				typ: CredentialTypeTLS,
+				options: []GenerateCredentialOption{
+					func(o *GenerateCredentialOptions) {
+						o.CommonName = "example.com"
+						o.DNSNames = []string{"example.com", "www.example.com", "192.0.2.1"}
+						o.ValidFor = 24 * time.Hour
+					},
+				},
+			},
+			want: func(t *testing.T, credential Credential) {
+				cert, err := tls.X509KeyPair([]byte(credential.Public), []byte(credential.Private))
+				if err != nil {
+					t.Fatalf("GenerateCredential() = unexpected key pair: %v", err)
+				}
+				x509Cert, err := x509.ParseCertificate(cert.Certificate[0])
+				if err != nil {
+					t.Fatalf("GenerateCredential() = unexpected certificate: %v", err)
+				}
+
+				got := struct {
+					commonName string
+					dnsNames   []string
+					ip         string
+					validFor   time.Duration
+				}{
+					commonName: x509Cert.Subject.CommonName,
+					dnsNames:   x509Cert.DNSNames,
+					ip:         x509Cert.IPAddresses[0].String(),
+					validFor:   x509Cert.NotAfter.Sub(x509Cert.NotBefore),
+				}
+				want := struct {
+					commonName string
+					dnsNames   []string
+					ip         string
+					validFor   time.Duration
+				}{
+					commonName: "example.com",
+					dnsNames:   []string{"example.com", "www.example.com"},
+					ip:         "192.0.2.1",
+					validFor:   24 * time.Hour,
+				}
+				if diff := cmp.Diff(want, got, cmp.AllowUnexported(want)); diff != "" {
+					t.Errorf("GenerateCredential() = unexpected certificate (-want +got)\n%s\n", diff)
+				}
+			},
+		},
+		{
+			name: "invalid type",
+			input: struct {
+				typ     CredentialType
+				options []GenerateCredentialOption
+			}{
+				typ: "password",
+			},
+			wantErr: ErrInvalidCredentialType,
+		},
+		{
+			name: "key - invalid bits",
+			input: struct {
+				typ     CredentialType
+				options []GenerateCredentialOption
+			}{
+				typ: CredentialTypeKey,
+				options: []GenerateCredentialOption{
+					func(o *GenerateCredentialOptions) {
+						o.Bits = 100
+					},
+				},
+			},
+			wantErr: ErrInvalidGenerateOptions,
+		},
+		{
+			name: "key - invalid encoding",
+			input: struct {
+				typ     CredentialType
+				options []GenerateCredentialOption
+			}{
+				typ: CredentialTypeKey,
+				options: []GenerateCredentialOption{
+					func(o *GenerateCredentialOptions) {
+						o.Encoding = "base32"
+					},
+				},
+			},
+			wantErr: ErrInvalidGenerateOptions,
+		},
+		{
+			name: "ssh-rsa - invalid bits",
+			input: struct {
+				typ     CredentialType
+				options []GenerateCredentialOption
+			}{
+				typ: CredentialTypeSSHRSA,
+				options: []GenerateCredentialOption{
+					func(o *GenerateCredentialOptions) {
+						o.Bits = 1024
+					},
+				},
+			},
+			wantErr: ErrInvalidGenerateOptions,
+		},
+		{
+			name: "tls - invalid validity",
+			input: struct {
+				typ     CredentialType
+				options []GenerateCredentialOption
+			}{
+				typ: CredentialTypeTLS,
+				options: []GenerateCredentialOption{
+					func(o *GenerateCredentialOptions) {
+						o.ValidFor = 20 * 365 * 24 * time.Hour
+					},
+				},
+			},
+			wantErr: ErrInvalidGenerateOptions,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := GenerateCredential(test.input.typ, test.input.options...)
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Fatalf("GenerateCredential() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+			if test.wantErr != nil {
+				return
+			}
+
+			if test.input.typ != got.Type {
+				t.Errorf("GenerateCredential() = unexpected type, want: %s, got: %s\n", test.input.typ, got.Type)
+			}
+			test.want(t, got)
+		})
+	}
+}
+
+// verifySSHKeyPair verifies that the private key of the credential is in
+// OpenSSH format and that the public key belongs to it.
+func verifySSHKeyPair(t *testing.T, credential Credential, algorithm, comment string) {
+	t.Helper()
+	signer, err := ssh.ParsePrivateKey([]byte(credential.Private))
+	if err != nil {
+		t.Fatalf("GenerateCredential() = unexpected private key: %v", err)
+	}
+	pub, gotComment, _, _, err := ssh.ParseAuthorizedKey([]byte(credential.Public))
+	if err != nil {
+		t.Fatalf("GenerateCredential() = unexpected public key: %v", err)
+	}
+
+	if diff := cmp.Diff(algorithm, pub.Type()); diff != "" {
+		t.Errorf("GenerateCredential() = unexpected algorithm (-want +got)\n%s\n", diff)
+	}
+	if diff := cmp.Diff(comment, gotComment); diff != "" {
+		t.Errorf("GenerateCredential() = unexpected comment (-want +got)\n%s\n", diff)
+	}
+	if diff := cmp.Diff(signer.PublicKey().Marshal(), pub.Marshal()); diff != "" {
+		t.Errorf("GenerateCredential() = public key does not match private key (-want +got)\n%s\n", diff)
+	}
+}
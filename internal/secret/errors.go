@@ -11,8 +11,18 @@ var (
 	ErrValueInvalid = errors.New("value invalid")
 	// ErrValueTooManyCharacters is returned when the secret value has too many characters.
 	ErrValueTooManyCharacters = errors.New("value has too many characters")
+	// ErrInvalidContent is returned when the structured content of a secret is invalid.
+	ErrInvalidContent = errors.New("invalid secret content")
+	// ErrInvalidLabel is returned when the label of a secret is invalid.
+	ErrInvalidLabel = errors.New("invalid label")
+	// ErrInvalidNote is returned when the note of a secret is invalid.
+	ErrInvalidNote = errors.New("invalid note")
 	// ErrInvalidExpirationTime is returned when the expiration time is invalid.
 	ErrInvalidExpirationTime = errors.New("invalid expiration time")
+	// ErrInvalidNotBefore is returned when the not before time is invalid.
+	ErrInvalidNotBefore = errors.New("invalid not before time")
+	// ErrSecretNotAvailable is returned when a secret is read before its not before time.
+	ErrSecretNotAvailable = errors.New("secret not yet available")
 	// ErrPassphraseNotBase64 is returned when the passphrase is not base64 encoded.
 	ErrPassphraseNotBase64 = errors.New("passphrase not base64 encoded")
 	// ErrPassphraseInvalid is returned when the passphrase input is invalid.
@@ -21,4 +31,26 @@ var (
 	ErrPassphraseTooManyCharacters = errors.New("passphrase has too many characters")
 	// ErrPassphraseTooFewCharacters is returned when the passphrase has too few characters.
 	ErrPassphraseTooFewCharacters = errors.New("passphrase has too few characters")
+	// ErrPassphraseTooWeak is returned when the estimated strength of the passphrase is below the minimum score.
+	ErrPassphraseTooWeak = errors.New("passphrase too weak")
+	// ErrInvalidAllowedNetworks is returned when the allowed networks of a secret are invalid.
+	ErrInvalidAllowedNetworks = errors.New("invalid allowed networks")
+	// ErrNetworkNotAllowed is returned when a secret is read from a network that is not allowed.
+	ErrNetworkNotAllowed = errors.New("network not allowed to read secret")
+	// ErrSecondFactorInvalid is returned when the second factor of a secret is invalid.
+	ErrSecondFactorInvalid = errors.New("invalid second factor")
+	// ErrSecondFactorRequired is returned when a secret is read without the code of its second factor.
+	ErrSecondFactorRequired = errors.New("second factor code required")
+	// ErrInvalidSecondFactorCode is returned when the code of the second factor of a secret is invalid.
+	ErrInvalidSecondFactorCode = errors.New("invalid second factor code")
+	// ErrSecondFactorAttemptsExceeded is returned when the second factor of a secret has failed too many times. The secret is deleted.
+	ErrSecondFactorAttemptsExceeded = errors.New("too many invalid second factor codes")
+	// ErrCodeRecentlySent is returned when a code for the second factor of a secret is requested too soon after the previous code.
+	ErrCodeRecentlySent = errors.New("second factor code recently sent")
+	// ErrInvalidGenerateOptions is returned when the options for generating a secret are invalid.
+	ErrInvalidGenerateOptions = errors.New("invalid options for generating secret")
+	// ErrInvalidBatch is returned when a batch of secrets to create is empty or too large.
+	ErrInvalidBatch = errors.New("invalid batch")
+	// ErrInvalidCredentialType is returned when the type of a credential to generate is invalid.
+	ErrInvalidCredentialType = errors.New("invalid credential type")
 )
@@ -0,0 +1,296 @@
+package secret
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// effLargeWordlist is the EFF large wordlist for generating passphrases
+// of words (diceware). See:
+// https://www.eff.org/deeplinks/2016/07/new-wordlists-random-passphrases
+//
+//go:embed wordlists/eff_large_wordlist.txt
+var effLargeWordlist string
+
+const (
+	// lowercaseCharset is the lowercase letters used for generating a secret.
+	lowercaseCharset = "abcdefghijklmnopqrstuvwxyz"
+	// uppercaseCharset is the uppercase letters used for generating a secret.
+	uppercaseCharset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	// digitCharset is the digits used for generating a secret.
+	digitCharset = "0123456789"
+	// specialCharset is the special characters used for generating a secret.
+	specialCharset = "_-!?=()&%#@*+.:"
+	// ambiguousCharset is the characters that are easily confused with each other
+	// and are excluded from a generated secret if requested.
+	ambiguousCharset = "0Oo1Il|"
+	// defaultGenerateSecretLength is the default length of a generated secret.
+	defaultGenerateSecretLength = 16
+	// maxGenerateSecretLength is the maximum length of a generated secret.
+	maxGenerateSecretLength = 512
+	// minGenerateWords is the minimum number of words of a generated passphrase.
+	minGenerateWords = 3
+	// maxGenerateWords is the maximum number of words of a generated passphrase.
+	maxGenerateWords = 20
+	// defaultWordSeparator is the default separator between the words of a
+	// generated passphrase.
+	defaultWordSeparator = "-"
+	// maxWordSeparatorLength is the maximum length of the separator between
+	// the words of a generated passphrase.
+	maxWordSeparatorLength = 3
+)
+
+// GenerateOptions contains the options for generating a new secret.
+type GenerateOptions struct {
+	// Length is the number of characters of the secret. Default: 16.
+	// A longer length than 512 will be trimmed to 512.
+	Length int
+	// Lowercase, Uppercase and Digits include lowercase letters, uppercase
+	// letters and digits in the secret. If none of them, nor Custom, are set
+	// all three are included.
+	Lowercase bool
+	Uppercase bool
+	Digits    bool
+	// SpecialCharacters includes special characters in the secret.
+	SpecialCharacters bool
+	// Custom is a custom set of characters to include in the secret. It must
+	// only contain printable ASCII characters (except space).
+	Custom string
+	// ExcludeAmbiguous excludes characters that are easily confused with
+	// each other, such as 0 and O, from the secret.
+	ExcludeAmbiguous bool
+	// Words is the number of words (3 to 20) of a passphrase generated from
+	// the EFF large wordlist. If set, a passphrase of words is generated
+	// instead of random characters, and Length and the character classes
+	// are ignored.
+	Words int
+	// Separator separates the words of a passphrase. Default: "-".
+	Separator string
+	// Capitalize capitalizes the first letter of each word of a passphrase.
+	Capitalize bool
+	// Number adds a digit to a random word of a passphrase.
+	Number bool
+	// Symbol adds a special character to a random word of a passphrase.
+	Symbol bool
+}
+
+// GenerateOption is a function that sets options for generating a new secret.
+type GenerateOption func(o *GenerateOptions)
+
+// Generate new secret with crypto/rand. The secret contains at least one
+// character from each of the requested character classes. The length of
+// the secret is set by the provided length option (with a max of 512
+// characters, a longer length will be trimmed to this value). If words
+// are set, a passphrase of words is generated instead.
+func Generate(options ...GenerateOption) (string, error) {
+	opts := GenerateOptions{
+		Length: defaultGenerateSecretLength,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+	if opts.Words != 0 {
+		return generateWords(opts)
+	}
+	if opts.Length <= 0 {
+		opts.Length = defaultGenerateSecretLength
+	}
+	if opts.Length > maxGenerateSecretLength {
+		opts.Length = maxGenerateSecretLength
+	}
+
+	classes, err := characterClasses(opts)
+	if err != nil {
+		return "", err
+	}
+	if opts.Length < len(classes) {
+		return "", fmt.Errorf("%w: length must be at least %d to include all character classes", ErrInvalidGenerateOptions, len(classes))
+	}
+
+	chars := make([]byte, 0, opts.Length)
+	for _, class := range classes {
+		c, err := randomChar(class)
+		if err != nil {
+			return "", err
+		}
+		chars = append(chars, c)
+	}
+
+	all := uniqueChars(strings.Join(classes, ""))
+	for len(chars) < opts.Length {
+		c, err := randomChar(all)
+		if err != nil {
+			return "", err
+		}
+		chars = append(chars, c)
+	}
+
+	// Shuffle (Fisher-Yates) so that the guaranteed characters of each
+	// class are not placed first.
+	for i := len(chars) - 1; i > 0; i-- {
+		j, err := randomInt(i + 1)
+		if err != nil {
+			return "", err
+		}
+		chars[i], chars[j] = chars[j], chars[i]
+	}
+
+	return string(chars), nil
+}
+
+// generate new secret. Used to be able to replace the generation of
+// secrets in tests.
+var generate = Generate
+
+// generateWords generates a passphrase of words from the EFF large wordlist.
+func generateWords(opts GenerateOptions) (string, error) {
+	if opts.Words < minGenerateWords || opts.Words > maxGenerateWords {
+		return "", fmt.Errorf("%w: words must be between %d and %d", ErrInvalidGenerateOptions, minGenerateWords, maxGenerateWords)
+	}
+	separator := opts.Separator
+	if len(separator) == 0 {
+		separator = defaultWordSeparator
+	}
+	if len(separator) > maxWordSeparatorLength {
+		return "", fmt.Errorf("%w: separator must be at most %d characters", ErrInvalidGenerateOptions, maxWordSeparatorLength)
+	}
+	for i := 0; i < len(separator); i++ {
+		if separator[i] < ' ' || separator[i] > '~' {
+			return "", fmt.Errorf("%w: separator must be printable ASCII characters", ErrInvalidGenerateOptions)
+		}
+	}
+
+	list := wordlist()
+	words := make([]string, opts.Words)
+	for i := range words {
+		n, err := randomInt(len(list))
+		if err != nil {
+			return "", err
+		}
+		words[i] = list[n]
+		if opts.Capitalize {
+			words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+		}
+	}
+
+	for _, extra := range []struct {
+		add   bool
+		chars string
+	}{
+		{add: opts.Number, chars: digitCharset},
+		{add: opts.Symbol, chars: specialCharset},
+	} {
+		if !extra.add {
+			continue
+		}
+		i, err := randomInt(len(words))
+		if err != nil {
+			return "", err
+		}
+		c, err := randomChar(extra.chars)
+		if err != nil {
+			return "", err
+		}
+		words[i] += string(c)
+	}
+
+	return strings.Join(words, separator), nil
+}
+
+// wordlist returns the words of the EFF large wordlist. Each line of the
+// list contains the dice rolls and the word, separated by a tab.
+var wordlist = sync.OnceValue(func() []string {
+	lines := strings.Split(strings.TrimSpace(effLargeWordlist), "\n")
+	words := make([]string, 0, len(lines))
+	for _, line := range lines {
+		_, word, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok {
+			continue
+		}
+		words = append(words, word)
+	}
+	return words
+})
+
+// characterClasses returns the character sets of the requested character
+// classes.
+func characterClasses(opts GenerateOptions) ([]string, error) {
+	var classes []string
+	if !opts.Lowercase && !opts.Uppercase && !opts.Digits && len(opts.Custom) == 0 {
+		opts.Lowercase, opts.Uppercase, opts.Digits = true, true, true
+	}
+	if opts.Lowercase {
+		classes = append(classes, lowercaseCharset)
+	}
+	if opts.Uppercase {
+		classes = append(classes, uppercaseCharset)
+	}
+	if opts.Digits {
+		classes = append(classes, digitCharset)
+	}
+	if opts.SpecialCharacters {
+		classes = append(classes, specialCharset)
+	}
+	if len(opts.Custom) > 0 {
+		for i := 0; i < len(opts.Custom); i++ {
+			if opts.Custom[i] < '!' || opts.Custom[i] > '~' {
+				return nil, fmt.Errorf("%w: custom characters must be printable ASCII characters", ErrInvalidGenerateOptions)
+			}
+		}
+		classes = append(classes, uniqueChars(opts.Custom))
+	}
+
+	if !opts.ExcludeAmbiguous {
+		return classes, nil
+	}
+	for i, class := range classes {
+		classes[i] = strings.Map(func(r rune) rune {
+			if strings.ContainsRune(ambiguousCharset, r) {
+				return -1
+			}
+			return r
+		}, class)
+		if len(classes[i]) == 0 {
+			return nil, fmt.Errorf("%w: no characters left in custom characters when excluding ambiguous characters", ErrInvalidGenerateOptions)
+		}
+	}
+	return classes, nil
+}
+
+// uniqueChars returns the characters of s without duplicates, so that
+// no character is more likely to be selected than another.
+func uniqueChars(s string) string {
+	var seen [128]bool
+	var builder strings.Builder
+	builder.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if seen[s[i]] {
+			continue
+		}
+		seen[s[i]] = true
+		builder.WriteByte(s[i])
+	}
+	return builder.String()
+}
+
+// randomChar returns a uniformly selected random character from chars.
+func randomChar(chars string) (byte, error) {
+	i, err := randomInt(len(chars))
+	if err != nil {
+		return 0, err
+	}
+	return chars[i], nil
+}
+
+// randomInt returns a uniformly selected random integer in [0, n).
+func randomInt(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("generate secret: %w", err)
+	}
+	return int(i.Int64()), nil
+}
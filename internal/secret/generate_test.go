@@ -0,0 +1,305 @@
+package secret
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input []GenerateOption
+		want  struct {
+			length  int
+			classes []string
+			allowed string
+		}
+		wantErr error
+	}{
+		{
+			name: "default",
+			want: struct {
+				length  int
+				classes []string
+				allowed string
+			}{
+				length:  16,
+				classes: []string{lowercaseCharset, uppercaseCharset, digitCharset},
+				allowed: lowercaseCharset + uppercaseCharset + digitCharset,
+			},
+		},
+		{
+			name: "special characters",
+			input: []GenerateOption{
+				func(o *GenerateOptions) {
+					o.Length = 32
+					o.SpecialCharacters = true
+				},
+			},
+			want: struct {
+				length  int
+				classes []string
+				allowed string
+			}{
+				length:  32,
+				classes: []string{lowercaseCharset, uppercaseCharset, digitCharset, specialCharset},
+				allowed: lowercaseCharset + uppercaseCharset + digitCharset + specialCharset,
+			},
+		},
+		{
+			name: "digits only",
+			input: []GenerateOption{
+				func(o *GenerateOptions) {
+					o.Length = 6
+					o.Digits = true
+				},
+			},
+			want: struct {
+				length  int
+				classes []string
+				allowed string
+			}{
+				length:  6,
+				classes: []string{digitCharset},
+				allowed: digitCharset,
+			},
+		},
+		{
+			name: "custom characters and uppercase",
+			input: []GenerateOption{
+				func(o *GenerateOptions) {
+					o.Length = 8
+					o.Uppercase = true
+					o.Custom = "$$~"
+				},
+			},
+			want: struct {
+				length  int
+				classes []string
+				allowed string
+			}{
+				length:  8,
+				classes: []string{uppercaseCharset, "$~"},
+				allowed: uppercaseCharset + "$~",
+			},
+		},
+		{
+			name: "exclude ambiguous characters",
+			input: []GenerateOption{
+				func(o *GenerateOptions) {
+					o.Length = 512
+					o.ExcludeAmbiguous = true
+				},
+			},
+			want: struct {
+				length  int
+				classes []string
+				allowed string
+			}{
+				length:  512,
+				classes: []string{lowercaseCharset, uppercaseCharset, digitCharset},
+				allowed: "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789",
+			},
+		},
+		{
+			name: "length exceeds max",
+			input: []GenerateOption{
+				func(o *GenerateOptions) {
+					o.Length = 1024
+				},
+			},
+			want: struct {
+				length  int
+				classes []string
+				allowed string
+			}{
+				length:  512,
+				classes: []string{lowercaseCharset, uppercaseCharset, digitCharset},
+				allowed: lowercaseCharset + uppercaseCharset + digitCharset,
+			},
+		},
+		{
+			name: "length shorter than character classes",
+			input: []GenerateOption{
+				func(o *GenerateOptions) {
+					o.Length = 3
+					o.SpecialCharacters = true
+				},
+			},
+			wantErr: ErrInvalidGenerateOptions,
+		},
+		{
+			name: "invalid custom characters",
+			input: []GenerateOption{
+				func(o *GenerateOptions) {
+					o.Custom = "a b"
+				},
+			},
+			wantErr: ErrInvalidGenerateOptions,
+		},
+		{
+			name: "only ambiguous custom characters",
+			input: []GenerateOption{
+				func(o *GenerateOptions) {
+					o.Custom = "0O"
+					o.ExcludeAmbiguous = true
+				},
+			},
+			wantErr: ErrInvalidGenerateOptions,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := Generate(test.input...)
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Fatalf("Generate() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+			if test.wantErr != nil {
+				return
+			}
+
+			if test.want.length != len(got) {
+				t.Errorf("Generate() = unexpected length, want: %d, got: %d\n", test.want.length, len(got))
+			}
+
+			for _, class := range test.want.classes {
+				if !strings.ContainsAny(got, class) {
+					t.Errorf("Generate() = expected at least one of %q in %q\n", class, got)
+				}
+			}
+
+			for _, c := range got {
+				if !strings.ContainsRune(test.want.allowed, c) {
+					t.Errorf("Generate() = unexpected character %q in %q\n", c, got)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerate_words(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input []GenerateOption
+		want  struct {
+			words     int
+			separator string
+			extras    int
+		}
+		wantErr error
+	}{
+		{
+			name: "words",
+			input: []GenerateOption{
+				func(o *GenerateOptions) {
+					o.Words = 6
+				},
+			},
+			want: struct {
+				words     int
+				separator string
+				extras    int
+			}{
+				words:     6,
+				separator: "-",
+			},
+		},
+		{
+			name: "words with separator, number and symbol",
+			input: []GenerateOption{
+				func(o *GenerateOptions) {
+					o.Words = 4
+					o.Separator = " "
+					o.Capitalize = true
+					o.Number = true
+					o.Symbol = true
+				},
+			},
+			want: struct {
+				words     int
+				separator string
+				extras    int
+			}{
+				words:     4,
+				separator: " ",
+				extras:    2,
+			},
+		},
+		{
+			name: "too few words",
+			input: []GenerateOption{
+				func(o *GenerateOptions) {
+					o.Words = 2
+				},
+			},
+			wantErr: ErrInvalidGenerateOptions,
+		},
+		{
+			name: "too many words",
+			input: []GenerateOption{
+				func(o *GenerateOptions) {
+					o.Words = 21
+				},
+			},
+			wantErr: ErrInvalidGenerateOptions,
+		},
+		{
+			name: "invalid separator",
+			input: []GenerateOption{
+				func(o *GenerateOptions) {
+					o.Words = 4
+					o.Separator = "\n"
+				},
+			},
+			wantErr: ErrInvalidGenerateOptions,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := Generate(test.input...)
+			if !errors.Is(gotErr, test.wantErr) {
+				t.Fatalf("Generate() = unexpected error, want: %v, got: %v\n", test.wantErr, gotErr)
+			}
+			if test.wantErr != nil {
+				return
+			}
+
+			// Some words of the wordlist contain a hyphen, so with the default
+			// separator only the minimum number of separators can be checked.
+			if test.want.separator == defaultWordSeparator {
+				if separators := strings.Count(got, test.want.separator); separators < test.want.words-1 {
+					t.Errorf("Generate() = unexpected number of words, want: %d, got: %d (%q)\n", test.want.words, separators+1, got)
+				}
+				return
+			}
+
+			words := strings.Split(got, test.want.separator)
+			if test.want.words != len(words) {
+				t.Errorf("Generate() = unexpected number of words, want: %d, got: %d (%q)\n", test.want.words, len(words), got)
+			}
+
+			var extras int
+			for _, word := range words {
+				trimmed := strings.TrimRight(word, digitCharset+specialCharset)
+				extras += len(word) - len(trimmed)
+				if !slices.Contains(wordlist(), strings.ToLower(trimmed)) {
+					t.Errorf("Generate() = unexpected word %q in %q\n", trimmed, got)
+				}
+			}
+			if test.want.extras != extras {
+				t.Errorf("Generate() = unexpected number of digits and symbols, want: %d, got: %d (%q)\n", test.want.extras, extras, got)
+			}
+		})
+	}
+}
+
+func TestWordlist(t *testing.T) {
+	words := wordlist()
+	if len(words) != 7776 {
+		t.Errorf("wordlist() = unexpected number of words, want: %d, got: %d\n", 7776, len(words))
+	}
+}
@@ -1,6 +1,11 @@
 package secret
 
-import "time"
+import (
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/mail"
+	"github.com/RedeployAB/burnit/internal/metrics"
+)
 
 // WithTimeout sets the timeout for the service.
 func WithTimeout(d time.Duration) ServiceOption {
@@ -16,3 +21,26 @@ func WithValueMaxCharacters(max int) ServiceOption {
 		s.valueMaxCharacters = max
 	}
 }
+
+// WithPassphraseMinScore sets the minimum estimated strength score
+// (0-4) of custom passphrases. 0 disables the check.
+func WithPassphraseMinScore(score int) ServiceOption {
+	return func(s *service) {
+		s.passphraseMinScore = score
+	}
+}
+
+// WithMetrics sets the metrics for the service.
+func WithMetrics(m *metrics.Metrics) ServiceOption {
+	return func(s *service) {
+		s.metrics = m
+	}
+}
+
+// WithMailer sets the mailer for the service. It is required for
+// secrets with an email second factor.
+func WithMailer(m mail.Mailer) ServiceOption {
+	return func(s *service) {
+		s.mailer = m
+	}
+}
@@ -0,0 +1,262 @@
+package secret
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	netmail "net/mail"
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/db"
+	dberrors "github.com/RedeployAB/burnit/internal/db/errors"
+	"github.com/RedeployAB/burnit/internal/mail"
+	"github.com/RedeployAB/burnit/internal/security"
+	"github.com/RedeployAB/burnit/internal/tracing"
+)
+
+const (
+	// SecondFactorTOTP is a second factor with a time-based one-time
+	// password from a TOTP secret shared by the creator of the secret.
+	SecondFactorTOTP = "totp"
+	// SecondFactorEmail is a second factor with a one-time code sent
+	// to an email address.
+	SecondFactorEmail = "email"
+)
+
+const (
+	// totpPeriod is the period of a TOTP code.
+	totpPeriod = 30 * time.Second
+	// codeSkew is the number of periods before and after the current
+	// period a TOTP code is accepted for.
+	codeSkew = 1
+	// emailCodeDuration is the duration a code sent by email is valid for.
+	emailCodeDuration = 10 * time.Minute
+	// sendCodeInterval is the minimum interval between codes sent by
+	// email for a secret.
+	sendCodeInterval = 1 * time.Minute
+	// maxSecondFactorAttempts is the number of failed attempts to verify
+	// the second factor of a secret after which the secret is deleted.
+	maxSecondFactorAttempts = 5
+)
+
+// SecondFactor contains the second factor required to read a secret.
+type SecondFactor struct {
+	// Type is the type of the second factor, SecondFactorTOTP or
+	// SecondFactorEmail.
+	Type string
+	// TOTPSecret is the base32 encoded TOTP secret. One is generated
+	// if it is not set when creating a secret.
+	TOTPSecret string
+	// Email is the address codes are sent to.
+	Email string
+}
+
+// secondFactorData contains the data used to verify a second factor.
+// It is stored encrypted with the passphrase of the secret.
+type secondFactorData struct {
+	Seed  string `json:"seed"`
+	Email string `json:"email,omitempty"`
+}
+
+// newSecondFactor validates the second factor of a secret to be created.
+// It returns the second factor together with the data to store with the
+// secret.
+func newSecondFactor(secondFactor SecondFactor, mailer mail.Mailer) (SecondFactor, secondFactorData, error) {
+	switch secondFactor.Type {
+	case SecondFactorTOTP:
+		if len(secondFactor.TOTPSecret) == 0 {
+			seed, err := security.GenerateTOTPSecret()
+			if err != nil {
+				return SecondFactor{}, secondFactorData{}, err
+			}
+			secondFactor.TOTPSecret = seed
+		} else if err := security.ValidTOTPSecret(secondFactor.TOTPSecret); err != nil {
+			return SecondFactor{}, secondFactorData{}, fmt.Errorf("%w: totp secret must be base32 encoded and at least 16 characters", ErrSecondFactorInvalid)
+		}
+		return SecondFactor{Type: SecondFactorTOTP, TOTPSecret: secondFactor.TOTPSecret}, secondFactorData{Seed: secondFactor.TOTPSecret}, nil
+	case SecondFactorEmail:
+		if mailer == nil {
+			return SecondFactor{}, secondFactorData{}, fmt.Errorf("%w: email is not supported", ErrSecondFactorInvalid)
+		}
+		addr, err := netmail.ParseAddress(secondFactor.Email)
+		if err != nil {
+			return SecondFactor{}, secondFactorData{}, fmt.Errorf("%w: invalid email address", ErrSecondFactorInvalid)
+		}
+		seed, err := security.GenerateTOTPSecret()
+		if err != nil {
+			return SecondFactor{}, secondFactorData{}, err
+		}
+		return SecondFactor{Type: SecondFactorEmail, Email: addr.Address}, secondFactorData{Seed: seed, Email: addr.Address}, nil
+	}
+	return SecondFactor{}, secondFactorData{}, fmt.Errorf("%w: type must be %s or %s", ErrSecondFactorInvalid, SecondFactorTOTP, SecondFactorEmail)
+}
+
+// encryptSecondFactor encrypts the data of a second factor with the passphrase
+// of the secret.
+func encryptSecondFactor(data secondFactorData, passphrase string) (string, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return encrypt(string(b), passphrase)
+}
+
+// decryptSecondFactor decrypts the data of a second factor with the passphrase
+// of the secret.
+func decryptSecondFactor(data, passphrase string, hashed bool) (secondFactorData, error) {
+	decrypted, err := decrypt(data, passphrase, hashed)
+	if err != nil {
+		return secondFactorData{}, err
+	}
+	var sfd secondFactorData
+	if err := json.Unmarshal([]byte(decrypted), &sfd); err != nil {
+		return secondFactorData{}, err
+	}
+	return sfd, nil
+}
+
+// verifySecondFactor verifies the code of the second factor of a secret.
+// Codes sent by email are verified against the hash of the latest code
+// sent for the secret, until it expires.
+func verifySecondFactor(secret db.Secret, data secondFactorData, code string) error {
+	if len(code) == 0 {
+		return fmt.Errorf("%w: %s", ErrSecondFactorRequired, secret.SecondFactor)
+	}
+	if secret.SecondFactor == SecondFactorEmail {
+		if len(secret.CodeHash) == 0 || !now().Before(secret.CodeExpiresAt) {
+			return ErrInvalidSecondFactorCode
+		}
+		if subtle.ConstantTimeCompare([]byte(hashCode(data.Seed, code)), []byte(secret.CodeHash)) != 1 {
+			return ErrInvalidSecondFactorCode
+		}
+		return nil
+	}
+	if !security.VerifyTOTP(data.Seed, code, now(), totpPeriod, codeSkew) {
+		return ErrInvalidSecondFactorCode
+	}
+	return nil
+}
+
+// hashCode hashes a code sent by email with the seed of the second
+// factor, so that the stored hash can not be checked against all
+// possible codes without the passphrase of the secret.
+func hashCode(seed, code string) string {
+	return hex.EncodeToString(security.HMACSHA256([]byte(seed), []byte(code)))
+}
+
+// failedSecondFactor counts a failed attempt to verify the second factor
+// of a secret. The secret is deleted when the number of failed attempts
+// reaches maxSecondFactorAttempts, and ErrSecondFactorAttemptsExceeded
+// is returned.
+func (s service) failedSecondFactor(ctx context.Context, id string) error {
+	attempts, err := s.secrets.IncrementFailedAttempts(ctx, id)
+	if err != nil {
+		if errors.Is(err, dberrors.ErrSecretNotFound) {
+			return ErrSecretNotFound
+		}
+		s.metrics.StoreError(metricsStore, "incrementFailedAttempts")
+		return fmt.Errorf("secret store: %w", err)
+	}
+	if attempts < maxSecondFactorAttempts {
+		return nil
+	}
+
+	if err := s.secrets.Delete(ctx, id); err != nil && !errors.Is(err, dberrors.ErrSecretNotFound) {
+		s.metrics.StoreError(metricsStore, "delete")
+		return fmt.Errorf("secret store: %w", err)
+	}
+	return ErrSecondFactorAttemptsExceeded
+}
+
+// SendCodeOptions contains options for sending a second factor code.
+type SendCodeOptions struct {
+	PassphraseHashed bool
+	// SourceIP is the IP address of the reader. It is checked against the
+	// allowed networks of the secret.
+	SourceIP string
+}
+
+// SendCodeOption is a function that sets options for sending a second
+// factor code.
+type SendCodeOption func(o *SendCodeOptions)
+
+// SendCode sends a code for the second factor of a secret to the email
+// address set when the secret was created. The passphrase is required
+// to decrypt the address. The code is random, can only be used once and
+// replaces any previous code of the secret. ErrCodeRecentlySent is
+// returned if a code has been sent within sendCodeInterval.
+func (s service) SendCode(ctx context.Context, id, passphrase string, options ...SendCodeOption) (err error) {
+	opts := SendCodeOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	ctx, span := tracing.Start(ctx, "secret.Service.SendCode")
+	defer func() {
+		tracing.End(span, unexpectedError(err))
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	dbSecret, err := s.secrets.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, dberrors.ErrSecretNotFound) {
+			return ErrSecretNotFound
+		}
+		s.metrics.StoreError(metricsStore, "get")
+		return fmt.Errorf("secret store: %w", err)
+	}
+	if dbSecret.ExpiresAt.Before(now()) {
+		return ErrSecretNotFound
+	}
+	if !networkAllowed(dbSecret.AllowedNetworks, opts.SourceIP) {
+		return ErrNetworkNotAllowed
+	}
+	if !dbSecret.NotBefore.IsZero() && now().Before(dbSecret.NotBefore) {
+		return fmt.Errorf("%w: available from %s", ErrSecretNotAvailable, dbSecret.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if dbSecret.SecondFactor != SecondFactorEmail || s.mailer == nil {
+		return fmt.Errorf("%w: secret does not have an email second factor", ErrSecondFactorInvalid)
+	}
+
+	data, err := decryptSecondFactor(dbSecret.SecondFactorData, passphrase, opts.PassphraseHashed)
+	if err != nil {
+		if errors.Is(err, security.ErrInvalidKey) {
+			s.metrics.SecretFailedPassphrase()
+			return ErrInvalidPassphrase
+		}
+		return fmt.Errorf("secret service: %w", err)
+	}
+
+	if !dbSecret.CodeExpiresAt.IsZero() {
+		if wait := dbSecret.CodeExpiresAt.Add(sendCodeInterval - emailCodeDuration).Sub(now()); wait > 0 {
+			return fmt.Errorf("%w: a new code can be sent in %d seconds", ErrCodeRecentlySent, int(math.Ceil(wait.Seconds())))
+		}
+	}
+
+	code, err := security.GenerateCode()
+	if err != nil {
+		return fmt.Errorf("secret service: %w", err)
+	}
+	if err := s.secrets.SetCode(ctx, id, hashCode(data.Seed, code), now().Add(emailCodeDuration)); err != nil {
+		if errors.Is(err, dberrors.ErrSecretNotFound) {
+			return ErrSecretNotFound
+		}
+		s.metrics.StoreError(metricsStore, "setCode")
+		return fmt.Errorf("secret store: %w", err)
+	}
+
+	if err := s.mailer.Send(ctx, mail.Message{
+		To:      data.Email,
+		Subject: "Code for reading secret",
+		Body:    fmt.Sprintf("Your code for reading the secret is %s.\n\nThe code expires within %d minutes and can only be used once.", code, int(emailCodeDuration.Minutes())),
+	}); err != nil {
+		return fmt.Errorf("secret service: %w", err)
+	}
+	return nil
+}
@@ -1,21 +1,6 @@
 package secret
 
-import (
-	"math/rand"
-	"strings"
-	"time"
-)
-
-const (
-	// charset is the standard letters used for generating a secret.
-	charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUWXYZ"
-	// specialCharset is the special characters used for generating a secret.
-	specialCharset = "_-!?=()&%"
-	// defaultGenerateSecretLength is the default length of a generated secret.
-	defaultGenerateSecretLength = 16
-	// maxGenerateSecretLength is the maximum length of a generated secret.
-	maxGenerateSecretLength = 512
-)
+import "time"
 
 // Secret contains the secret data.
 type Secret struct {
@@ -24,48 +9,29 @@ type Secret struct {
 	Passphrase string
 	TTL        time.Duration
 	ExpiresAt  time.Time
+	// NotBefore is the time from which the secret can be read. The
+	// secret can be read immediately if zero.
+	NotBefore time.Time
+	// CreatedBy identifies who created the secret, for auditing.
+	// It is stored with the secret but never returned to readers.
+	CreatedBy string
+	// AllowedNetworks contains the networks (CIDRs or IP addresses) that
+	// are allowed to read the secret. All networks are allowed if empty.
+	AllowedNetworks []string
+	// SecondFactor is the second factor required to read the secret.
+	// No second factor is required if nil.
+	SecondFactor *SecondFactor
+	// Generate contains the options to generate the value of the secret
+	// when it is created, instead of providing it. The generated value
+	// is returned by Create.
+	Generate *GenerateOptions
+	// Content is the structured content of the secret, such as
+	// credentials or key-value, instead of the text of Value.
+	Content *Content
+	// Label is a short label that is stored unencrypted and shown before
+	// the secret is read, to tell secrets apart.
+	Label string
+	// Note is a free-text note that is encrypted together with the
+	// secret and delivered alongside the value.
+	Note string
 }
-
-// GenerateOptions contains the options for generating a new secret.
-type GenerateOptions struct {
-	Length            int
-	SpecialCharacters bool
-}
-
-// GenerateOption is a function that sets options for generating a new secret.
-type GenerateOption func(o *GenerateOptions)
-
-// Generate new secret. The length of the secret is set by the provided
-// length argument (with a max of 512 characters, a longer length will be trimmed to this value).
-// If specialCharacters is set to true, the secret will contain special characters.
-func Generate(options ...GenerateOption) string {
-	opts := GenerateOptions{
-		Length: defaultGenerateSecretLength,
-	}
-	for _, option := range options {
-		option(&opts)
-	}
-	if opts.Length > maxGenerateSecretLength {
-		opts.Length = maxGenerateSecretLength
-	}
-
-	chars := charset
-	if opts.SpecialCharacters {
-		chars += specialCharset
-	}
-
-	var builder strings.Builder
-	builder.Grow(opts.Length * 3)
-	len := len(chars)
-
-	for i := 0; i < opts.Length; i++ {
-		builder.WriteByte(chars[rand.Intn(len)])
-	}
-
-	return builder.String()
-}
-
-// generate new secret. The length of the secret is set by the provided
-// length argument (with a max of 512 characters, a longer length will be trimmed to this value).
-// If specialCharacters is set to true, the secret will contain special characters.
-var generate = Generate
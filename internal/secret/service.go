@@ -8,12 +8,18 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/netip"
+	"strings"
 	"time"
+	"unicode"
 	"unicode/utf8"
 
 	"github.com/RedeployAB/burnit/internal/db"
 	dberrors "github.com/RedeployAB/burnit/internal/db/errors"
+	"github.com/RedeployAB/burnit/internal/mail"
+	"github.com/RedeployAB/burnit/internal/metrics"
 	"github.com/RedeployAB/burnit/internal/security"
+	"github.com/RedeployAB/burnit/internal/tracing"
 	"github.com/google/uuid"
 )
 
@@ -24,6 +30,9 @@ const (
 	defaultMinTTL = 1*time.Minute - 5*time.Second
 	// defaultMaxTTL is the default maximum TTL of a secret.
 	defaultMaxTTL = 168*time.Hour + 5*time.Second
+	// defaultMaxNotBefore is the default maximum time until a secret
+	// becomes available.
+	defaultMaxNotBefore = 30 * 24 * time.Hour
 	// defaultTimeout is the default timeout for service operations.
 	defaultTimeout = 10 * time.Second
 	// defaultCleanupInterval is the default interval for cleaning up expired secrets.
@@ -44,6 +53,32 @@ const (
 	defaultPassphraseMaxCharacters = 64
 )
 
+// passphraseStrengthInputs contains words that are guessable for passphrases
+// of this service, in addition to the dictionaries of the estimator.
+var passphraseStrengthInputs = []string{"burnit", "secret", "passphrase"}
+
+const (
+	// maxLabelCharacters is the maximum number of characters of the label of a secret.
+	maxLabelCharacters = 100
+	// maxNoteCharacters is the maximum number of characters of the note of a secret.
+	maxNoteCharacters = 1000
+)
+
+const (
+	// maxBatchSecrets is the maximum number of secrets in a batch.
+	maxBatchSecrets = 100
+)
+
+const (
+	// maxAllowedNetworks is the maximum number of networks allowed to read a secret.
+	maxAllowedNetworks = 20
+)
+
+const (
+	// metricsStore is the store label for metrics.
+	metricsStore = "secrets"
+)
+
 // newUUID generates a new UUID.
 var newUUID = func() string {
 	return uuid.New().String()
@@ -52,15 +87,25 @@ var newUUID = func() string {
 // Service is the interface that provides methods for secret operations.
 type Service interface {
 	// Generate a new secret.
-	Generate(options ...GenerateOption) string
+	Generate(options ...GenerateOption) (string, error)
+	// GenerateCredential generates a credential, such as a key or a key pair.
+	GenerateCredential(typ CredentialType, options ...GenerateCredentialOption) (Credential, error)
 	// Get a secret.
-	Get(id, passphrase string, options ...GetOption) (Secret, error)
+	Get(ctx context.Context, id, passphrase string, options ...GetOption) (Secret, error)
 	// Create a secret.
-	Create(secret Secret) (Secret, error)
+	Create(ctx context.Context, secret Secret) (Secret, error)
+	// CreateBatch creates secrets in a batch.
+	CreateBatch(ctx context.Context, secrets []Secret) ([]Secret, error)
 	// Delete a secret.
-	Delete(id string, options ...DeleteOption) error
+	Delete(ctx context.Context, id string, options ...DeleteOption) error
+	// SendCode sends a code for the email second factor of a secret.
+	SendCode(ctx context.Context, id, passphrase string, options ...SendCodeOption) error
+	// PassphraseStrength estimates the strength of a custom passphrase.
+	PassphraseStrength(passphrase string) PassphraseStrength
 	// Cleanup runs a cleanup routine to delete expired secrets.
 	Cleanup() chan error
+	// Ping checks the connection to the underlying store.
+	Ping(ctx context.Context) error
 	// Close the service and its resources.
 	Close() error
 }
@@ -73,6 +118,9 @@ type service struct {
 	valueMaxCharacters      int
 	passphraseMinCharacters int
 	passphraseMaxCharacters int
+	passphraseMinScore      int
+	metrics                 *metrics.Metrics
+	mailer                  mail.Mailer
 	stopCh                  chan struct{}
 }
 
@@ -102,19 +150,29 @@ func NewService(store db.SecretStore, options ...ServiceOption) (*service, error
 	return svc, nil
 }
 
-// Generate a new secret. The length of the secret is set by the provided
-// length argument (with a max of 512 characters, a longer length will be trimmed to this value).
-// If specialCharacters is set to true, the secret will contain special characters.
-func (s service) Generate(opts ...GenerateOption) string {
+// Generate a new secret. The length and character classes of the secret
+// are set by the provided options. See GenerateOptions.
+func (s service) Generate(opts ...GenerateOption) (string, error) {
 	return generate(opts...)
 }
 
+// GenerateCredential generates a credential of the provided type, such as
+// a symmetric key, an SSH key pair or a self-signed TLS certificate.
+func (s service) GenerateCredential(typ CredentialType, opts ...GenerateCredentialOption) (Credential, error) {
+	return generateCredential(typ, opts...)
+}
+
 // GetOptions contains options for getting a secret.
 type GetOptions struct {
 	NoDelete         bool
 	NoDecrypt        bool
 	PassphraseHashed bool
-	context          context.Context
+	// SourceIP is the IP address of the reader. It is checked against the
+	// allowed networks of the secret.
+	SourceIP string
+	// Code is the code of the second factor of the secret, if any.
+	Code   string
+	delete bool
 }
 
 // GetOption is a function that sets options for getting a secret.
@@ -122,53 +180,137 @@ type GetOption func(o *GetOptions)
 
 // Get a secret. The secret is deleted after it has been retrieved
 // and successfully decrypted if the option to delete it is set.
-func (s service) Get(id, passphrase string, options ...GetOption) (Secret, error) {
+// If the secret has allowed networks and the source IP is not
+// within them, ErrNetworkNotAllowed is returned and the secret
+// is kept. If the secret is read before its not before time,
+// ErrSecretNotAvailable is returned together with the ID and
+// not before time of the secret, and the secret is kept. If the
+// secret has a second factor, the code is verified before the
+// secret is decrypted. ErrSecondFactorRequired is returned together
+// with the ID and the type of the second factor if no code is
+// provided, and the secret is kept. Invalid codes are counted. After
+// maxSecondFactorAttempts invalid codes the secret is deleted and
+// ErrSecondFactorAttemptsExceeded is returned. The unencrypted label
+// of the secret is returned in all of these cases, for an invalid
+// passphrase and when the secret is not decrypted, so that it can be
+// shown before the secret is read.
+func (s service) Get(ctx context.Context, id, passphrase string, options ...GetOption) (secret Secret, err error) {
 	opts := GetOptions{}
 	for _, option := range options {
 		option(&opts)
 	}
 
-	var ctx context.Context
-	if opts.context != nil {
-		ctx = opts.context
-	} else {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(context.Background(), s.timeout)
-		defer cancel()
-	}
+	ctx, span := tracing.Start(ctx, "secret.Service.Get")
+	defer func() {
+		tracing.End(span, unexpectedError(err))
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
 
 	dbSecret, err := s.secrets.Get(ctx, id)
 	if err != nil {
 		if errors.Is(err, dberrors.ErrSecretNotFound) {
 			return Secret{}, ErrSecretNotFound
 		}
+		s.metrics.StoreError(metricsStore, "get")
 		return Secret{}, fmt.Errorf("secret store: %w", err)
 	}
 
 	if dbSecret.ExpiresAt.Before(now()) {
+		s.metrics.SecretExpired()
 		if err := s.secrets.Delete(ctx, id); err != nil {
+			s.metrics.StoreError(metricsStore, "delete")
 			return Secret{}, fmt.Errorf("secret store: %w", err)
 		}
 		return Secret{}, ErrSecretNotFound
 	}
 
-	if opts.NoDecrypt {
+	if !networkAllowed(dbSecret.AllowedNetworks, opts.SourceIP) {
+		return Secret{}, ErrNetworkNotAllowed
+	}
+
+	if !dbSecret.NotBefore.IsZero() && now().Before(dbSecret.NotBefore) {
 		return Secret{
-			ID: dbSecret.ID,
-		}, nil
+			ID:        dbSecret.ID,
+			NotBefore: dbSecret.NotBefore,
+			Label:     dbSecret.Label,
+		}, fmt.Errorf("%w: available from %s", ErrSecretNotAvailable, dbSecret.NotBefore.UTC().Format(time.RFC3339))
+	}
+
+	if opts.NoDecrypt {
+		secret = Secret{
+			ID:    dbSecret.ID,
+			Label: dbSecret.Label,
+		}
+		if len(dbSecret.SecondFactor) > 0 {
+			secret.SecondFactor = &SecondFactor{Type: dbSecret.SecondFactor}
+		}
+		return secret, nil
+	}
+
+	if len(dbSecret.SecondFactor) > 0 {
+		data, err := decryptSecondFactor(dbSecret.SecondFactorData, passphrase, opts.PassphraseHashed)
+		if err != nil {
+			if errors.Is(err, security.ErrInvalidKey) {
+				s.metrics.SecretFailedPassphrase()
+				return Secret{ID: dbSecret.ID, Label: dbSecret.Label}, ErrInvalidPassphrase
+			}
+			return Secret{}, fmt.Errorf("secret service: %w", err)
+		}
+		if err := verifySecondFactor(dbSecret, data, opts.Code); err != nil {
+			if errors.Is(err, ErrInvalidSecondFactorCode) {
+				if err := s.failedSecondFactor(ctx, id); err != nil {
+					return Secret{}, err
+				}
+			}
+			return Secret{
+				ID:           dbSecret.ID,
+				SecondFactor: &SecondFactor{Type: dbSecret.SecondFactor},
+				Label:        dbSecret.Label,
+			}, err
+		}
+		// Codes sent by email can only be used once.
+		if len(dbSecret.CodeHash) > 0 {
+			if err := s.secrets.SetCode(ctx, id, "", time.Time{}); err != nil {
+				if errors.Is(err, dberrors.ErrSecretNotFound) {
+					return Secret{}, ErrSecretNotFound
+				}
+				s.metrics.StoreError(metricsStore, "setCode")
+				return Secret{}, fmt.Errorf("secret store: %w", err)
+			}
+		}
 	}
 
 	decrypted, err := decrypt(dbSecret.Value, passphrase, opts.PassphraseHashed)
 	if err != nil {
 		if errors.Is(err, security.ErrInvalidKey) {
-			return Secret{}, ErrInvalidPassphrase
+			s.metrics.SecretFailedPassphrase()
+			return Secret{ID: dbSecret.ID, Label: dbSecret.Label}, ErrInvalidPassphrase
 		}
 		return Secret{}, fmt.Errorf("secret service: %w", err)
 	}
 
-	secret := Secret{
+	var note string
+	if len(dbSecret.Note) > 0 {
+		note, err = decrypt(dbSecret.Note, passphrase, opts.PassphraseHashed)
+		if err != nil {
+			return Secret{}, fmt.Errorf("secret service: %w", err)
+		}
+	}
+
+	secret = Secret{
 		ID:    dbSecret.ID,
 		Value: string(decrypted),
+		Label: dbSecret.Label,
+		Note:  note,
+	}
+	content, ok, err := decodeContent(secret.Value)
+	if err != nil {
+		return Secret{}, fmt.Errorf("secret service: %w", err)
+	}
+	if ok {
+		secret.Value, secret.Content = "", &content
 	}
 
 	if opts.NoDelete {
@@ -176,58 +318,232 @@ func (s service) Get(id, passphrase string, options ...GetOption) (Secret, error
 	}
 
 	if err := s.secrets.Delete(ctx, id); err != nil {
+		s.metrics.StoreError(metricsStore, "delete")
 		return secret, fmt.Errorf("secret store: %w", err)
 	}
 
+	if opts.delete {
+		s.metrics.SecretDeleted()
+	} else {
+		s.metrics.SecretRead()
+	}
+
 	return secret, nil
 }
 
 // Create a secret.
-func (s service) Create(secret Secret) (Secret, error) {
-	if err := validValue(secret.Value, s.valueMaxCharacters); err != nil {
+func (s service) Create(ctx context.Context, secret Secret) (_ Secret, err error) {
+	ctx, span := tracing.Start(ctx, "secret.Service.Create")
+	defer func() {
+		tracing.End(span, unexpectedError(err))
+	}()
+
+	dbSecret, created, err := s.newSecret(secret)
+	if err != nil {
 		return Secret{}, err
 	}
 
-	expiresAt, err := expirationTime(secret.TTL, secret.ExpiresAt)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	dbSecret, err = s.secrets.Create(ctx, dbSecret)
 	if err != nil {
-		return Secret{}, err
+		s.metrics.StoreError(metricsStore, "create")
+		return Secret{}, fmt.Errorf("secret store: %w", err)
+	}
+	s.metrics.SecretCreated()
+
+	return createdSecret(dbSecret, created), nil
+}
+
+// CreateBatch creates secrets in a batch. All secrets are validated
+// before any of them are stored, and they are stored in a transaction
+// where the store supports it. Errors for a secret contain its index
+// in the batch.
+func (s service) CreateBatch(ctx context.Context, secrets []Secret) (_ []Secret, err error) {
+	ctx, span := tracing.Start(ctx, "secret.Service.CreateBatch")
+	defer func() {
+		tracing.End(span, unexpectedError(err))
+	}()
+
+	if len(secrets) == 0 || len(secrets) > maxBatchSecrets {
+		return nil, fmt.Errorf("%w: batch must contain between 1 and %d secrets", ErrInvalidBatch, maxBatchSecrets)
+	}
+
+	dbSecrets := make([]db.Secret, 0, len(secrets))
+	created := make([]Secret, 0, len(secrets))
+	for i, secret := range secrets {
+		dbSecret, c, err := s.newSecret(secret)
+		if err != nil {
+			return nil, fmt.Errorf("secret %d: %w", i, err)
+		}
+		dbSecrets = append(dbSecrets, dbSecret)
+		created = append(created, c)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	dbSecrets, err = s.secrets.CreateMany(ctx, dbSecrets)
+	if err != nil {
+		s.metrics.StoreError(metricsStore, "createMany")
+		return nil, fmt.Errorf("secret store: %w", err)
+	}
+	if len(dbSecrets) != len(created) {
+		return nil, errors.New("secret store: unexpected number of created secrets")
+	}
+
+	for i := range created {
+		created[i] = createdSecret(dbSecrets[i], created[i])
+		s.metrics.SecretCreated()
+	}
+
+	return created, nil
+}
+
+// newSecret validates a secret and returns it encrypted to be stored,
+// together with the parts of the secret that are returned when it has
+// been created: the generated value and passphrase, the second factor
+// and the label.
+func (s service) newSecret(secret Secret) (db.Secret, Secret, error) {
+	if secret.Content != nil && (len(secret.Value) > 0 || secret.Generate != nil) {
+		return db.Secret{}, Secret{}, fmt.Errorf("%w: value can not be provided together with content", ErrInvalidContent)
+	}
+
+	var generated string
+	if secret.Generate != nil {
+		if len(secret.Value) > 0 {
+			return db.Secret{}, Secret{}, fmt.Errorf("%w: value can not be provided when it is generated", ErrValueInvalid)
+		}
+		var err error
+		generated, err = generate(func(o *GenerateOptions) {
+			*o = *secret.Generate
+		})
+		if err != nil {
+			return db.Secret{}, Secret{}, err
+		}
+		secret.Value = generated
+	}
+
+	value := secret.Value
+	if secret.Content != nil {
+		var err error
+		value, err = encodeContent(*secret.Content, s.valueMaxCharacters)
+		if err != nil {
+			return db.Secret{}, Secret{}, err
+		}
+	} else {
+		if err := validValue(secret.Value, s.valueMaxCharacters); err != nil {
+			return db.Secret{}, Secret{}, err
+		}
+		if strings.HasPrefix(secret.Value, contentPrefix) {
+			return db.Secret{}, Secret{}, fmt.Errorf("%w: secret value must be a valid non-empty UTF-8 encoded string", ErrValueInvalid)
+		}
+	}
+
+	label, err := validLabel(secret.Label)
+	if err != nil {
+		return db.Secret{}, Secret{}, err
+	}
+	if err := validNote(secret.Note); err != nil {
+		return db.Secret{}, Secret{}, err
+	}
+
+	expiresAt, err := expirationTime(secret.TTL, secret.ExpiresAt, secret.NotBefore)
+	if err != nil {
+		return db.Secret{}, Secret{}, err
+	}
+
+	allowedNetworks, err := parseAllowedNetworks(secret.AllowedNetworks)
+	if err != nil {
+		return db.Secret{}, Secret{}, err
+	}
+
+	var secondFactor *SecondFactor
+	var secondFactorData secondFactorData
+	if secret.SecondFactor != nil {
+		sf, data, err := newSecondFactor(*secret.SecondFactor, s.mailer)
+		if err != nil {
+			return db.Secret{}, Secret{}, err
+		}
+		secondFactor, secondFactorData = &sf, data
 	}
 
 	passphrase := secret.Passphrase
 	if len(passphrase) == 0 {
-		passphrase = generate(func(o *GenerateOptions) {
+		var err error
+		passphrase, err = generate(func(o *GenerateOptions) {
 			o.Length = defaultPassphraseCharacters
 			o.SpecialCharacters = true
 		})
+		if err != nil {
+			return db.Secret{}, Secret{}, fmt.Errorf("secret service: %w", err)
+		}
 	} else {
 		if err := validPassphrase(passphrase, s.passphraseMinCharacters, s.passphraseMaxCharacters); err != nil {
-			return Secret{}, err
+			return db.Secret{}, Secret{}, err
+		}
+		if err := s.strongPassphrase(passphrase); err != nil {
+			return db.Secret{}, Secret{}, err
 		}
 	}
 
-	encrypted, err := encrypt(secret.Value, passphrase)
+	encrypted, err := encrypt(value, passphrase)
 	if err != nil {
-		return Secret{}, fmt.Errorf("secret service: %w", err)
+		return db.Secret{}, Secret{}, fmt.Errorf("secret service: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
-	defer cancel()
+	var encryptedNote string
+	if len(secret.Note) > 0 {
+		encryptedNote, err = encrypt(secret.Note, passphrase)
+		if err != nil {
+			return db.Secret{}, Secret{}, fmt.Errorf("secret service: %w", err)
+		}
+	}
 
-	dbSecret, err := s.secrets.Create(ctx, db.Secret{
-		ID:        newUUID(),
-		Value:     encrypted,
-		ExpiresAt: expiresAt,
-	})
-	if err != nil {
-		return Secret{}, fmt.Errorf("secret store: %w", err)
+	var secondFactorType, encryptedSecondFactor string
+	if secondFactor != nil {
+		secondFactorType = secondFactor.Type
+		encryptedSecondFactor, err = encryptSecondFactor(secondFactorData, passphrase)
+		if err != nil {
+			return db.Secret{}, Secret{}, fmt.Errorf("secret service: %w", err)
+		}
 	}
 
+	dbSecret := db.Secret{
+		ID:               newUUID(),
+		Value:            encrypted,
+		ExpiresAt:        expiresAt,
+		NotBefore:        secret.NotBefore,
+		CreatedBy:        secret.CreatedBy,
+		AllowedNetworks:  allowedNetworks,
+		SecondFactor:     secondFactorType,
+		SecondFactorData: encryptedSecondFactor,
+		Label:            label,
+		Note:             encryptedNote,
+	}
+	created := Secret{
+		Value:        generated,
+		Passphrase:   passphrase,
+		SecondFactor: secondFactor,
+		Label:        label,
+	}
+	return dbSecret, created, nil
+}
+
+// createdSecret returns the secret that is returned when it has been
+// created, from the stored secret and the secret returned by newSecret.
+func createdSecret(dbSecret db.Secret, secret Secret) Secret {
 	return Secret{
-		ID:         dbSecret.ID,
-		Passphrase: passphrase,
-		TTL:        time.Until(dbSecret.ExpiresAt).Round(time.Minute),
-		ExpiresAt:  dbSecret.ExpiresAt,
-	}, nil
+		ID:           dbSecret.ID,
+		Value:        secret.Value,
+		Passphrase:   secret.Passphrase,
+		TTL:          time.Until(dbSecret.ExpiresAt).Round(time.Minute),
+		ExpiresAt:    dbSecret.ExpiresAt,
+		NotBefore:    dbSecret.NotBefore,
+		SecondFactor: secret.SecondFactor,
+		Label:        secret.Label,
+	}
 }
 
 // DeleteOptions contains options for deleting a secret.
@@ -235,25 +551,35 @@ type DeleteOptions struct {
 	Passphrase       string
 	VerifyPassphrase bool
 	PassphraseHashed bool
+	// SourceIP is the IP address of the caller. It is checked against the
+	// allowed networks of the secret when the passphrase is verified.
+	SourceIP string
+	// Code is the code of the second factor of the secret, if any. It is
+	// verified together with the passphrase.
+	Code string
 }
 
 // DeleteOption is a function that sets options for deleting a secret.
 type DeleteOption func(o *DeleteOptions)
 
 // Delete a secret.
-func (s service) Delete(id string, options ...DeleteOption) error {
+func (s service) Delete(ctx context.Context, id string, options ...DeleteOption) (err error) {
 	opts := DeleteOptions{}
 	for _, option := range options {
 		option(&opts)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
-	defer cancel()
+	ctx, span := tracing.Start(ctx, "secret.Service.Delete")
+	defer func() {
+		tracing.End(span, unexpectedError(err))
+	}()
 
 	if opts.VerifyPassphrase {
-		_, err := s.Get(id, opts.Passphrase, func(o *GetOptions) {
+		_, err := s.Get(ctx, id, opts.Passphrase, func(o *GetOptions) {
 			o.PassphraseHashed = opts.PassphraseHashed
-			o.context = ctx
+			o.SourceIP = opts.SourceIP
+			o.Code = opts.Code
+			o.delete = true
 		})
 		if err != nil {
 			return err
@@ -261,14 +587,19 @@ func (s service) Delete(id string, options ...DeleteOption) error {
 		return nil
 	}
 
-	err := s.secrets.Delete(ctx, id)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	err = s.secrets.Delete(ctx, id)
 	if err == nil {
+		s.metrics.SecretDeleted()
 		return nil
 	}
 
 	if errors.Is(err, dberrors.ErrSecretNotFound) || errors.Is(err, dberrors.ErrSecretNotDeleted) {
 		return ErrSecretNotFound
 	}
+	s.metrics.StoreError(metricsStore, "delete")
 	return fmt.Errorf("secret store: %w", err)
 }
 
@@ -288,11 +619,14 @@ func (s *service) Cleanup() chan error {
 			case <-time.After(s.cleanupInterval):
 				ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 
+				start := time.Now()
 				if err := s.secrets.DeleteExpired(ctx); err != nil {
 					if !errors.Is(err, dberrors.ErrSecretsNotDeleted) {
+						s.metrics.StoreError(metricsStore, "deleteExpired")
 						errCh <- fmt.Errorf("secret store: %w", err)
 					}
 				}
+				s.metrics.ObserveCleanup(metricsStore, time.Since(start))
 				cancel()
 			case <-s.stopCh:
 				return
@@ -302,6 +636,14 @@ func (s *service) Cleanup() chan error {
 	return errCh
 }
 
+// Ping checks the connection to the underlying store.
+func (s service) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.secrets.Ping(ctx)
+}
+
 // Close the service and its resources.
 func (s *service) Close() error {
 	s.stopCh <- struct{}{}
@@ -309,14 +651,29 @@ func (s *service) Close() error {
 }
 
 // expirationTime returns the expiration time of a secret. It
-// validates the provided duration and expiration time and returns
-// the expiration time based on the provided values.
-func expirationTime(ttl time.Duration, expiresAt time.Time) (time.Time, error) {
+// validates the provided duration, expiration time and not before
+// time and returns the expiration time based on the provided values.
+// If a not before time is provided, the duration (and the limits of
+// the expiration time) starts at the not before time.
+func expirationTime(ttl time.Duration, expiresAt, notBefore time.Time) (time.Time, error) {
 	current := now()
+	if !notBefore.IsZero() {
+		if notBefore.Before(current) {
+			return time.Time{}, fmt.Errorf("%w: must be in the future", ErrInvalidNotBefore)
+		}
+		if notBefore.After(current.Add(defaultMaxNotBefore)) {
+			return time.Time{}, fmt.Errorf("%w: must be within 30 days", ErrInvalidNotBefore)
+		}
+		current = notBefore
+	}
+
 	n := current
 	if !expiresAt.IsZero() {
 		n = expiresAt
 		if n.Before(current) {
+			if !notBefore.IsZero() {
+				return time.Time{}, fmt.Errorf("%w: must be after the not before time", ErrInvalidExpirationTime)
+			}
 			return time.Time{}, fmt.Errorf("%w: must be in the future", ErrInvalidExpirationTime)
 		}
 	} else if ttl > 0 {
@@ -437,3 +794,154 @@ func validPassphrase(passphrase string, minCharacters, maxCharacters int) error
 	}
 	return nil
 }
+
+// validLabel validates the label of a secret and returns it without
+// surrounding whitespace. The label is a single line as it is shown
+// unencrypted before the secret is read.
+func validLabel(label string) (string, error) {
+	label = strings.TrimSpace(label)
+	if utf8.RuneCountInString(label) > maxLabelCharacters {
+		return "", fmt.Errorf("%w: secret label max characters are %d", ErrInvalidLabel, maxLabelCharacters)
+	}
+	if !utf8.ValidString(label) || strings.IndexFunc(label, unicode.IsControl) >= 0 {
+		return "", fmt.Errorf("%w: secret label must be a valid single line UTF-8 encoded string", ErrInvalidLabel)
+	}
+	return label, nil
+}
+
+// validNote validates the note of a secret and returns an error if the
+// note is invalid.
+func validNote(note string) error {
+	if utf8.RuneCountInString(note) > maxNoteCharacters {
+		return fmt.Errorf("%w: secret note max characters are %d", ErrInvalidNote, maxNoteCharacters)
+	}
+	if !utf8.ValidString(note) || strings.ContainsRune(note, 0) {
+		return fmt.Errorf("%w: secret note must be a valid UTF-8 encoded string", ErrInvalidNote)
+	}
+	return nil
+}
+
+// PassphraseStrength contains the estimated strength of a passphrase
+// and the minimum score required by the service.
+type PassphraseStrength struct {
+	security.PassphraseStrength
+	MinScore int
+}
+
+// Sufficient returns true if the passphrase is strong enough to be used
+// for a secret.
+func (p PassphraseStrength) Sufficient() bool {
+	return p.Score >= p.MinScore
+}
+
+// PassphraseStrength estimates the strength of a custom passphrase. See
+// security.EstimatePassphraseStrength.
+func (s service) PassphraseStrength(passphrase string) PassphraseStrength {
+	return PassphraseStrength{
+		PassphraseStrength: security.EstimatePassphraseStrength(passphrase, passphraseStrengthInputs...),
+		MinScore:           s.passphraseMinScore,
+	}
+}
+
+// strongPassphrase returns an error if the estimated strength of the
+// passphrase is below the minimum score of the service.
+func (s service) strongPassphrase(passphrase string) error {
+	if s.passphraseMinScore <= security.MinPassphraseScore {
+		return nil
+	}
+	strength := s.PassphraseStrength(passphrase)
+	if strength.Sufficient() {
+		return nil
+	}
+	return fmt.Errorf("%w: secret passphrase is %s (score %d of %d), at least %s (score %d) is required", ErrPassphraseTooWeak, strength.Label(), strength.Score, security.MaxPassphraseScore, security.PassphraseStrengthLabel(strength.MinScore), strength.MinScore)
+}
+
+// parseAllowedNetworks validates the allowed networks of a secret and
+// returns them as CIDRs. Single IP addresses are converted to CIDRs
+// with a single address.
+func parseAllowedNetworks(networks []string) ([]string, error) {
+	if len(networks) == 0 {
+		return nil, nil
+	}
+	if len(networks) > maxAllowedNetworks {
+		return nil, fmt.Errorf("%w: max allowed networks are %d", ErrInvalidAllowedNetworks, maxAllowedNetworks)
+	}
+
+	parsed := make([]string, 0, len(networks))
+	for _, network := range networks {
+		network = strings.TrimSpace(network)
+		if !strings.Contains(network, "/") {
+			addr, err := netip.ParseAddr(network)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q is not a CIDR or IP address", ErrInvalidAllowedNetworks, network)
+			}
+			addr = addr.Unmap().WithZone("")
+			parsed = append(parsed, netip.PrefixFrom(addr, addr.BitLen()).String())
+			continue
+		}
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not a CIDR or IP address", ErrInvalidAllowedNetworks, network)
+		}
+		parsed = append(parsed, prefix.Masked().String())
+	}
+	return parsed, nil
+}
+
+// networkAllowed returns true if there are no allowed networks or if
+// the source IP is within any of them.
+func networkAllowed(networks []string, sourceIP string) bool {
+	if len(networks) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(sourceIP)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, network := range networks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			continue
+		}
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// unexpectedError returns the error if it is not the result of invalid
+// input or a missing secret. It is used to only record unexpected errors
+// on spans.
+func unexpectedError(err error) error {
+	for _, e := range []error{
+		ErrSecretNotFound,
+		ErrInvalidPassphrase,
+		ErrValueInvalid,
+		ErrValueTooManyCharacters,
+		ErrInvalidContent,
+		ErrInvalidLabel,
+		ErrInvalidNote,
+		ErrInvalidExpirationTime,
+		ErrInvalidNotBefore,
+		ErrSecretNotAvailable,
+		ErrPassphraseNotBase64,
+		ErrPassphraseInvalid,
+		ErrPassphraseTooManyCharacters,
+		ErrPassphraseTooFewCharacters,
+		ErrPassphraseTooWeak,
+		ErrInvalidAllowedNetworks,
+		ErrNetworkNotAllowed,
+		ErrSecondFactorInvalid,
+		ErrSecondFactorRequired,
+		ErrInvalidSecondFactorCode,
+		ErrInvalidGenerateOptions,
+		ErrInvalidBatch,
+	} {
+		if errors.Is(err, e) {
+			return nil
+		}
+	}
+	return err
+}
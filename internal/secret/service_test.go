@@ -7,13 +7,19 @@ import (
 	"encoding/hex"
 	"errors"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/RedeployAB/burnit/internal/db"
 	dberrors "github.com/RedeployAB/burnit/internal/db/errors"
+	"github.com/RedeployAB/burnit/internal/mail"
+	"github.com/RedeployAB/burnit/internal/metrics"
+	"github.com/RedeployAB/burnit/internal/security"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 )
@@ -102,9 +108,11 @@ func TestService_Get(t *testing.T) {
 	var tests = []struct {
 		name  string
 		input struct {
-			secrets db.SecretStore
-			id      string
-			key     string
+			secrets  db.SecretStore
+			id       string
+			key      string
+			sourceIP string
+			code     string
 		}
 		want    Secret
 		wantErr error
@@ -112,9 +120,76 @@ func TestService_Get(t *testing.T) {
 		{
 			name: "get secret",
 			input: struct {
-				secrets db.SecretStore
-				id      string
-				key     string
+				secrets  db.SecretStore
+				id       string
+				key      string
+				sourceIP string
+				code     string
+			}{
+				secrets: &stubSecretStore{
+					secrets: []db.Secret{
+						{
+							ID: "1",
+							Value: func() string {
+								v, _ := encrypt("secret", "key")
+								return v
+							}(),
+							ExpiresAt: now().Add(1 * time.Hour),
+						},
+					},
+				},
+				id:  "1",
+				key: "key",
+			},
+			want: Secret{
+				ID:    "1",
+				Value: "secret",
+			},
+		},
+		{
+			name: "get secret - credentials",
+			input: struct {
+				secrets  db.SecretStore
+				id       string
+				key      string
+				sourceIP string
+				code     string
+			}{
+				secrets: &stubSecretStore{
+					secrets: []db.Secret{
+						{
+							ID: "1",
+							Value: func() string {
+								v, _ := encrypt(contentPrefix+`{"type":"credentials","credentials":{"username":"user","password":"pass","url":"db.example.com"}}`, "key")
+								return v
+							}(),
+							ExpiresAt: now().Add(1 * time.Hour),
+						},
+					},
+				},
+				id:  "1",
+				key: "key",
+			},
+			want: Secret{
+				ID: "1",
+				Content: &Content{
+					Type: ContentTypeCredentials,
+					Credentials: &Credentials{
+						Username: "user",
+						Password: "pass",
+						URL:      "db.example.com",
+					},
+				},
+			},
+		},
+		{
+			name: "get secret - label and note",
+			input: struct {
+				secrets  db.SecretStore
+				id       string
+				key      string
+				sourceIP string
+				code     string
 			}{
 				secrets: &stubSecretStore{
 					secrets: []db.Secret{
@@ -125,6 +200,11 @@ func TestService_Get(t *testing.T) {
 								return v
 							}(),
 							ExpiresAt: now().Add(1 * time.Hour),
+							Label:     "Staging DB",
+							Note: func() string {
+								v, _ := encrypt("Rotated monthly.", "key")
+								return v
+							}(),
 						},
 					},
 				},
@@ -134,14 +214,49 @@ func TestService_Get(t *testing.T) {
 			want: Secret{
 				ID:    "1",
 				Value: "secret",
+				Label: "Staging DB",
+				Note:  "Rotated monthly.",
+			},
+		},
+		{
+			name: "get secret - invalid passphrase",
+			input: struct {
+				secrets  db.SecretStore
+				id       string
+				key      string
+				sourceIP string
+				code     string
+			}{
+				secrets: &stubSecretStore{
+					secrets: []db.Secret{
+						{
+							ID: "1",
+							Value: func() string {
+								v, _ := encrypt("secret", "key")
+								return v
+							}(),
+							ExpiresAt: now().Add(1 * time.Hour),
+							Label:     "Staging DB",
+						},
+					},
+				},
+				id:  "1",
+				key: "invalid",
+			},
+			want: Secret{
+				ID:    "1",
+				Label: "Staging DB",
 			},
+			wantErr: ErrInvalidPassphrase,
 		},
 		{
 			name: "get secret - not found",
 			input: struct {
-				secrets db.SecretStore
-				id      string
-				key     string
+				secrets  db.SecretStore
+				id       string
+				key      string
+				sourceIP string
+				code     string
 			}{
 				secrets: &stubSecretStore{},
 				id:      "1",
@@ -151,9 +266,11 @@ func TestService_Get(t *testing.T) {
 		{
 			name: "get secret - expired",
 			input: struct {
-				secrets db.SecretStore
-				id      string
-				key     string
+				secrets  db.SecretStore
+				id       string
+				key      string
+				sourceIP string
+				code     string
 			}{
 				secrets: &stubSecretStore{
 					secrets: []db.Secret{
@@ -172,19 +289,1259 @@ func TestService_Get(t *testing.T) {
 			},
 			wantErr: ErrSecretNotFound,
 		},
+		{
+			name: "get secret - allowed network",
+			input: struct {
+				secrets  db.SecretStore
+				id       string
+				key      string
+				sourceIP string
+				code     string
+			}{
+				secrets: &stubSecretStore{
+					secrets: []db.Secret{
+						{
+							ID: "1",
+							Value: func() string {
+								v, _ := encrypt("secret", "key")
+								return v
+							}(),
+							ExpiresAt:       now().Add(1 * time.Hour),
+							AllowedNetworks: []string{"192.168.1.0/24", "10.0.0.1/32"},
+						},
+					},
+				},
+				id:       "1",
+				key:      "key",
+				sourceIP: "10.0.0.1",
+			},
+			want: Secret{
+				ID:    "1",
+				Value: "secret",
+			},
+		},
+		{
+			name: "get secret - network not allowed",
+			input: struct {
+				secrets  db.SecretStore
+				id       string
+				key      string
+				sourceIP string
+				code     string
+			}{
+				secrets: &stubSecretStore{
+					secrets: []db.Secret{
+						{
+							ID: "1",
+							Value: func() string {
+								v, _ := encrypt("secret", "key")
+								return v
+							}(),
+							ExpiresAt:       now().Add(1 * time.Hour),
+							AllowedNetworks: []string{"192.168.1.0/24"},
+						},
+					},
+				},
+				id:       "1",
+				key:      "key",
+				sourceIP: "10.0.0.1",
+			},
+			wantErr: ErrNetworkNotAllowed,
+		},
+		{
+			name: "get secret - not yet available",
+			input: struct {
+				secrets  db.SecretStore
+				id       string
+				key      string
+				sourceIP string
+				code     string
+			}{
+				secrets: &stubSecretStore{
+					secrets: []db.Secret{
+						{
+							ID: "1",
+							Value: func() string {
+								v, _ := encrypt("secret", "key")
+								return v
+							}(),
+							ExpiresAt: now().Add(2 * time.Hour),
+							NotBefore: now().Add(1 * time.Hour).Truncate(time.Second),
+						},
+					},
+				},
+				id:  "1",
+				key: "key",
+			},
+			want: Secret{
+				ID:        "1",
+				NotBefore: now().Add(1 * time.Hour).Truncate(time.Second),
+			},
+			wantErr: ErrSecretNotAvailable,
+		},
+		{
+			name: "get secret - second factor",
+			input: struct {
+				secrets  db.SecretStore
+				id       string
+				key      string
+				sourceIP string
+				code     string
+			}{
+				secrets: &stubSecretStore{
+					secrets: []db.Secret{
+						{
+							ID: "1",
+							Value: func() string {
+								v, _ := encrypt("secret", "key")
+								return v
+							}(),
+							ExpiresAt:        now().Add(1 * time.Hour),
+							SecondFactor:     SecondFactorTOTP,
+							SecondFactorData: encryptedSecondFactorData,
+						},
+					},
+				},
+				id:  "1",
+				key: "key",
+				code: func() string {
+					code, _ := security.TOTP(totpSecret, now(), totpPeriod)
+					return code
+				}(),
+			},
+			want: Secret{
+				ID:    "1",
+				Value: "secret",
+			},
+		},
+		{
+			name: "get secret - second factor code required",
+			input: struct {
+				secrets  db.SecretStore
+				id       string
+				key      string
+				sourceIP string
+				code     string
+			}{
+				secrets: &stubSecretStore{
+					secrets: []db.Secret{
+						{
+							ID: "1",
+							Value: func() string {
+								v, _ := encrypt("secret", "key")
+								return v
+							}(),
+							ExpiresAt:        now().Add(1 * time.Hour),
+							SecondFactor:     SecondFactorTOTP,
+							SecondFactorData: encryptedSecondFactorData,
+						},
+					},
+				},
+				id:  "1",
+				key: "key",
+			},
+			want: Secret{
+				ID:           "1",
+				SecondFactor: &SecondFactor{Type: SecondFactorTOTP},
+			},
+			wantErr: ErrSecondFactorRequired,
+		},
+		{
+			name: "get secret - invalid second factor code",
+			input: struct {
+				secrets  db.SecretStore
+				id       string
+				key      string
+				sourceIP string
+				code     string
+			}{
+				secrets: &stubSecretStore{
+					secrets: []db.Secret{
+						{
+							ID: "1",
+							Value: func() string {
+								v, _ := encrypt("secret", "key")
+								return v
+							}(),
+							ExpiresAt:        now().Add(1 * time.Hour),
+							SecondFactor:     SecondFactorTOTP,
+							SecondFactorData: encryptedSecondFactorData,
+						},
+					},
+				},
+				id:   "1",
+				key:  "key",
+				code: "000000",
+			},
+			want: Secret{
+				ID:           "1",
+				SecondFactor: &SecondFactor{Type: SecondFactorTOTP},
+			},
+			wantErr: ErrInvalidSecondFactorCode,
+		},
 		{
 			name: "get secret - error",
 			input: struct {
-				secrets db.SecretStore
-				id      string
-				key     string
+				secrets  db.SecretStore
+				id       string
+				key      string
+				sourceIP string
+				code     string
 			}{
 				secrets: &stubSecretStore{
 					err: errGetSecret,
 				},
 				id: "1",
 			},
-			wantErr: errGetSecret,
+			wantErr: errGetSecret,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			svc := &service{
+				secrets: test.input.secrets,
+				timeout: defaultTimeout,
+			}
+
+			got, gotErr := svc.Get(context.Background(), test.input.id, test.input.key, func(o *GetOptions) {
+				o.SourceIP = test.input.sourceIP
+				o.Code = test.input.code
+			})
+
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(Secret{})); diff != "" {
+				t.Errorf("Get() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Get() = unexpected error (-want +got)\n%s\n", diff)
+			}
+
+			// Secrets that are not allowed to be read from the network, are
+			// not yet available or are read without a valid second factor
+			// code, are kept.
+			if errors.Is(gotErr, ErrNetworkNotAllowed) || errors.Is(gotErr, ErrSecretNotAvailable) || errors.Is(gotErr, ErrSecondFactorRequired) || errors.Is(gotErr, ErrInvalidSecondFactorCode) {
+				if _, err := test.input.secrets.Get(context.Background(), test.input.id); err != nil {
+					t.Errorf("Get() = expected secret to be kept, got: %v\n", err)
+				}
+			}
+		})
+	}
+}
+
+func TestService_Create(t *testing.T) {
+	now = func() time.Time {
+		return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	n := now()
+
+	generate = func(options ...GenerateOption) (string, error) {
+		if _, err := Generate(options...); err != nil {
+			return "", err
+		}
+		return "generated", nil
+	}
+
+	var tests = []struct {
+		name  string
+		input struct {
+			secrets db.SecretStore
+			secret  Secret
+			id      string
+		}
+		want    Secret
+		wantErr error
+	}{
+		{
+			name: "create secret",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				secret: Secret{
+					Value:      "secret",
+					Passphrase: "key",
+				},
+				id: "2",
+			},
+			want: Secret{
+				ID:         "2",
+				Passphrase: "key",
+				TTL:        time.Until(n.Add(defaultTTL)).Round(time.Minute),
+				ExpiresAt:  n.Add(defaultTTL),
+			},
+		},
+		{
+			name: "create secret - credentials",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				secret: Secret{
+					Passphrase: "key",
+					Content: &Content{
+						Type: ContentTypeCredentials,
+						Credentials: &Credentials{
+							Username: "user",
+							Password: "pass",
+						},
+					},
+				},
+				id: "2",
+			},
+			want: Secret{
+				ID:         "2",
+				Passphrase: "key",
+				TTL:        time.Until(n.Add(defaultTTL)).Round(time.Minute),
+				ExpiresAt:  n.Add(defaultTTL),
+			},
+		},
+		{
+			name: "create secret - key-value",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				secret: Secret{
+					Passphrase: "key",
+					Content: &Content{
+						Type: ContentTypeKeyValue,
+						KeyValue: map[string]string{
+							"host": "db.example.com",
+							"port": "5432",
+						},
+					},
+				},
+				id: "2",
+			},
+			want: Secret{
+				ID:         "2",
+				Passphrase: "key",
+				TTL:        time.Until(n.Add(defaultTTL)).Round(time.Minute),
+				ExpiresAt:  n.Add(defaultTTL),
+			},
+		},
+		{
+			name: "create secret - empty credentials",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				secret: Secret{
+					Passphrase: "key",
+					Content: &Content{
+						Type:        ContentTypeCredentials,
+						Credentials: &Credentials{},
+					},
+				},
+				id: "2",
+			},
+			wantErr: ErrInvalidContent,
+		},
+		{
+			name: "create secret - invalid content type",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				secret: Secret{
+					Passphrase: "key",
+					Content: &Content{
+						Type: "file",
+					},
+				},
+				id: "2",
+			},
+			wantErr: ErrInvalidContent,
+		},
+		{
+			name: "create secret - value and content",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				secret: Secret{
+					Value:      "secret",
+					Passphrase: "key",
+					Content: &Content{
+						Type:     ContentTypeKeyValue,
+						KeyValue: map[string]string{"key": "value"},
+					},
+				},
+				id: "2",
+			},
+			wantErr: ErrInvalidContent,
+		},
+		{
+			name: "create secret - value with content prefix",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				secret: Secret{
+					Value:      contentPrefix + `{"type":"keyValue"}`,
+					Passphrase: "key",
+				},
+				id: "2",
+			},
+			wantErr: ErrValueInvalid,
+		},
+		{
+			name: "create secret - label and note",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				secret: Secret{
+					Value:      "secret",
+					Passphrase: "key",
+					Label:      " Staging DB ",
+					Note:       "Rotated monthly.",
+				},
+				id: "2",
+			},
+			want: Secret{
+				ID:         "2",
+				Passphrase: "key",
+				TTL:        time.Until(n.Add(defaultTTL)).Round(time.Minute),
+				ExpiresAt:  n.Add(defaultTTL),
+				Label:      "Staging DB",
+			},
+		},
+		{
+			name: "create secret - label with too many characters",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				secret: Secret{
+					Value:      "secret",
+					Passphrase: "key",
+					Label:      strings.Repeat("a", maxLabelCharacters+1),
+				},
+				id: "2",
+			},
+			wantErr: ErrInvalidLabel,
+		},
+		{
+			name: "create secret - label with multiple lines",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				secret: Secret{
+					Value:      "secret",
+					Passphrase: "key",
+					Label:      "Staging\nDB",
+				},
+				id: "2",
+			},
+			wantErr: ErrInvalidLabel,
+		},
+		{
+			name: "create secret - note with too many characters",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				secret: Secret{
+					Value:      "secret",
+					Passphrase: "key",
+					Note:       strings.Repeat("a", maxNoteCharacters+1),
+				},
+				id: "2",
+			},
+			wantErr: ErrInvalidNote,
+		},
+		{
+			name: "create secret - generated value",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				secret: Secret{
+					Passphrase: "key",
+					Generate: &GenerateOptions{
+						Words: 4,
+					},
+				},
+				id: "2",
+			},
+			want: Secret{
+				ID:         "2",
+				Value:      "generated",
+				Passphrase: "key",
+				TTL:        time.Until(n.Add(defaultTTL)).Round(time.Minute),
+				ExpiresAt:  n.Add(defaultTTL),
+			},
+		},
+		{
+			name: "create secret - generated value with provided value",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				secret: Secret{
+					Value:      "secret",
+					Passphrase: "key",
+					Generate:   &GenerateOptions{},
+				},
+				id: "2",
+			},
+			wantErr: ErrValueInvalid,
+		},
+		{
+			name: "create secret - generated value with invalid options",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				secret: Secret{
+					Passphrase: "key",
+					Generate: &GenerateOptions{
+						Words: 1,
+					},
+				},
+				id: "2",
+			},
+			wantErr: ErrInvalidGenerateOptions,
+		},
+		{
+			name: "create secret - base64 encoded value",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				secret: Secret{
+					Value: func() string {
+						return base64.StdEncoding.EncodeToString([]byte("secret"))
+					}(),
+					Passphrase: "key",
+				},
+				id: "2",
+			},
+			want: Secret{
+				ID:         "2",
+				Passphrase: "key",
+				TTL:        time.Until(n.Add(defaultTTL)).Round(time.Minute),
+				ExpiresAt:  n.Add(defaultTTL),
+			},
+		},
+		{
+			name: "create secret - base64 encoded value with invalid data",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				secret: Secret{
+					Value: func() string {
+						f, err := os.OpenFile("../../assets/burnit.png", os.O_RDONLY, 0644)
+						if err != nil {
+							panic(err)
+						}
+						b, _ := io.ReadAll(f)
+						return base64.StdEncoding.EncodeToString(b)
+					}(),
+					Passphrase: "key",
+				},
+				id: "2",
+			},
+			wantErr: ErrValueInvalid,
+		},
+		{
+			name: "create secret - invalid allowed networks",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				secret: Secret{
+					Value:           "secret",
+					Passphrase:      "key",
+					AllowedNetworks: []string{"10.0.0.0/8", "office"},
+				},
+				id: "2",
+			},
+			wantErr: ErrInvalidAllowedNetworks,
+		},
+		{
+			name: "create secret - second factor totp",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				secret: Secret{
+					Value:        "secret",
+					Passphrase:   "key",
+					SecondFactor: &SecondFactor{Type: SecondFactorTOTP, TOTPSecret: totpSecret},
+				},
+				id: "2",
+			},
+			want: Secret{
+				ID:           "2",
+				Passphrase:   "key",
+				TTL:          time.Until(n.Add(defaultTTL)).Round(time.Minute),
+				ExpiresAt:    n.Add(defaultTTL),
+				SecondFactor: &SecondFactor{Type: SecondFactorTOTP, TOTPSecret: totpSecret},
+			},
+		},
+		{
+			name: "create secret - second factor email",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				secret: Secret{
+					Value:        "secret",
+					Passphrase:   "key",
+					SecondFactor: &SecondFactor{Type: SecondFactorEmail, Email: "Reader <reader@example.com>"},
+				},
+				id: "2",
+			},
+			want: Secret{
+				ID:           "2",
+				Passphrase:   "key",
+				TTL:          time.Until(n.Add(defaultTTL)).Round(time.Minute),
+				ExpiresAt:    n.Add(defaultTTL),
+				SecondFactor: &SecondFactor{Type: SecondFactorEmail, Email: "reader@example.com"},
+			},
+		},
+		{
+			name: "create secret - invalid second factor",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				secret: Secret{
+					Value:        "secret",
+					Passphrase:   "key",
+					SecondFactor: &SecondFactor{Type: SecondFactorTOTP, TOTPSecret: "not base32"},
+				},
+				id: "2",
+			},
+			wantErr: ErrSecondFactorInvalid,
+		},
+		{
+			name: "create secret - error",
+			input: struct {
+				secrets db.SecretStore
+				secret  Secret
+				id      string
+			}{
+				secrets: &stubSecretStore{
+					err: errCreateSecret,
+				},
+				secret: Secret{
+					Value:      "secret",
+					Passphrase: "key",
+				},
+			},
+			wantErr: errCreateSecret,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			newUUID = func() string {
+				return test.input.id
+			}
+
+			svc := &service{
+				secrets:                 test.input.secrets,
+				valueMaxCharacters:      40000,
+				passphraseMinCharacters: 3,
+				passphraseMaxCharacters: 8,
+				timeout:                 defaultTimeout,
+				mailer:                  &stubMailer{},
+			}
+
+			got, gotErr := svc.Create(context.Background(), test.input.secret)
+
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(Secret{})); diff != "" {
+				t.Errorf("Create() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Create() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestService_CreateBatch(t *testing.T) {
+	now = func() time.Time {
+		return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	n := now()
+
+	generate = func(options ...GenerateOption) (string, error) {
+		if _, err := Generate(options...); err != nil {
+			return "", err
+		}
+		return "generated", nil
+	}
+
+	var tests = []struct {
+		name  string
+		input struct {
+			store   *stubSecretStore
+			secrets []Secret
+		}
+		want    []Secret
+		wantErr error
+	}{
+		{
+			name: "create secrets",
+			input: struct {
+				store   *stubSecretStore
+				secrets []Secret
+			}{
+				store: &stubSecretStore{},
+				secrets: []Secret{
+					{
+						Value:      "secret",
+						Passphrase: "key",
+						Label:      "Anna",
+					},
+					{
+						Generate: &GenerateOptions{Words: 4},
+						TTL:      24 * time.Hour,
+						Label:    "Bertil",
+					},
+				},
+			},
+			want: []Secret{
+				{
+					ID:         "1",
+					Passphrase: "key",
+					TTL:        time.Until(n.Add(defaultTTL)).Round(time.Minute),
+					ExpiresAt:  n.Add(defaultTTL),
+					Label:      "Anna",
+				},
+				{
+					ID:         "2",
+					Value:      "generated",
+					Passphrase: "generated",
+					TTL:        time.Until(n.Add(24 * time.Hour)).Round(time.Minute),
+					ExpiresAt:  n.Add(24 * time.Hour),
+					Label:      "Bertil",
+				},
+			},
+		},
+		{
+			name: "create secrets - empty batch",
+			input: struct {
+				store   *stubSecretStore
+				secrets []Secret
+			}{
+				store: &stubSecretStore{},
+			},
+			wantErr: ErrInvalidBatch,
+		},
+		{
+			name: "create secrets - too many secrets",
+			input: struct {
+				store   *stubSecretStore
+				secrets []Secret
+			}{
+				store:   &stubSecretStore{},
+				secrets: make([]Secret, maxBatchSecrets+1),
+			},
+			wantErr: ErrInvalidBatch,
+		},
+		{
+			name: "create secrets - invalid secret",
+			input: struct {
+				store   *stubSecretStore
+				secrets []Secret
+			}{
+				store: &stubSecretStore{},
+				secrets: []Secret{
+					{
+						Value:      "secret",
+						Passphrase: "key",
+					},
+					{
+						Passphrase: "key",
+					},
+				},
+			},
+			wantErr: ErrValueInvalid,
+		},
+		{
+			name: "create secrets - error",
+			input: struct {
+				store   *stubSecretStore
+				secrets []Secret
+			}{
+				store: &stubSecretStore{
+					err: errCreateSecret,
+				},
+				secrets: []Secret{
+					{
+						Value:      "secret",
+						Passphrase: "key",
+					},
+				},
+			},
+			wantErr: errCreateSecret,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var id int
+			newUUID = func() string {
+				id++
+				return strconv.Itoa(id)
+			}
+
+			svc := &service{
+				secrets:                 test.input.store,
+				valueMaxCharacters:      40000,
+				passphraseMinCharacters: 3,
+				passphraseMaxCharacters: 8,
+				timeout:                 defaultTimeout,
+			}
+
+			got, gotErr := svc.CreateBatch(context.Background(), test.input.secrets)
+
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(Secret{})); diff != "" {
+				t.Errorf("CreateBatch() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("CreateBatch() = unexpected error (-want +got)\n%s\n", diff)
+			}
+
+			// No secrets are stored if any of them are invalid.
+			if gotErr != nil && len(test.input.store.secrets) > 0 {
+				t.Errorf("CreateBatch() = expected no secrets to be stored, got: %d\n", len(test.input.store.secrets))
+			}
+		})
+	}
+}
+
+func TestService_Create_passphraseMinScore(t *testing.T) {
+	now = func() time.Time {
+		return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	n := now()
+
+	var tests = []struct {
+		name  string
+		input struct {
+			minScore   int
+			passphrase string
+		}
+		want    Secret
+		wantErr error
+	}{
+		{
+			name: "strong passphrase",
+			input: struct {
+				minScore   int
+				passphrase string
+			}{
+				minScore:   3,
+				passphrase: "correct-horse-battery-staple",
+			},
+			want: Secret{
+				ID:         "1",
+				Passphrase: "correct-horse-battery-staple",
+				TTL:        time.Until(n.Add(defaultTTL)).Round(time.Minute),
+				ExpiresAt:  n.Add(defaultTTL),
+			},
+		},
+		{
+			name: "weak passphrase without min score",
+			input: struct {
+				minScore   int
+				passphrase string
+			}{
+				minScore:   0,
+				passphrase: "1234",
+			},
+			want: Secret{
+				ID:         "1",
+				Passphrase: "1234",
+				TTL:        time.Until(n.Add(defaultTTL)).Round(time.Minute),
+				ExpiresAt:  n.Add(defaultTTL),
+			},
+		},
+		{
+			name: "weak passphrase",
+			input: struct {
+				minScore   int
+				passphrase string
+			}{
+				minScore:   1,
+				passphrase: "1234",
+			},
+			wantErr: ErrPassphraseTooWeak,
+		},
+		{
+			name: "guessable passphrase",
+			input: struct {
+				minScore   int
+				passphrase string
+			}{
+				minScore:   2,
+				passphrase: "burnit",
+			},
+			wantErr: ErrPassphraseTooWeak,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			newUUID = func() string {
+				return "1"
+			}
+
+			svc := &service{
+				secrets:                 &stubSecretStore{},
+				valueMaxCharacters:      40000,
+				passphraseMinCharacters: defaultPassphraseMinCharacters,
+				passphraseMaxCharacters: defaultPassphraseMaxCharacters,
+				passphraseMinScore:      test.input.minScore,
+				timeout:                 defaultTimeout,
+			}
+
+			got, gotErr := svc.Create(context.Background(), Secret{Value: "secret", Passphrase: test.input.passphrase})
+
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(Secret{})); diff != "" {
+				t.Errorf("Create() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Create() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestService_PassphraseStrength(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input string
+		want  struct {
+			score      int
+			sufficient bool
+		}
+	}{
+		{
+			name:  "weak",
+			input: "1234",
+			want: struct {
+				score      int
+				sufficient bool
+			}{
+				score:      0,
+				sufficient: false,
+			},
+		},
+		{
+			name:  "strong",
+			input: "correct-horse-battery-staple",
+			want: struct {
+				score      int
+				sufficient bool
+			}{
+				score:      4,
+				sufficient: true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			svc := &service{passphraseMinScore: 2}
+			strength := svc.PassphraseStrength(test.input)
+
+			got := struct {
+				score      int
+				sufficient bool
+			}{
+				score:      strength.Score,
+				sufficient: strength.Sufficient(),
+			}
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(got)); diff != "" {
+				t.Errorf("PassphraseStrength() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestExpirationTime(t *testing.T) {
+	n := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time {
+		return n
+	}
+
+	var tests = []struct {
+		name  string
+		input struct {
+			ttl       time.Duration
+			expiresAt time.Time
+			notBefore time.Time
+		}
+		want    time.Time
+		wantErr error
+	}{
+		{
+			name: "default TTL",
+			want: n.Add(defaultTTL),
+		},
+		{
+			name: "TTL",
+			input: struct {
+				ttl       time.Duration
+				expiresAt time.Time
+				notBefore time.Time
+			}{
+				ttl: 2 * time.Hour,
+			},
+			want: n.Add(2 * time.Hour),
+		},
+		{
+			name: "not before - default TTL",
+			input: struct {
+				ttl       time.Duration
+				expiresAt time.Time
+				notBefore time.Time
+			}{
+				notBefore: n.Add(14 * 24 * time.Hour),
+			},
+			want: n.Add(14*24*time.Hour + defaultTTL),
+		},
+		{
+			name: "not before - TTL",
+			input: struct {
+				ttl       time.Duration
+				expiresAt time.Time
+				notBefore time.Time
+			}{
+				ttl:       72 * time.Hour,
+				notBefore: n.Add(14 * 24 * time.Hour),
+			},
+			want: n.Add(17 * 24 * time.Hour),
+		},
+		{
+			name: "not before - expires at",
+			input: struct {
+				ttl       time.Duration
+				expiresAt time.Time
+				notBefore time.Time
+			}{
+				expiresAt: n.Add(15 * 24 * time.Hour),
+				notBefore: n.Add(14 * 24 * time.Hour),
+			},
+			want: n.Add(15 * 24 * time.Hour),
+		},
+		{
+			name: "not before - in the past",
+			input: struct {
+				ttl       time.Duration
+				expiresAt time.Time
+				notBefore time.Time
+			}{
+				notBefore: n.Add(-time.Hour),
+			},
+			wantErr: ErrInvalidNotBefore,
+		},
+		{
+			name: "not before - too far in the future",
+			input: struct {
+				ttl       time.Duration
+				expiresAt time.Time
+				notBefore time.Time
+			}{
+				notBefore: n.Add(defaultMaxNotBefore + time.Hour),
+			},
+			wantErr: ErrInvalidNotBefore,
+		},
+		{
+			name: "not before - expires at before not before",
+			input: struct {
+				ttl       time.Duration
+				expiresAt time.Time
+				notBefore time.Time
+			}{
+				expiresAt: n.Add(2 * time.Hour),
+				notBefore: n.Add(3 * time.Hour),
+			},
+			wantErr: ErrInvalidExpirationTime,
+		},
+		{
+			name: "not before - expires at too long after not before",
+			input: struct {
+				ttl       time.Duration
+				expiresAt time.Time
+				notBefore time.Time
+			}{
+				expiresAt: n.Add(10 * 24 * time.Hour),
+				notBefore: n.Add(2 * time.Hour),
+			},
+			wantErr: ErrInvalidExpirationTime,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := expirationTime(test.input.ttl, test.input.expiresAt, test.input.notBefore)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("expirationTime() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("expirationTime() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestParseAllowedNetworks(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   []string
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:  "CIDRs and addresses",
+			input: []string{"10.8.1.0/16", " 192.168.1.1", "fd00::1", "::ffff:10.0.0.1"},
+			want:  []string{"10.8.0.0/16", "192.168.1.1/32", "fd00::1/128", "10.0.0.1/32"},
+		},
+		{
+			name:    "invalid network",
+			input:   []string{"10.0.0.0/33"},
+			wantErr: ErrInvalidAllowedNetworks,
+		},
+		{
+			name:    "too many networks",
+			input:   make([]string, maxAllowedNetworks+1),
+			wantErr: ErrInvalidAllowedNetworks,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := parseAllowedNetworks(test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("parseAllowedNetworks() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("parseAllowedNetworks() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestService_Delete(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			secrets db.SecretStore
+			id      string
+		}
+		wantErr error
+	}{
+		{
+			name: "delete secret",
+			input: struct {
+				secrets db.SecretStore
+				id      string
+			}{
+				secrets: &stubSecretStore{
+					secrets: []db.Secret{
+						{
+							ID:    "1",
+							Value: "secret",
+						},
+					},
+				},
+				id: "1",
+			},
+			wantErr: nil,
+		},
+		{
+			name: "delete secret - not found",
+			input: struct {
+				secrets db.SecretStore
+				id      string
+			}{
+				secrets: &stubSecretStore{},
+				id:      "1",
+			},
+			wantErr: ErrSecretNotFound,
+		},
+		{
+			name: "delete secret - error",
+			input: struct {
+				secrets db.SecretStore
+				id      string
+			}{
+				secrets: &stubSecretStore{
+					secrets: []db.Secret{
+						{
+							ID:    "1",
+							Value: "secret",
+						},
+					},
+					err: errDeleteSecret,
+				},
+				id: "1",
+			},
+			wantErr: errDeleteSecret,
 		},
 	}
 
@@ -195,205 +1552,206 @@ func TestService_Get(t *testing.T) {
 				timeout: defaultTimeout,
 			}
 
-			got, gotErr := svc.Get(test.input.id, test.input.key)
-
-			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(Secret{})); diff != "" {
-				t.Errorf("Get() = unexpected result (-want +got)\n%s\n", diff)
-			}
+			gotErr := svc.Delete(context.Background(), test.input.id)
 
 			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
-				t.Errorf("Get() = unexpected error (-want +got)\n%s\n", diff)
+				t.Errorf("Delete() = unexpected error (-want +got)\n%s\n", diff)
 			}
 		})
 	}
 }
 
-func TestService_Create(t *testing.T) {
+func TestService_SendCode(t *testing.T) {
+	n := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	now = func() time.Time {
-		return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		return n
 	}
 
-	n := now()
+	emailSecondFactorData, _ := encryptSecondFactor(secondFactorData{Seed: totpSecret, Email: "reader@example.com"}, "key")
 
 	var tests = []struct {
 		name  string
 		input struct {
-			secrets db.SecretStore
-			secret  Secret
+			secrets *stubSecretStore
+			mailer  *stubMailer
 			id      string
+			key     string
 		}
-		want    Secret
+		want    []mail.Message
 		wantErr error
 	}{
 		{
-			name: "create secret",
+			name: "send code",
 			input: struct {
-				secrets db.SecretStore
-				secret  Secret
+				secrets *stubSecretStore
+				mailer  *stubMailer
 				id      string
+				key     string
 			}{
-				secrets: &stubSecretStore{},
-				secret: Secret{
-					Value:      "secret",
-					Passphrase: "key",
+				secrets: &stubSecretStore{
+					secrets: []db.Secret{
+						{
+							ID:               "1",
+							ExpiresAt:        n.Add(1 * time.Hour),
+							SecondFactor:     SecondFactorEmail,
+							SecondFactorData: emailSecondFactorData,
+						},
+					},
 				},
-				id: "2",
+				mailer: &stubMailer{},
+				id:     "1",
+				key:    "key",
 			},
-			want: Secret{
-				ID:         "2",
-				Passphrase: "key",
-				TTL:        time.Until(n.Add(defaultTTL)).Round(time.Minute),
-				ExpiresAt:  n.Add(defaultTTL),
+			want: []mail.Message{
+				{
+					To:      "reader@example.com",
+					Subject: "Code for reading secret",
+				},
 			},
 		},
 		{
-			name: "create secret - base64 encoded value",
+			name: "send code - replaces previous code",
 			input: struct {
-				secrets db.SecretStore
-				secret  Secret
+				secrets *stubSecretStore
+				mailer  *stubMailer
 				id      string
+				key     string
 			}{
-				secrets: &stubSecretStore{},
-				secret: Secret{
-					Value: func() string {
-						return base64.StdEncoding.EncodeToString([]byte("secret"))
-					}(),
-					Passphrase: "key",
+				secrets: &stubSecretStore{
+					secrets: []db.Secret{
+						{
+							ID:               "1",
+							ExpiresAt:        n.Add(1 * time.Hour),
+							SecondFactor:     SecondFactorEmail,
+							SecondFactorData: emailSecondFactorData,
+							CodeHash:         hashCode(totpSecret, "123456"),
+							CodeExpiresAt:    n.Add(emailCodeDuration - sendCodeInterval),
+						},
+					},
 				},
-				id: "2",
+				mailer: &stubMailer{},
+				id:     "1",
+				key:    "key",
 			},
-			want: Secret{
-				ID:         "2",
-				Passphrase: "key",
-				TTL:        time.Until(n.Add(defaultTTL)).Round(time.Minute),
-				ExpiresAt:  n.Add(defaultTTL),
+			want: []mail.Message{
+				{
+					To:      "reader@example.com",
+					Subject: "Code for reading secret",
+				},
 			},
 		},
 		{
-			name: "create secret - base64 encoded value with invalid data",
+			name: "send code - recently sent",
 			input: struct {
-				secrets db.SecretStore
-				secret  Secret
+				secrets *stubSecretStore
+				mailer  *stubMailer
 				id      string
+				key     string
 			}{
-				secrets: &stubSecretStore{},
-				secret: Secret{
-					Value: func() string {
-						f, err := os.OpenFile("../../assets/burnit.png", os.O_RDONLY, 0644)
-						if err != nil {
-							panic(err)
-						}
-						b, _ := io.ReadAll(f)
-						return base64.StdEncoding.EncodeToString(b)
-					}(),
-					Passphrase: "key",
+				secrets: &stubSecretStore{
+					secrets: []db.Secret{
+						{
+							ID:               "1",
+							ExpiresAt:        n.Add(1 * time.Hour),
+							SecondFactor:     SecondFactorEmail,
+							SecondFactorData: emailSecondFactorData,
+							CodeHash:         hashCode(totpSecret, "123456"),
+							CodeExpiresAt:    n.Add(emailCodeDuration - 30*time.Second),
+						},
+					},
 				},
-				id: "2",
+				mailer: &stubMailer{},
+				id:     "1",
+				key:    "key",
 			},
-			wantErr: ErrValueInvalid,
+			wantErr: ErrCodeRecentlySent,
 		},
 		{
-			name: "create secret - error",
+			name: "send code - invalid passphrase",
 			input: struct {
-				secrets db.SecretStore
-				secret  Secret
+				secrets *stubSecretStore
+				mailer  *stubMailer
 				id      string
+				key     string
 			}{
 				secrets: &stubSecretStore{
-					err: errCreateSecret,
-				},
-				secret: Secret{
-					Value:      "secret",
-					Passphrase: "key",
+					secrets: []db.Secret{
+						{
+							ID:               "1",
+							ExpiresAt:        n.Add(1 * time.Hour),
+							SecondFactor:     SecondFactorEmail,
+							SecondFactorData: emailSecondFactorData,
+						},
+					},
 				},
+				mailer: &stubMailer{},
+				id:     "1",
+				key:    "invalid",
 			},
-			wantErr: errCreateSecret,
+			wantErr: ErrInvalidPassphrase,
 		},
-	}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			newUUID = func() string {
-				return test.input.id
-			}
-
-			svc := &service{
-				secrets:                 test.input.secrets,
-				valueMaxCharacters:      40000,
-				passphraseMinCharacters: 3,
-				passphraseMaxCharacters: 8,
-				timeout:                 defaultTimeout,
-			}
-
-			got, gotErr := svc.Create(test.input.secret)
-
-			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(Secret{})); diff != "" {
-				t.Errorf("Create() = unexpected result (-want +got)\n%s\n", diff)
-			}
-
-			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
-				t.Errorf("Create() = unexpected error (-want +got)\n%s\n", diff)
-			}
-		})
-	}
-}
-
-func TestService_Delete(t *testing.T) {
-	var tests = []struct {
-		name  string
-		input struct {
-			secrets db.SecretStore
-			id      string
-		}
-		wantErr error
-	}{
 		{
-			name: "delete secret",
+			name: "send code - no email second factor",
 			input: struct {
-				secrets db.SecretStore
+				secrets *stubSecretStore
+				mailer  *stubMailer
 				id      string
+				key     string
 			}{
 				secrets: &stubSecretStore{
 					secrets: []db.Secret{
 						{
-							ID:    "1",
-							Value: "secret",
+							ID:               "1",
+							ExpiresAt:        n.Add(1 * time.Hour),
+							SecondFactor:     SecondFactorTOTP,
+							SecondFactorData: encryptedSecondFactorData,
 						},
 					},
 				},
-				id: "1",
+				mailer: &stubMailer{},
+				id:     "1",
+				key:    "key",
 			},
-			wantErr: nil,
+			wantErr: ErrSecondFactorInvalid,
 		},
 		{
-			name: "delete secret - not found",
+			name: "send code - not found",
 			input: struct {
-				secrets db.SecretStore
+				secrets *stubSecretStore
+				mailer  *stubMailer
 				id      string
+				key     string
 			}{
 				secrets: &stubSecretStore{},
+				mailer:  &stubMailer{},
 				id:      "1",
+				key:     "key",
 			},
 			wantErr: ErrSecretNotFound,
 		},
 		{
-			name: "delete secret - error",
+			name: "send code - error",
 			input: struct {
-				secrets db.SecretStore
+				secrets *stubSecretStore
+				mailer  *stubMailer
 				id      string
+				key     string
 			}{
 				secrets: &stubSecretStore{
 					secrets: []db.Secret{
 						{
-							ID:    "1",
-							Value: "secret",
+							ID:               "1",
+							ExpiresAt:        n.Add(1 * time.Hour),
+							SecondFactor:     SecondFactorEmail,
+							SecondFactorData: emailSecondFactorData,
 						},
 					},
-					err: errDeleteSecret,
 				},
-				id: "1",
+				mailer: &stubMailer{err: errSendMail},
+				id:     "1",
+				key:    "key",
 			},
-			wantErr: errDeleteSecret,
+			wantErr: errSendMail,
 		},
 	}
 
@@ -402,17 +1760,206 @@ func TestService_Delete(t *testing.T) {
 			svc := &service{
 				secrets: test.input.secrets,
 				timeout: defaultTimeout,
+				mailer:  test.input.mailer,
 			}
 
-			gotErr := svc.Delete(test.input.id)
+			gotErr := svc.SendCode(context.Background(), test.input.id, test.input.key)
+
+			if diff := cmp.Diff(test.want, test.input.mailer.messages, cmpopts.IgnoreFields(mail.Message{}, "Body")); diff != "" {
+				t.Errorf("SendCode() = unexpected result (-want +got)\n%s\n", diff)
+			}
 
 			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
-				t.Errorf("Delete() = unexpected error (-want +got)\n%s\n", diff)
+				t.Errorf("SendCode() = unexpected error (-want +got)\n%s\n", diff)
+			}
+
+			// The code in the message is stored hashed, with an expiration time.
+			for _, message := range test.input.mailer.messages {
+				code := codeFromMessage(message)
+				got := test.input.secrets.secrets[0]
+				if got.CodeHash != hashCode(totpSecret, code) {
+					t.Errorf("SendCode() = expected hash of sent code %q to be stored\n", code)
+				}
+				if !got.CodeExpiresAt.Equal(n.Add(emailCodeDuration)) {
+					t.Errorf("SendCode() = unexpected code expiration time, want: %v, got: %v\n", n.Add(emailCodeDuration), got.CodeExpiresAt)
+				}
 			}
 		})
 	}
 }
 
+func TestService_Get_secondFactorEmail(t *testing.T) {
+	n := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time {
+		return n
+	}
+
+	emailSecondFactorData, _ := encryptSecondFactor(secondFactorData{Seed: totpSecret, Email: "reader@example.com"}, "key")
+	value, _ := encrypt("secret", "key")
+	secrets := &stubSecretStore{
+		secrets: []db.Secret{
+			{
+				ID:               "1",
+				Value:            value,
+				ExpiresAt:        n.Add(1 * time.Hour),
+				SecondFactor:     SecondFactorEmail,
+				SecondFactorData: emailSecondFactorData,
+			},
+		},
+	}
+	mailer := &stubMailer{}
+	svc := &service{
+		secrets: secrets,
+		timeout: defaultTimeout,
+		mailer:  mailer,
+	}
+
+	if err := svc.SendCode(context.Background(), "1", "key"); err != nil {
+		t.Fatalf("SendCode() = unexpected error: %v", err)
+	}
+	code := codeFromMessage(mailer.messages[0])
+
+	getWithCode := func(code string) (Secret, error) {
+		return svc.Get(context.Background(), "1", "key", func(o *GetOptions) {
+			o.Code = code
+			o.NoDelete = true
+		})
+	}
+
+	got, err := getWithCode(code)
+	if err != nil {
+		t.Fatalf("Get() = unexpected error: %v", err)
+	}
+	if got.Value != "secret" {
+		t.Errorf("Get() = unexpected value, want: secret, got: %s\n", got.Value)
+	}
+
+	// The code can only be used once.
+	if _, err := getWithCode(code); !errors.Is(err, ErrInvalidSecondFactorCode) {
+		t.Errorf("Get() = expected ErrInvalidSecondFactorCode for a reused code, got: %v\n", err)
+	}
+
+	// Codes expire.
+	now = func() time.Time {
+		return n.Add(sendCodeInterval)
+	}
+	if err := svc.SendCode(context.Background(), "1", "key"); err != nil {
+		t.Fatalf("SendCode() = unexpected error: %v", err)
+	}
+	code = codeFromMessage(mailer.messages[1])
+	now = func() time.Time {
+		return n.Add(sendCodeInterval + emailCodeDuration)
+	}
+	if _, err := getWithCode(code); !errors.Is(err, ErrInvalidSecondFactorCode) {
+		t.Errorf("Get() = expected ErrInvalidSecondFactorCode for an expired code, got: %v\n", err)
+	}
+}
+
+func TestService_Get_secondFactorAttempts(t *testing.T) {
+	n := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time {
+		return n
+	}
+
+	value, _ := encrypt("secret", "key")
+	secrets := &stubSecretStore{
+		secrets: []db.Secret{
+			{
+				ID:               "1",
+				Value:            value,
+				ExpiresAt:        n.Add(1 * time.Hour),
+				SecondFactor:     SecondFactorTOTP,
+				SecondFactorData: encryptedSecondFactorData,
+			},
+		},
+	}
+	svc := &service{
+		secrets: secrets,
+		timeout: defaultTimeout,
+	}
+
+	for i := 1; i <= maxSecondFactorAttempts; i++ {
+		_, err := svc.Get(context.Background(), "1", "key", func(o *GetOptions) {
+			o.Code = "000000"
+		})
+
+		wantErr := ErrInvalidSecondFactorCode
+		if i == maxSecondFactorAttempts {
+			wantErr = ErrSecondFactorAttemptsExceeded
+		}
+		if !errors.Is(err, wantErr) {
+			t.Errorf("Get() = unexpected error for attempt %d, want: %v, got: %v\n", i, wantErr, err)
+		}
+	}
+
+	// The secret is deleted after too many failed attempts, even with
+	// a valid code.
+	code, _ := security.TOTP(totpSecret, n, totpPeriod)
+	if _, err := svc.Get(context.Background(), "1", "key", func(o *GetOptions) {
+		o.Code = code
+	}); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Get() = expected ErrSecretNotFound, got: %v\n", err)
+	}
+}
+
+// codeFromMessage returns the code in a message with a code for
+// the second factor of a secret.
+func codeFromMessage(message mail.Message) string {
+	_, after, _ := strings.Cut(message.Body, "Your code for reading the secret is ")
+	code, _, _ := strings.Cut(after, ".")
+	return code
+}
+
+func TestService_Metrics(t *testing.T) {
+	m := metrics.New()
+	svc := &service{
+		secrets:                 &stubSecretStore{},
+		timeout:                 defaultTimeout,
+		valueMaxCharacters:      defaultValueMaxCharacters,
+		passphraseMinCharacters: defaultPassphraseMinCharacters,
+		passphraseMaxCharacters: defaultPassphraseMaxCharacters,
+		metrics:                 m,
+	}
+
+	read, err := svc.Create(context.Background(), Secret{Value: "secret", Passphrase: "passphrase"})
+	if err != nil {
+		t.Fatalf("Create() = unexpected error: %v", err)
+	}
+	deleted, err := svc.Create(context.Background(), Secret{Value: "secret", Passphrase: "passphrase"})
+	if err != nil {
+		t.Fatalf("Create() = unexpected error: %v", err)
+	}
+
+	if _, err := svc.Get(context.Background(), read.ID, "wrong"); !errors.Is(err, ErrInvalidPassphrase) {
+		t.Fatalf("Get() = expected invalid passphrase, got: %v", err)
+	}
+	if _, err := svc.Get(context.Background(), read.ID, "passphrase"); err != nil {
+		t.Fatalf("Get() = unexpected error: %v", err)
+	}
+	if err := svc.Delete(context.Background(), deleted.ID, func(o *DeleteOptions) {
+		o.Passphrase = "passphrase"
+		o.VerifyPassphrase = true
+	}); err != nil {
+		t.Fatalf("Delete() = unexpected error: %v", err)
+	}
+
+	want := []string{
+		"burnit_secrets_created_total 2",
+		"burnit_secrets_read_total 1",
+		"burnit_secrets_deleted_total 1",
+		"burnit_secrets_failed_passphrase_total 1",
+	}
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	got := rr.Body.String()
+	for _, w := range want {
+		if !strings.Contains(got, w) {
+			t.Errorf("metrics = expected %q, got:\n%s\n", w, got)
+		}
+	}
+}
+
 func TestValidValue(t *testing.T) {
 	var tests = []struct {
 		name    string
@@ -594,6 +2141,22 @@ func (r *stubSecretStore) Create(ctx context.Context, s db.Secret) (db.Secret, e
 	}, nil
 }
 
+func (r *stubSecretStore) CreateMany(ctx context.Context, secrets []db.Secret) ([]db.Secret, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	created := make([]db.Secret, 0, len(secrets))
+	for _, s := range secrets {
+		r.secrets = append(r.secrets, s)
+		created = append(created, db.Secret{
+			ID:        s.ID,
+			ExpiresAt: s.ExpiresAt,
+		})
+	}
+	return created, nil
+}
+
 func (r *stubSecretStore) Delete(ctx context.Context, id string) error {
 	if r.err != nil && errors.Is(r.err, errDeleteSecret) {
 		return r.err
@@ -609,6 +2172,26 @@ func (r *stubSecretStore) Delete(ctx context.Context, id string) error {
 	return dberrors.ErrSecretNotFound
 }
 
+func (r *stubSecretStore) IncrementFailedAttempts(ctx context.Context, id string) (int, error) {
+	for i, s := range r.secrets {
+		if s.ID == id {
+			r.secrets[i].FailedAttempts++
+			return r.secrets[i].FailedAttempts, nil
+		}
+	}
+	return 0, dberrors.ErrSecretNotFound
+}
+
+func (r *stubSecretStore) SetCode(ctx context.Context, id, hash string, expiresAt time.Time) error {
+	for i, s := range r.secrets {
+		if s.ID == id {
+			r.secrets[i].CodeHash, r.secrets[i].CodeExpiresAt = hash, expiresAt
+			return nil
+		}
+	}
+	return dberrors.ErrSecretNotFound
+}
+
 func (r *stubSecretStore) DeleteExpired(ctx context.Context) error {
 	if r.err != nil && errors.Is(r.err, errDeleteManySecrets) {
 		return r.err
@@ -622,15 +2205,50 @@ func (r *stubSecretStore) DeleteExpired(ctx context.Context) error {
 	return dberrors.ErrSecretsNotDeleted
 }
 
+func (r stubSecretStore) Iterate(ctx context.Context, fn func(secret db.Secret) error) error {
+	for _, s := range r.secrets {
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r stubSecretStore) Ping(ctx context.Context) error {
+	return r.err
+}
+
 func (r stubSecretStore) Close() error {
 	return nil
 }
 
+type stubMailer struct {
+	messages []mail.Message
+	err      error
+}
+
+func (m *stubMailer) Send(ctx context.Context, message mail.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.messages = append(m.messages, message)
+	return nil
+}
+
 var (
 	errGetSecret         = errors.New("get secret error")
 	errCreateSecret      = errors.New("create secret error")
 	errDeleteSecret      = errors.New("delete secret error")
 	errDeleteManySecrets = errors.New("delete many secrets error")
+	errSendMail          = errors.New("send mail error")
+)
+
+var (
+	// totpSecret is a base32 encoded TOTP secret.
+	totpSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	// encryptedSecondFactorData is the data of a TOTP second factor
+	// with totpSecret, encrypted with the passphrase "key".
+	encryptedSecondFactorData, _ = encryptSecondFactor(secondFactorData{Seed: totpSecret}, "key")
 )
 
 var (
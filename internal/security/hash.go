@@ -1,6 +1,9 @@
 package security
 
-import "crypto/sha256"
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+)
 
 // SHA256 hashes the given data using SHA-256.
 func SHA256(data []byte) []byte {
@@ -8,3 +11,10 @@ func SHA256(data []byte) []byte {
 	hasher.Write(data)
 	return hasher.Sum(nil)
 }
+
+// HMACSHA256 returns the HMAC of the given data using SHA-256 and the key.
+func HMACSHA256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
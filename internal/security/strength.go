@@ -0,0 +1,54 @@
+package security
+
+import (
+	"github.com/ccojocar/zxcvbn-go"
+)
+
+const (
+	// MinPassphraseScore is the lowest strength score of a passphrase.
+	MinPassphraseScore = 0
+	// MaxPassphraseScore is the highest strength score of a passphrase.
+	MaxPassphraseScore = 4
+)
+
+// passphraseStrengthLabels contains the labels of the strength scores.
+var passphraseStrengthLabels = [...]string{"very weak", "weak", "fair", "strong", "very strong"}
+
+// PassphraseStrength contains the estimated strength of a passphrase.
+type PassphraseStrength struct {
+	// Score is the strength from 0 (too guessable) to 4 (very unguessable).
+	Score int
+	// Entropy is the estimated entropy in bits.
+	Entropy float64
+	// CrackTime is a human readable estimate of the time to crack the
+	// passphrase in an offline attack against a slow hash.
+	CrackTime string
+}
+
+// Label returns the label of the score, such as weak or strong.
+func (s PassphraseStrength) Label() string {
+	return PassphraseStrengthLabel(s.Score)
+}
+
+// PassphraseStrengthLabel returns the label of a strength score.
+func PassphraseStrengthLabel(score int) string {
+	score = min(max(score, MinPassphraseScore), MaxPassphraseScore)
+	return passphraseStrengthLabels[score]
+}
+
+// EstimatePassphraseStrength estimates the strength of a passphrase the way
+// zxcvbn does: by finding the least guessable combination of dictionary
+// words, common passwords, names, keyboard patterns, sequences, repeats
+// and dates that make up the passphrase. Inputs are additional words,
+// such as names of the service, that should count as guessable.
+func EstimatePassphraseStrength(passphrase string, inputs ...string) PassphraseStrength {
+	if len(passphrase) == 0 {
+		return PassphraseStrength{Score: MinPassphraseScore}
+	}
+	result := zxcvbn.PasswordStrength(passphrase, inputs)
+	return PassphraseStrength{
+		Score:     min(max(result.Score, MinPassphraseScore), MaxPassphraseScore),
+		Entropy:   result.Entropy,
+		CrackTime: result.CrackTimeDisplay,
+	}
+}
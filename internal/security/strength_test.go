@@ -0,0 +1,139 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEstimatePassphraseStrength(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			passphrase string
+			inputs     []string
+		}
+		want int
+	}{
+		{
+			name: "empty",
+			input: struct {
+				passphrase string
+				inputs     []string
+			}{
+				passphrase: "",
+			},
+			want: 0,
+		},
+		{
+			name: "digits",
+			input: struct {
+				passphrase string
+				inputs     []string
+			}{
+				passphrase: "1234",
+			},
+			want: 0,
+		},
+		{
+			name: "common password",
+			input: struct {
+				passphrase string
+				inputs     []string
+			}{
+				passphrase: "Password1!",
+			},
+			want: 0,
+		},
+		{
+			name: "keyboard pattern",
+			input: struct {
+				passphrase string
+				inputs     []string
+			}{
+				passphrase: "qwertyuiop",
+			},
+			want: 0,
+		},
+		{
+			name: "user input",
+			input: struct {
+				passphrase string
+				inputs     []string
+			}{
+				passphrase: "burnitburnit",
+				inputs:     []string{"burnit"},
+			},
+			want: 0,
+		},
+		{
+			name: "words",
+			input: struct {
+				passphrase string
+				inputs     []string
+			}{
+				passphrase: "correct-horse-battery-staple",
+			},
+			want: 4,
+		},
+		{
+			name: "random characters",
+			input: struct {
+				passphrase string
+				inputs     []string
+			}{
+				passphrase: "xK9#mQ2$vL7!",
+			},
+			want: 4,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := EstimatePassphraseStrength(test.input.passphrase, test.input.inputs...)
+
+			if diff := cmp.Diff(test.want, got.Score); diff != "" {
+				t.Errorf("EstimatePassphraseStrength() = unexpected score (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestPassphraseStrengthLabel(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input int
+		want  string
+	}{
+		{
+			name:  "min",
+			input: 0,
+			want:  "very weak",
+		},
+		{
+			name:  "fair",
+			input: 2,
+			want:  "fair",
+		},
+		{
+			name:  "max",
+			input: 4,
+			want:  "very strong",
+		},
+		{
+			name:  "out of range",
+			input: 7,
+			want:  "very strong",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := PassphraseStrengthLabel(test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("PassphraseStrengthLabel() = unexpected label (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	// totpDigits is the number of digits of a TOTP code.
+	totpDigits = 6
+	// totpSecretBytes is the number of random bytes of a generated TOTP secret.
+	totpSecretBytes = 20
+	// totpMinSecretBytes is the minimum number of bytes of a TOTP secret.
+	totpMinSecretBytes = 10
+)
+
+var (
+	// ErrInvalidTOTPSecret is returned when a TOTP secret is not valid base32
+	// or is too short.
+	ErrInvalidTOTPSecret = errors.New("invalid totp secret")
+)
+
+// totpEncoding is the encoding of TOTP secrets, as used by authenticator apps.
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret generates a new base32 encoded TOTP secret.
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, totpSecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// GenerateCode generates a random one-time code with the same number
+// of digits as a TOTP code.
+func GenerateCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", totpDigits, n.Int64()), nil
+}
+
+// ValidTOTPSecret returns an error if the base32 encoded TOTP secret is
+// invalid or too short.
+func ValidTOTPSecret(secret string) error {
+	_, err := decodeTOTPSecret(secret)
+	return err
+}
+
+// TOTP returns the time-based one-time password (RFC 6238) for the secret
+// at time t, with HMAC-SHA1, 6 digits and the provided period.
+func TOTP(secret string, t time.Time, period time.Duration) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, uint64(t.Unix()/int64(period.Seconds()))), nil
+}
+
+// VerifyTOTP returns true if the code is the time-based one-time password for
+// the secret at time t, or within skew periods before or after t.
+func VerifyTOTP(secret, code string, t time.Time, period time.Duration, skew int) bool {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil || len(code) != totpDigits {
+		return false
+	}
+
+	counter := t.Unix() / int64(period.Seconds())
+	valid := 0
+	for i := -skew; i <= skew; i++ {
+		if counter+int64(i) < 0 {
+			continue
+		}
+		valid |= subtle.ConstantTimeCompare([]byte(hotp(key, uint64(counter+int64(i)))), []byte(code))
+	}
+	return valid == 1
+}
+
+// hotp returns the HMAC-based one-time password (RFC 4226) for the key
+// and counter.
+func hotp(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// decodeTOTPSecret decodes a base32 encoded TOTP secret. Spaces, padding and
+// lower case letters are accepted.
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := totpEncoding.DecodeString(strings.TrimRight(secret, "="))
+	if err != nil {
+		return nil, ErrInvalidTOTPSecret
+	}
+	if len(key) < totpMinSecretBytes {
+		return nil, fmt.Errorf("%w: must be at least %d bytes", ErrInvalidTOTPSecret, totpMinSecretBytes)
+	}
+	return key, nil
+}
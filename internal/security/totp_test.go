@@ -0,0 +1,170 @@
+package security
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the base32 encoded SHA1 secret of the test vectors in RFC 6238.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTP(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   time.Time
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "59",
+			input: time.Unix(59, 0),
+			want:  "287082",
+		},
+		{
+			name:  "1111111109",
+			input: time.Unix(1111111109, 0),
+			want:  "081804",
+		},
+		{
+			name:  "1234567890",
+			input: time.Unix(1234567890, 0),
+			want:  "005924",
+		},
+		{
+			name:  "2000000000",
+			input: time.Unix(2000000000, 0),
+			want:  "279037",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := TOTP(rfc6238Secret, test.input, 30*time.Second)
+			if gotErr != nil {
+				t.Fatalf("TOTP() = unexpected error: %v", gotErr)
+			}
+
+			if test.want != got {
+				t.Errorf("TOTP() = unexpected result, want: %s, got: %s\n", test.want, got)
+			}
+		})
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	var tests = []struct {
+		name  string
+		input struct {
+			secret string
+			code   string
+			t      time.Time
+		}
+		want bool
+	}{
+		{
+			name: "valid",
+			input: struct {
+				secret string
+				code   string
+				t      time.Time
+			}{
+				secret: rfc6238Secret,
+				code:   "081804",
+				t:      now,
+			},
+			want: true,
+		},
+		{
+			name: "valid - previous period",
+			input: struct {
+				secret string
+				code   string
+				t      time.Time
+			}{
+				secret: rfc6238Secret,
+				code:   "081804",
+				t:      now.Add(30 * time.Second),
+			},
+			want: true,
+		},
+		{
+			name: "invalid - expired",
+			input: struct {
+				secret string
+				code   string
+				t      time.Time
+			}{
+				secret: rfc6238Secret,
+				code:   "081804",
+				t:      now.Add(90 * time.Second),
+			},
+			want: false,
+		},
+		{
+			name: "invalid - wrong code",
+			input: struct {
+				secret string
+				code   string
+				t      time.Time
+			}{
+				secret: rfc6238Secret,
+				code:   "123456",
+				t:      now,
+			},
+			want: false,
+		},
+		{
+			name: "invalid - secret",
+			input: struct {
+				secret string
+				code   string
+				t      time.Time
+			}{
+				secret: "GEZDG",
+				code:   "081804",
+				t:      now,
+			},
+			want: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := VerifyTOTP(test.input.secret, test.input.code, test.input.t, 30*time.Second, 1)
+
+			if test.want != got {
+				t.Errorf("VerifyTOTP() = unexpected result, want: %v, got: %v\n", test.want, got)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret() = unexpected error: %v", err)
+	}
+
+	if err := ValidTOTPSecret(secret); err != nil {
+		t.Errorf("GenerateTOTPSecret() = invalid secret: %v", err)
+	}
+
+	if err := ValidTOTPSecret("not base32!"); !errors.Is(err, ErrInvalidTOTPSecret) {
+		t.Errorf("ValidTOTPSecret() = unexpected error, want: %v, got: %v\n", ErrInvalidTOTPSecret, err)
+	}
+}
+
+func TestGenerateCode(t *testing.T) {
+	code, err := GenerateCode()
+	if err != nil {
+		t.Fatalf("GenerateCode() = unexpected error: %v", err)
+	}
+
+	if len(code) != totpDigits || strings.Trim(code, "0123456789") != "" {
+		t.Errorf("GenerateCode() = unexpected code: %s\n", code)
+	}
+}
@@ -80,18 +80,40 @@ var errorCodeMaps = map[int]map[error]string{
 		ErrMalformedRequest:                   "MalformedRequest",
 		ErrPassphraseNotBase64:                "PassphraseNotBase64",
 		secret.ErrInvalidExpirationTime:       "InvalidExpirationTime",
+		secret.ErrInvalidNotBefore:            "InvalidNotBefore",
 		secret.ErrValueInvalid:                "ValueInvalid",
 		secret.ErrValueTooManyCharacters:      "ValueTooManyCharacters",
+		secret.ErrInvalidContent:              "InvalidContent",
+		secret.ErrInvalidLabel:                "InvalidLabel",
+		secret.ErrInvalidNote:                 "InvalidNote",
+		secret.ErrInvalidBatch:                "InvalidBatch",
 		secret.ErrPassphraseInvalid:           "PassphraseInvalid",
 		secret.ErrPassphraseTooFewCharacters:  "PassphraseTooFewCharacters",
 		secret.ErrPassphraseTooManyCharacters: "PassphraseTooManyCharacters",
+		secret.ErrPassphraseTooWeak:           "PassphraseTooWeak",
+		secret.ErrInvalidAllowedNetworks:      "InvalidAllowedNetworks",
+		secret.ErrSecondFactorInvalid:         "SecondFactorInvalid",
+		secret.ErrInvalidGenerateOptions:      "InvalidGenerateOptions",
+		secret.ErrInvalidCredentialType:       "InvalidCredentialType",
 		security.ErrInvalidBase64:             "InvalidBase64",
 	},
 	http.StatusUnauthorized: {
-		ErrPassphraseRequired:       "PassphraseRequired",
-		secret.ErrInvalidPassphrase: "InvalidPassphrase",
+		ErrPassphraseRequired:             "PassphraseRequired",
+		secret.ErrInvalidPassphrase:       "InvalidPassphrase",
+		secret.ErrSecondFactorRequired:    "SecondFactorRequired",
+		secret.ErrInvalidSecondFactorCode: "InvalidSecondFactorCode",
+	},
+	http.StatusForbidden: {
+		secret.ErrNetworkNotAllowed:  "NetworkNotAllowed",
+		secret.ErrSecretNotAvailable: "SecretNotAvailable",
 	},
 	http.StatusNotFound: {
 		secret.ErrSecretNotFound: "SecretNotFound",
 	},
+	http.StatusGone: {
+		secret.ErrSecondFactorAttemptsExceeded: "SecondFactorAttemptsExceeded",
+	},
+	http.StatusTooManyRequests: {
+		secret.ErrCodeRecentlySent: "CodeRecentlySent",
+	},
 }
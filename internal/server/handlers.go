@@ -2,18 +2,24 @@ package server
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
+	"math"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/RedeployAB/burnit/internal/api"
+	"github.com/RedeployAB/burnit/internal/auth"
 	"github.com/RedeployAB/burnit/internal/log"
 	"github.com/RedeployAB/burnit/internal/middleware"
 	"github.com/RedeployAB/burnit/internal/secret"
 	"github.com/RedeployAB/burnit/internal/security"
+	"github.com/RedeployAB/burnit/internal/tracing"
 	"github.com/RedeployAB/burnit/internal/ui"
 	"github.com/RedeployAB/burnit/internal/version"
 )
@@ -29,6 +35,15 @@ const (
 	contentTypeText = "text/plain"
 )
 
+const (
+	// healthStatusOK is the status for a healthy service or dependency.
+	healthStatusOK = "ok"
+	// healthStatusUnavailable is the status for an unavailable service or dependency.
+	healthStatusUnavailable = "unavailable"
+	// defaultReadinessTimeout is the default timeout for readiness checks.
+	defaultReadinessTimeout = 5 * time.Second
+)
+
 // index returns a handler for handling the index route.
 func index(ui ui.UI, log log.Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -46,7 +61,7 @@ func index(ui ui.UI, log log.Logger) http.Handler {
 			},
 		}); err != nil {
 			requestID := requestIDFromContext(r.Context())
-			log.Error("Failed to encode response.", serviceLog(err, "index", requestID)...)
+			log.Error("Failed to encode response.", serviceLog(r.Context(), err, "index")...)
 			writeServerError(w, requestID)
 			return
 		}
@@ -64,10 +79,88 @@ func notFound(ui ui.UI) http.Handler {
 	})
 }
 
+// healthCheck is a named check of a dependency.
+type healthCheck struct {
+	name string
+	ping func(ctx context.Context) error
+}
+
+// healthz returns a handler for handling liveness checks.
+func healthz(log log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := encode(w, http.StatusOK, api.Health{Status: healthStatusOK}); err != nil {
+			requestID := requestIDFromContext(r.Context())
+			log.Error("Failed to encode response.", serviceLog(r.Context(), err, "healthz")...)
+			writeServerError(w, requestID)
+			return
+		}
+	})
+}
+
+// readyz returns a handler for handling readiness checks. The dependencies
+// are checked concurrently and the server is reported as not ready if any
+// of them fail or if the server is shutting down.
+func readyz(checks []healthCheck, shuttingDown *atomic.Bool, log log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if shuttingDown != nil && shuttingDown.Load() {
+			if err := encode(w, http.StatusServiceUnavailable, api.Health{Status: healthStatusUnavailable}); err != nil {
+				requestID := requestIDFromContext(r.Context())
+				log.Error("Failed to encode response.", serviceLog(r.Context(), err, "readyz")...)
+				writeServerError(w, requestID)
+			}
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), defaultReadinessTimeout)
+		defer cancel()
+
+		dependencies := make([]api.Dependency, len(checks))
+		errs := make([]error, len(checks))
+		var wg sync.WaitGroup
+		for i, check := range checks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = check.ping(ctx)
+			}()
+		}
+		wg.Wait()
+
+		health := api.Health{Status: healthStatusOK, Dependencies: dependencies}
+		statusCode := http.StatusOK
+		for i, check := range checks {
+			dependencies[i] = api.Dependency{Name: check.name, Status: healthStatusOK}
+			if errs[i] != nil {
+				log.Error("Readiness check failed.", append(serviceLog(r.Context(), errs[i], "readyz"), "dependency", check.name)...)
+				dependencies[i].Status = healthStatusUnavailable
+				health.Status = healthStatusUnavailable
+				statusCode = http.StatusServiceUnavailable
+			}
+		}
+
+		if err := encode(w, statusCode, health); err != nil {
+			requestID := requestIDFromContext(r.Context())
+			log.Error("Failed to encode response.", serviceLog(r.Context(), err, "readyz")...)
+			writeServerError(w, requestID)
+			return
+		}
+	})
+}
+
 // generateSecret generates a new secret.
 func generateSecret(secrets secret.Service, log log.Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		secret := secrets.Generate(parseGenerateSecretQuery(r.URL.Query()))
+		secret, err := secrets.Generate(parseGenerateSecretQuery(r.URL.Query()))
+		if err != nil {
+			if statusCode, code := errorCode(err); statusCode != 0 {
+				writeError(w, err, statusCode, code)
+				return
+			}
+			requestID := requestIDFromContext(r.Context())
+			log.Error("Failed to generate secret.", serviceLog(r.Context(), err, "generateSecret")...)
+			writeServerError(w, requestID)
+			return
+		}
 
 		if header := r.Header.Get("Accept"); header == contentTypeText {
 			writeValue(w, secret)
@@ -76,7 +169,90 @@ func generateSecret(secrets secret.Service, log log.Logger) http.Handler {
 
 		if err := encode(w, http.StatusOK, api.Secret{Value: secret}); err != nil {
 			requestID := requestIDFromContext(r.Context())
-			log.Error("Failed to encode response.", serviceLog(err, "generateSecret", requestID)...)
+			log.Error("Failed to encode response.", serviceLog(r.Context(), err, "generateSecret")...)
+			writeServerError(w, requestID)
+			return
+		}
+	})
+}
+
+// generateCredential generates a credential, such as a key or a key pair.
+func generateCredential(secrets secret.Service, log log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		credential, err := secrets.GenerateCredential(secret.CredentialType(r.PathValue("type")), parseGenerateCredentialQuery(r.URL.Query()))
+		if err != nil {
+			if statusCode, code := errorCode(err); statusCode != 0 {
+				writeError(w, err, statusCode, code)
+				return
+			}
+			requestID := requestIDFromContext(r.Context())
+			log.Error("Failed to generate credential.", serviceLog(r.Context(), err, "generateCredential")...)
+			writeServerError(w, requestID)
+			return
+		}
+
+		if err := encode(w, http.StatusOK, api.Credential{Type: string(credential.Type), Private: credential.Private, Public: credential.Public}); err != nil {
+			requestID := requestIDFromContext(r.Context())
+			log.Error("Failed to encode response.", serviceLog(r.Context(), err, "generateCredential")...)
+			writeServerError(w, requestID)
+			return
+		}
+	})
+}
+
+// storeCredential generates a credential and stores its private part
+// as a secret. The request body is optional.
+func storeCredential(secrets secret.Service, log log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		credentialRequest, err := decode[api.StoreCredentialRequest](r)
+		if err != nil && !errors.Is(err, ErrEmptyRequest) {
+			statusCode, code := errorCode(err)
+			writeError(w, err, statusCode, code)
+			return
+		}
+
+		credential, err := secrets.GenerateCredential(secret.CredentialType(r.PathValue("type")), parseGenerateCredentialQuery(r.URL.Query()))
+		if err != nil {
+			if statusCode, code := errorCode(err); statusCode != 0 {
+				writeError(w, err, statusCode, code)
+				return
+			}
+			requestID := requestIDFromContext(r.Context())
+			log.Error("Failed to generate credential.", serviceLog(r.Context(), err, "storeCredential")...)
+			writeServerError(w, requestID)
+			return
+		}
+
+		newSecret := toCreateSecret(&api.CreateSecretRequest{
+			Value:           credential.Private,
+			Passphrase:      credentialRequest.Passphrase,
+			TTL:             credentialRequest.TTL,
+			ExpiresAt:       credentialRequest.ExpiresAt,
+			NotBefore:       credentialRequest.NotBefore,
+			AllowedNetworks: credentialRequest.AllowedNetworks,
+			SecondFactor:    credentialRequest.SecondFactor,
+		})
+		if key, ok := auth.FromContext(r.Context()); ok {
+			newSecret.CreatedBy = "apikey:" + key.Name
+		}
+
+		s, err := secrets.Create(r.Context(), newSecret)
+		if err != nil {
+			if statusCode, code := errorCode(err); statusCode != 0 {
+				writeError(w, err, statusCode, code)
+				return
+			}
+			requestID := requestIDFromContext(r.Context())
+			log.Error("Failed to create secret.", serviceLog(r.Context(), err, "storeCredential")...)
+			writeServerError(w, requestID)
+			return
+		}
+
+		apiSecret := toAPISecret(&s)
+		w.Header().Set("Location", "/secrets/"+s.ID)
+		if err := encode(w, http.StatusCreated, api.Credential{Type: string(credential.Type), Public: credential.Public, Secret: &apiSecret}); err != nil {
+			requestID := requestIDFromContext(r.Context())
+			log.Error("Failed to encode response.", serviceLog(r.Context(), err, "storeCredential")...)
 			writeServerError(w, requestID)
 			return
 		}
@@ -99,21 +275,27 @@ func getSecret(secrets secret.Service, log log.Logger) http.Handler {
 			return
 		}
 
-		secret, err := secrets.Get(id, passphrase)
+		s, err := secrets.Get(r.Context(), id, passphrase, func(o *secret.GetOptions) {
+			o.SourceIP = sourceIPFromContext(r.Context())
+			o.Code = r.Header.Get("Second-Factor-Code")
+		})
 		if err != nil {
+			if errors.Is(err, secret.ErrSecretNotAvailable) {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(s.NotBefore).Seconds()))))
+			}
 			if statusCode, code := errorCode(err); statusCode != 0 {
 				writeError(w, err, statusCode, code)
 				return
 			}
 			requestID := requestIDFromContext(r.Context())
-			log.Error("Failed to get secret.", serviceLog(err, "getSecret", requestID)...)
+			log.Error("Failed to get secret.", serviceLog(r.Context(), err, "getSecret")...)
 			writeServerError(w, requestID)
 			return
 		}
 
-		if err := encode(w, http.StatusOK, api.Secret{Value: secret.Value}); err != nil {
+		if err := encode(w, http.StatusOK, toAPISecretContent(&s)); err != nil {
 			requestID := requestIDFromContext(r.Context())
-			log.Error("Failed to encode response.", serviceLog(err, "getSecret", requestID)...)
+			log.Error("Failed to encode response.", serviceLog(r.Context(), err, "getSecret")...)
 			writeServerError(w, requestID)
 			return
 		}
@@ -130,22 +312,94 @@ func createSecret(secrets secret.Service, log log.Logger) http.Handler {
 			return
 		}
 
-		secret, err := secrets.Create(toCreateSecret(&secretRequest))
+		newSecret := toCreateSecret(&secretRequest)
+		if key, ok := auth.FromContext(r.Context()); ok {
+			newSecret.CreatedBy = "apikey:" + key.Name
+		}
+
+		secret, err := secrets.Create(r.Context(), newSecret)
 		if err != nil {
 			if statusCode, code := errorCode(err); statusCode != 0 {
 				writeError(w, err, statusCode, code)
 				return
 			}
 			requestID := requestIDFromContext(r.Context())
-			log.Error("Failed to create secret.", serviceLog(err, "createSecret", requestID)...)
+			log.Error("Failed to create secret.", serviceLog(r.Context(), err, "createSecret")...)
 			writeServerError(w, requestID)
 			return
 		}
 
+		response := toAPISecret(&secret)
+		if secretRequest.Generate != nil && secretRequest.Generate.ReturnValue {
+			response.Value = secret.Value
+		}
+
 		w.Header().Set("Location", "/secrets/"+secret.ID)
-		if err := encode(w, http.StatusCreated, toAPISecret(&secret)); err != nil {
+		if err := encode(w, http.StatusCreated, response); err != nil {
+			requestID := requestIDFromContext(r.Context())
+			log.Error("Failed to encode response.", serviceLog(r.Context(), err, "createSecret")...)
+			writeServerError(w, requestID)
+			return
+		}
+	})
+}
+
+// createSecrets creates a batch of secrets and returns a link to
+// share for each of them. The links use the base URL of the request
+// body, the configured base URL or the base URL of the request, in
+// that order.
+func createSecrets(secrets secret.Service, baseURL string, log log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secretsRequest, err := decode[api.CreateSecretsRequest](r)
+		if err != nil {
+			statusCode, code := errorCode(err)
+			writeError(w, err, statusCode, code)
+			return
+		}
+
+		var createdBy string
+		if key, ok := auth.FromContext(r.Context()); ok {
+			createdBy = "apikey:" + key.Name
+		}
+
+		newSecrets := make([]secret.Secret, len(secretsRequest.Secrets))
+		for i := range secretsRequest.Secrets {
+			newSecrets[i] = toCreateSecret(&secretsRequest.Secrets[i])
+			newSecrets[i].CreatedBy = createdBy
+		}
+
+		created, err := secrets.CreateBatch(r.Context(), newSecrets)
+		if err != nil {
+			if statusCode, code := errorCode(err); statusCode != 0 {
+				writeError(w, err, statusCode, code)
+				return
+			}
 			requestID := requestIDFromContext(r.Context())
-			log.Error("Failed to encode response.", serviceLog(err, "createSecret", requestID)...)
+			log.Error("Failed to create secrets.", serviceLog(r.Context(), err, "createSecrets")...)
+			writeServerError(w, requestID)
+			return
+		}
+
+		linkBaseURL := secretsRequest.BaseURL
+		if len(linkBaseURL) == 0 {
+			linkBaseURL = baseURL
+		}
+		if len(linkBaseURL) == 0 {
+			linkBaseURL = requestBaseURL(r)
+		}
+
+		response := api.CreateSecretsResponse{Secrets: make([]api.Secret, len(created))}
+		for i := range created {
+			response.Secrets[i] = toAPISecret(&created[i])
+			if generate := secretsRequest.Secrets[i].Generate; generate != nil && generate.ReturnValue {
+				response.Secrets[i].Value = created[i].Value
+			}
+			response.Secrets[i].Link = secretLink(linkBaseURL, created[i].ID, created[i].Passphrase)
+		}
+
+		if err := encode(w, http.StatusCreated, response); err != nil {
+			requestID := requestIDFromContext(r.Context())
+			log.Error("Failed to encode response.", serviceLog(r.Context(), err, "createSecrets")...)
 			writeServerError(w, requestID)
 			return
 		}
@@ -168,16 +422,18 @@ func deleteSecret(secrets secret.Service, log log.Logger) http.Handler {
 			return
 		}
 
-		if err := secrets.Delete(id, func(o *secret.DeleteOptions) {
+		if err := secrets.Delete(r.Context(), id, func(o *secret.DeleteOptions) {
 			o.VerifyPassphrase = true
 			o.Passphrase = passphrase
+			o.SourceIP = sourceIPFromContext(r.Context())
+			o.Code = r.Header.Get("Second-Factor-Code")
 		}); err != nil {
 			if statusCode, code := errorCode(err); statusCode != 0 {
 				writeError(w, err, statusCode, code)
 				return
 			}
 			requestID := requestIDFromContext(r.Context())
-			log.Error("Failed to delete secret.", serviceLog(err, "deleteSecret", requestID)...)
+			log.Error("Failed to delete secret.", serviceLog(r.Context(), err, "deleteSecret")...)
 			writeServerError(w, requestID)
 			return
 		}
@@ -185,40 +441,118 @@ func deleteSecret(secrets secret.Service, log log.Logger) http.Handler {
 	})
 }
 
-// parseGenerateSecretQuery parses the query parameters for length
-// and special characters.
+// sendSecretCode sends a code for the email second factor of a secret.
+func sendSecretCode(secrets secret.Service, log log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if len(id) == 0 {
+			writeError(w, errors.New("secret ID is required"), http.StatusBadRequest, "SecretIDRequired")
+			return
+		}
+
+		passphrase, err := getPassphrase(r.Header)
+		if err != nil {
+			statusCode, code := errorCode(err)
+			writeError(w, err, statusCode, code)
+			return
+		}
+
+		if err := secrets.SendCode(r.Context(), id, passphrase, func(o *secret.SendCodeOptions) {
+			o.SourceIP = sourceIPFromContext(r.Context())
+		}); err != nil {
+			if statusCode, code := errorCode(err); statusCode != 0 {
+				writeError(w, err, statusCode, code)
+				return
+			}
+			requestID := requestIDFromContext(r.Context())
+			log.Error("Failed to send code.", serviceLog(r.Context(), err, "sendSecretCode")...)
+			writeServerError(w, requestID)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// parseGenerateSecretQuery parses the query parameters for length,
+// character classes, excluded ambiguous characters and words.
 func parseGenerateSecretQuery(v url.Values) secret.GenerateOption {
-	var length string
-	if l, ok := v["length"]; ok {
-		length = l[0]
+	l, err := strconv.Atoi(queryValue(v, "length", "l"))
+	if err != nil {
+		l = defaultLength
 	}
-	if l, ok := v["l"]; ok {
-		length = l[0]
+	words, err := strconv.Atoi(queryValue(v, "words"))
+	if err != nil {
+		words = 0
 	}
 
-	var specialCharacters string
-	if sc, ok := v["specialCharacters"]; ok {
-		specialCharacters = sc[0]
-	}
-	if sc, ok := v["sc"]; ok {
-		specialCharacters = sc[0]
+	return func(o *secret.GenerateOptions) {
+		o.Length = l
+		o.Lowercase = queryBool(v, "lowercase")
+		o.Uppercase = queryBool(v, "uppercase")
+		o.Digits = queryBool(v, "digits")
+		o.SpecialCharacters = queryBool(v, "specialCharacters", "sc")
+		o.Custom = queryValue(v, "custom")
+		o.ExcludeAmbiguous = queryBool(v, "excludeAmbiguous")
+		o.Words = words
+		o.Separator = queryValue(v, "separator")
+		o.Capitalize = queryBool(v, "capitalize")
+		o.Number = queryBool(v, "number")
+		o.Symbol = queryBool(v, "symbol")
 	}
+}
 
-	l, err := strconv.Atoi(length)
+// parseGenerateCredentialQuery parses the query parameters for generating
+// a credential.
+func parseGenerateCredentialQuery(v url.Values) secret.GenerateCredentialOption {
+	bits, err := strconv.Atoi(queryValue(v, "bits"))
 	if err != nil {
-		l = defaultLength
+		bits = 0
 	}
-	sc, err := strconv.ParseBool(specialCharacters)
+	validFor, err := time.ParseDuration(queryValue(v, "validFor"))
 	if err != nil {
-		sc = false
+		validFor = 0
+	}
+	var dnsNames []string
+	for _, names := range v["dnsNames"] {
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); len(name) > 0 {
+				dnsNames = append(dnsNames, name)
+			}
+		}
 	}
 
-	return func(o *secret.GenerateOptions) {
-		o.Length = l
-		o.SpecialCharacters = sc
+	return func(o *secret.GenerateCredentialOptions) {
+		o.Bits = bits
+		o.Encoding = queryValue(v, "encoding")
+		o.Comment = queryValue(v, "comment")
+		o.CommonName = queryValue(v, "commonName", "cn")
+		o.DNSNames = dnsNames
+		o.ValidFor = validFor
 	}
 }
 
+// queryValue returns the value of the query parameter with the provided
+// key or any of its aliases. Later keys take precedence.
+func queryValue(v url.Values, keys ...string) string {
+	var value string
+	for _, key := range keys {
+		if values, ok := v[key]; ok {
+			value = values[0]
+		}
+	}
+	return value
+}
+
+// queryBool returns the value of the provided keys in the query as a
+// boolean. It returns false if not set or if the value is invalid.
+func queryBool(v url.Values, keys ...string) bool {
+	b, err := strconv.ParseBool(queryValue(v, keys...))
+	if err != nil {
+		return false
+	}
+	return b
+}
+
 // toCreateSecret converts a CreateSecretRequest to a secret.
 func toCreateSecret(s *api.CreateSecretRequest) secret.Secret {
 	var ttl time.Duration
@@ -234,15 +568,76 @@ func toCreateSecret(s *api.CreateSecretRequest) secret.Secret {
 	if s.ExpiresAt != nil {
 		expiresAt = s.ExpiresAt.Time
 	}
+	var notBefore time.Time
+	if s.NotBefore != nil {
+		notBefore = s.NotBefore.Time
+	}
+
+	var secondFactor *secret.SecondFactor
+	if s.SecondFactor != nil {
+		secondFactor = &secret.SecondFactor{
+			Type:       s.SecondFactor.Type,
+			TOTPSecret: s.SecondFactor.TOTPSecret,
+			Email:      s.SecondFactor.Email,
+		}
+	}
+
+	var generate *secret.GenerateOptions
+	if s.Generate != nil {
+		generate = &secret.GenerateOptions{
+			Length:            s.Generate.Length,
+			Lowercase:         s.Generate.Lowercase,
+			Uppercase:         s.Generate.Uppercase,
+			Digits:            s.Generate.Digits,
+			SpecialCharacters: s.Generate.SpecialCharacters,
+			Custom:            s.Generate.Custom,
+			ExcludeAmbiguous:  s.Generate.ExcludeAmbiguous,
+			Words:             s.Generate.Words,
+			Separator:         s.Generate.Separator,
+			Capitalize:        s.Generate.Capitalize,
+			Number:            s.Generate.Number,
+			Symbol:            s.Generate.Symbol,
+		}
+	}
 
 	return secret.Secret{
-		Value:      s.Value,
-		Passphrase: s.Passphrase,
-		TTL:        ttl,
-		ExpiresAt:  expiresAt,
+		Value:           s.Value,
+		Passphrase:      s.Passphrase,
+		TTL:             ttl,
+		ExpiresAt:       expiresAt,
+		NotBefore:       notBefore,
+		AllowedNetworks: s.AllowedNetworks,
+		SecondFactor:    secondFactor,
+		Generate:        generate,
+		Content:         toSecretContent(s),
+		Label:           s.Label,
+		Note:            s.Note,
 	}
 }
 
+// toSecretContent converts the structured content of a create secret
+// request to secret.Content. It returns nil for text.
+func toSecretContent(s *api.CreateSecretRequest) *secret.Content {
+	switch s.Type {
+	case "", string(secret.ContentTypeText):
+		return nil
+	}
+
+	content := &secret.Content{
+		Type:     secret.ContentType(s.Type),
+		KeyValue: s.KeyValue,
+	}
+	if s.Credentials != nil {
+		content.Credentials = &secret.Credentials{
+			Username: s.Credentials.Username,
+			Password: s.Credentials.Password,
+			URL:      s.Credentials.URL,
+			Notes:    s.Credentials.Notes,
+		}
+	}
+	return content
+}
+
 // getPassphrase retrieves the passphrase from the headers and
 // decodes it.
 func getPassphrase(header http.Header) (string, error) {
@@ -265,21 +660,101 @@ func toAPISecret(s *secret.Secret) api.Secret {
 	if !s.ExpiresAt.IsZero() {
 		expiresAt = &api.Time{Time: s.ExpiresAt}
 	}
+	var notBefore *api.Time
+	if !s.NotBefore.IsZero() {
+		notBefore = &api.Time{Time: s.NotBefore}
+	}
+
+	var secondFactor *api.SecondFactor
+	if s.SecondFactor != nil {
+		secondFactor = &api.SecondFactor{
+			Type:       s.SecondFactor.Type,
+			TOTPSecret: s.SecondFactor.TOTPSecret,
+			Email:      s.SecondFactor.Email,
+		}
+		if len(s.SecondFactor.TOTPSecret) > 0 {
+			secondFactor.TOTPURI = totpURI(s.ID, s.SecondFactor.TOTPSecret)
+		}
+	}
 
 	return api.Secret{
-		ID:         s.ID,
-		Passphrase: s.Passphrase,
-		TTL:        s.TTL.String(),
-		ExpiresAt:  expiresAt,
+		ID:           s.ID,
+		Passphrase:   s.Passphrase,
+		TTL:          s.TTL.String(),
+		ExpiresAt:    expiresAt,
+		NotBefore:    notBefore,
+		Label:        s.Label,
+		SecondFactor: secondFactor,
+	}
+}
+
+// toAPISecretContent converts the value or structured content, the
+// label and the note of a secret to api.Secret.
+func toAPISecretContent(s *secret.Secret) api.Secret {
+	if s.Content == nil {
+		return api.Secret{Value: s.Value, Label: s.Label, Note: s.Note}
+	}
+
+	response := api.Secret{
+		Type:     string(s.Content.Type),
+		KeyValue: s.Content.KeyValue,
+		Label:    s.Label,
+		Note:     s.Note,
 	}
+	if s.Content.Credentials != nil {
+		response.Credentials = &api.Credentials{
+			Username: s.Content.Credentials.Username,
+			Password: s.Content.Credentials.Password,
+			URL:      s.Content.Credentials.URL,
+			Notes:    s.Content.Credentials.Notes,
+		}
+	}
+	return response
+}
+
+// totpURI returns the otpauth URI of the TOTP secret of a secret.
+func totpURI(id, totpSecret string) string {
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/burnit:" + id,
+		RawQuery: url.Values{"secret": {totpSecret}, "issuer": {"burnit"}}.Encode(),
+	}
+	return u.String()
+}
+
+// secretLink returns the link to read a secret in the UI. The passphrase
+// is included as a hash, in the same format as links created in the UI.
+func secretLink(baseURL, id, passphrase string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/ui/secrets/" + id + "/" + base64.RawURLEncoding.EncodeToString(security.SHA256([]byte(passphrase)))
+}
+
+// requestBaseURL returns the base URL (scheme and host) of the request.
+// Behind a proxy that terminates TLS or rewrites the host it is not the
+// URL of the clients, and the base URL should be configured.
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
 }
 
 // serviceLog formats the log message for a service.
-func serviceLog(err error, handler, requestID string) []any {
-	return []any{"type", "service", "handler", handler, "error", err, "requestId", requestID}
+func serviceLog(ctx context.Context, err error, handler string) []any {
+	args := []any{"type", "service", "handler", handler, "error", err, "requestId", requestIDFromContext(ctx)}
+	if traceID := tracing.TraceID(ctx); len(traceID) > 0 {
+		args = append(args, "traceId", traceID)
+	}
+	return args
 }
 
 // requestIDFromContext returns the request ID from the context.
 func requestIDFromContext(ctx context.Context) string {
 	return middleware.RequestIDFromContext(ctx)
 }
+
+// sourceIPFromContext returns the source IP from the context.
+func sourceIPFromContext(ctx context.Context) string {
+	return middleware.SourceIPFromContext(ctx)
+}
@@ -1,14 +1,18 @@
 package server
 
 import (
+	"context"
 	"encoding/base64"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/RedeployAB/burnit/internal/secret"
 	"github.com/google/go-cmp/cmp"
@@ -115,6 +119,852 @@ func TestServer_generateSecret(t *testing.T) {
 				body:   []byte("aaaaaaaa"),
 			},
 		},
+		{
+			name: "generate secret - invalid options",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{err: secret.ErrInvalidGenerateOptions},
+				req:     httptest.NewRequest("GET", "/secret?length=2&custom=%20", nil),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusBadRequest,
+				body:   []byte(`{"statusCode":400,"code":"InvalidGenerateOptions","error":"invalid options for generating secret"}` + "\n"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req := test.input.req
+
+			generateSecret(test.input.secrets, &stubLogger{}).ServeHTTP(rr, req)
+
+			gotCode := rr.Code
+			gotBody := rr.Body.Bytes()
+
+			if diff := cmp.Diff(test.want.status, gotCode); diff != "" {
+				t.Errorf("generateSecret() = unexpected status code (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.want.body, gotBody); diff != "" {
+				t.Errorf("generateSecret() = unexpected body (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestParseGenerateSecretQuery(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input string
+		want  secret.GenerateOptions
+	}{
+		{
+			name:  "defaults",
+			input: "",
+			want: secret.GenerateOptions{
+				Length: defaultLength,
+			},
+		},
+		{
+			name:  "aliases",
+			input: "l=24&sc=true",
+			want: secret.GenerateOptions{
+				Length:            24,
+				SpecialCharacters: true,
+			},
+		},
+		{
+			name:  "character classes",
+			input: "length=32&lowercase=true&uppercase=true&digits=true&specialCharacters=true&custom=%C2%A7~&excludeAmbiguous=true",
+			want: secret.GenerateOptions{
+				Length:            32,
+				Lowercase:         true,
+				Uppercase:         true,
+				Digits:            true,
+				SpecialCharacters: true,
+				Custom:            "§~",
+				ExcludeAmbiguous:  true,
+			},
+		},
+		{
+			name:  "words",
+			input: "words=6&separator=.&capitalize=true&number=true&symbol=true",
+			want: secret.GenerateOptions{
+				Length:     defaultLength,
+				Words:      6,
+				Separator:  ".",
+				Capitalize: true,
+				Number:     true,
+				Symbol:     true,
+			},
+		},
+		{
+			name:  "invalid values",
+			input: "length=a&digits=b&words=c",
+			want: secret.GenerateOptions{
+				Length: defaultLength,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := url.ParseQuery(test.input)
+			if err != nil {
+				t.Fatalf("ParseQuery() = unexpected error: %v", err)
+			}
+
+			got := secret.GenerateOptions{}
+			parseGenerateSecretQuery(v)(&got)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("parseGenerateSecretQuery() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestParseGenerateCredentialQuery(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input string
+		want  secret.GenerateCredentialOptions
+	}{
+		{
+			name:  "defaults",
+			input: "",
+			want:  secret.GenerateCredentialOptions{},
+		},
+		{
+			name:  "options",
+			input: "bits=4096&encoding=hex&comment=user@example.com&cn=example.com&dnsNames=example.com,%20www.example.com&dnsNames=192.0.2.1&validFor=24h",
+			want: secret.GenerateCredentialOptions{
+				Bits:       4096,
+				Encoding:   "hex",
+				Comment:    "user@example.com",
+				CommonName: "example.com",
+				DNSNames:   []string{"example.com", "www.example.com", "192.0.2.1"},
+				ValidFor:   24 * time.Hour,
+			},
+		},
+		{
+			name:  "invalid values",
+			input: "bits=a&validFor=b",
+			want:  secret.GenerateCredentialOptions{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := url.ParseQuery(test.input)
+			if err != nil {
+				t.Fatalf("ParseQuery() = unexpected error: %v", err)
+			}
+
+			got := secret.GenerateCredentialOptions{}
+			parseGenerateCredentialQuery(v)(&got)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("parseGenerateCredentialQuery() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestServer_getSecret(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			secrets secret.Service
+			req     *http.Request
+			path    string
+		}
+		want struct {
+			status int
+			body   []byte
+		}
+	}{
+		{
+			name: "get secret",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+				path    string
+			}{
+				secrets: &stubSecretService{
+					secrets: []secret.Secret{
+						{ID: "1", Value: "secret"},
+					},
+				},
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/secrets/1", nil)
+					req.SetPathValue("id", "1")
+					req.Header.Set("Passphrase", base64.StdEncoding.EncodeToString([]byte("passphrase")))
+					return req
+				}(),
+				path: "/secret/1",
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusOK,
+				body:   []byte(`{"value":"secret"}` + "\n"),
+			},
+		},
+		{
+			name: "get secret - credentials",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+				path    string
+			}{
+				secrets: &stubSecretService{
+					secrets: []secret.Secret{
+						{
+							ID: "1",
+							Content: &secret.Content{
+								Type: secret.ContentTypeCredentials,
+								Credentials: &secret.Credentials{
+									Username: "user",
+									Password: "pass",
+									URL:      "db.example.com",
+								},
+							},
+						},
+					},
+				},
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/secrets/1", nil)
+					req.SetPathValue("id", "1")
+					req.Header.Set("Passphrase", base64.StdEncoding.EncodeToString([]byte("passphrase")))
+					return req
+				}(),
+				path: "/secret/1",
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusOK,
+				body:   []byte(`{"type":"credentials","credentials":{"username":"user","password":"pass","url":"db.example.com"}}` + "\n"),
+			},
+		},
+		{
+			name: "get secret - label and note",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+				path    string
+			}{
+				secrets: &stubSecretService{
+					secrets: []secret.Secret{
+						{ID: "1", Value: "secret", Label: "Staging DB", Note: "Rotated monthly."},
+					},
+				},
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/secrets/1", nil)
+					req.SetPathValue("id", "1")
+					req.Header.Set("Passphrase", base64.StdEncoding.EncodeToString([]byte("passphrase")))
+					return req
+				}(),
+				path: "/secret/1",
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusOK,
+				body:   []byte(`{"value":"secret","label":"Staging DB","note":"Rotated monthly."}` + "\n"),
+			},
+		},
+		{
+			name: "get secret - passphrase required",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+				path    string
+			}{
+				secrets: &stubSecretService{
+					secrets: []secret.Secret{
+						{ID: "1", Value: "secret", Passphrase: "passphrase"},
+					},
+					err: secret.ErrInvalidPassphrase,
+				},
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/secrets/1", nil)
+					req.SetPathValue("id", "1")
+					return req
+				}(),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusUnauthorized,
+				body:   []byte(`{"statusCode":401,"code":"PassphraseRequired","error":"passphrase required"}` + "\n"),
+			},
+		},
+		{
+			name: "get secret - invalid passphrase",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+				path    string
+			}{
+				secrets: &stubSecretService{
+					secrets: []secret.Secret{
+						{ID: "1", Value: "secret", Passphrase: "passphrase"},
+					},
+					err: secret.ErrInvalidPassphrase,
+				},
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/secrets/1", nil)
+					req.SetPathValue("id", "1")
+					req.Header.Set("Passphrase", base64.StdEncoding.EncodeToString([]byte("invalid")))
+					return req
+				}(),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusUnauthorized,
+				body:   []byte(`{"statusCode":401,"code":"InvalidPassphrase","error":"invalid passphrase"}` + "\n"),
+			},
+		},
+		{
+			name: "get secret - second factor code required",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+				path    string
+			}{
+				secrets: &stubSecretService{
+					err: secret.ErrSecondFactorRequired,
+				},
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/secrets/1", nil)
+					req.SetPathValue("id", "1")
+					req.Header.Set("Passphrase", base64.StdEncoding.EncodeToString([]byte("passphrase")))
+					return req
+				}(),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusUnauthorized,
+				body:   []byte(`{"statusCode":401,"code":"SecondFactorRequired","error":"second factor code required"}` + "\n"),
+			},
+		},
+		{
+			name: "get secret - secret not found",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+				path    string
+			}{
+				secrets: &stubSecretService{
+					secrets: []secret.Secret{},
+				},
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/secrets/1", nil)
+					req.SetPathValue("id", "1")
+					req.Header.Set("Passphrase", base64.StdEncoding.EncodeToString([]byte("invalid")))
+					return req
+				}(),
+				path: "/secret/1",
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusNotFound,
+				body:   []byte(`{"statusCode":404,"code":"SecretNotFound","error":"secret not found"}` + "\n"),
+			},
+		},
+		{
+			name: "get secret - error from service",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+				path    string
+			}{
+				secrets: &stubSecretService{
+					err: errSecretService,
+				},
+				req: func() *http.Request {
+					req := httptest.NewRequest("GET", "/secrets/1", nil)
+					req.SetPathValue("id", "1")
+					req.Header.Set("Passphrase", base64.StdEncoding.EncodeToString([]byte("passphrase")))
+					return req
+				}(),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusInternalServerError,
+				body:   []byte(`{"statusCode":500,"code":"ServerError","error":"internal server error"}` + "\n"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req := test.input.req
+
+			getSecret(test.input.secrets, &stubLogger{}).ServeHTTP(rr, req)
+
+			gotCode := rr.Code
+			gotBody := rr.Body.Bytes()
+
+			if diff := cmp.Diff(test.want.status, gotCode); diff != "" {
+				t.Errorf("getSecret() = unexpected status code (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.want.body, gotBody); diff != "" {
+				t.Errorf("getSecret() = unexpected body (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestServer_createSecret(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			secrets secret.Service
+			req     *http.Request
+		}
+		want struct {
+			status int
+			body   []byte
+		}
+	}{
+		{
+			name: "create secret",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{},
+				req:     httptest.NewRequest("POST", "/secret", strings.NewReader(`{"value":"1","ttl":"1h"}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusCreated,
+				body:   []byte(`{"id":"1","passphrase":"passphrase","ttl":"1h0m0s"}` + "\n"),
+			},
+		},
+		{
+			name: "create secret - error empty value",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{},
+				req:     httptest.NewRequest("POST", "/secret", strings.NewReader(`{"value":"","ttl":"1h"}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusBadRequest,
+				body:   []byte(`{"statusCode":400,"code":"InvalidRequest","error":"invalid request: value is required"}` + "\n"),
+			},
+		},
+		{
+			name: "create secret - generated value",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{},
+				req:     httptest.NewRequest("POST", "/secret", strings.NewReader(`{"ttl":"1h","generate":{"words":6}}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusCreated,
+				body:   []byte(`{"id":"1","passphrase":"passphrase","ttl":"1h0m0s"}` + "\n"),
+			},
+		},
+		{
+			name: "create secret - generated value returned",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{},
+				req:     httptest.NewRequest("POST", "/secret", strings.NewReader(`{"ttl":"1h","generate":{"length":32,"returnValue":true}}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusCreated,
+				body:   []byte(`{"id":"1","value":"generated","passphrase":"passphrase","ttl":"1h0m0s"}` + "\n"),
+			},
+		},
+		{
+			name: "create secret - error value and generate",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{},
+				req:     httptest.NewRequest("POST", "/secret", strings.NewReader(`{"value":"1","ttl":"1h","generate":{"length":32}}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusBadRequest,
+				body:   []byte(`{"statusCode":400,"code":"InvalidRequest","error":"invalid request: value can not be provided together with generate"}` + "\n"),
+			},
+		},
+		{
+			name: "create secret - error invalid generate options",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{
+					err: secret.ErrInvalidGenerateOptions,
+				},
+				req: httptest.NewRequest("POST", "/secret", strings.NewReader(`{"ttl":"1h","generate":{"words":1}}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusBadRequest,
+				body:   []byte(`{"statusCode":400,"code":"InvalidGenerateOptions","error":"invalid options for generating secret"}` + "\n"),
+			},
+		},
+		{
+			name: "create secret - key-value",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{},
+				req:     httptest.NewRequest("POST", "/secret", strings.NewReader(`{"ttl":"1h","type":"keyValue","keyValue":{"host":"db.example.com"}}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusCreated,
+				body:   []byte(`{"id":"1","passphrase":"passphrase","ttl":"1h0m0s"}` + "\n"),
+			},
+		},
+		{
+			name: "create secret - error value and structured content",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{},
+				req:     httptest.NewRequest("POST", "/secret", strings.NewReader(`{"value":"1","type":"credentials","credentials":{"username":"user"}}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusBadRequest,
+				body:   []byte(`{"statusCode":400,"code":"InvalidRequest","error":"invalid request: value can not be provided together with structured content"}` + "\n"),
+			},
+		},
+		{
+			name: "create secret - error invalid type",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{},
+				req:     httptest.NewRequest("POST", "/secret", strings.NewReader(`{"value":"1","type":"file"}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusBadRequest,
+				body:   []byte(`{"statusCode":400,"code":"InvalidRequest","error":"invalid request: type must be text, credentials or keyValue"}` + "\n"),
+			},
+		},
+		{
+			name: "create secret - label and note",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{},
+				req:     httptest.NewRequest("POST", "/secret", strings.NewReader(`{"value":"1","ttl":"1h","label":"Staging DB","note":"Rotated monthly."}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusCreated,
+				body:   []byte(`{"id":"1","passphrase":"passphrase","ttl":"1h0m0s","label":"Staging DB"}` + "\n"),
+			},
+		},
+		{
+			name: "create secret - error invalid label",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{
+					err: secret.ErrInvalidLabel,
+				},
+				req: httptest.NewRequest("POST", "/secret", strings.NewReader(`{"value":"1","ttl":"1h","label":"Staging\nDB"}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusBadRequest,
+				body:   []byte(`{"statusCode":400,"code":"InvalidLabel","error":"invalid label"}` + "\n"),
+			},
+		},
+		{
+			name: "create secret - error from service",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{
+					err: errSecretService,
+				},
+				req: httptest.NewRequest("POST", "/secret", strings.NewReader(`{"value":"1","ttl":"1h"}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusInternalServerError,
+				body:   []byte(`{"statusCode":500,"code":"ServerError","error":"internal server error"}` + "\n"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req := test.input.req
+
+			createSecret(test.input.secrets, &stubLogger{}).ServeHTTP(rr, req)
+
+			gotCode := rr.Code
+			gotBody := rr.Body.Bytes()
+
+			if diff := cmp.Diff(test.want.status, gotCode); diff != "" {
+				t.Errorf("createSecret() = unexpected status code (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.want.body, gotBody); diff != "" {
+				t.Errorf("createSecret() = unexpected body (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestServer_createSecrets(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			secrets secret.Service
+			baseURL string
+			req     *http.Request
+		}
+		want struct {
+			status int
+			body   []byte
+		}
+	}{
+		{
+			name: "create secrets",
+			input: struct {
+				secrets secret.Service
+				baseURL string
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{},
+				req:     httptest.NewRequest("POST", "/secrets:batch", strings.NewReader(`{"secrets":[{"value":"1","ttl":"1h","label":"Anna"},{"ttl":"1h","generate":{"words":4,"returnValue":true}}]}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusCreated,
+				body:   []byte(`{"secrets":[{"id":"1","passphrase":"passphrase","ttl":"1h0m0s","label":"Anna","link":"http://example.com/ui/secrets/1/HgiePFMjrYCpB2e91ZByl7QTgWPwJwl_072-q1KNLWg"},{"id":"2","value":"generated","passphrase":"passphrase","ttl":"1h0m0s","link":"http://example.com/ui/secrets/2/HgiePFMjrYCpB2e91ZByl7QTgWPwJwl_072-q1KNLWg"}]}` + "\n"),
+			},
+		},
+		{
+			name: "create secrets - base URL",
+			input: struct {
+				secrets secret.Service
+				baseURL string
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{},
+				req:     httptest.NewRequest("POST", "/secrets:batch", strings.NewReader(`{"secrets":[{"value":"1","ttl":"1h"}],"baseUrl":"https://burnit.example.com/"}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusCreated,
+				body:   []byte(`{"secrets":[{"id":"1","passphrase":"passphrase","ttl":"1h0m0s","link":"https://burnit.example.com/ui/secrets/1/HgiePFMjrYCpB2e91ZByl7QTgWPwJwl_072-q1KNLWg"}]}` + "\n"),
+			},
+		},
+		{
+			name: "create secrets - configured base URL",
+			input: struct {
+				secrets secret.Service
+				baseURL string
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{},
+				baseURL: "https://burnit.example.com",
+				req:     httptest.NewRequest("POST", "/secrets:batch", strings.NewReader(`{"secrets":[{"value":"1","ttl":"1h"}]}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusCreated,
+				body:   []byte(`{"secrets":[{"id":"1","passphrase":"passphrase","ttl":"1h0m0s","link":"https://burnit.example.com/ui/secrets/1/HgiePFMjrYCpB2e91ZByl7QTgWPwJwl_072-q1KNLWg"}]}` + "\n"),
+			},
+		},
+		{
+			name: "create secrets - base URL overrides configured base URL",
+			input: struct {
+				secrets secret.Service
+				baseURL string
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{},
+				baseURL: "https://burnit.example.com",
+				req:     httptest.NewRequest("POST", "/secrets:batch", strings.NewReader(`{"secrets":[{"value":"1","ttl":"1h"}],"baseUrl":"https://secrets.example.org"}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusCreated,
+				body:   []byte(`{"secrets":[{"id":"1","passphrase":"passphrase","ttl":"1h0m0s","link":"https://secrets.example.org/ui/secrets/1/HgiePFMjrYCpB2e91ZByl7QTgWPwJwl_072-q1KNLWg"}]}` + "\n"),
+			},
+		},
+		{
+			name: "create secrets - error no secrets",
+			input: struct {
+				secrets secret.Service
+				baseURL string
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{},
+				req:     httptest.NewRequest("POST", "/secrets:batch", strings.NewReader(`{"secrets":[]}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusBadRequest,
+				body:   []byte(`{"statusCode":400,"code":"InvalidRequest","error":"invalid request: secrets are required"}` + "\n"),
+			},
+		},
+		{
+			name: "create secrets - error invalid secret",
+			input: struct {
+				secrets secret.Service
+				baseURL string
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{},
+				req:     httptest.NewRequest("POST", "/secrets:batch", strings.NewReader(`{"secrets":[{"value":"1"},{"ttl":"1h"}]}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusBadRequest,
+				body:   []byte(`{"statusCode":400,"code":"InvalidRequest","error":"invalid request: secret 1: value is required"}` + "\n"),
+			},
+		},
+		{
+			name: "create secrets - error invalid base URL",
+			input: struct {
+				secrets secret.Service
+				baseURL string
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{},
+				req:     httptest.NewRequest("POST", "/secrets:batch", strings.NewReader(`{"secrets":[{"value":"1"}],"baseUrl":"burnit.example.com"}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusBadRequest,
+				body:   []byte(`{"statusCode":400,"code":"InvalidRequest","error":"invalid request: baseUrl is invalid, expected an absolute http or https URL"}` + "\n"),
+			},
+		},
+		{
+			name: "create secrets - error invalid batch",
+			input: struct {
+				secrets secret.Service
+				baseURL string
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{
+					err: secret.ErrInvalidBatch,
+				},
+				req: httptest.NewRequest("POST", "/secrets:batch", strings.NewReader(`{"secrets":[{"value":"1"}]}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusBadRequest,
+				body:   []byte(`{"statusCode":400,"code":"InvalidBatch","error":"invalid batch"}` + "\n"),
+			},
+		},
+		{
+			name: "create secrets - error from service",
+			input: struct {
+				secrets secret.Service
+				baseURL string
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{
+					err: errSecretService,
+				},
+				req: httptest.NewRequest("POST", "/secrets:batch", strings.NewReader(`{"secrets":[{"value":"1"}]}`)),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusInternalServerError,
+				body:   []byte(`{"statusCode":500,"code":"ServerError","error":"internal server error"}` + "\n"),
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -122,29 +972,28 @@ func TestServer_generateSecret(t *testing.T) {
 			rr := httptest.NewRecorder()
 			req := test.input.req
 
-			generateSecret(test.input.secrets, &stubLogger{}).ServeHTTP(rr, req)
+			createSecrets(test.input.secrets, test.input.baseURL, &stubLogger{}).ServeHTTP(rr, req)
 
 			gotCode := rr.Code
 			gotBody := rr.Body.Bytes()
 
 			if diff := cmp.Diff(test.want.status, gotCode); diff != "" {
-				t.Errorf("generateSecret() = unexpected status code (-want +got)\n%s\n", diff)
+				t.Errorf("createSecrets() = unexpected status code (-want +got)\n%s\n", diff)
 			}
 
 			if diff := cmp.Diff(test.want.body, gotBody); diff != "" {
-				t.Errorf("generateSecret() = unexpected body (-want +got)\n%s\n", diff)
+				t.Errorf("createSecrets() = unexpected body (-want +got)\n%s\n", diff)
 			}
 		})
 	}
 }
 
-func TestServer_getSecret(t *testing.T) {
+func TestServer_generateCredential(t *testing.T) {
 	var tests = []struct {
 		name  string
 		input struct {
 			secrets secret.Service
 			req     *http.Request
-			path    string
 		}
 		want struct {
 			status int
@@ -152,49 +1001,38 @@ func TestServer_getSecret(t *testing.T) {
 		}
 	}{
 		{
-			name: "get secret",
+			name: "generate credential",
 			input: struct {
 				secrets secret.Service
 				req     *http.Request
-				path    string
 			}{
-				secrets: &stubSecretService{
-					secrets: []secret.Secret{
-						{ID: "1", Value: "secret"},
-					},
-				},
+				secrets: &stubSecretService{},
 				req: func() *http.Request {
-					req := httptest.NewRequest("GET", "/secrets/1", nil)
-					req.SetPathValue("id", "1")
-					req.Header.Set("Passphrase", base64.StdEncoding.EncodeToString([]byte("passphrase")))
+					req := httptest.NewRequest("GET", "/generate/ssh-ed25519", nil)
+					req.SetPathValue("type", "ssh-ed25519")
 					return req
 				}(),
-				path: "/secret/1",
 			},
 			want: struct {
 				status int
 				body   []byte
 			}{
 				status: http.StatusOK,
-				body:   []byte(`{"value":"secret"}` + "\n"),
+				body:   []byte(`{"type":"ssh-ed25519","private":"private","public":"public"}` + "\n"),
 			},
 		},
 		{
-			name: "get secret - passphrase required",
+			name: "generate credential - invalid type",
 			input: struct {
 				secrets secret.Service
 				req     *http.Request
-				path    string
 			}{
 				secrets: &stubSecretService{
-					secrets: []secret.Secret{
-						{ID: "1", Value: "secret", Passphrase: "passphrase"},
-					},
-					err: secret.ErrInvalidPassphrase,
+					err: secret.ErrInvalidCredentialType,
 				},
 				req: func() *http.Request {
-					req := httptest.NewRequest("GET", "/secrets/1", nil)
-					req.SetPathValue("id", "1")
+					req := httptest.NewRequest("GET", "/generate/password", nil)
+					req.SetPathValue("type", "password")
 					return req
 				}(),
 			},
@@ -202,27 +1040,22 @@ func TestServer_getSecret(t *testing.T) {
 				status int
 				body   []byte
 			}{
-				status: http.StatusUnauthorized,
-				body:   []byte(`{"statusCode":401,"code":"PassphraseRequired","error":"passphrase required"}` + "\n"),
+				status: http.StatusBadRequest,
+				body:   []byte(`{"statusCode":400,"code":"InvalidCredentialType","error":"invalid credential type"}` + "\n"),
 			},
 		},
 		{
-			name: "get secret - invalid passphrase",
+			name: "generate credential - error from service",
 			input: struct {
 				secrets secret.Service
 				req     *http.Request
-				path    string
 			}{
 				secrets: &stubSecretService{
-					secrets: []secret.Secret{
-						{ID: "1", Value: "secret", Passphrase: "passphrase"},
-					},
-					err: secret.ErrInvalidPassphrase,
+					err: errSecretService,
 				},
 				req: func() *http.Request {
-					req := httptest.NewRequest("GET", "/secrets/1", nil)
-					req.SetPathValue("id", "1")
-					req.Header.Set("Passphrase", base64.StdEncoding.EncodeToString([]byte("invalid")))
+					req := httptest.NewRequest("GET", "/generate/key", nil)
+					req.SetPathValue("type", "key")
 					return req
 				}(),
 			},
@@ -230,50 +1063,95 @@ func TestServer_getSecret(t *testing.T) {
 				status int
 				body   []byte
 			}{
-				status: http.StatusUnauthorized,
-				body:   []byte(`{"statusCode":401,"code":"InvalidPassphrase","error":"invalid passphrase"}` + "\n"),
+				status: http.StatusInternalServerError,
+				body:   []byte(`{"statusCode":500,"code":"ServerError","error":"internal server error"}` + "\n"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			generateCredential(test.input.secrets, &stubLogger{}).ServeHTTP(rr, test.input.req)
+
+			gotCode := rr.Code
+			gotBody := rr.Body.Bytes()
+
+			if diff := cmp.Diff(test.want.status, gotCode); diff != "" {
+				t.Errorf("generateCredential() = unexpected status code (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.want.body, gotBody); diff != "" {
+				t.Errorf("generateCredential() = unexpected body (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestServer_storeCredential(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			secrets secret.Service
+			req     *http.Request
+		}
+		want struct {
+			status int
+			body   []byte
+		}
+	}{
+		{
+			name: "store credential",
+			input: struct {
+				secrets secret.Service
+				req     *http.Request
+			}{
+				secrets: &stubSecretService{},
+				req: func() *http.Request {
+					req := httptest.NewRequest("POST", "/generate/wireguard", strings.NewReader(`{"ttl":"1h"}`))
+					req.SetPathValue("type", "wireguard")
+					return req
+				}(),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusCreated,
+				body:   []byte(`{"type":"wireguard","public":"public","secret":{"id":"1","passphrase":"passphrase","ttl":"1h0m0s"}}` + "\n"),
 			},
 		},
 		{
-			name: "get secret - secret not found",
+			name: "store credential - empty body",
 			input: struct {
 				secrets secret.Service
 				req     *http.Request
-				path    string
 			}{
-				secrets: &stubSecretService{
-					secrets: []secret.Secret{},
-				},
+				secrets: &stubSecretService{},
 				req: func() *http.Request {
-					req := httptest.NewRequest("GET", "/secrets/1", nil)
-					req.SetPathValue("id", "1")
-					req.Header.Set("Passphrase", base64.StdEncoding.EncodeToString([]byte("invalid")))
+					req := httptest.NewRequest("POST", "/generate/uuid", nil)
+					req.SetPathValue("type", "uuid")
 					return req
 				}(),
-				path: "/secret/1",
 			},
 			want: struct {
 				status int
 				body   []byte
 			}{
-				status: http.StatusNotFound,
-				body:   []byte(`{"statusCode":404,"code":"SecretNotFound","error":"secret not found"}` + "\n"),
+				status: http.StatusCreated,
+				body:   []byte(`{"type":"uuid","public":"public","secret":{"id":"1","passphrase":"passphrase","ttl":"0s"}}` + "\n"),
 			},
 		},
 		{
-			name: "get secret - error from service",
+			name: "store credential - invalid request",
 			input: struct {
 				secrets secret.Service
 				req     *http.Request
-				path    string
 			}{
-				secrets: &stubSecretService{
-					err: errSecretService,
-				},
+				secrets: &stubSecretService{},
 				req: func() *http.Request {
-					req := httptest.NewRequest("GET", "/secrets/1", nil)
-					req.SetPathValue("id", "1")
-					req.Header.Set("Passphrase", base64.StdEncoding.EncodeToString([]byte("passphrase")))
+					req := httptest.NewRequest("POST", "/generate/key", strings.NewReader(`{"ttl":"1 hour"}`))
+					req.SetPathValue("type", "key")
 					return req
 				}(),
 			},
@@ -281,8 +1159,8 @@ func TestServer_getSecret(t *testing.T) {
 				status int
 				body   []byte
 			}{
-				status: http.StatusInternalServerError,
-				body:   []byte(`{"statusCode":500,"code":"ServerError","error":"internal server error"}` + "\n"),
+				status: http.StatusBadRequest,
+				body:   []byte(`{"statusCode":400,"code":"InvalidRequest","error":"invalid request: ttl is invalid, expected format is 1h30m"}` + "\n"),
 			},
 		},
 	}
@@ -290,25 +1168,23 @@ func TestServer_getSecret(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			rr := httptest.NewRecorder()
-			req := test.input.req
-
-			getSecret(test.input.secrets, &stubLogger{}).ServeHTTP(rr, req)
+			storeCredential(test.input.secrets, &stubLogger{}).ServeHTTP(rr, test.input.req)
 
 			gotCode := rr.Code
 			gotBody := rr.Body.Bytes()
 
 			if diff := cmp.Diff(test.want.status, gotCode); diff != "" {
-				t.Errorf("getSecret() = unexpected status code (-want +got)\n%s\n", diff)
+				t.Errorf("storeCredential() = unexpected status code (-want +got)\n%s\n", diff)
 			}
 
 			if diff := cmp.Diff(test.want.body, gotBody); diff != "" {
-				t.Errorf("getSecret() = unexpected body (-want +got)\n%s\n", diff)
+				t.Errorf("storeCredential() = unexpected body (-want +got)\n%s\n", diff)
 			}
 		})
 	}
 }
 
-func TestServer_createSecret(t *testing.T) {
+func TestServer_sendSecretCode(t *testing.T) {
 	var tests = []struct {
 		name  string
 		input struct {
@@ -321,56 +1197,172 @@ func TestServer_createSecret(t *testing.T) {
 		}
 	}{
 		{
-			name: "create secret",
+			name: "send code",
 			input: struct {
 				secrets secret.Service
 				req     *http.Request
 			}{
 				secrets: &stubSecretService{},
-				req:     httptest.NewRequest("POST", "/secret", strings.NewReader(`{"value":"1","ttl":"1h"}`)),
+				req: func() *http.Request {
+					req := httptest.NewRequest("POST", "/secrets/1/code", nil)
+					req.SetPathValue("id", "1")
+					req.Header.Set("Passphrase", base64.StdEncoding.EncodeToString([]byte("passphrase")))
+					return req
+				}(),
 			},
 			want: struct {
 				status int
 				body   []byte
 			}{
-				status: http.StatusCreated,
-				body:   []byte(`{"id":"1","passphrase":"passphrase","ttl":"1h0m0s"}` + "\n"),
+				status: http.StatusAccepted,
 			},
 		},
 		{
-			name: "create secret - error empty value",
+			name: "send code - no email second factor",
 			input: struct {
 				secrets secret.Service
 				req     *http.Request
 			}{
-				secrets: &stubSecretService{},
-				req:     httptest.NewRequest("POST", "/secret", strings.NewReader(`{"value":"","ttl":"1h"}`)),
+				secrets: &stubSecretService{
+					err: secret.ErrSecondFactorInvalid,
+				},
+				req: func() *http.Request {
+					req := httptest.NewRequest("POST", "/secrets/1/code", nil)
+					req.SetPathValue("id", "1")
+					req.Header.Set("Passphrase", base64.StdEncoding.EncodeToString([]byte("passphrase")))
+					return req
+				}(),
 			},
 			want: struct {
 				status int
 				body   []byte
 			}{
 				status: http.StatusBadRequest,
-				body:   []byte(`{"statusCode":400,"code":"InvalidRequest","error":"invalid request: value is required"}` + "\n"),
+				body:   []byte(`{"statusCode":400,"code":"SecondFactorInvalid","error":"invalid second factor"}` + "\n"),
 			},
 		},
 		{
-			name: "create secret - error from service",
+			name: "send code - passphrase required",
 			input: struct {
 				secrets secret.Service
 				req     *http.Request
 			}{
-				secrets: &stubSecretService{
-					err: errSecretService,
+				secrets: &stubSecretService{},
+				req: func() *http.Request {
+					req := httptest.NewRequest("POST", "/secrets/1/code", nil)
+					req.SetPathValue("id", "1")
+					return req
+				}(),
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusUnauthorized,
+				body:   []byte(`{"statusCode":401,"code":"PassphraseRequired","error":"passphrase required"}` + "\n"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+
+			sendSecretCode(test.input.secrets, &stubLogger{}).ServeHTTP(rr, test.input.req)
+
+			if diff := cmp.Diff(test.want.status, rr.Code); diff != "" {
+				t.Errorf("sendSecretCode() = unexpected status code (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.want.body, rr.Body.Bytes()); diff != "" {
+				t.Errorf("sendSecretCode() = unexpected body (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestServer_healthz(t *testing.T) {
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/healthz", nil)
+
+	healthz(&stubLogger{}).ServeHTTP(rr, req)
+
+	if diff := cmp.Diff(http.StatusOK, rr.Code); diff != "" {
+		t.Errorf("healthz() = unexpected status code (-want +got)\n%s\n", diff)
+	}
+
+	if diff := cmp.Diff([]byte(`{"status":"ok"}`+"\n"), rr.Body.Bytes()); diff != "" {
+		t.Errorf("healthz() = unexpected body (-want +got)\n%s\n", diff)
+	}
+}
+
+func TestServer_readyz(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			checks       []healthCheck
+			shuttingDown bool
+		}
+		want struct {
+			status int
+			body   []byte
+		}
+	}{
+		{
+			name: "ready",
+			input: struct {
+				checks       []healthCheck
+				shuttingDown bool
+			}{
+				checks: []healthCheck{
+					{name: "secrets", ping: stubSecretService{}.Ping},
+					{name: "sessions", ping: stubSecretService{}.Ping},
 				},
-				req: httptest.NewRequest("POST", "/secret", strings.NewReader(`{"value":"1","ttl":"1h"}`)),
 			},
 			want: struct {
 				status int
 				body   []byte
 			}{
-				status: http.StatusInternalServerError,
-				body:   []byte(`{"statusCode":500,"code":"ServerError","error":"internal server error"}` + "\n"),
+				status: http.StatusOK,
+				body:   []byte(`{"status":"ok","dependencies":[{"name":"secrets","status":"ok"},{"name":"sessions","status":"ok"}]}` + "\n"),
+			},
+		},
+		{
+			name: "not ready - dependency unavailable",
+			input: struct {
+				checks       []healthCheck
+				shuttingDown bool
+			}{
+				checks: []healthCheck{
+					{name: "secrets", ping: stubSecretService{}.Ping},
+					{name: "sessions", ping: stubSecretService{err: errSecretService}.Ping},
+				},
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusServiceUnavailable,
+				body:   []byte(`{"status":"unavailable","dependencies":[{"name":"secrets","status":"ok"},{"name":"sessions","status":"unavailable"}]}` + "\n"),
+			},
+		},
+		{
+			name: "not ready - shutting down",
+			input: struct {
+				checks       []healthCheck
+				shuttingDown bool
+			}{
+				checks: []healthCheck{
+					{name: "secrets", ping: stubSecretService{}.Ping},
+				},
+				shuttingDown: true,
+			},
+			want: struct {
+				status int
+				body   []byte
+			}{
+				status: http.StatusServiceUnavailable,
+				body:   []byte(`{"status":"unavailable"}` + "\n"),
 			},
 		},
 	}
@@ -378,19 +1370,19 @@ func TestServer_createSecret(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			rr := httptest.NewRecorder()
-			req := test.input.req
+			req := httptest.NewRequest("GET", "/readyz", nil)
 
-			createSecret(test.input.secrets, &stubLogger{}).ServeHTTP(rr, req)
+			shuttingDown := &atomic.Bool{}
+			shuttingDown.Store(test.input.shuttingDown)
 
-			gotCode := rr.Code
-			gotBody := rr.Body.Bytes()
+			readyz(test.input.checks, shuttingDown, &stubLogger{}).ServeHTTP(rr, req)
 
-			if diff := cmp.Diff(test.want.status, gotCode); diff != "" {
-				t.Errorf("createSecret() = unexpected status code (-want +got)\n%s\n", diff)
+			if diff := cmp.Diff(test.want.status, rr.Code); diff != "" {
+				t.Errorf("readyz() = unexpected status code (-want +got)\n%s\n", diff)
 			}
 
-			if diff := cmp.Diff(test.want.body, gotBody); diff != "" {
-				t.Errorf("createSecret() = unexpected body (-want +got)\n%s\n", diff)
+			if diff := cmp.Diff(test.want.body, rr.Body.Bytes()); diff != "" {
+				t.Errorf("readyz() = unexpected body (-want +got)\n%s\n", diff)
 			}
 		})
 	}
@@ -405,7 +1397,10 @@ func (s stubSecretService) Start() error {
 	return nil
 }
 
-func (s stubSecretService) Generate(options ...secret.GenerateOption) string {
+func (s stubSecretService) Generate(options ...secret.GenerateOption) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
 	opts := secret.GenerateOptions{}
 	for _, option := range options {
 		option(&opts)
@@ -419,22 +1414,30 @@ func (s stubSecretService) Generate(options ...secret.GenerateOption) string {
 			builder.WriteString("a")
 		}
 	}
-	return builder.String()
+	return builder.String(), nil
+}
+
+func (s stubSecretService) GenerateCredential(typ secret.CredentialType, options ...secret.GenerateCredentialOption) (secret.Credential, error) {
+	if s.err != nil {
+		return secret.Credential{}, s.err
+	}
+	return secret.Credential{Type: typ, Private: "private", Public: "public"}, nil
 }
 
-func (s stubSecretService) Get(id, passphrase string, options ...secret.GetOption) (secret.Secret, error) {
+func (s stubSecretService) Get(ctx context.Context, id, passphrase string, options ...secret.GetOption) (secret.Secret, error) {
 	if s.err != nil {
 		return secret.Secret{}, s.err
 	}
 
 	var sec secret.Secret
+	var found bool
 	for _, s := range s.secrets {
 		if s.ID == id {
-			sec = s
+			sec, found = s, true
 			break
 		}
 	}
-	if sec == (secret.Secret{}) {
+	if !found {
 		return secret.Secret{}, secret.ErrSecretNotFound
 	}
 
@@ -448,7 +1451,7 @@ func (s stubSecretService) Get(id, passphrase string, options ...secret.GetOptio
 	return sec, nil
 }
 
-func (s *stubSecretService) Create(se secret.Secret) (secret.Secret, error) {
+func (s *stubSecretService) Create(ctx context.Context, se secret.Secret) (secret.Secret, error) {
 	if s.err != nil {
 		return secret.Secret{}, s.err
 	}
@@ -473,15 +1476,47 @@ func (s *stubSecretService) Create(se secret.Secret) (secret.Secret, error) {
 		id = strconv.Itoa(lastNum)
 	}
 
-	secret := secret.Secret{ID: id, Value: se.Value, Passphrase: "passphrase", TTL: se.TTL}
+	if se.Generate != nil {
+		se.Value = "generated"
+	}
+
+	secret := secret.Secret{ID: id, Value: se.Value, Passphrase: "passphrase", TTL: se.TTL, Label: se.Label}
 	s.secrets = append(s.secrets, secret)
 	return secret, nil
 }
 
-func (s stubSecretService) Delete(id string, options ...secret.DeleteOption) error {
+func (s *stubSecretService) CreateBatch(ctx context.Context, secrets []secret.Secret) ([]secret.Secret, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	created := make([]secret.Secret, 0, len(secrets))
+	for _, se := range secrets {
+		secret, err := s.Create(ctx, se)
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, secret)
+	}
+	return created, nil
+}
+
+func (s stubSecretService) Delete(ctx context.Context, id string, options ...secret.DeleteOption) error {
 	return nil
 }
 
+func (s stubSecretService) SendCode(ctx context.Context, id, passphrase string, options ...secret.SendCodeOption) error {
+	return s.err
+}
+
+func (s stubSecretService) PassphraseStrength(passphrase string) secret.PassphraseStrength {
+	return secret.PassphraseStrength{}
+}
+
+func (s stubSecretService) Ping(ctx context.Context) error {
+	return s.err
+}
+
 func (s stubSecretService) Close() error {
 	return nil
 }
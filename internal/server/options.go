@@ -1,10 +1,14 @@
 package server
 
 import (
+	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/RedeployAB/burnit/internal/auth"
 	"github.com/RedeployAB/burnit/internal/log"
+	"github.com/RedeployAB/burnit/internal/metrics"
+	"github.com/RedeployAB/burnit/internal/middleware"
 	"github.com/RedeployAB/burnit/internal/ui"
 )
 
@@ -85,6 +89,27 @@ func WithTimeouts(read, write, idle time.Duration) Option {
 	}
 }
 
+// WithBaseURL configures the server with the base URL of the links
+// to created secrets, such as https://burnit.example.com. Requests
+// can override it.
+func WithBaseURL(baseURL string) Option {
+	return func(s *server) {
+		if len(baseURL) > 0 {
+			s.baseURL = baseURL
+		}
+	}
+}
+
+// WithShutdownDelay configures the server to report itself as not ready
+// for the given delay before it shuts down.
+func WithShutdownDelay(delay time.Duration) Option {
+	return func(s *server) {
+		if delay > 0 {
+			s.shutdownDelay = delay
+		}
+	}
+}
+
 // WithTLS configures the server with the given TLS configuration.
 func WithTLS(tls TLSConfig) Option {
 	return func(s *server) {
@@ -106,12 +131,52 @@ func WithCORS(cors CORS) Option {
 // WithRateLimiter configures the server with the given rate limiter.
 func WithRateLimiter(rateLimiter RateLimiter) Option {
 	return func(s *server) {
-		if !rateLimiter.isEmpty() {
+		if !rateLimiter.isEmpty() || rateLimiter.Store != nil {
 			s.rateLimiter = rateLimiter
 		}
 	}
 }
 
+// WithAPIKeys configures the server to require API keys for creating,
+// generating and deleting secrets.
+func WithAPIKeys(keys auth.APIKeyStore) Option {
+	return func(s *server) {
+		if keys != nil {
+			s.apiKeys = keys
+		}
+	}
+}
+
+// WithRequestID configures the server with the given request ID configuration.
+func WithRequestID(requestID RequestID) Option {
+	return func(s *server) {
+		if len(requestID.Header) > 0 {
+			s.requestID = requestID
+		}
+	}
+}
+
+// WithTrustedProxies configures the server with the proxies that are trusted
+// to set headers with information about the original request.
+func WithTrustedProxies(proxies middleware.TrustedProxies) Option {
+	return func(s *server) {
+		if len(proxies) > 0 {
+			s.proxies = proxies
+		}
+	}
+}
+
+// WithAllowedNetworks configures the server with the networks that are
+// allowed to create and generate secrets. Retrieving secrets is allowed
+// from all networks.
+func WithAllowedNetworks(networks middleware.AllowedNetworks) Option {
+	return func(s *server) {
+		if len(networks) > 0 {
+			s.networks = networks
+		}
+	}
+}
+
 // WithUI configures the server with the given UI.
 func WithUI(ui ui.UI) Option {
 	return func(s *server) {
@@ -120,3 +185,24 @@ func WithUI(ui ui.UI) Option {
 		}
 	}
 }
+
+// WithMetrics configures the server with the given metrics. If address
+// is set the metrics are served on a separate listener on that address,
+// otherwise they are served on the server's listener.
+func WithMetrics(m *metrics.Metrics, address string) Option {
+	return func(s *server) {
+		if m == nil {
+			return
+		}
+		s.metrics = m
+		if len(address) > 0 {
+			s.metricsServer = &http.Server{
+				Addr:         address,
+				Handler:      m.Handler(),
+				ReadTimeout:  defaultReadTimeout,
+				WriteTimeout: defaultWriteTimeout,
+				IdleTimeout:  defaultIdleTimeout,
+			}
+		}
+	}
+}
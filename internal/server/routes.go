@@ -3,6 +3,10 @@ package server
 import (
 	"net/http"
 
+	"github.com/RedeployAB/burnit/internal/auth"
+	"github.com/RedeployAB/burnit/internal/db"
+	"github.com/RedeployAB/burnit/internal/db/inmem"
+	"github.com/RedeployAB/burnit/internal/metrics"
 	"github.com/RedeployAB/burnit/internal/middleware"
 	"github.com/RedeployAB/burnit/internal/ui"
 )
@@ -14,31 +18,69 @@ const (
 
 // routes sets up the routes for the server.
 func (s *server) routes() {
-	s.httpServer.Handler = middleware.Chain(
-		s.httpServer.Handler,
-		middleware.RequestID(),
-		middleware.SourceIP(),
+	baseMiddlewares := []middleware.Middleware{
+		middleware.RequestID(func(o *middleware.RequestIDOptions) {
+			o.Header = s.requestID.Header
+			o.TrustedProxies = s.proxies
+		}),
+		middleware.SourceIP(func(o *middleware.SourceIPOptions) {
+			o.TrustedProxies = s.proxies
+		}),
+		middleware.Tracing(),
 		middleware.Logger(s.log),
-	)
+	}
+	if s.metrics != nil {
+		baseMiddlewares = append(baseMiddlewares, middleware.Metrics(s.metrics))
+	}
+	s.httpServer.Handler = middleware.Chain(s.httpServer.Handler, baseMiddlewares...)
 
-	middlewares, shutdownFuncs := setupMiddlewares(s.rateLimiter, s.cors)
+	var rejectedUI http.Handler
+	if s.ui != nil {
+		rejectedUI = ui.TooManyRequests(s.ui)
+	}
+	store, shutdownFuncs := setupRateLimitStore(s.rateLimiter, s.apiKeys != nil)
 	s.shutdownFuncs = append(s.shutdownFuncs, shutdownFuncs...)
+	rl := setupRateLimits(s.rateLimiter, store, s.metrics, rejectedUI)
+	keys := setupAPIKeys(s.apiKeys, store, s.metrics)
+	networks := setupAllowedNetworks(s.networks, s.ui)
+
+	middlewares := setupMiddlewares(rl.global, s.cors)
+
+	// Health and readiness handlers.
+	s.router.Handle("GET /healthz", healthz(s.log))
+	s.router.Handle("GET /readyz", readyz(s.healthChecks(), s.shuttingDown, s.log))
+
+	// Metrics handler, if not served on a separate listener.
+	if s.metrics != nil && s.metricsServer == nil {
+		s.router.Handle("GET /metrics", s.metrics.Handler())
+	}
 
 	// Secret router and handlers.
 	secretRouter := http.NewServeMux()
-	secretRouter.Handle("GET /secret", generateSecret(s.secrets, s.log))
+	secretRouter.Handle("GET /secret", middleware.Chain(generateSecret(s.secrets, s.log), networks.api, keys.generate, rl.generate))
+
+	// Generate router and handlers for credentials. Storing a credential
+	// creates a secret.
+	generateRouter := http.NewServeMux()
+	generateRouter.Handle("GET /generate/{type}", middleware.Chain(generateCredential(s.secrets, s.log), networks.api, keys.generate, rl.generate))
+	generateRouter.Handle("POST /generate/{type}", middleware.Chain(storeCredential(s.secrets, s.log), networks.api, keys.create, rl.create))
 
 	// Secrets router and handlers.
 	secretsRouter := http.NewServeMux()
-	secretsRouter.Handle("GET /secrets/{id}", getSecret(s.secrets, s.log))
-	secretsRouter.Handle("POST /secrets", createSecret(s.secrets, s.log))
-	secretsRouter.Handle("DELETE /secrets/{id}", deleteSecret(s.secrets, s.log))
+	secretsRouter.Handle("GET /secrets/{id}", middleware.Chain(getSecret(s.secrets, s.log), rl.retrieve, rl.failedPassphrase))
+	secretsRouter.Handle("POST /secrets", middleware.Chain(createSecret(s.secrets, s.log), networks.api, keys.create, rl.create))
+	secretsRouter.Handle("POST /secrets:batch", middleware.Chain(createSecrets(s.secrets, s.baseURL, s.log), networks.api, keys.create, rl.create))
+	secretsRouter.Handle("POST /secrets/{id}/code", middleware.Chain(sendSecretCode(s.secrets, s.log), rl.retrieve, rl.failedPassphrase))
+	secretsRouter.Handle("DELETE /secrets/{id}", middleware.Chain(deleteSecret(s.secrets, s.log), keys.delete, rl.retrieve, rl.failedPassphrase))
 
 	secretHandler := middleware.Chain(secretRouter, middlewares...)
 	secretsHandler := middleware.Chain(secretsRouter, middlewares...)
+	generateHandler := middleware.Chain(generateRouter, middlewares...)
 
 	s.router.Handle("/secret", secretHandler)
 	s.router.Handle("/secrets", secretsHandler)
+	s.router.Handle("/secrets:batch", secretsHandler)
+	s.router.Handle("/generate", generateHandler)
 
 	if s.ui == nil {
 		s.router.Handle("/{$}", index(nil, s.log))
@@ -48,13 +90,40 @@ func (s *server) routes() {
 
 	uiMiddlewares := setupUIMiddlewares(s.ui.RuntimeParse())
 
+	createSecret := networks.ui(ui.CreateSecret(s.ui, s.secrets))
+	createSecretHandler := middleware.Chain(ui.CreateSecretHandler(s.ui, s.secrets, s.log), networks.ui, middleware.HTMX, rl.ui, rl.uiCreate)
+	generateSecretHandler := middleware.Chain(ui.GenerateSecretHandler(s.ui, s.secrets, s.log), networks.ui, middleware.HTMX, rl.ui)
+	passphraseStrengthHandler := middleware.Chain(ui.PassphraseStrengthHandler(s.ui, s.secrets, s.log), networks.ui, middleware.HTMX, rl.ui)
+
 	fer := http.NewServeMux()
-	fer.Handle("/ui/secrets", ui.CreateSecret(s.ui, s.secrets))
-	fer.Handle("/ui/secrets/", ui.GetSecret(s.ui, s.secrets, s.log))
+	if s.ui.Auth() != nil {
+		// Creating secrets requires a signed in user. Viewing secrets
+		// remains anonymous.
+		requireUser := ui.RequireUser(s.ui, s.log)
+		createSecret = middleware.Chain(ui.CreateSecret(s.ui, s.secrets), networks.ui, requireUser)
+		createSecretHandler = middleware.Chain(ui.CreateSecretHandler(s.ui, s.secrets, s.log), networks.ui, middleware.HTMX, rl.ui, rl.uiCreate, requireUser)
+		generateSecretHandler = middleware.Chain(ui.GenerateSecretHandler(s.ui, s.secrets, s.log), networks.ui, middleware.HTMX, rl.ui, requireUser)
+		passphraseStrengthHandler = middleware.Chain(ui.PassphraseStrengthHandler(s.ui, s.secrets, s.log), networks.ui, middleware.HTMX, rl.ui, requireUser)
+
+		fer.Handle("GET /ui/auth/login", ui.Login(s.ui))
+		fer.Handle("GET /ui/auth/callback", ui.AuthCallback(s.ui, s.log))
+		fer.Handle("POST /ui/auth/logout", ui.Logout(s.ui, s.log))
+	}
+	fer.Handle("/ui/secrets", createSecret)
+	// Links to secrets contain the passphrase, and are limited like
+	// the form for retrieving secrets. The ID is a path value so that
+	// the limits can be keyed by secret.
+	getSecret := middleware.Chain(ui.GetSecret(s.ui, s.secrets, s.log), rl.uiRetrieve, rl.uiFailedPassphrase)
+	fer.Handle("/ui/secrets/{id}", getSecret)
+	fer.Handle("/ui/secrets/{id}/{passphrase}", getSecret)
+	fer.Handle("/ui/secrets/", getSecret)
 	fer.Handle("/ui/about", ui.About(s.ui))
 	fer.Handle("/ui/privacy", ui.Privacy(s.ui))
-	fer.Handle("/ui/handlers/secret/get", middleware.HTMX(ui.GetSecretHandler(s.ui, s.secrets, s.log)))
-	fer.Handle("/ui/handlers/secret/create", middleware.HTMX(ui.CreateSecretHandler(s.ui, s.secrets, s.log)))
+	fer.Handle("/ui/handlers/secret/get", middleware.Chain(ui.GetSecretHandler(s.ui, s.secrets, s.log), middleware.HTMX, rl.ui, rl.uiRetrieve, rl.uiFailedPassphrase))
+	fer.Handle("/ui/handlers/secret/code", middleware.Chain(ui.SendCodeHandler(s.ui, s.secrets, s.log), middleware.HTMX, rl.ui, rl.uiRetrieve, rl.uiFailedPassphrase))
+	fer.Handle("/ui/handlers/secret/create", createSecretHandler)
+	fer.Handle("GET /ui/handlers/secret/generate", generateSecretHandler)
+	fer.Handle("POST /ui/handlers/secret/passphrase-strength", passphraseStrengthHandler)
 	fer.Handle("/ui/", ui.NotFound(s.ui))
 
 	uiHandler := middleware.Chain(fer, uiMiddlewares...)
@@ -66,24 +135,163 @@ func (s *server) routes() {
 }
 
 // setupMiddlewares sets up the middlewares for the server.
-func setupMiddlewares(rl RateLimiter, c CORS) ([]middleware.Middleware, []func() error) {
+func setupMiddlewares(rateLimiter middleware.Middleware, c CORS) []middleware.Middleware {
 	middlewares := []middleware.Middleware{}
-	var shutdownFuncs []func() error
-	if !rl.isEmpty() {
-		mw, closeRateLimiter := middleware.RateLimiter(
-			middleware.WithRateLimiterRate(rl.Rate),
-			middleware.WithRateLimiterBurst(rl.Burst),
-			middleware.WithRateLimiterTTL(rl.TTL),
-			middleware.WithRateLimiterCleanupInterval(rl.CleanupInterval),
-		)
-		middlewares = append(middlewares, mw)
-		shutdownFuncs = append(shutdownFuncs, closeRateLimiter)
+	if rateLimiter != nil {
+		middlewares = append(middlewares, rateLimiter)
 	}
 	if !c.isEmpty() {
 		middlewares = append(middlewares, middleware.CORS(c.Origin))
 	}
 	middlewares = append(middlewares, middleware.Headers())
-	return middlewares, shutdownFuncs
+	return middlewares
+}
+
+// rateLimits contains the rate limit middlewares for the server. The
+// middlewares for policies that are not configured pass requests through.
+type rateLimits struct {
+	global             middleware.Middleware
+	generate           middleware.Middleware
+	create             middleware.Middleware
+	retrieve           middleware.Middleware
+	failedPassphrase   middleware.Middleware
+	ui                 middleware.Middleware
+	uiCreate           middleware.Middleware
+	uiRetrieve         middleware.Middleware
+	uiFailedPassphrase middleware.Middleware
+}
+
+// setupRateLimitStore sets up the store for rate limits and API key quotas.
+// If no store is configured an in-memory store is created when it is needed.
+func setupRateLimitStore(rl RateLimiter, apiKeys bool) (db.RateLimitStore, []func() error) {
+	store := rl.Store
+	if store == nil {
+		if rl.isEmpty() && !apiKeys {
+			return nil, nil
+		}
+		store = inmem.NewRateLimitStore(func(o *inmem.RateLimitStoreOptions) {
+			if rl.TTL > 0 {
+				o.TTL = rl.TTL
+			}
+			if rl.CleanupInterval > 0 {
+				o.CleanupInterval = rl.CleanupInterval
+			}
+		})
+	}
+	return store, []func() error{store.Close}
+}
+
+// setupRateLimits sets up the rate limit middlewares. All middlewares share
+// the same store. Rejected UI requests are handled by rejectedUI.
+func setupRateLimits(rl RateLimiter, store db.RateLimitStore, m *metrics.Metrics, rejectedUI http.Handler) rateLimits {
+	limits := rateLimits{
+		generate:           passThrough,
+		create:             passThrough,
+		retrieve:           passThrough,
+		failedPassphrase:   passThrough,
+		ui:                 passThrough,
+		uiCreate:           passThrough,
+		uiRetrieve:         passThrough,
+		uiFailedPassphrase: passThrough,
+	}
+	if rl.isEmpty() {
+		return limits
+	}
+
+	if rl.hasDefault() {
+		limits.global, _ = middleware.RateLimiter(
+			middleware.WithRateLimiterRate(rl.Rate),
+			middleware.WithRateLimiterBurst(rl.Burst),
+			middleware.WithRateLimiterStore(store),
+			middleware.WithRateLimiterMetrics(m),
+		)
+	}
+
+	policy := func(name string, p RateLimitPolicy, failuresOnly bool, rejected http.Handler) middleware.Middleware {
+		if p.isEmpty() {
+			return passThrough
+		}
+		return middleware.RateLimit(store, middleware.RateLimitPolicy{
+			Name:         name,
+			Rate:         p.Rate,
+			Burst:        p.Burst,
+			Key:          middleware.RateLimitKey(p.Key),
+			FailuresOnly: failuresOnly,
+		}, middleware.WithRateLimiterMetrics(m), middleware.WithRateLimiterRejectedHandler(rejected))
+	}
+
+	limits.generate = policy("generate", rl.Policies.Generate, false, nil)
+	limits.create = policy("create", rl.Policies.Create, false, nil)
+	limits.retrieve = policy("retrieve", rl.Policies.Retrieve, false, nil)
+	limits.failedPassphrase = policy("failedPassphrase", rl.Policies.FailedPassphrase, true, nil)
+	limits.ui = policy("ui", rl.Policies.UI, false, rejectedUI)
+	limits.uiCreate = policy("create", rl.Policies.Create, false, rejectedUI)
+	limits.uiRetrieve = policy("retrieve", rl.Policies.Retrieve, false, rejectedUI)
+	limits.uiFailedPassphrase = policy("failedPassphrase", rl.Policies.FailedPassphrase, true, rejectedUI)
+
+	return limits
+}
+
+// apiKeys contains the API key middlewares for the server, per scope.
+// If API keys are not configured the middlewares pass requests through.
+type apiKeys struct {
+	create   middleware.Middleware
+	generate middleware.Middleware
+	delete   middleware.Middleware
+}
+
+// setupAPIKeys sets up the API key middlewares. The quotas of the keys
+// are kept in the provided store.
+func setupAPIKeys(keys auth.APIKeyStore, store db.RateLimitStore, m *metrics.Metrics) apiKeys {
+	if keys == nil {
+		return apiKeys{
+			create:   passThrough,
+			generate: passThrough,
+			delete:   passThrough,
+		}
+	}
+
+	options := []middleware.APIKeyOption{
+		middleware.WithAPIKeyStore(store),
+		middleware.WithAPIKeyMetrics(m),
+	}
+	return apiKeys{
+		create:   middleware.APIKey(keys, auth.ScopeCreate, options...),
+		generate: middleware.APIKey(keys, auth.ScopeGenerate, options...),
+		delete:   middleware.APIKey(keys, auth.ScopeDelete, options...),
+	}
+}
+
+// allowedNetworks contains the allowed network middlewares for the server.
+// If allowed networks are not configured the middlewares pass requests through.
+type allowedNetworks struct {
+	api middleware.Middleware
+	ui  middleware.Middleware
+}
+
+// setupAllowedNetworks sets up the allowed network middlewares. Rejected UI
+// requests are handled by the UI.
+func setupAllowedNetworks(networks middleware.AllowedNetworks, u ui.UI) allowedNetworks {
+	if len(networks) == 0 {
+		return allowedNetworks{
+			api: passThrough,
+			ui:  passThrough,
+		}
+	}
+
+	n := allowedNetworks{
+		api: middleware.AllowNetworks(networks),
+		ui:  passThrough,
+	}
+	if u != nil {
+		n.ui = middleware.AllowNetworks(networks, middleware.WithAllowedNetworksRejectedHandler(ui.Forbidden(u)))
+	}
+	return n
+}
+
+// passThrough is a middleware that passes requests through.
+func passThrough(next http.Handler) http.Handler {
+	return next
 }
 
 // setupUIMiddlewares sets up the middlewares for the UI.
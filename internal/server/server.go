@@ -7,10 +7,15 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/RedeployAB/burnit/internal/auth"
+	"github.com/RedeployAB/burnit/internal/db"
 	"github.com/RedeployAB/burnit/internal/log"
+	"github.com/RedeployAB/burnit/internal/metrics"
+	"github.com/RedeployAB/burnit/internal/middleware"
 	"github.com/RedeployAB/burnit/internal/secret"
 	"github.com/RedeployAB/burnit/internal/ui"
 )
@@ -32,9 +37,18 @@ type server struct {
 	ui            ui.UI
 	tls           TLSConfig
 	rateLimiter   RateLimiter
+	apiKeys       auth.APIKeyStore
 	log           log.Logger
 	cors          CORS
+	metrics       *metrics.Metrics
+	metricsServer *http.Server
+	requestID     RequestID
+	proxies       middleware.TrustedProxies
+	networks      middleware.AllowedNetworks
 	shutdownFuncs []func() error
+	shuttingDown  *atomic.Bool
+	shutdownDelay time.Duration
+	baseURL       string
 	stopCh        chan os.Signal
 	errCh         chan error
 }
@@ -56,11 +70,51 @@ type RateLimiter struct {
 	Burst           int
 	TTL             time.Duration
 	CleanupInterval time.Duration
+	Store           db.RateLimitStore
+	Policies        RateLimitPolicies
 }
 
 // isEmpty returns true if the RateLimiter is empty.
 func (r RateLimiter) isEmpty() bool {
-	return r.Rate == 0 && r.Burst == 0 && r.TTL == 0 && r.CleanupInterval == 0
+	return !r.hasDefault() && r.Policies.isEmpty()
+}
+
+// hasDefault returns true if the default rate limit (for all API
+// requests) is configured.
+func (r RateLimiter) hasDefault() bool {
+	return r.Rate != 0 || r.Burst != 0 || r.TTL != 0 || r.CleanupInterval != 0
+}
+
+// RateLimitPolicies holds the configuration for the server's rate limit
+// policies, per operation.
+type RateLimitPolicies struct {
+	Generate         RateLimitPolicy
+	Create           RateLimitPolicy
+	Retrieve         RateLimitPolicy
+	FailedPassphrase RateLimitPolicy
+	UI               RateLimitPolicy
+}
+
+// isEmpty returns true if the RateLimitPolicies are empty.
+func (p RateLimitPolicies) isEmpty() bool {
+	return p.Generate.isEmpty() && p.Create.isEmpty() && p.Retrieve.isEmpty() && p.FailedPassphrase.isEmpty() && p.UI.isEmpty()
+}
+
+// RateLimitPolicy holds the configuration for a rate limit policy.
+type RateLimitPolicy struct {
+	Rate  float64
+	Burst int
+	Key   string
+}
+
+// isEmpty returns true if the RateLimitPolicy is empty.
+func (p RateLimitPolicy) isEmpty() bool {
+	return p.Rate == 0
+}
+
+// RequestID holds the configuration for the server's request ID settings.
+type RequestID struct {
+	Header string
 }
 
 // CORS holds the configuration for the server's CORS settings.
@@ -103,9 +157,10 @@ func New(secrets secret.Service, options ...Option) (*server, error) {
 			WriteTimeout: defaultWriteTimeout,
 			IdleTimeout:  defaultIdleTimeout,
 		},
-		secrets: secrets,
-		stopCh:  make(chan os.Signal),
-		errCh:   make(chan error),
+		secrets:      secrets,
+		shuttingDown: &atomic.Bool{},
+		stopCh:       make(chan os.Signal),
+		errCh:        make(chan error),
 	}
 	for _, option := range options {
 		option(s)
@@ -158,6 +213,15 @@ func (s server) Start() error {
 		}
 	}()
 
+	if s.metricsServer != nil {
+		go func() {
+			if err := s.metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				s.errCh <- err
+			}
+		}()
+		s.log.Info("Metrics server started.", "address", s.metricsServer.Addr)
+	}
+
 	go func() {
 		s.stop()
 	}()
@@ -174,6 +238,26 @@ func (s server) Start() error {
 	}
 }
 
+// Handler sets up the routes of the server and returns its handler
+// without starting the server, to serve it with another http.Server,
+// such as an httptest.Server. It should not be combined with Start.
+func (s *server) Handler() http.Handler {
+	s.routes()
+	return s.httpServer.Handler
+}
+
+// healthChecks returns the health checks for the dependencies
+// of the server.
+func (s server) healthChecks() []healthCheck {
+	checks := []healthCheck{
+		{name: "secrets", ping: s.secrets.Ping},
+	}
+	if s.ui != nil && s.ui.Sessions() != nil {
+		checks = append(checks, healthCheck{name: "sessions", ping: s.ui.Sessions().Ping})
+	}
+	return checks
+}
+
 // listenAndServe wraps around http.Server ListenAndServe and
 // ListenAndServeTLS depending on TLS configuration.
 func (s *server) listenAndServe() error {
@@ -184,15 +268,32 @@ func (s *server) listenAndServe() error {
 	return s.httpServer.ListenAndServe()
 }
 
-// stop the server.
+// stop the server when it receives an interrupt or termination signal.
 func (s server) stop() {
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-	sig := <-stop
+	s.shutdown(<-stop)
+}
+
+// shutdown the server. The server is reported as not ready for the
+// shutdown delay before it stops accepting requests, so that readiness
+// probes have time to detect it and stop routing new traffic to it.
+// Requests are served as usual during the delay.
+func (s server) shutdown(sig os.Signal) {
+	s.shuttingDown.Store(true)
+	if s.shutdownDelay > 0 {
+		s.log.Info("Server shutting down.", "delay", s.shutdownDelay.String())
+		time.Sleep(s.shutdownDelay)
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
+	s.httpServer.SetKeepAlivesEnabled(false)
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		s.errCh <- err
+	}
+
 	if err := s.secrets.Close(); err != nil {
 		s.errCh <- err
 	}
@@ -203,9 +304,10 @@ func (s server) stop() {
 		}
 	}
 
-	s.httpServer.SetKeepAlivesEnabled(false)
-	if err := s.httpServer.Shutdown(ctx); err != nil {
-		s.errCh <- err
+	if s.metricsServer != nil {
+		if err := s.metricsServer.Shutdown(ctx); err != nil {
+			s.errCh <- err
+		}
 	}
 
 	s.stopCh <- sig
@@ -3,15 +3,23 @@ package server
 import (
 	"context"
 	"errors"
+	"io/fs"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"testing"
+	"testing/fstest"
 	"time"
 
+	"github.com/RedeployAB/burnit/internal/db/inmem"
 	"github.com/RedeployAB/burnit/internal/log"
 	"github.com/RedeployAB/burnit/internal/secret"
+	"github.com/RedeployAB/burnit/internal/session"
+	"github.com/RedeployAB/burnit/internal/ui"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 )
@@ -88,7 +96,7 @@ func TestNew(t *testing.T) {
 				t.Errorf("New(%v) = nil; want %v", test.input, test.want)
 			}
 
-			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(server{}, stubSecretService{}), cmpopts.IgnoreUnexported(http.Server{}, http.ServeMux{}), cmpopts.IgnoreFields(server{}, "stopCh", "errCh", "log")); diff != "" {
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(server{}, stubSecretService{}), cmpopts.IgnoreUnexported(http.Server{}, http.ServeMux{}), cmpopts.IgnoreFields(server{}, "stopCh", "errCh", "log", "shuttingDown")); diff != "" {
 				t.Errorf("New(%v) = unexpected result (-want +got):\n%s\n", test.input, diff)
 			}
 		})
@@ -107,8 +115,9 @@ func TestServer_Start(t *testing.T) {
 			log: &stubLogger{
 				logs: &logs,
 			},
-			stopCh: make(chan os.Signal),
-			errCh:  make(chan error),
+			shuttingDown: &atomic.Bool{},
+			stopCh:       make(chan os.Signal),
+			errCh:        make(chan error),
 		}
 		go func() {
 			time.Sleep(time.Millisecond * 100)
@@ -143,8 +152,9 @@ func TestServer_Start_Error(t *testing.T) {
 			log: &stubLogger{
 				logs: &logs,
 			},
-			stopCh: make(chan os.Signal),
-			errCh:  make(chan error),
+			shuttingDown: &atomic.Bool{},
+			stopCh:       make(chan os.Signal),
+			errCh:        make(chan error),
 		}
 
 		httpServer := &http.Server{
@@ -172,11 +182,155 @@ func TestServer_Start_Error(t *testing.T) {
 	})
 }
 
+func TestServer_Handler(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			method string
+			target string
+		}
+		want int
+	}{
+		{
+			name: "serve health check",
+			input: struct {
+				method string
+				target string
+			}{
+				method: http.MethodGet,
+				target: "/healthz",
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "serve create secret",
+			input: struct {
+				method string
+				target string
+			}{
+				method: http.MethodPost,
+				target: "/secrets",
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "serve create secrets",
+			input: struct {
+				method string
+				target string
+			}{
+				method: http.MethodPost,
+				target: "/secrets:batch",
+			},
+			want: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv, err := New(&stubSecretService{}, WithLogger(&stubLogger{}))
+			if err != nil {
+				t.Fatalf("New() = unexpected error: %v\n", err)
+			}
+
+			rr := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rr, httptest.NewRequest(test.input.method, test.input.target, nil))
+
+			if diff := cmp.Diff(test.want, rr.Code); diff != "" {
+				t.Errorf("Handler() = unexpected status code (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestServer_Handler_uiGetSecretRateLimit(t *testing.T) {
+	sessions, _ := session.NewService(inmem.NewSessionStore())
+	srv, err := New(
+		&stubInvalidPassphraseService{},
+		WithLogger(&stubLogger{}),
+		WithUI(&stubUI{sessions: sessions}),
+		WithRateLimiter(RateLimiter{
+			Policies: RateLimitPolicies{
+				FailedPassphrase: RateLimitPolicy{Rate: 0.001, Burst: 2, Key: "secret"},
+			},
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New() = unexpected error: %v\n", err)
+	}
+
+	handler := srv.Handler()
+	var got []int
+	for _, target := range []string{"/ui/secrets/1/aW52YWxpZA", "/ui/secrets/1/aW52YWxpZA", "/ui/secrets/1/aW52YWxpZA", "/ui/secrets/2/aW52YWxpZA"} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		got = append(got, rr.Code)
+	}
+
+	want := []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusUnauthorized}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Handler() = unexpected status codes (-want +got)\n%s\n", diff)
+	}
+}
+
+func TestServer_shutdown(t *testing.T) {
+	t.Run("not ready during shutdown delay", func(t *testing.T) {
+		srv, err := New(&stubSecretService{}, WithLogger(&stubLogger{}), WithShutdownDelay(500*time.Millisecond))
+		if err != nil {
+			t.Fatalf("New() = unexpected error: %v\n", err)
+		}
+		srv.stopCh = make(chan os.Signal, 1)
+
+		ts := httptest.NewServer(srv.Handler())
+		defer ts.Close()
+
+		readyz := func() int {
+			resp, err := ts.Client().Get(ts.URL + "/readyz")
+			if err != nil {
+				t.Fatalf("Get() = unexpected error: %v\n", err)
+			}
+			resp.Body.Close()
+			return resp.StatusCode
+		}
+
+		if diff := cmp.Diff(http.StatusOK, readyz()); diff != "" {
+			t.Errorf("readyz before shutdown = unexpected status code (-want +got)\n%s\n", diff)
+		}
+
+		go srv.shutdown(syscall.SIGTERM)
+		for !srv.shuttingDown.Load() {
+			time.Sleep(time.Millisecond)
+		}
+
+		if diff := cmp.Diff(http.StatusServiceUnavailable, readyz()); diff != "" {
+			t.Errorf("readyz during shutdown delay = unexpected status code (-want +got)\n%s\n", diff)
+		}
+
+		select {
+		case <-srv.stopCh:
+			t.Errorf("shutdown() = stopped before shutdown delay\n")
+		default:
+		}
+
+		select {
+		case sig := <-srv.stopCh:
+			if diff := cmp.Diff(os.Signal(syscall.SIGTERM), sig); diff != "" {
+				t.Errorf("shutdown() = unexpected signal (-want +got)\n%s\n", diff)
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("shutdown() = not stopped after shutdown delay\n")
+		}
+	})
+}
+
 type stubLogger struct {
+	mu   sync.Mutex
 	logs *[]string
 }
 
 func (l *stubLogger) Info(msg string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	if l.logs == nil {
 		l.logs = &[]string{}
 	}
@@ -196,6 +350,8 @@ func (l *stubLogger) Info(msg string, args ...any) {
 }
 
 func (l *stubLogger) Error(msg string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	if l.logs == nil {
 		l.logs = &[]string{}
 	}
@@ -217,3 +373,44 @@ func (l *stubLogger) Error(msg string, args ...any) {
 func (l *stubLogger) Debug(msg string, args ...any) {}
 
 func (l *stubLogger) Warn(msg string, args ...any) {}
+
+type stubUI struct {
+	sessions session.Service
+}
+
+func (u *stubUI) Render(w http.ResponseWriter, statusCode int, tmpl string, data any, options ...ui.RenderOption) {
+	w.WriteHeader(statusCode)
+}
+
+func (u *stubUI) Static() fs.FS {
+	return fstest.MapFS{}
+}
+
+func (u *stubUI) Sessions() session.Service {
+	return u.sessions
+}
+
+func (u *stubUI) RuntimeParse() bool {
+	return false
+}
+
+func (u *stubUI) Auth() *ui.Auth {
+	return nil
+}
+
+// stubInvalidPassphraseService is a secret service where every
+// passphrase is invalid.
+type stubInvalidPassphraseService struct {
+	stubSecretService
+}
+
+func (s *stubInvalidPassphraseService) Get(ctx context.Context, id, passphrase string, options ...secret.GetOption) (secret.Secret, error) {
+	opts := secret.GetOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+	if opts.NoDecrypt {
+		return secret.Secret{ID: id}, nil
+	}
+	return secret.Secret{}, secret.ErrInvalidPassphrase
+}
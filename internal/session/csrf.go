@@ -1,10 +1,9 @@
 package session
 
 import (
+	"crypto/rand"
 	"encoding/base64"
 	"time"
-
-	"github.com/RedeployAB/burnit/internal/secret"
 )
 
 const (
@@ -67,9 +66,9 @@ func (c CSRF) Expired() bool {
 	return c.expiresAt.Before(now())
 }
 
+// randomString returns a random URL safe string.
 var randomString = func() string {
-	return base64.RawURLEncoding.EncodeToString([]byte(secret.Generate(func(o *secret.GenerateOptions) {
-		o.Length = 32
-		o.SpecialCharacters = true
-	})))
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
 }
@@ -1,6 +1,10 @@
 package session
 
-import "time"
+import (
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/metrics"
+)
 
 // WithTimeout sets the timeout for the service.
 func WithTimeout(d time.Duration) ServiceOption {
@@ -25,6 +29,13 @@ func WithCSRF(csrf CSRF) SessionOption {
 	}
 }
 
+// WithUser sets the authenticated user of the session.
+func WithUser(user string) SessionOption {
+	return func(o *SessionOptions) {
+		o.User = user
+	}
+}
+
 // WithCSRFOptions sets the options for the CSRF token for the session.
 func WithCSRFOptions(options ...CSRFOption) SessionOption {
 	return func(o *SessionOptions) {
@@ -66,3 +77,10 @@ func DeleteWithCSRFToken(token string) DeleteOption {
 		o.CSRFToken = token
 	}
 }
+
+// WithMetrics sets the metrics for the service.
+func WithMetrics(m *metrics.Metrics) ServiceOption {
+	return func(s *service) {
+		s.metrics = m
+	}
+}
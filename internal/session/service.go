@@ -8,6 +8,7 @@ import (
 
 	"github.com/RedeployAB/burnit/internal/db"
 	dberrors "github.com/RedeployAB/burnit/internal/db/errors"
+	"github.com/RedeployAB/burnit/internal/metrics"
 )
 
 const (
@@ -18,16 +19,23 @@ const (
 	defaultCleanupInterval = time.Minute
 )
 
+const (
+	// metricsStore is the store label for metrics.
+	metricsStore = "sessions"
+)
+
 // Service is an interface for handling sessions.
 type Service interface {
 	// Get a session by its ID.
-	Get(options ...GetOption) (Session, error)
+	Get(ctx context.Context, options ...GetOption) (Session, error)
 	// Set a session.
-	Set(session Session) error
+	Set(ctx context.Context, session Session) error
 	// Delete a session by its ID.
-	Delete(options ...DeleteOption) error
+	Delete(ctx context.Context, options ...DeleteOption) error
 	// Cleanup runs a cleanup routine to delete expired sessions.
 	Cleanup() chan error
+	// Ping checks the connection to the underlying store.
+	Ping(ctx context.Context) error
 	// Close the service.
 	Close() error
 }
@@ -37,6 +45,7 @@ type service struct {
 	sessions        db.SessionStore
 	timeout         time.Duration
 	cleanupInterval time.Duration
+	metrics         *metrics.Metrics
 	stopCh          chan struct{}
 }
 
@@ -73,13 +82,13 @@ type GetOptions struct {
 type GetOption func(o *GetOptions)
 
 // Get a session by its ID.
-func (s service) Get(options ...GetOption) (Session, error) {
+func (s service) Get(ctx context.Context, options ...GetOption) (Session, error) {
 	opts := GetOptions{}
 	for _, option := range options {
 		option(&opts)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
 	var getFunc func(context.Context, string) (db.Session, error)
@@ -114,8 +123,8 @@ func (s service) Get(options ...GetOption) (Session, error) {
 }
 
 // Set a session.
-func (s service) Set(session Session) error {
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+func (s service) Set(ctx context.Context, session Session) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
 	if _, err := s.sessions.Upsert(ctx, db.Session{
@@ -125,6 +134,7 @@ func (s service) Set(session Session) error {
 			Token:     session.CSRF().Token(),
 			ExpiresAt: session.CSRF().ExpiresAt(),
 		},
+		User: session.User(),
 	}); err != nil {
 		return err
 	}
@@ -142,13 +152,13 @@ type DeleteOptions struct {
 type DeleteOption func(o *DeleteOptions)
 
 // Delete a session by its ID.
-func (s service) Delete(options ...DeleteOption) error {
+func (s service) Delete(ctx context.Context, options ...DeleteOption) error {
 	opts := DeleteOptions{}
 	for _, option := range options {
 		option(&opts)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
 	var deleteFunc func(context.Context, string) error
@@ -183,11 +193,14 @@ func (s *service) Cleanup() chan error {
 			case <-time.After(s.cleanupInterval):
 				ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 
+				start := time.Now()
 				if err := s.sessions.DeleteExpired(ctx); err != nil {
 					if !errors.Is(err, dberrors.ErrSessionsNotDeleted) {
+						s.metrics.StoreError(metricsStore, "deleteExpired")
 						errCh <- fmt.Errorf("session store: %w", err)
 					}
 				}
+				s.metrics.ObserveCleanup(metricsStore, time.Since(start))
 				cancel()
 			case <-s.stopCh:
 				return
@@ -197,6 +210,14 @@ func (s *service) Cleanup() chan error {
 	return errCh
 }
 
+// Ping checks the connection to the underlying store.
+func (s service) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.sessions.Ping(ctx)
+}
+
 // Close the service.
 func (s *service) Close() error {
 	s.stopCh <- struct{}{}
@@ -213,5 +234,6 @@ func withSession(s db.Session) SessionOption {
 			o.Token = s.CSRF.Token
 			o.ExpiresAt = s.CSRF.ExpiresAt
 		})
+		o.User = s.User
 	}
 }
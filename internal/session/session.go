@@ -16,6 +16,7 @@ type Session struct {
 	id        string
 	csrf      CSRF
 	expiresAt time.Time
+	user      string
 }
 
 // SessionOptions is a struct that holds the session options.
@@ -24,6 +25,7 @@ type SessionOptions struct {
 	ExpiresAt   time.Time
 	CSRF        CSRF
 	CSRFOptions []CSRFOption
+	User        string
 }
 
 // SessionOption is a function that sets a session option.
@@ -56,6 +58,7 @@ func NewSession(options ...SessionOption) Session {
 		id:        id,
 		expiresAt: opts.ExpiresAt,
 		csrf:      csrf,
+		user:      opts.User,
 	}
 }
 
@@ -90,6 +93,11 @@ func (s Session) CSRF() CSRF {
 	return s.csrf
 }
 
+// User returns the authenticated user of the session, if any.
+func (s Session) User() string {
+	return s.user
+}
+
 // DeleteCSRF deletes the CSRF token.
 func (s *Session) DeleteCSRF() Session {
 	s.csrf = CSRF{}
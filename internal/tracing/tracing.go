@@ -0,0 +1,133 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// instrumentationName is the name of the instrumentation library.
+	instrumentationName = "github.com/RedeployAB/burnit"
+	// defaultServiceName is the default service name reported with spans.
+	defaultServiceName = "burnit"
+	// defaultEndpoint is the default OTLP (HTTP) endpoint. It targets
+	// a collector running on the same host.
+	defaultEndpoint = "http://localhost:4318"
+	// defaultSampleRatio is the default ratio of traces to sample.
+	defaultSampleRatio = 1.0
+	// defaultExportTimeout is the default timeout for exporting spans.
+	defaultExportTimeout = 10 * time.Second
+)
+
+// Options contains options for tracing.
+type Options struct {
+	// Endpoint is the URL of the OTLP (HTTP) endpoint. The scheme
+	// (http or https) determines if the connection is secure.
+	Endpoint string
+	// SampleRatio is the ratio of traces to sample (0 to 1).
+	// Traces with a sampled parent are always sampled.
+	SampleRatio float64
+	// ServiceName is the name of the service reported with spans.
+	ServiceName string
+	// ServiceVersion is the version of the service reported with spans.
+	ServiceVersion string
+	// ExportTimeout is the timeout for exporting spans.
+	ExportTimeout time.Duration
+}
+
+// Option is a function that sets options for tracing.
+type Option func(o *Options)
+
+// Setup configures a tracer provider that exports spans with OTLP over HTTP
+// and sets it together with a W3C trace context propagator as the global
+// tracer provider and propagator. It returns a function that flushes
+// remaining spans and shuts down the tracer provider.
+func Setup(ctx context.Context, options ...Option) (func(ctx context.Context) error, error) {
+	opts := Options{
+		Endpoint:      defaultEndpoint,
+		SampleRatio:   defaultSampleRatio,
+		ServiceName:   defaultServiceName,
+		ExportTimeout: defaultExportTimeout,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	if opts.SampleRatio < 0 || opts.SampleRatio > 1 {
+		return nil, errors.New("sample ratio must be between 0 and 1")
+	}
+
+	exporter, err := otlptracehttp.New(
+		ctx,
+		otlptracehttp.WithEndpointURL(opts.Endpoint),
+		otlptracehttp.WithTimeout(opts.ExportTimeout),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("service.name", opts.ServiceName),
+	}
+	if len(opts.ServiceVersion) > 0 {
+		attrs = append(attrs, attribute.String("service.version", opts.ServiceVersion))
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attrs...))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer for the application. If tracing has
+// not been set up the tracer does not record any spans.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start a span with the given name.
+func Start(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, options...)
+}
+
+// End the span and record the error, if any.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID returns the trace ID of the span in the context. Returns
+// an empty string if the context contains no valid span.
+func TraceID(ctx context.Context) string {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.HasTraceID() {
+		return ""
+	}
+	return spanCtx.TraceID().String()
+}
@@ -0,0 +1,135 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetup(t *testing.T) {
+	var tests = []struct {
+		name    string
+		input   []Option
+		wantErr bool
+	}{
+		{
+			name: "setup with defaults",
+		},
+		{
+			name: "setup with options",
+			input: []Option{
+				func(o *Options) {
+					o.Endpoint = "https://collector.example.com:4318"
+					o.SampleRatio = 0.5
+					o.ServiceVersion = "1.0.0"
+				},
+			},
+		},
+		{
+			name: "setup with invalid sample ratio",
+			input: []Option{
+				func(o *Options) {
+					o.SampleRatio = 2
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			shutdown, gotErr := Setup(context.Background(), test.input...)
+			if (gotErr != nil) != test.wantErr {
+				t.Fatalf("Setup() = unexpected error: %v", gotErr)
+			}
+			if test.wantErr {
+				return
+			}
+
+			if err := shutdown(context.Background()); err != nil {
+				t.Errorf("shutdown() = unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestEnd(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input error
+		want  codes.Code
+	}{
+		{
+			name: "end span",
+			want: codes.Unset,
+		},
+		{
+			name:  "end span with error",
+			input: errors.New("error"),
+			want:  codes.Error,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+			_, span := provider.Tracer("test").Start(context.Background(), "test")
+			End(span, test.input)
+
+			got := recorder.Ended()[0].Status().Code
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("End() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestTraceID(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+
+	var tests = []struct {
+		name  string
+		input context.Context
+		want  string
+	}{
+		{
+			name:  "context without span",
+			input: context.Background(),
+			want:  "",
+		},
+		{
+			name: "context with span",
+			input: trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID: traceID,
+				SpanID:  spanID,
+			})),
+			want: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := TraceID(test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("TraceID() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package ui
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/auth"
+	"github.com/RedeployAB/burnit/internal/log"
+	"github.com/RedeployAB/burnit/internal/session"
+)
+
+const (
+	// sessionCookie is the name of the cookie holding the ID of the
+	// session of a signed in user.
+	sessionCookie = "burnit_session"
+	// authFlowCookie is the name of the cookie holding the state, nonce
+	// and PKCE verifier of an ongoing sign in.
+	authFlowCookie = "burnit_auth"
+	// authFlowDuration is the maximum duration of a sign in.
+	authFlowDuration = 10 * time.Minute
+	// authPath is the path of the authentication routes.
+	authPath = "/ui/auth"
+	// defaultAuthSessionDuration is the default duration of the session
+	// of a signed in user.
+	defaultAuthSessionDuration = 8 * time.Hour
+)
+
+// Authenticator authenticates users with an external identity provider.
+type Authenticator interface {
+	// AuthCodeURL returns the URL of the provider to redirect to for sign in.
+	AuthCodeURL(state, nonce, verifier string) string
+	// Exchange exchanges the authorization code from the provider and
+	// returns the authenticated user.
+	Exchange(ctx context.Context, code, nonce, verifier string) (string, error)
+}
+
+// Auth contains the configuration for signing in users to the UI.
+type Auth struct {
+	Authenticator Authenticator
+	// SessionDuration is the duration of the session of a signed in user.
+	SessionDuration time.Duration
+	// SecureCookies sets the Secure attribute on the cookies. It should
+	// be set when the UI is served over HTTPS.
+	SecureCookies bool
+}
+
+// Login handles requests to sign in. The user is redirected to the
+// identity provider.
+func Login(ui UI) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state, nonce, verifier := randomString(), randomString(), randomString()
+		http.SetCookie(w, &http.Cookie{
+			Name:     authFlowCookie,
+			Value:    strings.Join([]string{state, nonce, verifier}, "."),
+			Path:     authPath,
+			MaxAge:   int(authFlowDuration.Seconds()),
+			HttpOnly: true,
+			Secure:   ui.Auth().SecureCookies,
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, ui.Auth().Authenticator.AuthCodeURL(state, nonce, verifier), http.StatusFound)
+	})
+}
+
+// AuthCallback handles the redirect from the identity provider after sign in.
+// A session is created for the user and the user is redirected to the page
+// for creating secrets.
+func AuthCallback(ui UI, log log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{
+			Name:     authFlowCookie,
+			Path:     authPath,
+			MaxAge:   -1,
+			HttpOnly: true,
+			Secure:   ui.Auth().SecureCookies,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		state, nonce, verifier, ok := authFlowFromRequest(r)
+		if !ok || subtle.ConstantTimeCompare([]byte(state), []byte(r.URL.Query().Get("state"))) != 1 {
+			ui.Render(w, http.StatusBadRequest, "sign-in-failed", errorResponse{Title: "Could not sign in", Message: "The sign in has expired or is invalid. Please try again."})
+			return
+		}
+		if len(r.URL.Query().Get("error")) > 0 {
+			ui.Render(w, http.StatusUnauthorized, "sign-in-failed", errorResponse{Title: "Could not sign in", Message: "The sign in was cancelled or denied by the identity provider."})
+			return
+		}
+
+		user, err := ui.Auth().Authenticator.Exchange(r.Context(), r.URL.Query().Get("code"), nonce, verifier)
+		if err != nil {
+			if errors.Is(err, auth.ErrUserNotAllowed) {
+				ui.Render(w, http.StatusForbidden, "sign-in-failed", errorResponse{Title: "Could not sign in", Message: "The user is not allowed to create secrets."})
+				return
+			}
+			requestID := requestIDFromContext(r.Context())
+			log.Error("Failed to sign in.", uiLog(r.Context(), err, "AuthCallback")...)
+			ui.Render(w, http.StatusUnauthorized, "sign-in-failed", errorResponse{Title: "Could not sign in", Message: "The sign in could not be verified. Please try again.", RequestID: requestID})
+			return
+		}
+
+		expiresAt := time.Now().Add(ui.Auth().SessionDuration)
+		sess := session.NewSession(session.WithUser(user), session.WithExpiresAt(expiresAt))
+		if err := ui.Sessions().Set(r.Context(), sess); err != nil {
+			requestID := requestIDFromContext(r.Context())
+			log.Error("Failed to set session.", uiLog(r.Context(), err, "AuthCallback")...)
+			ui.Render(w, http.StatusInternalServerError, "sign-in-failed", errorResponse{Title: "An error occured", Message: "Could not create session.", RequestID: requestID})
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     sessionCookie,
+			Value:    sess.ID(),
+			Path:     "/ui",
+			Expires:  expiresAt,
+			HttpOnly: true,
+			Secure:   ui.Auth().SecureCookies,
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, "/ui/secrets", http.StatusFound)
+	})
+}
+
+// Logout handles requests to sign out. The session of the user is deleted.
+func Logout(ui UI, log log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			requestID := requestIDFromContext(r.Context())
+			log.Error("Failed to parse form.", uiLog(r.Context(), err, "Logout")...)
+			ui.Render(w, http.StatusBadRequest, "sign-in-failed", errorResponse{Title: "An error occured", Message: "Could not parse form.", RequestID: requestID})
+			return
+		}
+
+		defer func() {
+			if err := ui.Sessions().Delete(r.Context(), session.DeleteWithCSRFToken(r.FormValue("csrf-token"))); err != nil {
+				log.Error("Failed to delete session.", uiLog(r.Context(), err, "Logout")...)
+			}
+		}()
+
+		ok, statusCode, errResp, err := validateCSRFTToken(r.Context(), ui.Sessions(), r.FormValue("csrf-token"))
+		if err != nil {
+			log.Error("Failed to validate CSRF token.", uiLog(r.Context(), err, "Logout")...)
+			ui.Render(w, statusCode, "sign-in-failed", errResp)
+			return
+		}
+		if !ok {
+			ui.Render(w, statusCode, "sign-in-failed", errResp)
+			return
+		}
+
+		if cookie, err := r.Cookie(sessionCookie); err == nil && len(cookie.Value) > 0 {
+			if err := ui.Sessions().Delete(r.Context(), session.DeleteWithID(cookie.Value)); err != nil && !errors.Is(err, session.ErrSessionNotFound) {
+				log.Error("Failed to delete session.", uiLog(r.Context(), err, "Logout")...)
+			}
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     sessionCookie,
+			Path:     "/ui",
+			MaxAge:   -1,
+			HttpOnly: true,
+			Secure:   ui.Auth().SecureCookies,
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, "/ui/about", http.StatusSeeOther)
+	})
+}
+
+// RequireUser is a middleware that requires a signed in user. Users that
+// are not signed in are redirected to sign in. The user is set in the
+// request context.
+func RequireUser(ui UI, log log.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, err := userFromRequest(r, ui.Sessions())
+			if err != nil {
+				if !errors.Is(err, session.ErrSessionNotFound) && !errors.Is(err, http.ErrNoCookie) {
+					log.Error("Failed to get session.", uiLog(r.Context(), err, "RequireUser")...)
+				}
+				// Requests made by htmx follow the HX-Redirect header instead
+				// of a redirect response.
+				if r.Header.Get("HX-Request") == "true" {
+					w.Header().Set("HX-Redirect", authPath+"/login")
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				http.Redirect(w, r, authPath+"/login", http.StatusFound)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKeyUser, user)))
+		})
+	}
+}
+
+// userFromRequest returns the user of the session of the request.
+func userFromRequest(r *http.Request, sessions session.Service) (string, error) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return "", err
+	}
+	sess, err := sessions.Get(r.Context(), session.GetWithID(cookie.Value))
+	if err != nil {
+		return "", err
+	}
+	if sess.Expired() || len(sess.User()) == 0 {
+		return "", session.ErrSessionNotFound
+	}
+	return sess.User(), nil
+}
+
+// signOutCSRFToken returns a CSRF token for the sign out form of the
+// signed in user. An empty token is returned if no user is signed in.
+func signOutCSRFToken(ctx context.Context, sessions session.Service) string {
+	if len(userFromContext(ctx)) == 0 {
+		return ""
+	}
+	sess := session.NewSession(session.WithCSRF(session.NewCSRF()))
+	sessions.Set(ctx, sess)
+	return sess.CSRF().Token()
+}
+
+// authFlowFromRequest returns the state, nonce and verifier of an ongoing
+// sign in.
+func authFlowFromRequest(r *http.Request) (string, string, string, bool) {
+	cookie, err := r.Cookie(authFlowCookie)
+	if err != nil {
+		return "", "", "", false
+	}
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 3 {
+		return "", "", "", false
+	}
+	return parts[0], parts[1], parts[2], true
+}
+
+// contextKey is a custom type for context keys.
+type contextKey int
+
+const (
+	// contextKeyUser is the context key for the signed in user.
+	contextKeyUser contextKey = 0
+)
+
+// userFromContext returns the signed in user from the context, if any.
+func userFromContext(ctx context.Context) string {
+	user, _ := ctx.Value(contextKeyUser).(string)
+	return user
+}
+
+// randomString returns a random URL safe string.
+func randomString() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
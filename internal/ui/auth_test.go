@@ -0,0 +1,309 @@
+package ui
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/auth"
+	"github.com/RedeployAB/burnit/internal/db/inmem"
+	"github.com/RedeployAB/burnit/internal/session"
+)
+
+func TestAuth(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			user  string
+			err   error
+			state string
+			htmx  bool
+		}
+		want struct {
+			callbackCode int
+			code         int
+			location     string
+			hxRedirect   string
+			user         string
+		}
+	}{
+		{
+			name: "signed in",
+			input: struct {
+				user  string
+				err   error
+				state string
+				htmx  bool
+			}{
+				user: "user@example.com",
+			},
+			want: struct {
+				callbackCode int
+				code         int
+				location     string
+				hxRedirect   string
+				user         string
+			}{
+				callbackCode: http.StatusFound,
+				code:         http.StatusOK,
+				user:         "user@example.com",
+			},
+		},
+		{
+			name: "not signed in - invalid state",
+			input: struct {
+				user  string
+				err   error
+				state string
+				htmx  bool
+			}{
+				user:  "user@example.com",
+				state: "invalid",
+			},
+			want: struct {
+				callbackCode int
+				code         int
+				location     string
+				hxRedirect   string
+				user         string
+			}{
+				callbackCode: http.StatusBadRequest,
+				code:         http.StatusFound,
+				location:     "/ui/auth/login",
+			},
+		},
+		{
+			name: "not signed in - user not allowed",
+			input: struct {
+				user  string
+				err   error
+				state string
+				htmx  bool
+			}{
+				err: auth.ErrUserNotAllowed,
+			},
+			want: struct {
+				callbackCode int
+				code         int
+				location     string
+				hxRedirect   string
+				user         string
+			}{
+				callbackCode: http.StatusForbidden,
+				code:         http.StatusFound,
+				location:     "/ui/auth/login",
+			},
+		},
+		{
+			name: "not signed in - htmx",
+			input: struct {
+				user  string
+				err   error
+				state string
+				htmx  bool
+			}{
+				err:  auth.ErrUserNotAllowed,
+				htmx: true,
+			},
+			want: struct {
+				callbackCode int
+				code         int
+				location     string
+				hxRedirect   string
+				user         string
+			}{
+				callbackCode: http.StatusForbidden,
+				code:         http.StatusUnauthorized,
+				hxRedirect:   "/ui/auth/login",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sessions, err := session.NewService(inmem.NewSessionStore())
+			if err != nil {
+				t.Fatalf("NewService() = unexpected error: %v", err)
+			}
+			authenticator := &stubAuthenticator{user: test.input.user, err: test.input.err}
+			u, err := New(sessions, func(o *Options) {
+				o.Auth = &Auth{Authenticator: authenticator}
+			})
+			if err != nil {
+				t.Fatalf("New() = unexpected error: %v", err)
+			}
+
+			// Sign in.
+			rr := httptest.NewRecorder()
+			Login(u).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ui/auth/login", nil))
+			location, err := url.Parse(rr.Header().Get("Location"))
+			if err != nil {
+				t.Fatalf("Login() = unexpected location: %v", err)
+			}
+			if authenticator.verifier == "" || authenticator.nonce == "" {
+				t.Fatalf("Login() = expected nonce and verifier\n")
+			}
+
+			state := location.Query().Get("state")
+			if len(test.input.state) > 0 {
+				state = test.input.state
+			}
+			req := httptest.NewRequest(http.MethodGet, "/ui/auth/callback?code=code&state="+state, nil)
+			for _, cookie := range rr.Result().Cookies() {
+				req.AddCookie(cookie)
+			}
+			rr = httptest.NewRecorder()
+			AuthCallback(u, &stubLogger{}).ServeHTTP(rr, req)
+
+			if test.want.callbackCode != rr.Code {
+				t.Errorf("AuthCallback() = unexpected status code, want: %d, got: %d\n", test.want.callbackCode, rr.Code)
+			}
+
+			// Request a page that requires a signed in user.
+			req = httptest.NewRequest(http.MethodGet, "/ui/secrets", nil)
+			for _, cookie := range rr.Result().Cookies() {
+				if cookie.Name == sessionCookie {
+					req.AddCookie(cookie)
+				}
+			}
+			if test.input.htmx {
+				req.Header.Set("HX-Request", "true")
+			}
+			var gotUser string
+			rr = httptest.NewRecorder()
+			RequireUser(u, &stubLogger{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUser = userFromContext(r.Context())
+			})).ServeHTTP(rr, req)
+
+			if test.want.code != rr.Code {
+				t.Errorf("RequireUser() = unexpected status code, want: %d, got: %d\n", test.want.code, rr.Code)
+			}
+
+			if test.want.location != rr.Header().Get("Location") {
+				t.Errorf("RequireUser() = unexpected location, want: %s, got: %s\n", test.want.location, rr.Header().Get("Location"))
+			}
+
+			if test.want.hxRedirect != rr.Header().Get("HX-Redirect") {
+				t.Errorf("RequireUser() = unexpected HX-Redirect, want: %s, got: %s\n", test.want.hxRedirect, rr.Header().Get("HX-Redirect"))
+			}
+
+			if test.want.user != gotUser {
+				t.Errorf("RequireUser() = unexpected user, want: %s, got: %s\n", test.want.user, gotUser)
+			}
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			csrfToken string
+		}
+		want struct {
+			code     int
+			signedIn bool
+		}
+	}{
+		{
+			name: "sign out",
+			want: struct {
+				code     int
+				signedIn bool
+			}{
+				code:     http.StatusSeeOther,
+				signedIn: false,
+			},
+		},
+		{
+			name: "sign out - invalid CSRF token",
+			input: struct {
+				csrfToken string
+			}{
+				csrfToken: "invalid",
+			},
+			want: struct {
+				code     int
+				signedIn bool
+			}{
+				code:     http.StatusBadRequest,
+				signedIn: true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sessions, err := session.NewService(inmem.NewSessionStore())
+			if err != nil {
+				t.Fatalf("NewService() = unexpected error: %v", err)
+			}
+			u, err := New(sessions, func(o *Options) {
+				o.Auth = &Auth{Authenticator: &stubAuthenticator{}}
+			})
+			if err != nil {
+				t.Fatalf("New() = unexpected error: %v", err)
+			}
+
+			sess := session.NewSession(session.WithUser("user@example.com"), session.WithExpiresAt(time.Now().Add(time.Hour)))
+			if err := sessions.Set(context.Background(), sess); err != nil {
+				t.Fatalf("Set() = unexpected error: %v", err)
+			}
+
+			csrfToken := signOutCSRFToken(context.WithValue(context.Background(), contextKeyUser, "user@example.com"), sessions)
+			if len(test.input.csrfToken) > 0 {
+				csrfToken = test.input.csrfToken
+			}
+
+			form := url.Values{"csrf-token": {csrfToken}}
+			req := httptest.NewRequest(http.MethodPost, "/ui/auth/logout", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.AddCookie(&http.Cookie{Name: sessionCookie, Value: sess.ID()})
+			rr := httptest.NewRecorder()
+			Logout(u, &stubLogger{}).ServeHTTP(rr, req)
+
+			if test.want.code != rr.Code {
+				t.Errorf("Logout() = unexpected status code, want: %d, got: %d\n", test.want.code, rr.Code)
+			}
+
+			_, err = sessions.Get(context.Background(), session.GetWithID(sess.ID()))
+			if gotSignedIn := err == nil; test.want.signedIn != gotSignedIn {
+				t.Errorf("Logout() = unexpected signed in, want: %v, got: %v\n", test.want.signedIn, gotSignedIn)
+			}
+		})
+	}
+}
+
+type stubAuthenticator struct {
+	user     string
+	err      error
+	nonce    string
+	verifier string
+}
+
+func (a *stubAuthenticator) AuthCodeURL(state, nonce, verifier string) string {
+	a.nonce = nonce
+	a.verifier = verifier
+	return "https://idp.example.com/authorize?state=" + url.QueryEscape(state)
+}
+
+func (a *stubAuthenticator) Exchange(ctx context.Context, code, nonce, verifier string) (string, error) {
+	if a.err != nil {
+		return "", a.err
+	}
+	if code != "code" || nonce != a.nonce || verifier != a.verifier {
+		return "", auth.ErrInvalidIDToken
+	}
+	return a.user, nil
+}
+
+type stubLogger struct{}
+
+func (l *stubLogger) Debug(msg string, args ...any) {}
+func (l *stubLogger) Error(msg string, args ...any) {}
+func (l *stubLogger) Info(msg string, args ...any)  {}
+func (l *stubLogger) Warn(msg string, args ...any)  {}
@@ -14,6 +14,16 @@ import (
 	"github.com/RedeployAB/burnit/internal/secret"
 	"github.com/RedeployAB/burnit/internal/security"
 	"github.com/RedeployAB/burnit/internal/session"
+	"github.com/RedeployAB/burnit/internal/tracing"
+)
+
+const (
+	// generatedWords is the number of words of a passphrase generated
+	// for the form to create a secret.
+	generatedWords = 6
+	// generatedCharacters is the number of characters of a secret generated
+	// for the form to create a secret.
+	generatedCharacters = 24
 )
 
 // Index handles requests to the index route.
@@ -30,6 +40,32 @@ func NotFound(ui UI) http.Handler {
 	})
 }
 
+// TooManyRequests handles requests that have been rejected by
+// a rate limit. Requests made by htmx get the error partial.
+func TooManyRequests(ui UI) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := errorResponse{Title: "Too many requests", Message: "Too many requests. Please try again later."}
+		if r.Header.Get("HX-Request") == "true" {
+			ui.Render(w, http.StatusTooManyRequests, "error", data, WithPartial())
+			return
+		}
+		ui.Render(w, http.StatusTooManyRequests, "too-many-requests", data)
+	})
+}
+
+// Forbidden handles requests that are not allowed from the network
+// of the client. Requests made by htmx get the error partial.
+func Forbidden(ui UI) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := errorResponse{Title: "Not allowed", Message: "Creating secrets is not allowed from this network."}
+		if r.Header.Get("HX-Request") == "true" {
+			ui.Render(w, http.StatusForbidden, "error", data, WithPartial())
+			return
+		}
+		ui.Render(w, http.StatusForbidden, "forbidden", data)
+	})
+}
+
 // About handles requests to the about route.
 func About(ui UI) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -50,8 +86,8 @@ func CreateSecret(ui UI, secrets secret.Service) http.Handler {
 		// Sessions are only implemented for CSRF tokens at the moment.
 		// Use the CSRF token as the session ID when setting the session.
 		sess := session.NewSession(session.WithCSRF(session.NewCSRF()))
-		ui.Sessions().Set(sess)
-		ui.Render(w, http.StatusOK, "secret-create", secretCreateResponse{CSRFToken: sess.CSRF().Token()})
+		ui.Sessions().Set(r.Context(), sess)
+		ui.Render(w, http.StatusOK, "secret-create", secretCreateResponse{CSRFToken: sess.CSRF().Token(), User: userFromContext(r.Context()), SignOutCSRFToken: signOutCSRFToken(r.Context(), ui.Sessions())})
 	})
 }
 
@@ -64,16 +100,26 @@ func GetSecret(ui UI, secrets secret.Service, log log.Logger) http.Handler {
 			return
 		}
 
-		if _, err = secrets.Get(id, passphrase, func(o *secret.GetOptions) {
+		s, err := secrets.Get(r.Context(), id, passphrase, func(o *secret.GetOptions) {
 			o.NoDecrypt = true
-		}); err != nil {
+			o.SourceIP = sourceIPFromContext(r.Context())
+		})
+		if err != nil {
 			if errors.Is(err, secret.ErrSecretNotFound) {
 				ui.Render(w, http.StatusNotFound, "secret-not-found", nil)
 				return
 			}
+			if errors.Is(err, secret.ErrNetworkNotAllowed) {
+				ui.Render(w, http.StatusForbidden, "secret-not-allowed", nil)
+				return
+			}
+			if errors.Is(err, secret.ErrSecretNotAvailable) {
+				ui.Render(w, http.StatusForbidden, "secret-not-available", newSecretNotAvailableResponse(s.NotBefore))
+				return
+			}
 
 			requestID := requestIDFromContext(r.Context())
-			log.Error("Failed to get secret.", uiLog(err, "GetSecret", requestID)...)
+			log.Error("Failed to get secret.", uiLog(r.Context(), err, "GetSecret")...)
 			ui.Render(w, http.StatusInternalServerError, "error", errorResponse{Title: "An error occured", Message: "Could not retrieve secret.", RequestID: requestID}, WithPartial())
 			return
 		}
@@ -82,8 +128,8 @@ func GetSecret(ui UI, secrets secret.Service, log log.Logger) http.Handler {
 			// Sessions are only implemented for CSRF tokens at the moment.
 			// Use the CSRF token as the session ID when setting the session.
 			sess := session.NewSession(session.WithCSRF(session.NewCSRF()))
-			ui.Sessions().Set(sess)
-			ui.Render(w, http.StatusUnauthorized, "secret-get-passphrase", secretGetResponse{ID: id, CSRFToken: sess.CSRF().Token()})
+			ui.Sessions().Set(r.Context(), sess)
+			ui.Render(w, http.StatusOK, "secret-get-passphrase", secretGetResponse{ID: id, CSRFToken: sess.CSRF().Token(), Label: s.Label})
 			return
 		}
 
@@ -93,17 +139,48 @@ func GetSecret(ui UI, secrets secret.Service, log log.Logger) http.Handler {
 			return
 		}
 
-		s, err := secrets.Get(id, string(decodedPassphrase), func(o *secret.GetOptions) {
+		// Secrets with a label are revealed from an interstitial that shows
+		// the label, so that the recipient can make sure that it is the
+		// right secret before it is read and deleted.
+		if len(s.Label) > 0 {
+			sess := session.NewSession(session.WithCSRF(session.NewCSRF()))
+			ui.Sessions().Set(r.Context(), sess)
+			ui.Render(w, http.StatusOK, "secret-get-reveal", secretGetResponse{ID: id, PassphraseHash: passphrase, CSRFToken: sess.CSRF().Token(), Label: s.Label})
+			return
+		}
+
+		s, err = secrets.Get(r.Context(), id, string(decodedPassphrase), func(o *secret.GetOptions) {
 			o.PassphraseHashed = true
+			o.SourceIP = sourceIPFromContext(r.Context())
 		})
 		if err != nil {
 			if errors.Is(err, secret.ErrSecretNotFound) {
 				ui.Render(w, http.StatusNotFound, "secret-not-found", nil)
 				return
 			}
+			if errors.Is(err, secret.ErrNetworkNotAllowed) {
+				ui.Render(w, http.StatusForbidden, "secret-not-allowed", nil)
+				return
+			}
+			if errors.Is(err, secret.ErrSecretNotAvailable) {
+				ui.Render(w, http.StatusForbidden, "secret-not-available", newSecretNotAvailableResponse(s.NotBefore))
+				return
+			}
+			if errors.Is(err, secret.ErrSecondFactorRequired) {
+				ui.Render(w, http.StatusOK, "secret-get-code", newSecretGetCodeResponse(r.Context(), ui, s, passphrase))
+				return
+			}
+			if errors.Is(err, secret.ErrInvalidPassphrase) {
+				// Invalid passphrases are rendered with 401 Unauthorized so that
+				// they count towards the rate limit for failed passphrases.
+				sess := session.NewSession(session.WithCSRF(session.NewCSRF()))
+				ui.Sessions().Set(r.Context(), sess)
+				ui.Render(w, http.StatusUnauthorized, "secret-get-passphrase", secretGetResponse{ID: id, CSRFToken: sess.CSRF().Token(), Label: s.Label})
+				return
+			}
 
 			requestID := requestIDFromContext(r.Context())
-			log.Error("Failed to get secret.", uiLog(err, "GetSecret", requestID)...)
+			log.Error("Failed to get secret.", uiLog(r.Context(), err, "GetSecret")...)
 			ui.Render(w, http.StatusInternalServerError, "error", errorResponse{Title: "An error occured", Message: "Could not retrieve secret.", RequestID: requestID}, WithPartial())
 			return
 		}
@@ -112,6 +189,9 @@ func GetSecret(ui UI, secrets secret.Service, log log.Logger) http.Handler {
 			ID:             s.ID,
 			PassphraseHash: passphrase,
 			Value:          s.Value,
+			Fields:         secretFields(s.Content),
+			Label:          s.Label,
+			Note:           s.Note,
 		}
 
 		ui.Render(w, http.StatusOK, "secret-get", response)
@@ -123,28 +203,27 @@ func CreateSecretHandler(ui UI, secrets secret.Service, log log.Logger) http.Han
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
 			sess := session.NewSession(session.WithCSRF(session.NewCSRF()))
-			ui.Sessions().Set(sess)
-			ui.Render(w, http.StatusOK, "secret-create", secretCreateResponse{CSRFToken: sess.CSRF().Token()}, WithPartial())
+			ui.Sessions().Set(r.Context(), sess)
+			ui.Render(w, http.StatusOK, "secret-create", secretCreateResponse{CSRFToken: sess.CSRF().Token(), User: userFromContext(r.Context()), SignOutCSRFToken: signOutCSRFToken(r.Context(), ui.Sessions())}, WithPartial())
 			return
 		}
 
 		if err := r.ParseForm(); err != nil {
 			requestID := requestIDFromContext(r.Context())
-			log.Error("Failed to parse form.", uiLog(err, "HandlerCreateSecret", requestID)...)
+			log.Error("Failed to parse form.", uiLog(r.Context(), err, "HandlerCreateSecret")...)
 			ui.Render(w, http.StatusBadRequest, "error", errorResponse{Title: "An error occured", Message: "Could not parse form.", RequestID: requestID}, WithPartial())
 			return
 		}
 
 		defer func() {
-			if err := ui.Sessions().Delete(session.DeleteWithCSRFToken(r.FormValue("csrf-token"))); err != nil {
-				log.Error("Failed to delete session.", uiLog(err, "HandlerCreateSecret", requestIDFromContext(r.Context()))...)
+			if err := ui.Sessions().Delete(r.Context(), session.DeleteWithCSRFToken(r.FormValue("csrf-token"))); err != nil {
+				log.Error("Failed to delete session.", uiLog(r.Context(), err, "HandlerCreateSecret")...)
 			}
 		}()
 
 		ok, statusCode, errResp, err := validateCSRFTToken(r.Context(), ui.Sessions(), r.FormValue("csrf-token"))
 		if err != nil {
-			requestID := requestIDFromContext(r.Context())
-			log.Error("Failed to validate CSRF token.", uiLog(err, "HandlerCreateSecret", requestID)...)
+			log.Error("Failed to validate CSRF token.", uiLog(r.Context(), err, "HandlerCreateSecret")...)
 			ui.Render(w, statusCode, "error", errResp, WithPartial())
 			return
 		}
@@ -165,10 +244,13 @@ func CreateSecretHandler(ui UI, secrets secret.Service, log log.Logger) http.Han
 			return
 		}
 
-		s, err := secrets.Create(secret.Secret{
+		s, err := secrets.Create(r.Context(), secret.Secret{
 			Value:      r.FormValue("value"),
 			Passphrase: r.FormValue("custom-value"),
 			TTL:        ttl,
+			CreatedBy:  userFromContext(r.Context()),
+			Label:      r.FormValue("label"),
+			Note:       r.FormValue("note"),
 		})
 		if err != nil {
 			var response errorResponse
@@ -177,7 +259,7 @@ func CreateSecretHandler(ui UI, secrets secret.Service, log log.Logger) http.Han
 				statusCode = http.StatusInternalServerError
 				requestID := requestIDFromContext(r.Context())
 				response = errorResponse{Title: "An error occured", Message: "Internal server error.", RequestID: requestID}
-				log.Error("Failed to create secret.", uiLog(err, "HandlerCreateSecret", requestID)...)
+				log.Error("Failed to create secret.", uiLog(r.Context(), err, "HandlerCreateSecret")...)
 			} else {
 				statusCode = http.StatusBadRequest
 				response = errorResponse{Title: "Could not create secret", Message: formatErrorMessage(err)}
@@ -198,26 +280,83 @@ func CreateSecretHandler(ui UI, secrets secret.Service, log log.Logger) http.Han
 	})
 }
 
+// GenerateSecretHandler handles requests to generate a value for the form
+// to create a secret. The query parameter mode selects between a passphrase
+// of words (words) and random characters (characters).
+func GenerateSecretHandler(ui UI, secrets secret.Service, log log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		option := func(o *secret.GenerateOptions) {
+			o.Words = generatedWords
+			o.Capitalize = true
+			o.Number = true
+		}
+		if r.URL.Query().Get("mode") == "characters" {
+			option = func(o *secret.GenerateOptions) {
+				o.Length = generatedCharacters
+				o.SpecialCharacters = true
+			}
+		}
+
+		value, err := secrets.Generate(option)
+		if err != nil {
+			requestID := requestIDFromContext(r.Context())
+			log.Error("Failed to generate secret.", uiLog(r.Context(), err, "HandlerGenerateSecret")...)
+			ui.Render(w, http.StatusInternalServerError, "error", errorResponse{Title: "An error occured", Message: "Could not generate secret.", RequestID: requestID}, WithPartial())
+			return
+		}
+
+		ui.Render(w, http.StatusOK, "secret-generated", secretGeneratedResponse{Value: value}, WithPartial())
+	})
+}
+
+// PassphraseStrengthHandler handles requests to estimate the strength of
+// the custom passphrase of the form to create a secret.
+func PassphraseStrengthHandler(ui UI, secrets secret.Service, log log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			requestID := requestIDFromContext(r.Context())
+			log.Error("Failed to parse form.", uiLog(r.Context(), err, "HandlerPassphraseStrength")...)
+			ui.Render(w, http.StatusBadRequest, "error", errorResponse{Title: "An error occured", Message: "Could not parse form.", RequestID: requestID}, WithPartial())
+			return
+		}
+
+		passphrase := r.FormValue("custom-value")
+		if len(passphrase) == 0 {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		strength := secrets.PassphraseStrength(passphrase)
+		ui.Render(w, http.StatusOK, "passphrase-strength", passphraseStrengthResponse{
+			Score:      strength.Score,
+			Label:      strength.Label(),
+			CrackTime:  strength.CrackTime,
+			MinLabel:   security.PassphraseStrengthLabel(strength.MinScore),
+			Sufficient: strength.Sufficient(),
+		}, WithPartial())
+	})
+}
+
 // GetSecretHandler handles requests containing a form to get a secret.
 // This form will be used when a passphrase is not provided in the URL.
 func GetSecretHandler(ui UI, secrets secret.Service, log log.Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if err := r.ParseForm(); err != nil {
 			requestID := middleware.RequestIDFromContext(r.Context())
-			log.Error("Failed to parse form.", uiLog(err, "HandlerGetSecret", requestID)...)
+			log.Error("Failed to parse form.", uiLog(r.Context(), err, "HandlerGetSecret")...)
 			ui.Render(w, http.StatusInternalServerError, "error", errorResponse{Title: "An error occured", Message: "Could not parse form.", RequestID: requestID}, WithPartial())
 			return
 		}
 
 		defer func() {
-			if err := ui.Sessions().Delete(session.DeleteWithCSRFToken(r.FormValue("csrf-token"))); err != nil {
-				log.Error("Failed to delete session.", "handler", "HandlerGetSecret", "error", err, "requestId", requestIDFromContext(r.Context()))
+			if err := ui.Sessions().Delete(r.Context(), session.DeleteWithCSRFToken(r.FormValue("csrf-token"))); err != nil {
+				log.Error("Failed to delete session.", uiLog(r.Context(), err, "HandlerGetSecret")...)
 			}
 		}()
 
 		ok, statusCode, errResp, err := validateCSRFTToken(r.Context(), ui.Sessions(), r.FormValue("csrf-token"))
 		if err != nil {
-			log.Error("Failed to validate CSRF token.", uiLog(err, "HandlerGetSecret", requestIDFromContext(r.Context()))...)
+			log.Error("Failed to validate CSRF token.", uiLog(r.Context(), err, "HandlerGetSecret")...)
 			ui.Render(w, statusCode, "error", errResp, WithPartial())
 			return
 		}
@@ -229,43 +368,179 @@ func GetSecretHandler(ui UI, secrets secret.Service, log log.Logger) http.Handle
 		id := r.FormValue("id")
 		if len(id) == 0 {
 			requestID := middleware.RequestIDFromContext(r.Context())
-			log.Error("Missing ID in request.", uiLog(err, "HandlerGetSecret", requestID)...)
+			log.Error("Missing ID in request.", uiLog(r.Context(), err, "HandlerGetSecret")...)
 			ui.Render(w, http.StatusInternalServerError, "error", errorResponse{Title: "An error occured", Message: "Missing ID.", RequestID: requestID}, WithPartial())
 			return
 		}
-		passphrase := r.FormValue("custom-value")
-		if len(passphrase) == 0 {
+		// The passphrase is provided in the form, or as a hash by the form
+		// for the code of a second factor.
+		passphrase, passphraseHash := r.FormValue("custom-value"), r.FormValue("passphrase-hash")
+		if len(passphrase) == 0 && len(passphraseHash) == 0 {
 			ui.Render(w, http.StatusOK, "secret-get-passphrase", secretGetResponse{ID: id}, WithPartial())
 			return
 		}
+		hashed := len(passphrase) == 0
+		if !hashed {
+			passphraseHash = base64.RawURLEncoding.EncodeToString(security.SHA256([]byte(passphrase)))
+		} else {
+			decoded, err := security.DecodeBase64(passphraseHash)
+			if err != nil {
+				ui.Render(w, http.StatusBadRequest, "error", errorResponse{Title: "Could not retrieve secret", Message: "Invalid passphrase."}, WithPartial())
+				return
+			}
+			passphrase = string(decoded)
+		}
 
-		s, err := secrets.Get(id, passphrase)
+		s, err := secrets.Get(r.Context(), id, passphrase, func(o *secret.GetOptions) {
+			o.PassphraseHashed = hashed
+			o.SourceIP = sourceIPFromContext(r.Context())
+			o.Code = r.FormValue("code")
+		})
 		if err != nil {
 			if errors.Is(err, secret.ErrSecretNotFound) {
 				ui.Render(w, http.StatusNotFound, "secret-not-found", nil)
 				return
 			}
+			if errors.Is(err, secret.ErrNetworkNotAllowed) {
+				ui.Render(w, http.StatusForbidden, "error", errorResponse{Title: "Could not retrieve secret", Message: "The secret can not be read from this network."}, WithPartial())
+				return
+			}
+			if errors.Is(err, secret.ErrSecretNotAvailable) {
+				ui.Render(w, http.StatusForbidden, "error", errorResponse{Title: "Secret not yet available", Message: "The secret can be read from " + newSecretNotAvailableResponse(s.NotBefore).NotBefore + "."}, WithPartial())
+				return
+			}
 			if errors.Is(err, secret.ErrInvalidPassphrase) {
-				ui.Render(w, http.StatusUnauthorized, "secret-get-passphrase", secretGetResponse{ID: id, CSRFToken: r.FormValue("csrf-token")}, WithPartial())
+				ui.Render(w, http.StatusUnauthorized, "secret-get-passphrase", secretGetResponse{ID: id, CSRFToken: r.FormValue("csrf-token"), Label: s.Label}, WithPartial())
+				return
+			}
+			if errors.Is(err, secret.ErrSecondFactorRequired) {
+				ui.Render(w, http.StatusOK, "secret-get-code", newSecretGetCodeResponse(r.Context(), ui, s, passphraseHash), WithPartial())
+				return
+			}
+			if errors.Is(err, secret.ErrInvalidSecondFactorCode) {
+				response := newSecretGetCodeResponse(r.Context(), ui, s, passphraseHash)
+				response.InvalidCode = true
+				ui.Render(w, http.StatusUnauthorized, "secret-get-code", response, WithPartial())
+				return
+			}
+			if errors.Is(err, secret.ErrSecondFactorAttemptsExceeded) {
+				ui.Render(w, http.StatusGone, "error", errorResponse{Title: "Could not retrieve secret", Message: "The secret has been deleted after too many invalid codes."}, WithPartial())
 				return
 			}
 
 			requestID := middleware.RequestIDFromContext(r.Context())
-			log.Error("Failed to get secret.", uiLog(err, "HandlerGetSecret", requestID)...)
+			log.Error("Failed to get secret.", uiLog(r.Context(), err, "HandlerGetSecret")...)
 			ui.Render(w, http.StatusInternalServerError, "error", errorResponse{Title: "An error occured", Message: "Could not retrieve secret.", RequestID: requestID}, WithPartial())
 			return
 		}
 
 		response := secretGetResponse{
 			ID:             s.ID,
-			PassphraseHash: passphrase,
+			PassphraseHash: passphraseHash,
 			Value:          s.Value,
+			Fields:         secretFields(s.Content),
+			Label:          s.Label,
+			Note:           s.Note,
 		}
 
 		ui.Render(w, http.StatusOK, "secret-get", response, WithPartial())
 	})
 }
 
+// SendCodeHandler handles requests containing a form to send the code
+// of the email second factor of a secret.
+func SendCodeHandler(ui UI, secrets secret.Service, log log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			requestID := middleware.RequestIDFromContext(r.Context())
+			log.Error("Failed to parse form.", uiLog(r.Context(), err, "HandlerSendCode")...)
+			ui.Render(w, http.StatusInternalServerError, "error", errorResponse{Title: "An error occured", Message: "Could not parse form.", RequestID: requestID}, WithPartial())
+			return
+		}
+
+		defer func() {
+			if err := ui.Sessions().Delete(r.Context(), session.DeleteWithCSRFToken(r.FormValue("csrf-token"))); err != nil {
+				log.Error("Failed to delete session.", uiLog(r.Context(), err, "HandlerSendCode")...)
+			}
+		}()
+
+		ok, statusCode, errResp, err := validateCSRFTToken(r.Context(), ui.Sessions(), r.FormValue("csrf-token"))
+		if err != nil {
+			log.Error("Failed to validate CSRF token.", uiLog(r.Context(), err, "HandlerSendCode")...)
+			ui.Render(w, statusCode, "error", errResp, WithPartial())
+			return
+		}
+		if !ok {
+			ui.Render(w, statusCode, "error", errResp, WithPartial())
+			return
+		}
+
+		id, passphraseHash := r.FormValue("id"), r.FormValue("passphrase-hash")
+		if len(id) == 0 || len(passphraseHash) == 0 {
+			ui.Render(w, http.StatusBadRequest, "error", errorResponse{Title: "Could not send code", Message: "Missing ID or passphrase."}, WithPartial())
+			return
+		}
+		passphrase, err := security.DecodeBase64(passphraseHash)
+		if err != nil {
+			ui.Render(w, http.StatusBadRequest, "error", errorResponse{Title: "Could not send code", Message: "Invalid passphrase."}, WithPartial())
+			return
+		}
+
+		if err := secrets.SendCode(r.Context(), id, string(passphrase), func(o *secret.SendCodeOptions) {
+			o.PassphraseHashed = true
+			o.SourceIP = sourceIPFromContext(r.Context())
+		}); err != nil {
+			if errors.Is(err, secret.ErrSecretNotFound) {
+				ui.Render(w, http.StatusNotFound, "secret-not-found", nil)
+				return
+			}
+			if errors.Is(err, secret.ErrNetworkNotAllowed) {
+				ui.Render(w, http.StatusForbidden, "error", errorResponse{Title: "Could not send code", Message: "The secret can not be read from this network."}, WithPartial())
+				return
+			}
+			if errors.Is(err, secret.ErrInvalidPassphrase) || errors.Is(err, secret.ErrSecondFactorInvalid) || errors.Is(err, secret.ErrSecretNotAvailable) {
+				ui.Render(w, http.StatusBadRequest, "error", errorResponse{Title: "Could not send code", Message: formatErrorMessage(err)}, WithPartial())
+				return
+			}
+			if errors.Is(err, secret.ErrCodeRecentlySent) {
+				ui.Render(w, http.StatusTooManyRequests, "error", errorResponse{Title: "Could not send code", Message: formatErrorMessage(err)}, WithPartial())
+				return
+			}
+
+			requestID := middleware.RequestIDFromContext(r.Context())
+			log.Error("Failed to send code.", uiLog(r.Context(), err, "HandlerSendCode")...)
+			ui.Render(w, http.StatusInternalServerError, "error", errorResponse{Title: "An error occured", Message: "Could not send code.", RequestID: requestID}, WithPartial())
+			return
+		}
+
+		response := newSecretGetCodeResponse(r.Context(), ui, secret.Secret{ID: id, SecondFactor: &secret.SecondFactor{Type: secret.SecondFactorEmail}}, passphraseHash)
+		response.CodeSent = true
+		ui.Render(w, http.StatusOK, "secret-get-code", response, WithPartial())
+	})
+}
+
+// newSecretGetCodeResponse returns the response data for a secret that
+// requires the code of a second factor. A new session is set for the
+// CSRF token of the form.
+func newSecretGetCodeResponse(ctx context.Context, ui UI, s secret.Secret, passphraseHash string) secretGetCodeResponse {
+	// Sessions are only implemented for CSRF tokens at the moment.
+	// Use the CSRF token as the session ID when setting the session.
+	sess := session.NewSession(session.WithCSRF(session.NewCSRF()))
+	ui.Sessions().Set(ctx, sess)
+
+	var secondFactor string
+	if s.SecondFactor != nil {
+		secondFactor = s.SecondFactor.Type
+	}
+	return secretGetCodeResponse{
+		ID:             s.ID,
+		PassphraseHash: passphraseHash,
+		SecondFactor:   secondFactor,
+		CSRFToken:      sess.CSRF().Token(),
+		Label:          s.Label,
+	}
+}
+
 // extractIDAndPassphrase extracts the ID and passphrase from the path.
 func extractIDAndPassphrase(route, path string) (string, string, error) {
 	path = strings.TrimPrefix(path, route)
@@ -288,7 +563,7 @@ func extractIDAndPassphrase(route, path string) (string, string, error) {
 // In this implementation the CSRF token is the session ID, since
 // sessions have only been implemented for CSRF tokens.
 func validateCSRFTToken(ctx context.Context, sessions session.Service, token string) (bool, int, errorResponse, error) {
-	sess, err := sessions.Get(session.GetWithCSRFToken(token))
+	sess, err := sessions.Get(ctx, session.GetWithCSRFToken(token))
 	if err != nil {
 		title := "Could not retrieve session"
 		if errors.Is(err, session.ErrSessionNotFound) {
@@ -315,11 +590,20 @@ func validateCSRFTToken(ctx context.Context, sessions session.Service, token str
 }
 
 // uiLog formats the log message for the UI.
-func uiLog(err error, handler, requestID string) []any {
-	return []any{"type", "ui", "handler", handler, "error", err, "requestId", requestID}
+func uiLog(ctx context.Context, err error, handler string) []any {
+	args := []any{"type", "ui", "handler", handler, "error", err, "requestId", requestIDFromContext(ctx)}
+	if traceID := tracing.TraceID(ctx); len(traceID) > 0 {
+		args = append(args, "traceId", traceID)
+	}
+	return args
 }
 
 // requestIDFromContext returns the request ID from the context.
 func requestIDFromContext(ctx context.Context) string {
 	return middleware.RequestIDFromContext(ctx)
 }
+
+// sourceIPFromContext returns the source IP from the context.
+func sourceIPFromContext(ctx context.Context) string {
+	return middleware.SourceIPFromContext(ctx)
+}
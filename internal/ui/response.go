@@ -2,8 +2,11 @@ package ui
 
 import (
 	"errors"
+	"maps"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/RedeployAB/burnit/internal/secret"
 )
@@ -15,6 +18,25 @@ type secretCreateResponse struct {
 	Passphrase     string
 	PassphraseHash string
 	CSRFToken      string
+	// User is the signed in user, if any.
+	User string
+	// SignOutCSRFToken is the CSRF token of the sign out form.
+	SignOutCSRFToken string
+}
+
+// secretGeneratedResponse is the response data for a generate secret request.
+type secretGeneratedResponse struct {
+	Value string
+}
+
+// passphraseStrengthResponse is the response data for a passphrase
+// strength request.
+type passphraseStrengthResponse struct {
+	Score      int
+	Label      string
+	CrackTime  string
+	MinLabel   string
+	Sufficient bool
 }
 
 // secretGetResponse is the response data for a get secret request.
@@ -23,6 +45,76 @@ type secretGetResponse struct {
 	PassphraseHash string
 	Value          string
 	CSRFToken      string
+	// Label is the unencrypted label of the secret, shown before
+	// the secret is read.
+	Label string
+	// Note is the note delivered alongside the value of the secret.
+	Note string
+	// Fields contains the labeled fields of a secret with structured
+	// content. The value is shown instead if empty.
+	Fields []secretField
+}
+
+// secretField is a labeled field of a secret with structured content.
+type secretField struct {
+	Label     string
+	Value     string
+	Multiline bool
+}
+
+// secretFields returns the labeled fields of structured content. Empty
+// credentials fields are left out and keys are sorted.
+func secretFields(content *secret.Content) []secretField {
+	if content == nil {
+		return nil
+	}
+
+	var fields []secretField
+	if content.Credentials != nil {
+		for _, field := range []secretField{
+			{Label: "Username", Value: content.Credentials.Username},
+			{Label: "Password", Value: content.Credentials.Password},
+			{Label: "URL", Value: content.Credentials.URL},
+			{Label: "Notes", Value: content.Credentials.Notes, Multiline: true},
+		} {
+			if len(field.Value) > 0 {
+				fields = append(fields, field)
+			}
+		}
+	}
+
+	keys := slices.Sorted(maps.Keys(content.KeyValue))
+	for _, key := range keys {
+		value := content.KeyValue[key]
+		fields = append(fields, secretField{Label: key, Value: value, Multiline: strings.Contains(value, "\n")})
+	}
+	return fields
+}
+
+// secretGetCodeResponse is the response data for a secret that
+// requires the code of a second factor.
+type secretGetCodeResponse struct {
+	ID             string
+	PassphraseHash string
+	SecondFactor   string
+	CSRFToken      string
+	Label          string
+	// CodeSent is set when a code has been sent by email.
+	CodeSent bool
+	// InvalidCode is set when the provided code was invalid.
+	InvalidCode bool
+}
+
+// secretNotAvailableResponse is the response data for a secret that
+// is not yet available.
+type secretNotAvailableResponse struct {
+	NotBefore string
+}
+
+// newSecretNotAvailableResponse returns the response data for a secret
+// that is not available until notBefore.
+func newSecretNotAvailableResponse(notBefore time.Time) secretNotAvailableResponse {
+	return secretNotAvailableResponse{NotBefore: notBefore.UTC().Format("Monday 2 January 2006 15:04 MST")}
 }
 
 // errorResponse is the response data for an error.
@@ -52,10 +144,14 @@ func isSecretBadRequestError(err error) bool {
 		secret.ErrValueInvalid,
 		secret.ErrInvalidPassphrase,
 		secret.ErrValueTooManyCharacters,
+		secret.ErrInvalidContent,
+		secret.ErrInvalidLabel,
+		secret.ErrInvalidNote,
 		secret.ErrInvalidExpirationTime,
 		secret.ErrPassphraseInvalid,
 		secret.ErrPassphraseTooFewCharacters,
 		secret.ErrPassphraseTooManyCharacters,
+		secret.ErrPassphraseTooWeak,
 	}
 
 	for _, e := range errs {
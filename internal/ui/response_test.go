@@ -4,6 +4,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/RedeployAB/burnit/internal/secret"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -45,3 +46,59 @@ func TestFormatErrorMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestSecretFields(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input *secret.Content
+		want  []secretField
+	}{
+		{
+			name:  "text",
+			input: nil,
+			want:  nil,
+		},
+		{
+			name: "credentials",
+			input: &secret.Content{
+				Type: secret.ContentTypeCredentials,
+				Credentials: &secret.Credentials{
+					Username: "user",
+					Password: "pass",
+					Notes:    "notes",
+				},
+			},
+			want: []secretField{
+				{Label: "Username", Value: "user"},
+				{Label: "Password", Value: "pass"},
+				{Label: "Notes", Value: "notes", Multiline: true},
+			},
+		},
+		{
+			name: "key-value",
+			input: &secret.Content{
+				Type: secret.ContentTypeKeyValue,
+				KeyValue: map[string]string{
+					"port":        "5432",
+					"host":        "db.example.com",
+					"certificate": "line 1\nline 2",
+				},
+			},
+			want: []secretField{
+				{Label: "certificate", Value: "line 1\nline 2", Multiline: true},
+				{Label: "host", Value: "db.example.com"},
+				{Label: "port", Value: "5432"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := secretFields(test.input)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("secretFields() = unexpected result (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
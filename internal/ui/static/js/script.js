@@ -62,6 +62,14 @@ document.addEventListener('htmx:afterSwap', (event) => {
     }
   }
 
+  // Update the counter and validation for a generated secret value.
+  if (target.id == 'secret-form-value') {
+    const secretFormTextArea = document.getElementById('secret-form-textarea');
+    if (secretFormTextArea) {
+      secretFormTextArea.dispatchEvent(new Event('input', { bubbles: true }));
+    }
+  }
+
   if (target.id == 'secret-result-container') {
     const errorOverlayCloseButton = document.getElementById('error-overlay-close-button');
     if (errorOverlayCloseButton) {
@@ -83,12 +91,25 @@ document.addEventListener('DOMContentLoaded', () => {
   }
 });
 
+// Handle copy buttons of the fields of a secret result with structured content.
+document.addEventListener('click', (event) => {
+  const button = event.target.closest('[data-copy]');
+  if (button) {
+    copyToClipboard(button.getAttribute('data-copy'), button.id);
+  }
+});
+
 // Handle events before htmx swap for secret result. This to be able to update the swap
 // type to beforeend in case of an error for the overlay.
 document.addEventListener('htmx:beforeSwap', (event) => {
   const detail = event.detail;
 
-  if (detail.xhr.status == 400) {
+  if (detail.xhr.status == 400 || detail.xhr.status == 429) {
+    detail.shouldSwap = true;
+  }
+
+  // An invalid code renders the code form again with a message.
+  if (detail.xhr.status == 401 && document.getElementById('secret-code-form')) {
     detail.shouldSwap = true;
   }
 
@@ -121,7 +142,7 @@ document.addEventListener('htmx:responseError', (event) => {
     const status = detail.xhr.status;
     const secretResultFormPassphrase = document.getElementById('secret-result-form-passphrase');
 
-    if (status == 401) {
+    if (status == 401 && secretResultFormPassphrase) {
       secretResultFormPassphrase.addEventListener('click', () => {
         secretResultFormPassphrase.setAttribute('placeholder', 'Passphrase');
         secretResultFormPassphrase.classList.remove('placeholder-red-500');
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"embed"
+	"errors"
 	"html/template"
 	"io/fs"
 	"net/http"
@@ -36,6 +37,9 @@ type UI interface {
 	Static() fs.FS
 	Sessions() session.Service
 	RuntimeParse() bool
+	// Auth returns the configuration for signing in users, or nil
+	// if signing in is not enabled.
+	Auth() *Auth
 }
 
 // ui is a user interface handler.
@@ -46,6 +50,7 @@ type ui struct {
 	templateDir  string
 	staticFS     fs.FS
 	runtimeParse bool
+	auth         *Auth
 }
 
 // Options for the UI.
@@ -53,6 +58,8 @@ type Options struct {
 	TemplateDir  string
 	StaticDir    string
 	RuntimeParse bool
+	// Auth enables signing in users for creating secrets.
+	Auth *Auth
 }
 
 // Option is a function that configures the UI.
@@ -73,6 +80,17 @@ func New(sessions session.Service, options ...Option) (*ui, error) {
 		head:         newStylesAndScripts(opts.RuntimeParse),
 	}
 
+	if opts.Auth != nil {
+		if opts.Auth.Authenticator == nil {
+			return nil, errors.New("auth: nil authenticator")
+		}
+		auth := *opts.Auth
+		if auth.SessionDuration <= 0 {
+			auth.SessionDuration = defaultAuthSessionDuration
+		}
+		ui.auth = &auth
+	}
+
 	if err := ui.parseTemplates(templateFS, defaultTemplateDir, true); err != nil {
 		return nil, err
 	}
@@ -192,6 +210,12 @@ func (u ui) Sessions() session.Service {
 	return u.sessions
 }
 
+// Auth returns the configuration for signing in users, or nil
+// if signing in is not enabled.
+func (u ui) Auth() *Auth {
+	return u.auth
+}
+
 // trimExtension trims the extension from a file name.
 func trimExtension(name string) string {
 	return strings.TrimSuffix(name, filepath.Ext(name))
@@ -1,18 +1,44 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"strconv"
+	"time"
 
+	"github.com/RedeployAB/burnit/internal/api"
+	"github.com/RedeployAB/burnit/internal/batch"
 	"github.com/RedeployAB/burnit/internal/config"
 	"github.com/RedeployAB/burnit/internal/log"
+	"github.com/RedeployAB/burnit/internal/middleware"
+	"github.com/RedeployAB/burnit/internal/migrate"
 	"github.com/RedeployAB/burnit/internal/server"
+	"github.com/RedeployAB/burnit/internal/tracing"
 	"github.com/RedeployAB/burnit/internal/version"
 )
 
 func main() {
 	log := log.New()
+	if len(os.Args) > 1 && os.Args[1] == "migrate-store" {
+		if err := runMigrateStore(log, os.Args[2:]); err != nil {
+			log.Error("Migration error.", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "create-secrets" {
+		if err := runCreateSecrets(os.Args[2:]); err != nil {
+			log.Error("Create secrets error.", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := run(log); err != nil {
 		log.Error("Server error.", "error", err)
 		os.Exit(1)
@@ -37,6 +63,38 @@ func run(log log.Logger) error {
 		log.Warn("Using in-memory database. Secrets will not be persisted after restart.")
 	}
 
+	if cfg.Server.Tracing.Enabled != nil && *cfg.Server.Tracing.Enabled {
+		shutdownTracing, err := tracing.Setup(context.Background(), func(o *tracing.Options) {
+			if len(cfg.Server.Tracing.Endpoint) > 0 {
+				o.Endpoint = cfg.Server.Tracing.Endpoint
+			}
+			if cfg.Server.Tracing.SampleRatio > 0 {
+				o.SampleRatio = cfg.Server.Tracing.SampleRatio
+			}
+			o.ServiceVersion = version.Version()
+		})
+		if err != nil {
+			return fmt.Errorf("could not setup tracing: %w", err)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				log.Error("Could not shutdown tracing.", "error", err)
+			}
+		}()
+	}
+
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("could not parse trusted proxies: %w", err)
+	}
+
+	allowedNetworks, err := middleware.ParseAllowedNetworks(cfg.Server.AllowedNetworks)
+	if err != nil {
+		return fmt.Errorf("could not parse allowed networks: %w", err)
+	}
+
 	services, err := config.Setup(cfg)
 	if err != nil {
 		return fmt.Errorf("could not setup services: %w", err)
@@ -53,7 +111,22 @@ func run(log log.Logger) error {
 			Burst:           cfg.Server.RateLimiter.Burst,
 			TTL:             cfg.Server.RateLimiter.TTL,
 			CleanupInterval: cfg.Server.RateLimiter.CleanupInterval,
+			Store:           services.RateLimitStore,
+			Policies: server.RateLimitPolicies{
+				Generate:         rateLimitPolicy(cfg.Server.RateLimiter.Policies.Generate),
+				Create:           rateLimitPolicy(cfg.Server.RateLimiter.Policies.Create),
+				Retrieve:         rateLimitPolicy(cfg.Server.RateLimiter.Policies.Retrieve),
+				FailedPassphrase: rateLimitPolicy(cfg.Server.RateLimiter.Policies.FailedPassphrase),
+				UI:               rateLimitPolicy(cfg.Server.RateLimiter.Policies.UI),
+			},
 		}),
+		server.WithMetrics(services.Metrics, cfg.Server.Metrics.Address),
+		server.WithRequestID(server.RequestID{Header: cfg.Server.RequestIDHeader}),
+		server.WithShutdownDelay(cfg.Server.ShutdownDelay),
+		server.WithBaseURL(cfg.Server.BaseURL),
+		server.WithTrustedProxies(trustedProxies),
+		server.WithAllowedNetworks(allowedNetworks),
+		server.WithAPIKeys(services.APIKeys),
 		server.WithUI(services.UI),
 	)
 	if err != nil {
@@ -66,3 +139,108 @@ func run(log log.Logger) error {
 
 	return nil
 }
+
+// rateLimitPolicy converts a rate limit policy from the configuration
+// to a server rate limit policy.
+func rateLimitPolicy(policy config.RateLimiterPolicy) server.RateLimitPolicy {
+	return server.RateLimitPolicy{
+		Rate:  policy.Rate,
+		Burst: policy.Burst,
+		Key:   policy.Key,
+	}
+}
+
+// runMigrateStore migrates unexpired secrets and sessions from one
+// database to another.
+func runMigrateStore(log log.Logger, args []string) error {
+	cfg, err := config.ParseMigrateFlags(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return fmt.Errorf("could not parse flags: %w", err)
+	}
+
+	from, err := config.SetupStores(&cfg.From)
+	if err != nil {
+		return fmt.Errorf("could not setup source stores: %w", err)
+	}
+	defer from.Close()
+
+	to, err := config.SetupStores(&cfg.To)
+	if err != nil {
+		return fmt.Errorf("could not setup destination stores: %w", err)
+	}
+	defer to.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	log.Info("Migrating secrets.", "from", cfg.From.Secrets.Driver, "to", cfg.To.Secrets.Driver)
+	secrets, err := migrate.Secrets(ctx, from.Secrets, to.Secrets)
+	if err != nil {
+		return fmt.Errorf("could not migrate secrets: %w", err)
+	}
+	log.Info("Secrets migrated.", "migrated", secrets.Migrated, "skipped", secrets.Skipped)
+
+	log.Info("Migrating sessions.", "from", cfg.From.Secrets.Driver, "to", cfg.To.Secrets.Driver)
+	sessions, err := migrate.Sessions(ctx, from.Sessions, to.Sessions)
+	if err != nil {
+		return fmt.Errorf("could not migrate sessions: %w", err)
+	}
+	log.Info("Sessions migrated.", "migrated", sessions.Migrated)
+
+	return nil
+}
+
+// runCreateSecrets creates a batch of secrets from a file and writes
+// a link to share for each of them to stdout. Nothing but the created
+// secrets is written to stdout, so that the output can be redirected
+// to a file.
+func runCreateSecrets(args []string) error {
+	cfg, err := config.ParseCreateSecretsFlags(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return fmt.Errorf("could not parse flags: %w", err)
+	}
+
+	var r io.Reader = os.Stdin
+	if cfg.File != "-" {
+		f, err := os.Open(cfg.File)
+		if err != nil {
+			return fmt.Errorf("could not open file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	secrets, err := batch.Read(r, cfg.Format)
+	if err != nil {
+		return fmt.Errorf("could not read secrets: %w", err)
+	}
+	if cfg.TTL > 0 {
+		for i := range secrets {
+			if len(secrets[i].TTL) == 0 && secrets[i].ExpiresAt == nil {
+				secrets[i].TTL = cfg.TTL.String()
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	created, err := batch.Create(ctx, http.DefaultClient, cfg.URL, cfg.APIKey, api.CreateSecretsRequest{
+		Secrets: secrets,
+		BaseURL: cfg.BaseURL,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create secrets: %w", err)
+	}
+
+	if err := batch.Write(os.Stdout, cfg.Output, created); err != nil {
+		return fmt.Errorf("could not write secrets: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,252 @@
+// Package client provides a client for the burnit API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/api"
+)
+
+const (
+	// defaultTimeout is the default timeout of the HTTP client.
+	defaultTimeout = 30 * time.Second
+	// defaultMaxRetries is the default maximum number of retries of a request.
+	defaultMaxRetries = 3
+	// defaultRetryWait is the default wait before the first retry of a
+	// request. The wait is doubled for each retry.
+	defaultRetryWait = 500 * time.Millisecond
+	// defaultMaxRetryWait is the default maximum wait before a retry.
+	defaultMaxRetryWait = 10 * time.Second
+)
+
+const (
+	// contentType is the content type header.
+	contentType = "Content-Type"
+	// contentTypeJSON is the content type for JSON.
+	contentTypeJSON = "application/json"
+)
+
+// Client is a client for the burnit API.
+type Client struct {
+	baseURL      string
+	linkBaseURL  string
+	apiKey       string
+	httpClient   *http.Client
+	maxRetries   int
+	retryWait    time.Duration
+	maxRetryWait time.Duration
+}
+
+// Options contains options for the client.
+type Options struct {
+	// HTTPClient is the HTTP client used for requests. Defaults to a
+	// client with a timeout of 30 seconds.
+	HTTPClient *http.Client
+	// APIKey is the API key sent with requests, if set.
+	APIKey string
+	// LinkBaseURL is the base URL of the links to created secrets.
+	// Defaults to the base URL of the client.
+	LinkBaseURL string
+	// MaxRetries is the maximum number of retries of a request. Defaults
+	// to 3. Set to a negative value to disable retries.
+	MaxRetries int
+	// RetryWait is the wait before the first retry of a request. The wait
+	// is doubled for each retry. Defaults to 500 milliseconds.
+	RetryWait time.Duration
+	// MaxRetryWait is the maximum wait before a retry. A request is not
+	// retried if the server asks for a longer wait with Retry-After.
+	// Defaults to 10 seconds.
+	MaxRetryWait time.Duration
+}
+
+// Option is a function that sets options for the client.
+type Option func(o *Options)
+
+// New creates a new client for the API at baseURL, such as
+// https://burnit.example.com.
+func New(baseURL string, options ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return nil, fmt.Errorf("invalid base URL: %q, expected an absolute http or https URL", baseURL)
+	}
+
+	opts := Options{
+		MaxRetries:   defaultMaxRetries,
+		RetryWait:    defaultRetryWait,
+		MaxRetryWait: defaultMaxRetryWait,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	c := &Client{
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		linkBaseURL:  strings.TrimSuffix(opts.LinkBaseURL, "/"),
+		apiKey:       opts.APIKey,
+		httpClient:   opts.HTTPClient,
+		maxRetries:   max(opts.MaxRetries, 0),
+		retryWait:    opts.RetryWait,
+		maxRetryWait: opts.MaxRetryWait,
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	if len(c.linkBaseURL) == 0 {
+		c.linkBaseURL = c.baseURL
+	}
+	if c.retryWait <= 0 {
+		c.retryWait = defaultRetryWait
+	}
+	if c.maxRetryWait <= 0 {
+		c.maxRetryWait = defaultMaxRetryWait
+	}
+
+	return c, nil
+}
+
+// request contains the parts of a request to the API.
+type request struct {
+	method string
+	path   string
+	header http.Header
+	body   any
+	// status is the expected status code of the response.
+	status int
+	// idempotent is true if the request can be retried after
+	// a network error or a gateway error, where it is unknown
+	// whether the server handled it.
+	idempotent bool
+}
+
+// do sends the request and decodes the response into v, if v is not nil.
+// Requests rejected by a rate limit (429) or by an unavailable server (503)
+// are retried, as they have not been handled by the server. Idempotent
+// requests are also retried after network and gateway (502 and 504) errors.
+func (c Client) do(ctx context.Context, r request, v any) error {
+	var body []byte
+	if r.body != nil {
+		var err error
+		body, err = json.Marshal(r.body)
+		if err != nil {
+			return err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, r.method, c.baseURL+r.path, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		for key, values := range r.header {
+			req.Header[key] = values
+		}
+		req.Header.Set("Accept", contentTypeJSON)
+		if body != nil {
+			req.Header.Set(contentType, contentTypeJSON)
+		}
+		if len(c.apiKey) > 0 {
+			req.Header.Set("X-API-Key", c.apiKey)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			if r.idempotent && ctx.Err() == nil && attempt < c.maxRetries {
+				if err := c.wait(ctx, c.backoff(attempt)); err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+
+		if resp.StatusCode == r.status {
+			defer resp.Body.Close()
+			if v == nil {
+				return nil
+			}
+			if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+				return fmt.Errorf("could not decode response: %w", err)
+			}
+			return nil
+		}
+
+		respErr := readError(resp)
+		if attempt < c.maxRetries && retryable(resp.StatusCode, r.idempotent) {
+			wait := max(respErr.RetryAfter, c.backoff(attempt))
+			if wait <= c.maxRetryWait {
+				if err := c.wait(ctx, wait); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		return respErr
+	}
+}
+
+// backoff returns the wait before the retry after the attempt.
+func (c Client) backoff(attempt int) time.Duration {
+	wait := min(c.retryWait, c.maxRetryWait)
+	for range attempt {
+		wait *= 2
+		if wait >= c.maxRetryWait {
+			return c.maxRetryWait
+		}
+	}
+	return wait
+}
+
+// wait for the duration or until the context is done.
+func (c Client) wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryable returns true if a request with a response with the
+// status code can be retried.
+func retryable(statusCode int, idempotent bool) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent
+	}
+	return false
+}
+
+// readError reads an error response and closes its body.
+func readError(resp *http.Response) *Error {
+	defer resp.Body.Close()
+
+	respErr := &Error{
+		StatusCode: resp.StatusCode,
+		RetryAfter: retryAfter(resp.Header),
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return respErr
+	}
+	var apiErr api.Error
+	if err := json.Unmarshal(b, &apiErr); err != nil {
+		return respErr
+	}
+	respErr.Code = apiErr.Code
+	respErr.Message = apiErr.Err
+	respErr.RequestID = apiErr.RequestID
+	return respErr
+}
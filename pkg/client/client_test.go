@@ -0,0 +1,868 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/auth"
+	"github.com/RedeployAB/burnit/internal/db/inmem"
+	"github.com/RedeployAB/burnit/internal/secret"
+	"github.com/RedeployAB/burnit/internal/server"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestNew(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			baseURL string
+			options []Option
+		}
+		want    *Client
+		wantErr error
+	}{
+		{
+			name: "new client",
+			input: struct {
+				baseURL string
+				options []Option
+			}{
+				baseURL: "https://burnit.example.com/",
+			},
+			want: &Client{
+				baseURL:      "https://burnit.example.com",
+				linkBaseURL:  "https://burnit.example.com",
+				httpClient:   &http.Client{Timeout: defaultTimeout},
+				maxRetries:   defaultMaxRetries,
+				retryWait:    defaultRetryWait,
+				maxRetryWait: defaultMaxRetryWait,
+			},
+		},
+		{
+			name: "new client - with options",
+			input: struct {
+				baseURL string
+				options []Option
+			}{
+				baseURL: "http://localhost:3000",
+				options: []Option{
+					func(o *Options) {
+						o.APIKey = "key"
+						o.LinkBaseURL = "https://burnit.example.com"
+						o.MaxRetries = -1
+						o.RetryWait = time.Second
+						o.MaxRetryWait = time.Minute
+					},
+				},
+			},
+			want: &Client{
+				baseURL:      "http://localhost:3000",
+				linkBaseURL:  "https://burnit.example.com",
+				apiKey:       "key",
+				httpClient:   &http.Client{Timeout: defaultTimeout},
+				retryWait:    time.Second,
+				maxRetryWait: time.Minute,
+			},
+		},
+		{
+			name: "new client - invalid base URL",
+			input: struct {
+				baseURL string
+				options []Option
+			}{
+				baseURL: "burnit.example.com",
+			},
+			wantErr: cmpopts.AnyError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := New(test.input.baseURL, test.input.options...)
+
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(Client{})); diff != "" {
+				t.Errorf("New() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("New() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestClient_Create(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			secret NewSecret
+		}
+		want    Secret
+		wantErr error
+	}{
+		{
+			name: "create secret",
+			input: struct {
+				secret NewSecret
+			}{
+				secret: NewSecret{
+					Value:      "secret",
+					Passphrase: "passphrase",
+					TTL:        time.Hour,
+					Label:      "Staging DB",
+					Note:       "Rotated monthly.",
+				},
+			},
+			want: Secret{
+				Passphrase: "passphrase",
+				TTL:        time.Hour,
+				Label:      "Staging DB",
+			},
+		},
+		{
+			name: "create secret - generated value",
+			input: struct {
+				secret NewSecret
+			}{
+				secret: NewSecret{
+					Passphrase: "passphrase",
+					TTL:        2 * time.Hour,
+					Generate: &GenerateOptions{
+						Words:       4,
+						ReturnValue: true,
+					},
+				},
+			},
+			want: Secret{
+				Passphrase: "passphrase",
+				TTL:        2 * time.Hour,
+			},
+		},
+		{
+			name: "create secret - error invalid request",
+			input: struct {
+				secret NewSecret
+			}{
+				secret: NewSecret{
+					Passphrase: "passphrase",
+				},
+			},
+			wantErr: ErrInvalidRequest,
+		},
+		{
+			name: "create secret - error value too many characters",
+			input: struct {
+				secret NewSecret
+			}{
+				secret: NewSecret{
+					Value: strings.Repeat("a", 4001),
+				},
+			},
+			wantErr: ErrValueTooManyCharacters,
+		},
+		{
+			name: "create secret - error invalid label",
+			input: struct {
+				secret NewSecret
+			}{
+				secret: NewSecret{
+					Value: "secret",
+					Label: "Staging\nDB",
+				},
+			},
+			wantErr: ErrInvalidLabel,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := newTestServer(t)
+			c := newTestClient(t, srv.URL)
+
+			got, gotErr := c.Create(context.Background(), test.input.secret)
+
+			if diff := cmp.Diff(test.want, got, cmpopts.IgnoreFields(Secret{}, "ID", "Value", "ExpiresAt", "Link")); diff != "" {
+				t.Errorf("Create() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Create() = unexpected error (-want +got)\n%s\n", diff)
+			}
+
+			if gotErr != nil {
+				return
+			}
+			if wantLink := Link(srv.URL, got.ID, got.Passphrase); got.Link != wantLink {
+				t.Errorf("Create() = unexpected link, want: %s, got: %s\n", wantLink, got.Link)
+			}
+			if test.input.secret.Generate != nil && len(strings.Split(got.Value, "-")) != test.input.secret.Generate.Words {
+				t.Errorf("Create() = unexpected generated value: %s\n", got.Value)
+			}
+		})
+	}
+}
+
+func TestClient_Get(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			secret     NewSecret
+			id         string
+			passphrase string
+		}
+		want    Secret
+		wantErr error
+	}{
+		{
+			name: "get secret",
+			input: struct {
+				secret     NewSecret
+				id         string
+				passphrase string
+			}{
+				secret: NewSecret{
+					Value:      "secret",
+					Passphrase: "passphrase",
+					Label:      "Staging DB",
+					Note:       "Rotated monthly.",
+				},
+				passphrase: "passphrase",
+			},
+			want: Secret{
+				Value: "secret",
+				Label: "Staging DB",
+				Note:  "Rotated monthly.",
+			},
+		},
+		{
+			name: "get secret - passphrase with characters not allowed in headers",
+			input: struct {
+				secret     NewSecret
+				id         string
+				passphrase string
+			}{
+				secret: NewSecret{
+					Value:      "secret",
+					Passphrase: "päss\tphråse",
+				},
+				passphrase: "päss\tphråse",
+			},
+			want: Secret{
+				Value: "secret",
+			},
+		},
+		{
+			name: "get secret - credentials",
+			input: struct {
+				secret     NewSecret
+				id         string
+				passphrase string
+			}{
+				secret: NewSecret{
+					Passphrase: "passphrase",
+					Type:       "credentials",
+					Credentials: &Credentials{
+						Username: "admin",
+						Password: "password",
+					},
+				},
+				passphrase: "passphrase",
+			},
+			want: Secret{
+				Type: "credentials",
+				Credentials: &Credentials{
+					Username: "admin",
+					Password: "password",
+				},
+			},
+		},
+		{
+			name: "get secret - error invalid passphrase",
+			input: struct {
+				secret     NewSecret
+				id         string
+				passphrase string
+			}{
+				secret: NewSecret{
+					Value:      "secret",
+					Passphrase: "passphrase",
+				},
+				passphrase: "wrong",
+			},
+			wantErr: ErrInvalidPassphrase,
+		},
+		{
+			name: "get secret - error passphrase required",
+			input: struct {
+				secret     NewSecret
+				id         string
+				passphrase string
+			}{
+				secret: NewSecret{
+					Value:      "secret",
+					Passphrase: "passphrase",
+				},
+			},
+			wantErr: ErrPassphraseRequired,
+		},
+		{
+			name: "get secret - error not found",
+			input: struct {
+				secret     NewSecret
+				id         string
+				passphrase string
+			}{
+				secret: NewSecret{
+					Value:      "secret",
+					Passphrase: "passphrase",
+				},
+				id:         "00000000-0000-0000-0000-000000000000",
+				passphrase: "passphrase",
+			},
+			wantErr: ErrSecretNotFound,
+		},
+		{
+			name: "get secret - error not available",
+			input: struct {
+				secret     NewSecret
+				id         string
+				passphrase string
+			}{
+				secret: NewSecret{
+					Value:      "secret",
+					Passphrase: "passphrase",
+					NotBefore:  time.Now().Add(time.Hour),
+				},
+				passphrase: "passphrase",
+			},
+			wantErr: ErrSecretNotAvailable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := newTestServer(t)
+			c := newTestClient(t, srv.URL)
+
+			created, err := c.Create(context.Background(), test.input.secret)
+			if err != nil {
+				t.Fatalf("Create() = unexpected error: %v\n", err)
+			}
+			id := test.input.id
+			if len(id) == 0 {
+				id = created.ID
+			}
+
+			got, gotErr := c.Get(context.Background(), id, test.input.passphrase)
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Get() = unexpected result (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Get() = unexpected error (-want +got)\n%s\n", diff)
+			}
+
+			var respErr *Error
+			if errors.Is(gotErr, ErrSecretNotAvailable) && (!errors.As(gotErr, &respErr) || respErr.RetryAfter <= 0) {
+				t.Errorf("Get() = expected retry after to be set, got: %v\n", gotErr)
+			}
+		})
+	}
+}
+
+func TestClient_Get_burned(t *testing.T) {
+	srv := newTestServer(t)
+	c := newTestClient(t, srv.URL)
+
+	created, err := c.Create(context.Background(), NewSecret{Value: "secret"})
+	if err != nil {
+		t.Fatalf("Create() = unexpected error: %v\n", err)
+	}
+
+	if _, err := c.Get(context.Background(), created.ID, created.Passphrase); err != nil {
+		t.Fatalf("Get() = unexpected error: %v\n", err)
+	}
+
+	_, gotErr := c.Get(context.Background(), created.ID, created.Passphrase)
+	if diff := cmp.Diff(ErrSecretNotFound, gotErr, cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("Get() = unexpected error (-want +got)\n%s\n", diff)
+	}
+}
+
+func TestClient_Delete(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			passphrase string
+		}
+		wantErr    error
+		wantGetErr error
+	}{
+		{
+			name: "delete secret",
+			input: struct {
+				passphrase string
+			}{
+				passphrase: "passphrase",
+			},
+			wantGetErr: ErrSecretNotFound,
+		},
+		{
+			name: "delete secret - error invalid passphrase",
+			input: struct {
+				passphrase string
+			}{
+				passphrase: "wrong",
+			},
+			wantErr: ErrInvalidPassphrase,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := newTestServer(t)
+			c := newTestClient(t, srv.URL)
+
+			created, err := c.Create(context.Background(), NewSecret{Value: "secret", Passphrase: "passphrase"})
+			if err != nil {
+				t.Fatalf("Create() = unexpected error: %v\n", err)
+			}
+
+			gotErr := c.Delete(context.Background(), created.ID, test.input.passphrase)
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Delete() = unexpected error (-want +got)\n%s\n", diff)
+			}
+
+			_, gotGetErr := c.Get(context.Background(), created.ID, "passphrase")
+			if diff := cmp.Diff(test.wantGetErr, gotGetErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Get() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestClient_Generate(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			options []GenerateOption
+		}
+		want    func(value string) bool
+		wantErr error
+	}{
+		{
+			name: "generate secret",
+			want: func(value string) bool {
+				return len(value) == 16
+			},
+		},
+		{
+			name: "generate secret - with length",
+			input: struct {
+				options []GenerateOption
+			}{
+				options: []GenerateOption{
+					func(o *GenerateOptions) {
+						o.Length = 32
+						o.SpecialCharacters = true
+					},
+				},
+			},
+			want: func(value string) bool {
+				return len(value) == 32
+			},
+		},
+		{
+			name: "generate secret - with words",
+			input: struct {
+				options []GenerateOption
+			}{
+				options: []GenerateOption{
+					func(o *GenerateOptions) {
+						o.Words = 5
+						o.Separator = "_"
+					},
+				},
+			},
+			want: func(value string) bool {
+				return len(strings.Split(value, "_")) == 5
+			},
+		},
+		{
+			name: "generate secret - error invalid options",
+			input: struct {
+				options []GenerateOption
+			}{
+				options: []GenerateOption{
+					func(o *GenerateOptions) {
+						o.Words = 1
+					},
+				},
+			},
+			wantErr: ErrInvalidGenerateOptions,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := newTestServer(t)
+			c := newTestClient(t, srv.URL)
+
+			got, gotErr := c.Generate(context.Background(), test.input.options...)
+
+			if test.want != nil && !test.want(got) {
+				t.Errorf("Generate() = unexpected result: %s\n", got)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Generate() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestClient_apiKeys(t *testing.T) {
+	keys, err := auth.NewAPIKeyStore(
+		auth.APIKey{Name: "creator", Hash: auth.HashAPIKey("create-key"), Scopes: []auth.Scope{auth.ScopeCreate}},
+		auth.APIKey{Name: "generator", Hash: auth.HashAPIKey("generate-key"), Scopes: []auth.Scope{auth.ScopeGenerate}},
+	)
+	if err != nil {
+		t.Fatalf("NewAPIKeyStore() = unexpected error: %v\n", err)
+	}
+
+	var tests = []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{
+			name:  "create secret with api key",
+			input: "create-key",
+		},
+		{
+			name:    "create secret - error api key required",
+			wantErr: ErrAPIKeyRequired,
+		},
+		{
+			name:    "create secret - error invalid api key",
+			input:   "invalid-key",
+			wantErr: ErrInvalidAPIKey,
+		},
+		{
+			name:    "create secret - error insufficient scope",
+			input:   "generate-key",
+			wantErr: ErrInsufficientScope,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := newTestServer(t, server.WithAPIKeys(keys))
+			c := newTestClient(t, srv.URL, func(o *Options) {
+				o.APIKey = test.input
+			})
+
+			_, gotErr := c.Create(context.Background(), NewSecret{Value: "secret"})
+
+			if diff := cmp.Diff(test.wantErr, gotErr, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Create() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestClient_retry(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input struct {
+			status   int
+			failures int32
+			options  []Option
+			call     func(c *Client) error
+		}
+		want    int32
+		wantErr error
+	}{
+		{
+			name: "retry create on service unavailable",
+			input: struct {
+				status   int
+				failures int32
+				options  []Option
+				call     func(c *Client) error
+			}{
+				status:   http.StatusServiceUnavailable,
+				failures: 2,
+				call: func(c *Client) error {
+					_, err := c.Create(context.Background(), NewSecret{Value: "secret"})
+					return err
+				},
+			},
+			want: 3,
+		},
+		{
+			name: "retry generate on bad gateway",
+			input: struct {
+				status   int
+				failures int32
+				options  []Option
+				call     func(c *Client) error
+			}{
+				status:   http.StatusBadGateway,
+				failures: 1,
+				call: func(c *Client) error {
+					_, err := c.Generate(context.Background())
+					return err
+				},
+			},
+			want: 2,
+		},
+		{
+			name: "no retry of get on bad gateway",
+			input: struct {
+				status   int
+				failures int32
+				options  []Option
+				call     func(c *Client) error
+			}{
+				status:   http.StatusBadGateway,
+				failures: 1,
+				call: func(c *Client) error {
+					_, err := c.Get(context.Background(), "00000000-0000-0000-0000-000000000000", "passphrase")
+					return err
+				},
+			},
+			want:    1,
+			wantErr: &Error{StatusCode: http.StatusBadGateway},
+		},
+		{
+			name: "no retry when disabled",
+			input: struct {
+				status   int
+				failures int32
+				options  []Option
+				call     func(c *Client) error
+			}{
+				status:   http.StatusServiceUnavailable,
+				failures: 1,
+				options: []Option{
+					func(o *Options) {
+						o.MaxRetries = -1
+					},
+				},
+				call: func(c *Client) error {
+					_, err := c.Generate(context.Background())
+					return err
+				},
+			},
+			want:    1,
+			wantErr: &Error{StatusCode: http.StatusServiceUnavailable},
+		},
+		{
+			name: "retries exhausted",
+			input: struct {
+				status   int
+				failures int32
+				options  []Option
+				call     func(c *Client) error
+			}{
+				status:   http.StatusServiceUnavailable,
+				failures: 10,
+				call: func(c *Client) error {
+					_, err := c.Generate(context.Background())
+					return err
+				},
+			},
+			want:    defaultMaxRetries + 1,
+			wantErr: &Error{StatusCode: http.StatusServiceUnavailable},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler, err := newTestHandler()
+			if err != nil {
+				t.Fatalf("newTestHandler() = unexpected error: %v\n", err)
+			}
+
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) <= test.input.failures {
+					w.WriteHeader(test.input.status)
+					return
+				}
+				handler.ServeHTTP(w, r)
+			}))
+			defer srv.Close()
+
+			c := newTestClient(t, srv.URL, test.input.options...)
+			gotErr := test.input.call(c)
+
+			if diff := cmp.Diff(test.want, requests.Load()); diff != "" {
+				t.Errorf("do() = unexpected number of requests (-want +got)\n%s\n", diff)
+			}
+
+			if diff := cmp.Diff(test.wantErr, gotErr); diff != "" {
+				t.Errorf("do() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
+
+func TestClient_retry_rateLimited(t *testing.T) {
+	srv := newTestServer(t, server.WithRateLimiter(server.RateLimiter{Rate: 0.01, Burst: 1}))
+	c := newTestClient(t, srv.URL)
+
+	if _, err := c.Generate(context.Background()); err != nil {
+		t.Fatalf("Generate() = unexpected error: %v\n", err)
+	}
+
+	// The rate limiter asks for a longer wait than the maximum wait
+	// before a retry, so the request is not retried.
+	_, gotErr := c.Generate(context.Background())
+	if diff := cmp.Diff(ErrTooManyRequests, gotErr, cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("Generate() = unexpected error (-want +got)\n%s\n", diff)
+	}
+
+	var respErr *Error
+	if !errors.As(gotErr, &respErr) || respErr.RetryAfter <= 0 {
+		t.Errorf("Generate() = expected retry after to be set, got: %v\n", gotErr)
+	}
+}
+
+func TestClient_context(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := newTestClient(t, srv.URL, func(o *Options) {
+		o.RetryWait = time.Second
+		o.MaxRetryWait = time.Minute
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, gotErr := c.Generate(ctx)
+	if diff := cmp.Diff(context.DeadlineExceeded, gotErr, cmpopts.EquateErrors()); diff != "" {
+		t.Errorf("Generate() = unexpected error (-want +got)\n%s\n", diff)
+	}
+}
+
+func TestLink(t *testing.T) {
+	got := Link("https://burnit.example.com/", "1", "passphrase")
+	want := "https://burnit.example.com/ui/secrets/1/HgiePFMjrYCpB2e91ZByl7QTgWPwJwl_072-q1KNLWg"
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Link() = unexpected result (-want +got)\n%s\n", diff)
+	}
+}
+
+// newTestHandler returns the handler of a server from the server package,
+// with an in-memory secret store.
+func newTestHandler(options ...server.Option) (http.Handler, error) {
+	secrets, err := secret.NewService(inmem.NewSecretStore())
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := server.New(secrets, append([]server.Option{server.WithLogger(&stubLogger{})}, options...)...)
+	if err != nil {
+		return nil, err
+	}
+	return srv.Handler(), nil
+}
+
+// newTestServer returns a test server that serves the handler of a server
+// from the server package.
+func newTestServer(t *testing.T, options ...server.Option) *httptest.Server {
+	t.Helper()
+	handler, err := newTestHandler(options...)
+	if err != nil {
+		t.Fatalf("newTestHandler() = unexpected error: %v\n", err)
+	}
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newTestClient returns a client for the test server at url, with short
+// waits between retries.
+func newTestClient(t *testing.T, url string, options ...Option) *Client {
+	t.Helper()
+	c, err := New(url, append([]Option{func(o *Options) {
+		o.RetryWait = time.Millisecond
+		o.MaxRetryWait = 10 * time.Millisecond
+	}}, options...)...)
+	if err != nil {
+		t.Fatalf("New() = unexpected error: %v\n", err)
+	}
+	return c
+}
+
+type stubLogger struct{}
+
+func (l stubLogger) Debug(msg string, args ...any) {}
+
+func (l stubLogger) Error(msg string, args ...any) {}
+
+func (l stubLogger) Info(msg string, args ...any) {}
+
+func (l stubLogger) Warn(msg string, args ...any) {}
+
+func TestError_Unwrap(t *testing.T) {
+	var tests = []struct {
+		name  string
+		input *Error
+		want  error
+	}{
+		{
+			name:  "secret not found",
+			input: &Error{StatusCode: http.StatusNotFound, Code: "SecretNotFound"},
+			want:  ErrSecretNotFound,
+		},
+		{
+			name:  "second factor attempts exceeded",
+			input: &Error{StatusCode: http.StatusGone, Code: "SecondFactorAttemptsExceeded"},
+			want:  ErrSecondFactorAttemptsExceeded,
+		},
+		{
+			name:  "quota exceeded",
+			input: &Error{StatusCode: http.StatusTooManyRequests, Code: "QuotaExceeded"},
+			want:  ErrQuotaExceeded,
+		},
+		{
+			name:  "code recently sent",
+			input: &Error{StatusCode: http.StatusTooManyRequests, Code: "CodeRecentlySent"},
+			want:  ErrCodeRecentlySent,
+		},
+		{
+			name:  "too many requests without code",
+			input: &Error{StatusCode: http.StatusTooManyRequests},
+			want:  ErrTooManyRequests,
+		},
+		{
+			name:  "unknown code",
+			input: &Error{StatusCode: http.StatusTeapot, Code: "Unknown"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.input.Unwrap()
+
+			if diff := cmp.Diff(test.want, got, cmpopts.EquateErrors()); diff != "" {
+				t.Errorf("Unwrap() = unexpected error (-want +got)\n%s\n", diff)
+			}
+		})
+	}
+}
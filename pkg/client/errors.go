@@ -0,0 +1,198 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Errors returned by the API, by their error code. An *Error returned
+// by the client wraps the error of its code, which makes it possible
+// to check for them with errors.Is.
+var (
+	// ErrEmptyRequest is returned when the request body is empty.
+	ErrEmptyRequest = errors.New("empty request")
+	// ErrInvalidRequest is returned when the request is invalid.
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrMalformedRequest is returned when the request body is malformed.
+	ErrMalformedRequest = errors.New("malformed request")
+	// ErrPassphraseNotBase64 is returned when the passphrase is not base64
+	// encoded.
+	ErrPassphraseNotBase64 = errors.New("passphrase not base64 encoded")
+	// ErrInvalidExpirationTime is returned when the expiration time of a
+	// secret is invalid.
+	ErrInvalidExpirationTime = errors.New("invalid expiration time")
+	// ErrInvalidNotBefore is returned when the not before time of a secret
+	// is invalid.
+	ErrInvalidNotBefore = errors.New("invalid not before time")
+	// ErrValueInvalid is returned when the value of a secret is invalid.
+	ErrValueInvalid = errors.New("value invalid")
+	// ErrValueTooManyCharacters is returned when the value of a secret has
+	// too many characters.
+	ErrValueTooManyCharacters = errors.New("value has too many characters")
+	// ErrInvalidContent is returned when the structured content of a secret
+	// is invalid.
+	ErrInvalidContent = errors.New("invalid secret content")
+	// ErrInvalidLabel is returned when the label of a secret is invalid.
+	ErrInvalidLabel = errors.New("invalid label")
+	// ErrInvalidNote is returned when the note of a secret is invalid.
+	ErrInvalidNote = errors.New("invalid note")
+	// ErrInvalidBatch is returned when a batch of secrets is invalid.
+	ErrInvalidBatch = errors.New("invalid batch")
+	// ErrPassphraseInvalid is returned when the passphrase of a secret is
+	// invalid.
+	ErrPassphraseInvalid = errors.New("passphrase invalid")
+	// ErrPassphraseTooFewCharacters is returned when the passphrase of a
+	// secret has too few characters.
+	ErrPassphraseTooFewCharacters = errors.New("passphrase has too few characters")
+	// ErrPassphraseTooManyCharacters is returned when the passphrase of a
+	// secret has too many characters.
+	ErrPassphraseTooManyCharacters = errors.New("passphrase has too many characters")
+	// ErrPassphraseTooWeak is returned when the estimated strength of the
+	// passphrase of a secret is below the minimum score.
+	ErrPassphraseTooWeak = errors.New("passphrase too weak")
+	// ErrInvalidAllowedNetworks is returned when the allowed networks of
+	// a secret are invalid.
+	ErrInvalidAllowedNetworks = errors.New("invalid allowed networks")
+	// ErrSecondFactorInvalid is returned when the second factor of a
+	// secret is invalid.
+	ErrSecondFactorInvalid = errors.New("invalid second factor")
+	// ErrInvalidGenerateOptions is returned when the options to generate
+	// a secret are invalid.
+	ErrInvalidGenerateOptions = errors.New("invalid options for generating secret")
+	// ErrInvalidCredentialType is returned when the type of credential to
+	// generate is not supported.
+	ErrInvalidCredentialType = errors.New("invalid credential type")
+	// ErrInvalidBase64 is returned when a base64 encoded string is invalid.
+	ErrInvalidBase64 = errors.New("invalid base64 encoding")
+	// ErrSecretIDRequired is returned when the ID of a secret is missing.
+	ErrSecretIDRequired = errors.New("secret ID is required")
+	// ErrPassphraseRequired is returned when the passphrase is missing.
+	ErrPassphraseRequired = errors.New("passphrase required")
+	// ErrInvalidPassphrase is returned when the passphrase does not match
+	// the secret.
+	ErrInvalidPassphrase = errors.New("invalid passphrase")
+	// ErrSecondFactorRequired is returned when a second factor code is
+	// required to read a secret.
+	ErrSecondFactorRequired = errors.New("second factor code required")
+	// ErrInvalidSecondFactorCode is returned when the second factor code
+	// is invalid or has expired.
+	ErrInvalidSecondFactorCode = errors.New("invalid second factor code")
+	// ErrAPIKeyRequired is returned when an API key is required.
+	ErrAPIKeyRequired = errors.New("api key required")
+	// ErrInvalidAPIKey is returned when the API key is invalid.
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrInsufficientScope is returned when the API key does not have the
+	// scope required for the operation.
+	ErrInsufficientScope = errors.New("insufficient scope")
+	// ErrNetworkNotAllowed is returned when the request is not made from
+	// an allowed network.
+	ErrNetworkNotAllowed = errors.New("network not allowed")
+	// ErrSecretNotAvailable is returned when a secret is not available
+	// until its not before time.
+	ErrSecretNotAvailable = errors.New("secret not yet available")
+	// ErrSecretNotFound is returned when a secret does not exist, or has
+	// been read.
+	ErrSecretNotFound = errors.New("secret not found")
+	// ErrSecondFactorAttemptsExceeded is returned when too many invalid
+	// second factor codes have been provided, and the secret has been
+	// deleted.
+	ErrSecondFactorAttemptsExceeded = errors.New("too many invalid second factor codes")
+	// ErrQuotaExceeded is returned when the quota of the API key is exceeded.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrCodeRecentlySent is returned when a second factor code was
+	// recently sent for the secret.
+	ErrCodeRecentlySent = errors.New("second factor code recently sent")
+	// ErrTooManyRequests is returned when the rate limit is exceeded.
+	ErrTooManyRequests = errors.New("too many requests")
+	// ErrServerError is returned when the server fails to handle the request.
+	ErrServerError = errors.New("internal server error")
+)
+
+// codeErrors maps error codes of the API to errors.
+var codeErrors = map[string]error{
+	"EmptyRequest":                 ErrEmptyRequest,
+	"InvalidRequest":               ErrInvalidRequest,
+	"MalformedRequest":             ErrMalformedRequest,
+	"PassphraseNotBase64":          ErrPassphraseNotBase64,
+	"InvalidExpirationTime":        ErrInvalidExpirationTime,
+	"InvalidNotBefore":             ErrInvalidNotBefore,
+	"ValueInvalid":                 ErrValueInvalid,
+	"ValueTooManyCharacters":       ErrValueTooManyCharacters,
+	"InvalidContent":               ErrInvalidContent,
+	"InvalidLabel":                 ErrInvalidLabel,
+	"InvalidNote":                  ErrInvalidNote,
+	"InvalidBatch":                 ErrInvalidBatch,
+	"PassphraseInvalid":            ErrPassphraseInvalid,
+	"PassphraseTooFewCharacters":   ErrPassphraseTooFewCharacters,
+	"PassphraseTooManyCharacters":  ErrPassphraseTooManyCharacters,
+	"PassphraseTooWeak":            ErrPassphraseTooWeak,
+	"InvalidAllowedNetworks":       ErrInvalidAllowedNetworks,
+	"SecondFactorInvalid":          ErrSecondFactorInvalid,
+	"InvalidGenerateOptions":       ErrInvalidGenerateOptions,
+	"InvalidCredentialType":        ErrInvalidCredentialType,
+	"InvalidBase64":                ErrInvalidBase64,
+	"SecretIDRequired":             ErrSecretIDRequired,
+	"PassphraseRequired":           ErrPassphraseRequired,
+	"InvalidPassphrase":            ErrInvalidPassphrase,
+	"SecondFactorRequired":         ErrSecondFactorRequired,
+	"InvalidSecondFactorCode":      ErrInvalidSecondFactorCode,
+	"APIKeyRequired":               ErrAPIKeyRequired,
+	"InvalidAPIKey":                ErrInvalidAPIKey,
+	"InsufficientScope":            ErrInsufficientScope,
+	"NetworkNotAllowed":            ErrNetworkNotAllowed,
+	"SecretNotAvailable":           ErrSecretNotAvailable,
+	"SecretNotFound":               ErrSecretNotFound,
+	"SecondFactorAttemptsExceeded": ErrSecondFactorAttemptsExceeded,
+	"QuotaExceeded":                ErrQuotaExceeded,
+	"CodeRecentlySent":             ErrCodeRecentlySent,
+	"ServerError":                  ErrServerError,
+}
+
+// Error is an error response from the API.
+type Error struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Code is the error code, such as SecretNotFound.
+	Code string
+	// Message is the error message.
+	Message string
+	// RequestID is the ID of the request, set for server errors.
+	RequestID string
+	// RetryAfter is the duration to wait before the request can be
+	// made again, if the response has a Retry-After header.
+	RetryAfter time.Duration
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	if len(e.Message) == 0 {
+		return http.StatusText(e.StatusCode)
+	}
+	return e.Message
+}
+
+// Unwrap returns the error of the error code, or nil if the code is
+// not known.
+func (e *Error) Unwrap() error {
+	if err, ok := codeErrors[e.Code]; ok {
+		return err
+	}
+	switch e.StatusCode {
+	case http.StatusTooManyRequests:
+		return ErrTooManyRequests
+	case http.StatusInternalServerError:
+		return ErrServerError
+	}
+	return nil
+}
+
+// retryAfter parses the Retry-After header (in seconds) of a response.
+func retryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,355 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RedeployAB/burnit/internal/api"
+)
+
+// Secret represents a secret.
+type Secret struct {
+	ID         string
+	Value      string
+	Passphrase string
+	TTL        time.Duration
+	ExpiresAt  time.Time
+	NotBefore  time.Time
+	// Type is the type of the content of the secret. It is only set
+	// for structured content (credentials and keyValue).
+	Type string
+	// Credentials is the content of a secret of the type credentials.
+	Credentials *Credentials
+	// KeyValue is the content of a secret of the type keyValue.
+	KeyValue map[string]string
+	// Label is the unencrypted label of the secret.
+	Label string
+	// Note is the note delivered alongside the value of the secret.
+	Note string
+	// SecondFactor is the second factor required to read the secret.
+	SecondFactor *SecondFactor
+	// Link is the link to share to read the secret in the UI. It is
+	// only set for created secrets.
+	Link string
+}
+
+// Credentials represents the content of a secret of the type credentials.
+type Credentials struct {
+	Username string
+	Password string
+	URL      string
+	Notes    string
+}
+
+// SecondFactor represents the second factor required to read a secret.
+type SecondFactor struct {
+	// Type is the type of the second factor, totp or email.
+	Type string
+	// TOTPSecret is the base32 encoded TOTP secret. One is generated
+	// if it is not provided.
+	TOTPSecret string
+	// TOTPURI is the otpauth URI of the TOTP secret, to be added to
+	// an authenticator app.
+	TOTPURI string
+	// Email is the address codes are sent to.
+	Email string
+}
+
+// NewSecret contains the secret to create.
+type NewSecret struct {
+	Value      string
+	Passphrase string
+	TTL        time.Duration
+	ExpiresAt  time.Time
+	// NotBefore is the time from which the secret can be read.
+	NotBefore time.Time
+	// AllowedNetworks contains the networks (CIDRs or IP addresses)
+	// that are allowed to read the secret.
+	AllowedNetworks []string
+	// SecondFactor is the second factor required to read the secret.
+	SecondFactor *SecondFactor
+	// Generate contains the options to generate the value of the
+	// secret, instead of providing it.
+	Generate *GenerateOptions
+	// Type is the type of the content of the secret: text (default),
+	// credentials or keyValue.
+	Type string
+	// Credentials is the content of a secret of the type credentials.
+	Credentials *Credentials
+	// KeyValue is the content of a secret of the type keyValue.
+	KeyValue map[string]string
+	// Label is a short label that is stored unencrypted and shown
+	// before the secret is read.
+	Label string
+	// Note is a free-text note that is encrypted and delivered
+	// alongside the value of the secret.
+	Note string
+}
+
+// GenerateOptions contains options for generating a secret.
+type GenerateOptions struct {
+	Length            int
+	Lowercase         bool
+	Uppercase         bool
+	Digits            bool
+	SpecialCharacters bool
+	Custom            string
+	ExcludeAmbiguous  bool
+	Words             int
+	Separator         string
+	Capitalize        bool
+	Number            bool
+	Symbol            bool
+	// ReturnValue returns the generated value when a secret is
+	// created. It has no effect on Generate.
+	ReturnValue bool
+}
+
+// GenerateOption is a function that sets options for generating a secret.
+type GenerateOption func(o *GenerateOptions)
+
+// GetOptions contains options for getting a secret.
+type GetOptions struct {
+	// Code is the second factor code of the secret.
+	Code string
+}
+
+// GetOption is a function that sets options for getting a secret.
+type GetOption func(o *GetOptions)
+
+// DeleteOptions contains options for deleting a secret.
+type DeleteOptions struct {
+	// Code is the second factor code of the secret.
+	Code string
+}
+
+// DeleteOption is a function that sets options for deleting a secret.
+type DeleteOption func(o *DeleteOptions)
+
+// Generate a secret without storing it.
+func (c Client) Generate(ctx context.Context, options ...GenerateOption) (string, error) {
+	opts := GenerateOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	query := url.Values{}
+	if opts.Length > 0 {
+		query.Set("length", strconv.Itoa(opts.Length))
+	}
+	if opts.Words > 0 {
+		query.Set("words", strconv.Itoa(opts.Words))
+	}
+	if len(opts.Custom) > 0 {
+		query.Set("custom", opts.Custom)
+	}
+	if len(opts.Separator) > 0 {
+		query.Set("separator", opts.Separator)
+	}
+	for key, value := range map[string]bool{
+		"lowercase":         opts.Lowercase,
+		"uppercase":         opts.Uppercase,
+		"digits":            opts.Digits,
+		"specialCharacters": opts.SpecialCharacters,
+		"excludeAmbiguous":  opts.ExcludeAmbiguous,
+		"capitalize":        opts.Capitalize,
+		"number":            opts.Number,
+		"symbol":            opts.Symbol,
+	} {
+		if value {
+			query.Set(key, "true")
+		}
+	}
+
+	path := "/secret"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var secret api.Secret
+	if err := c.do(ctx, request{method: http.MethodGet, path: path, status: http.StatusOK, idempotent: true}, &secret); err != nil {
+		return "", err
+	}
+	return secret.Value, nil
+}
+
+// Get a secret. The secret is burned (deleted) when it has been read.
+// Get is not retried after network errors, since the secret might have
+// been burned without the response being received.
+func (c Client) Get(ctx context.Context, id, passphrase string, options ...GetOption) (Secret, error) {
+	opts := GetOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	var secret api.Secret
+	if err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   secretPath(id),
+		header: secretHeader(passphrase, opts.Code),
+		status: http.StatusOK,
+	}, &secret); err != nil {
+		return Secret{}, err
+	}
+	return toSecret(&secret), nil
+}
+
+// Create a secret. The created secret contains the ID and passphrase
+// of the secret and a link to share to read it in the UI.
+func (c Client) Create(ctx context.Context, secret NewSecret) (Secret, error) {
+	var created api.Secret
+	if err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/secrets",
+		body:   toCreateSecretRequest(&secret),
+		status: http.StatusCreated,
+	}, &created); err != nil {
+		return Secret{}, err
+	}
+
+	s := toSecret(&created)
+	s.Link = Link(c.linkBaseURL, s.ID, s.Passphrase)
+	return s, nil
+}
+
+// Delete a secret. The passphrase of the secret is required.
+func (c Client) Delete(ctx context.Context, id, passphrase string, options ...DeleteOption) error {
+	opts := DeleteOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   secretPath(id),
+		header: secretHeader(passphrase, opts.Code),
+		status: http.StatusNoContent,
+	}, nil)
+}
+
+// Link returns the link to read a secret in the UI. The passphrase is
+// included as its hash, in the same format as links created in the UI.
+func Link(baseURL, id, passphrase string) string {
+	hash := sha256.Sum256([]byte(passphrase))
+	return strings.TrimSuffix(baseURL, "/") + "/ui/secrets/" + url.PathEscape(id) + "/" + base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// secretPath returns the path of a secret.
+func secretPath(id string) string {
+	return "/secrets/" + url.PathEscape(id)
+}
+
+// secretHeader returns the headers to read or delete a secret. The
+// passphrase is sent base64 encoded, since it can contain characters
+// that are not allowed in headers.
+func secretHeader(passphrase, code string) http.Header {
+	header := http.Header{}
+	if len(passphrase) > 0 {
+		header.Set("Passphrase", base64.StdEncoding.EncodeToString([]byte(passphrase)))
+	}
+	if len(code) > 0 {
+		header.Set("Second-Factor-Code", code)
+	}
+	return header
+}
+
+// toCreateSecretRequest converts a NewSecret to an api.CreateSecretRequest.
+func toCreateSecretRequest(s *NewSecret) api.CreateSecretRequest {
+	req := api.CreateSecretRequest{
+		Value:           s.Value,
+		Passphrase:      s.Passphrase,
+		AllowedNetworks: s.AllowedNetworks,
+		Type:            s.Type,
+		KeyValue:        s.KeyValue,
+		Label:           s.Label,
+		Note:            s.Note,
+	}
+	if s.TTL > 0 {
+		req.TTL = s.TTL.String()
+	}
+	if !s.ExpiresAt.IsZero() {
+		req.ExpiresAt = &api.Time{Time: s.ExpiresAt}
+	}
+	if !s.NotBefore.IsZero() {
+		req.NotBefore = &api.Time{Time: s.NotBefore}
+	}
+	if s.SecondFactor != nil {
+		req.SecondFactor = &api.SecondFactor{
+			Type:       s.SecondFactor.Type,
+			TOTPSecret: s.SecondFactor.TOTPSecret,
+			Email:      s.SecondFactor.Email,
+		}
+	}
+	if s.Credentials != nil {
+		req.Credentials = &api.Credentials{
+			Username: s.Credentials.Username,
+			Password: s.Credentials.Password,
+			URL:      s.Credentials.URL,
+			Notes:    s.Credentials.Notes,
+		}
+	}
+	if s.Generate != nil {
+		req.Generate = &api.GenerateSecretRequest{
+			Length:            s.Generate.Length,
+			Lowercase:         s.Generate.Lowercase,
+			Uppercase:         s.Generate.Uppercase,
+			Digits:            s.Generate.Digits,
+			SpecialCharacters: s.Generate.SpecialCharacters,
+			Custom:            s.Generate.Custom,
+			ExcludeAmbiguous:  s.Generate.ExcludeAmbiguous,
+			Words:             s.Generate.Words,
+			Separator:         s.Generate.Separator,
+			Capitalize:        s.Generate.Capitalize,
+			Number:            s.Generate.Number,
+			Symbol:            s.Generate.Symbol,
+			ReturnValue:       s.Generate.ReturnValue,
+		}
+	}
+	return req
+}
+
+// toSecret converts an api.Secret to a Secret.
+func toSecret(s *api.Secret) Secret {
+	secret := Secret{
+		ID:         s.ID,
+		Value:      s.Value,
+		Passphrase: s.Passphrase,
+		Type:       s.Type,
+		KeyValue:   s.KeyValue,
+		Label:      s.Label,
+		Note:       s.Note,
+		Link:       s.Link,
+	}
+	if len(s.TTL) > 0 {
+		secret.TTL, _ = time.ParseDuration(s.TTL)
+	}
+	if s.ExpiresAt != nil {
+		secret.ExpiresAt = s.ExpiresAt.Time
+	}
+	if s.NotBefore != nil {
+		secret.NotBefore = s.NotBefore.Time
+	}
+	if s.Credentials != nil {
+		secret.Credentials = &Credentials{
+			Username: s.Credentials.Username,
+			Password: s.Credentials.Password,
+			URL:      s.Credentials.URL,
+			Notes:    s.Credentials.Notes,
+		}
+	}
+	if s.SecondFactor != nil {
+		secret.SecondFactor = &SecondFactor{
+			Type:       s.SecondFactor.Type,
+			TOTPSecret: s.SecondFactor.TOTPSecret,
+			TOTPURI:    s.SecondFactor.TOTPURI,
+			Email:      s.SecondFactor.Email,
+		}
+	}
+	return secret
+}